@@ -0,0 +1,57 @@
+package ast
+
+import (
+	"strings"
+
+	"github.com/rarnu/goscript/file"
+)
+
+// Comment is a comment of the source code. Comments are not nodes of the AST, they are recorded on the Program
+// when parsing with parser.WithComments.
+type Comment struct {
+	Idx  file.Idx // The position of the comment marker
+	Text string   // The text of the comment including the markers, e.g. "// x" or "/* x */"
+}
+
+// End returns the position just after the comment.
+func (self *Comment) End() file.Idx {
+	return self.Idx + file.Idx(len(self.Text))
+}
+
+// IsDoc reports whether the comment is a documentation (JSDoc) comment, i.e. it starts with "/**".
+func (self *Comment) IsDoc() bool {
+	return strings.HasPrefix(self.Text, "/**") && self.Text != "/**/"
+}
+
+// CommentMap maps a node to the comments attached to it, in source order. A comment is attached to:
+//
+//   - the outermost node ending just before it on the same line if the comment ends the line (a trailing comment,
+//     e.g. `f(); // x`), unless it is a documentation comment, otherwise
+//   - the outermost node starting just after it (a leading comment, e.g. a JSDoc comment of a function), otherwise
+//   - the innermost node enclosing it (e.g. a comment at the end of a block), otherwise to the Program.
+type CommentMap map[Node][]*Comment
+
+// Leading returns the comments attached to node that precede it.
+func (self CommentMap) Leading(node Node) []*Comment {
+	if prg, ok := node.(*Program); ok && len(prg.Body) == 0 {
+		return nil
+	}
+	var res []*Comment
+	for _, c := range self[node] {
+		if c.End() <= node.Idx0() {
+			res = append(res, c)
+		}
+	}
+	return res
+}
+
+// Doc returns the documentation comment of node, i.e. the last of its leading comments if it is a JSDoc comment.
+// It returns nil if there is none.
+func (self CommentMap) Doc(node Node) *Comment {
+	if leading := self.Leading(node); len(leading) > 0 {
+		if c := leading[len(leading)-1]; c.IsDoc() {
+			return c
+		}
+	}
+	return nil
+}
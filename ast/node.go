@@ -633,6 +633,10 @@ type Program struct {
 	DeclarationList []*VariableDeclaration
 
 	File *file.File
+
+	// Comments and CommentMap are only set when parsing with parser.WithComments.
+	Comments   []*Comment // All comments in source order
+	CommentMap CommentMap // The comments attached to the nodes
 }
 
 // ==== //
@@ -764,10 +768,18 @@ func (self *MetaProperty) Idx1() file.Idx {
 	return self.Property.Idx1()
 }
 
-func (self *BadStatement) Idx1() file.Idx        { return self.To }
-func (self *BlockStatement) Idx1() file.Idx      { return self.RightBrace + 1 }
-func (self *BranchStatement) Idx1() file.Idx     { return self.Idx }
-func (self *CaseStatement) Idx1() file.Idx       { return self.Consequent[len(self.Consequent)-1].Idx1() }
+func (self *BadStatement) Idx1() file.Idx    { return self.To }
+func (self *BlockStatement) Idx1() file.Idx  { return self.RightBrace + 1 }
+func (self *BranchStatement) Idx1() file.Idx { return self.Idx }
+func (self *CaseStatement) Idx1() file.Idx {
+	if l := len(self.Consequent); l > 0 {
+		return self.Consequent[l-1].Idx1()
+	}
+	if self.Test != nil {
+		return self.Test.Idx1() + 1 // ":"
+	}
+	return self.Case + 8 // "default:"
+}
 func (self *CatchStatement) Idx1() file.Idx      { return self.Body.Idx1() }
 func (self *DebuggerStatement) Idx1() file.Idx   { return self.Debugger + 8 }
 func (self *DoWhileStatement) Idx1() file.Idx    { return self.Test.Idx1() }
@@ -785,8 +797,13 @@ func (self *IfStatement) Idx1() file.Idx {
 func (self *LabelledStatement) Idx1() file.Idx { return self.Colon + 1 }
 func (self *Program) Idx1() file.Idx           { return self.Body[len(self.Body)-1].Idx1() }
 func (self *ReturnStatement) Idx1() file.Idx   { return self.Return + 6 }
-func (self *SwitchStatement) Idx1() file.Idx   { return self.Body[len(self.Body)-1].Idx1() }
-func (self *ThrowStatement) Idx1() file.Idx    { return self.Argument.Idx1() }
+func (self *SwitchStatement) Idx1() file.Idx {
+	if l := len(self.Body); l > 0 {
+		return self.Body[l-1].Idx1()
+	}
+	return self.Discriminant.Idx1() + 1 // ")"
+}
+func (self *ThrowStatement) Idx1() file.Idx { return self.Argument.Idx1() }
 func (self *TryStatement) Idx1() file.Idx {
 	if self.Finally != nil {
 		return self.Finally.Idx1()
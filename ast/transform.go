@@ -0,0 +1,471 @@
+package ast
+
+import "fmt"
+
+// Transform traverses an AST in depth-first order and replaces each node with the result of f(node). f is
+// invoked after the children of the node have been transformed, so it is given the node with its new children.
+// Transform returns the result of f for the root node.
+//
+// The node returned by f must be usable in the place of the original one (e.g. an Expression for an expression,
+// a *BlockStatement for the body of a function), otherwise Transform panics. If f returns nil the node is removed
+// from the list that contains it or the field referring to it is set to nil. The elements of array literals and
+// patterns are the exception: they are set to nil, which denotes a hole. Identifiers embedded by value (such as
+// the property name of a DotExpression) cannot be removed.
+//
+// As with Walk, DeclarationList fields are not traversed.
+func Transform(node Node, f func(Node) Node) Node {
+	t := &transformer{f: f}
+	return t.node(node)
+}
+
+type transformer struct {
+	f func(Node) Node
+}
+
+func (t *transformer) mismatch(orig, repl Node) string {
+	return fmt.Sprintf("ast.Transform: %T cannot be used in place of %T", repl, orig)
+}
+
+func (t *transformer) node(node Node) Node {
+	switch n := node.(type) {
+	// Expressions
+	case *BadExpression, *BooleanLiteral, *Identifier, *PrivateIdentifier, *NullLiteral, *NumberLiteral,
+		*RegExpLiteral, *StringLiteral, *TemplateElement, *ThisExpression, *SuperExpression:
+		// nothing to do
+
+	case *ArrayLiteral:
+		n.Value = t.elements(n.Value)
+	case *ArrayPattern:
+		n.Elements = t.elements(n.Elements)
+		n.Rest = t.expr(n.Rest)
+	case *AssignExpression:
+		n.Left = t.expr(n.Left)
+		n.Right = t.expr(n.Right)
+	case *AwaitExpression:
+		n.Argument = t.expr(n.Argument)
+	case *BinaryExpression:
+		n.Left = t.expr(n.Left)
+		n.Right = t.expr(n.Right)
+	case *BracketExpression:
+		n.Left = t.expr(n.Left)
+		n.Member = t.expr(n.Member)
+	case *CallExpression:
+		n.Callee = t.expr(n.Callee)
+		n.ArgumentList = t.exprList(n.ArgumentList)
+	case *ConditionalExpression:
+		n.Test = t.expr(n.Test)
+		n.Consequent = t.expr(n.Consequent)
+		n.Alternate = t.expr(n.Alternate)
+	case *DotExpression:
+		n.Left = t.expr(n.Left)
+		t.identValue(&n.Identifier)
+	case *PrivateDotExpression:
+		n.Left = t.expr(n.Left)
+		if r, ok := t.node(&n.Identifier).(*PrivateIdentifier); ok && r != nil {
+			n.Identifier = *r
+		} else {
+			panic(t.mismatch(&n.Identifier, r))
+		}
+	case *OptionalChain:
+		n.Expression = t.expr(n.Expression)
+	case *Optional:
+		n.Expression = t.expr(n.Expression)
+	case *FunctionLiteral:
+		t.function(n)
+	case *ClassLiteral:
+		t.class(n)
+	case *ArrowFunctionLiteral:
+		n.ParameterList = t.parameterList(n.ParameterList)
+		if n.Body != nil {
+			r := t.node(n.Body)
+			if body, ok := r.(ConciseBody); ok || r == nil {
+				n.Body = body
+			} else {
+				panic(t.mismatch(n.Body, r))
+			}
+		}
+	case *NewExpression:
+		n.Callee = t.expr(n.Callee)
+		n.ArgumentList = t.exprList(n.ArgumentList)
+	case *ObjectLiteral:
+		n.Value = t.properties(n.Value)
+	case *ObjectPattern:
+		n.Properties = t.properties(n.Properties)
+		n.Rest = t.expr(n.Rest)
+	case *ParameterList:
+		n.List = t.bindingList(n.List)
+		n.Rest = t.expr(n.Rest)
+	case *PropertyShort:
+		t.identValue(&n.Name)
+		n.Initializer = t.expr(n.Initializer)
+	case *PropertyKeyed:
+		n.Key = t.expr(n.Key)
+		n.Value = t.expr(n.Value)
+	case *SpreadElement:
+		n.Expression = t.expr(n.Expression)
+	case *SequenceExpression:
+		n.Sequence = t.exprList(n.Sequence)
+	case *TemplateLiteral:
+		n.Tag = t.expr(n.Tag)
+		for i, elem := range n.Elements {
+			if r, ok := t.node(elem).(*TemplateElement); ok && r != nil {
+				n.Elements[i] = r
+			} else {
+				panic(t.mismatch(elem, r))
+			}
+			if i < len(n.Expressions) {
+				n.Expressions[i] = t.expr(n.Expressions[i])
+			}
+		}
+	case *UnaryExpression:
+		n.Operand = t.expr(n.Operand)
+	case *MetaProperty:
+		n.Meta = t.ident(n.Meta)
+		n.Property = t.ident(n.Property)
+	case *YieldExpression:
+		n.Argument = t.expr(n.Argument)
+	case *Binding:
+		t.binding(n)
+	case *ExpressionBody:
+		n.Expression = t.expr(n.Expression)
+
+	// Statements
+	case *BadStatement, *DebuggerStatement, *EmptyStatement:
+		// nothing to do
+
+	case *BlockStatement:
+		n.List = t.stmtList(n.List)
+	case *BranchStatement:
+		n.Label = t.ident(n.Label)
+	case *CaseStatement:
+		t.caseClause(n)
+	case *CatchStatement:
+		t.catchClause(n)
+	case *DoWhileStatement:
+		n.Body = t.stmt(n.Body)
+		n.Test = t.expr(n.Test)
+	case *ExpressionStatement:
+		n.Expression = t.expr(n.Expression)
+	case *ForInStatement:
+		n.Into = t.forInto(n.Into)
+		n.Source = t.expr(n.Source)
+		n.Body = t.stmt(n.Body)
+	case *ForOfStatement:
+		n.Into = t.forInto(n.Into)
+		n.Source = t.expr(n.Source)
+		n.Body = t.stmt(n.Body)
+	case *ForStatement:
+		if n.Initializer != nil {
+			r := t.node(n.Initializer)
+			if init, ok := r.(ForLoopInitializer); ok || r == nil {
+				n.Initializer = init
+			} else {
+				panic(t.mismatch(n.Initializer, r))
+			}
+		}
+		n.Test = t.expr(n.Test)
+		n.Update = t.expr(n.Update)
+		n.Body = t.stmt(n.Body)
+	case *IfStatement:
+		n.Test = t.expr(n.Test)
+		n.Consequent = t.stmt(n.Consequent)
+		n.Alternate = t.stmt(n.Alternate)
+	case *LabelledStatement:
+		n.Label = t.ident(n.Label)
+		n.Statement = t.stmt(n.Statement)
+	case *ReturnStatement:
+		n.Argument = t.expr(n.Argument)
+	case *SwitchStatement:
+		n.Discriminant = t.expr(n.Discriminant)
+		cases := n.Body[:0]
+		def := -1
+		for _, c := range n.Body {
+			r := t.node(c)
+			if r == nil {
+				continue
+			}
+			c1, ok := r.(*CaseStatement)
+			if !ok {
+				panic(t.mismatch(c, r))
+			}
+			if c1.Test == nil {
+				def = len(cases)
+			}
+			cases = append(cases, c1)
+		}
+		n.Body, n.Default = cases, def
+	case *ThrowStatement:
+		n.Argument = t.expr(n.Argument)
+	case *TryStatement:
+		n.Body = t.block(n.Body)
+		if n.Catch != nil {
+			r := t.node(n.Catch)
+			if c, ok := r.(*CatchStatement); ok || r == nil {
+				n.Catch = c
+			} else {
+				panic(t.mismatch(n.Catch, r))
+			}
+		}
+		n.Finally = t.block(n.Finally)
+	case *VariableStatement:
+		n.List = t.bindingList(n.List)
+	case *LexicalDeclaration:
+		n.List = t.bindingList(n.List)
+	case *WhileStatement:
+		n.Test = t.expr(n.Test)
+		n.Body = t.stmt(n.Body)
+	case *WithStatement:
+		n.Object = t.expr(n.Object)
+		n.Body = t.stmt(n.Body)
+	case *FunctionDeclaration:
+		n.Function = t.functionLiteral(n.Function)
+	case *ClassDeclaration:
+		r := t.node(n.Class)
+		if c, ok := r.(*ClassLiteral); ok || r == nil {
+			n.Class = c
+		} else {
+			panic(t.mismatch(n.Class, r))
+		}
+
+	// Declarations, class elements and for loop parts
+	case *VariableDeclaration:
+		n.List = t.bindingList(n.List)
+	case *FieldDefinition:
+		n.Key = t.expr(n.Key)
+		n.Initializer = t.expr(n.Initializer)
+	case *MethodDefinition:
+		n.Key = t.expr(n.Key)
+		n.Body = t.functionLiteral(n.Body)
+	case *ClassStaticBlock:
+		n.Block = t.block(n.Block)
+	case *ForLoopInitializerExpression:
+		n.Expression = t.expr(n.Expression)
+	case *ForLoopInitializerVarDeclList:
+		n.List = t.bindingList(n.List)
+	case *ForLoopInitializerLexicalDecl:
+		if r, ok := t.node(&n.LexicalDeclaration).(*LexicalDeclaration); ok && r != nil {
+			n.LexicalDeclaration = *r
+		} else {
+			panic(t.mismatch(&n.LexicalDeclaration, r))
+		}
+	case *ForIntoVar:
+		n.Binding = t.bindingNode(n.Binding)
+	case *ForDeclaration:
+		n.Target = t.bindingTarget(n.Target)
+	case *ForIntoExpression:
+		n.Expression = t.expr(n.Expression)
+
+	case *Program:
+		n.Body = t.stmtList(n.Body)
+
+	default:
+		panic(fmt.Sprintf("ast.Transform: unexpected node type %T", n))
+	}
+
+	return t.f(node)
+}
+
+func (t *transformer) expr(expr Expression) Expression {
+	if expr == nil {
+		return nil
+	}
+	r := t.node(expr)
+	if r == nil {
+		return nil
+	}
+	if e, ok := r.(Expression); ok {
+		return e
+	}
+	panic(t.mismatch(expr, r))
+}
+
+func (t *transformer) exprList(list []Expression) []Expression {
+	res := list[:0]
+	for _, expr := range list {
+		if e := t.expr(expr); e != nil {
+			res = append(res, e)
+		}
+	}
+	return res
+}
+
+func (t *transformer) elements(list []Expression) []Expression {
+	for i, expr := range list {
+		list[i] = t.expr(expr)
+	}
+	return list
+}
+
+func (t *transformer) stmt(stmt Statement) Statement {
+	if stmt == nil {
+		return nil
+	}
+	r := t.node(stmt)
+	if r == nil {
+		return nil
+	}
+	if s, ok := r.(Statement); ok {
+		return s
+	}
+	panic(t.mismatch(stmt, r))
+}
+
+func (t *transformer) stmtList(list []Statement) []Statement {
+	res := list[:0]
+	for _, stmt := range list {
+		if s := t.stmt(stmt); s != nil {
+			res = append(res, s)
+		}
+	}
+	return res
+}
+
+func (t *transformer) block(b *BlockStatement) *BlockStatement {
+	if b == nil {
+		return nil
+	}
+	r := t.node(b)
+	if res, ok := r.(*BlockStatement); ok || r == nil {
+		return res
+	}
+	panic(t.mismatch(b, r))
+}
+
+func (t *transformer) ident(id *Identifier) *Identifier {
+	if id == nil {
+		return nil
+	}
+	r := t.node(id)
+	if res, ok := r.(*Identifier); ok || r == nil {
+		return res
+	}
+	panic(t.mismatch(id, r))
+}
+
+func (t *transformer) identValue(id *Identifier) {
+	r, ok := t.node(id).(*Identifier)
+	if !ok || r == nil {
+		panic(t.mismatch(id, r))
+	}
+	*id = *r
+}
+
+func (t *transformer) bindingTarget(target BindingTarget) BindingTarget {
+	if target == nil {
+		return nil
+	}
+	r := t.node(target)
+	if res, ok := r.(BindingTarget); ok || r == nil {
+		return res
+	}
+	panic(t.mismatch(target, r))
+}
+
+func (t *transformer) binding(b *Binding) {
+	b.Target = t.bindingTarget(b.Target)
+	b.Initializer = t.expr(b.Initializer)
+}
+
+func (t *transformer) bindingNode(b *Binding) *Binding {
+	if b == nil {
+		return nil
+	}
+	r := t.node(b)
+	if res, ok := r.(*Binding); ok || r == nil {
+		return res
+	}
+	panic(t.mismatch(b, r))
+}
+
+func (t *transformer) bindingList(list []*Binding) []*Binding {
+	res := list[:0]
+	for _, b := range list {
+		if b1 := t.bindingNode(b); b1 != nil {
+			res = append(res, b1)
+		}
+	}
+	return res
+}
+
+func (t *transformer) properties(list []Property) []Property {
+	res := list[:0]
+	for _, prop := range list {
+		r := t.node(prop)
+		if r == nil {
+			continue
+		}
+		p, ok := r.(Property)
+		if !ok {
+			panic(t.mismatch(prop, r))
+		}
+		res = append(res, p)
+	}
+	return res
+}
+
+func (t *transformer) parameterList(list *ParameterList) *ParameterList {
+	if list == nil {
+		return nil
+	}
+	r := t.node(list)
+	if res, ok := r.(*ParameterList); ok || r == nil {
+		return res
+	}
+	panic(t.mismatch(list, r))
+}
+
+func (t *transformer) function(fn *FunctionLiteral) {
+	fn.Name = t.ident(fn.Name)
+	fn.ParameterList = t.parameterList(fn.ParameterList)
+	fn.Body = t.block(fn.Body)
+}
+
+func (t *transformer) functionLiteral(fn *FunctionLiteral) *FunctionLiteral {
+	if fn == nil {
+		return nil
+	}
+	r := t.node(fn)
+	if res, ok := r.(*FunctionLiteral); ok || r == nil {
+		return res
+	}
+	panic(t.mismatch(fn, r))
+}
+
+func (t *transformer) class(cls *ClassLiteral) {
+	cls.Name = t.ident(cls.Name)
+	cls.SuperClass = t.expr(cls.SuperClass)
+	body := cls.Body[:0]
+	for _, elem := range cls.Body {
+		r := t.node(elem)
+		if r == nil {
+			continue
+		}
+		e, ok := r.(ClassElement)
+		if !ok {
+			panic(t.mismatch(elem, r))
+		}
+		body = append(body, e)
+	}
+	cls.Body = body
+}
+
+func (t *transformer) caseClause(c *CaseStatement) {
+	c.Test = t.expr(c.Test)
+	c.Consequent = t.stmtList(c.Consequent)
+}
+
+func (t *transformer) catchClause(c *CatchStatement) {
+	c.Parameter = t.bindingTarget(c.Parameter)
+	c.Body = t.block(c.Body)
+}
+
+func (t *transformer) forInto(into ForInto) ForInto {
+	if into == nil {
+		return nil
+	}
+	r := t.node(into)
+	if res, ok := r.(ForInto); ok || r == nil {
+		return res
+	}
+	panic(t.mismatch(into, r))
+}
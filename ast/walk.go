@@ -0,0 +1,310 @@
+package ast
+
+import "fmt"
+
+// A Visitor's Visit method is invoked for each node encountered by Walk.
+// If the result visitor w is not nil, Walk visits each of the children
+// of node with the visitor w, followed by a call of w.Visit(nil).
+type Visitor interface {
+	Visit(node Node) (w Visitor)
+}
+
+// Walk traverses an AST in depth-first order: It starts by calling v.Visit(node); node must not be nil.
+// If the visitor w returned by v.Visit(node) is not nil, Walk is invoked recursively with visitor w for
+// each of the non-nil children of node in source order, followed by a call of w.Visit(nil).
+//
+// DeclarationList fields are not traversed, they only refer to the bindings of var declarations that are
+// reachable from the function body.
+func Walk(v Visitor, node Node) {
+	if v = v.Visit(node); v == nil {
+		return
+	}
+
+	switch n := node.(type) {
+	// Expressions
+	case *BadExpression, *BooleanLiteral, *Identifier, *PrivateIdentifier, *NullLiteral, *NumberLiteral,
+		*RegExpLiteral, *StringLiteral, *TemplateElement, *ThisExpression, *SuperExpression:
+		// nothing to do
+
+	case *ArrayLiteral:
+		walkExpressionList(v, n.Value)
+	case *ArrayPattern:
+		walkExpressionList(v, n.Elements)
+		if n.Rest != nil {
+			Walk(v, n.Rest)
+		}
+	case *AssignExpression:
+		Walk(v, n.Left)
+		Walk(v, n.Right)
+	case *AwaitExpression:
+		Walk(v, n.Argument)
+	case *BinaryExpression:
+		Walk(v, n.Left)
+		Walk(v, n.Right)
+	case *BracketExpression:
+		Walk(v, n.Left)
+		Walk(v, n.Member)
+	case *CallExpression:
+		Walk(v, n.Callee)
+		walkExpressionList(v, n.ArgumentList)
+	case *ConditionalExpression:
+		Walk(v, n.Test)
+		Walk(v, n.Consequent)
+		Walk(v, n.Alternate)
+	case *DotExpression:
+		Walk(v, n.Left)
+		Walk(v, &n.Identifier)
+	case *PrivateDotExpression:
+		Walk(v, n.Left)
+		Walk(v, &n.Identifier)
+	case *OptionalChain:
+		Walk(v, n.Expression)
+	case *Optional:
+		Walk(v, n.Expression)
+	case *FunctionLiteral:
+		if n.Name != nil {
+			Walk(v, n.Name)
+		}
+		if n.ParameterList != nil {
+			Walk(v, n.ParameterList)
+		}
+		if n.Body != nil {
+			Walk(v, n.Body)
+		}
+	case *ClassLiteral:
+		if n.Name != nil {
+			Walk(v, n.Name)
+		}
+		if n.SuperClass != nil {
+			Walk(v, n.SuperClass)
+		}
+		for _, elem := range n.Body {
+			Walk(v, elem)
+		}
+	case *ArrowFunctionLiteral:
+		if n.ParameterList != nil {
+			Walk(v, n.ParameterList)
+		}
+		if n.Body != nil {
+			Walk(v, n.Body)
+		}
+	case *NewExpression:
+		Walk(v, n.Callee)
+		walkExpressionList(v, n.ArgumentList)
+	case *ObjectLiteral:
+		for _, prop := range n.Value {
+			Walk(v, prop)
+		}
+	case *ObjectPattern:
+		for _, prop := range n.Properties {
+			Walk(v, prop)
+		}
+		if n.Rest != nil {
+			Walk(v, n.Rest)
+		}
+	case *ParameterList:
+		for _, b := range n.List {
+			Walk(v, b)
+		}
+		if n.Rest != nil {
+			Walk(v, n.Rest)
+		}
+	case *PropertyShort:
+		Walk(v, &n.Name)
+		if n.Initializer != nil {
+			Walk(v, n.Initializer)
+		}
+	case *PropertyKeyed:
+		Walk(v, n.Key)
+		Walk(v, n.Value)
+	case *SpreadElement:
+		Walk(v, n.Expression)
+	case *SequenceExpression:
+		walkExpressionList(v, n.Sequence)
+	case *TemplateLiteral:
+		if n.Tag != nil {
+			Walk(v, n.Tag)
+		}
+		for i, elem := range n.Elements {
+			Walk(v, elem)
+			if i < len(n.Expressions) {
+				Walk(v, n.Expressions[i])
+			}
+		}
+	case *UnaryExpression:
+		Walk(v, n.Operand)
+	case *MetaProperty:
+		Walk(v, n.Meta)
+		Walk(v, n.Property)
+	case *YieldExpression:
+		if n.Argument != nil {
+			Walk(v, n.Argument)
+		}
+	case *Binding:
+		Walk(v, n.Target)
+		if n.Initializer != nil {
+			Walk(v, n.Initializer)
+		}
+	case *ExpressionBody:
+		Walk(v, n.Expression)
+
+	// Statements
+	case *BadStatement, *DebuggerStatement, *EmptyStatement:
+		// nothing to do
+
+	case *BlockStatement:
+		walkStatementList(v, n.List)
+	case *BranchStatement:
+		if n.Label != nil {
+			Walk(v, n.Label)
+		}
+	case *CaseStatement:
+		if n.Test != nil {
+			Walk(v, n.Test)
+		}
+		walkStatementList(v, n.Consequent)
+	case *CatchStatement:
+		if n.Parameter != nil {
+			Walk(v, n.Parameter)
+		}
+		Walk(v, n.Body)
+	case *DoWhileStatement:
+		Walk(v, n.Body)
+		Walk(v, n.Test)
+	case *ExpressionStatement:
+		Walk(v, n.Expression)
+	case *ForInStatement:
+		Walk(v, n.Into)
+		Walk(v, n.Source)
+		Walk(v, n.Body)
+	case *ForOfStatement:
+		Walk(v, n.Into)
+		Walk(v, n.Source)
+		Walk(v, n.Body)
+	case *ForStatement:
+		if n.Initializer != nil {
+			Walk(v, n.Initializer)
+		}
+		if n.Test != nil {
+			Walk(v, n.Test)
+		}
+		if n.Update != nil {
+			Walk(v, n.Update)
+		}
+		Walk(v, n.Body)
+	case *IfStatement:
+		Walk(v, n.Test)
+		Walk(v, n.Consequent)
+		if n.Alternate != nil {
+			Walk(v, n.Alternate)
+		}
+	case *LabelledStatement:
+		Walk(v, n.Label)
+		Walk(v, n.Statement)
+	case *ReturnStatement:
+		if n.Argument != nil {
+			Walk(v, n.Argument)
+		}
+	case *SwitchStatement:
+		Walk(v, n.Discriminant)
+		for _, c := range n.Body {
+			Walk(v, c)
+		}
+	case *ThrowStatement:
+		Walk(v, n.Argument)
+	case *TryStatement:
+		Walk(v, n.Body)
+		if n.Catch != nil {
+			Walk(v, n.Catch)
+		}
+		if n.Finally != nil {
+			Walk(v, n.Finally)
+		}
+	case *VariableStatement:
+		for _, b := range n.List {
+			Walk(v, b)
+		}
+	case *LexicalDeclaration:
+		for _, b := range n.List {
+			Walk(v, b)
+		}
+	case *WhileStatement:
+		Walk(v, n.Test)
+		Walk(v, n.Body)
+	case *WithStatement:
+		Walk(v, n.Object)
+		Walk(v, n.Body)
+	case *FunctionDeclaration:
+		Walk(v, n.Function)
+	case *ClassDeclaration:
+		Walk(v, n.Class)
+
+	// Declarations, class elements and for loop parts
+	case *VariableDeclaration:
+		for _, b := range n.List {
+			Walk(v, b)
+		}
+	case *FieldDefinition:
+		Walk(v, n.Key)
+		if n.Initializer != nil {
+			Walk(v, n.Initializer)
+		}
+	case *MethodDefinition:
+		Walk(v, n.Key)
+		Walk(v, n.Body)
+	case *ClassStaticBlock:
+		Walk(v, n.Block)
+	case *ForLoopInitializerExpression:
+		Walk(v, n.Expression)
+	case *ForLoopInitializerVarDeclList:
+		for _, b := range n.List {
+			Walk(v, b)
+		}
+	case *ForLoopInitializerLexicalDecl:
+		Walk(v, &n.LexicalDeclaration)
+	case *ForIntoVar:
+		Walk(v, n.Binding)
+	case *ForDeclaration:
+		Walk(v, n.Target)
+	case *ForIntoExpression:
+		Walk(v, n.Expression)
+
+	case *Program:
+		walkStatementList(v, n.Body)
+
+	default:
+		panic(fmt.Sprintf("ast.Walk: unexpected node type %T", n))
+	}
+
+	v.Visit(nil)
+}
+
+func walkExpressionList(v Visitor, list []Expression) {
+	for _, expr := range list {
+		if expr != nil {
+			Walk(v, expr)
+		}
+	}
+}
+
+func walkStatementList(v Visitor, list []Statement) {
+	for _, stmt := range list {
+		Walk(v, stmt)
+	}
+}
+
+type inspector func(Node) bool
+
+func (f inspector) Visit(node Node) Visitor {
+	if f(node) {
+		return f
+	}
+	return nil
+}
+
+// Inspect traverses an AST in depth-first order: It starts by calling f(node); node must not be nil.
+// If f returns true, Inspect invokes f recursively for each of the non-nil children of node,
+// followed by a call of f(nil).
+func Inspect(node Node, f func(Node) bool) {
+	Walk(inspector(f), node)
+}
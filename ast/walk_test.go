@@ -0,0 +1,269 @@
+package ast_test
+
+import (
+	goast "go/ast"
+	goparser "go/parser"
+	gotoken "go/token"
+	"reflect"
+	"strconv"
+	"testing"
+
+	"github.com/rarnu/goscript/ast"
+	"github.com/rarnu/goscript/parser"
+	"github.com/rarnu/goscript/printer"
+)
+
+// corpus returns the programs of the parser tests that are expected to parse without errors,
+// plus a few covering the newer syntax.
+func corpus(t *testing.T) []*ast.Program {
+	f, err := goparser.ParseFile(gotoken.NewFileSet(), "../parser/parser_test.go", nil, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sources := []string{
+		`class A extends B { static #x = 1; #y; static { this.z = A.#x } get [k]() { return #y in this } }`,
+		`a?.b.c?.[d]?.(e); new.target; let {p, q: [r = 1, ...s], ...u} = o; for (const [k, v] of m) ;`,
+		"async function* f(a = 1, ...b) { yield* g`x${await h}y`; label: for (x in y) continue label }",
+		`try { } catch ({message}) { } finally { } switch (x) { case 1: default: } with (o) debugger`,
+		`for (let i = 0, j; i < 1; i++) ; for (var v of []) ; x => ({}); async (y) => { }`,
+	}
+	goast.Inspect(f, func(n goast.Node) bool {
+		call, ok := n.(*goast.CallExpr)
+		if !ok || len(call.Args) != 2 {
+			return true
+		}
+		if fn, ok := call.Fun.(*goast.Ident); !ok || fn.Name != "test" {
+			return true
+		}
+		lit, ok := call.Args[0].(*goast.BasicLit)
+		if !ok || lit.Kind != gotoken.STRING {
+			return true
+		}
+		if chk, ok := call.Args[1].(*goast.Ident); !ok || chk.Name != "nil" {
+			return true
+		}
+		if src, err := strconv.Unquote(lit.Value); err == nil {
+			sources = append(sources, src)
+		}
+		return true
+	})
+
+	var programs []*ast.Program
+	for _, src := range sources {
+		if prg, err := parser.ParseFile(nil, "", src, 0); err == nil {
+			programs = append(programs, prg)
+		}
+	}
+	if len(programs) < 100 {
+		t.Fatalf("The corpus is too small: %d", len(programs))
+	}
+	return programs
+}
+
+// reachable collects all nodes reachable from node through the fields of the node structs.
+func reachable(node ast.Node) map[ast.Node]bool {
+	nodeType := reflect.TypeOf((*ast.Node)(nil)).Elem()
+	res := make(map[ast.Node]bool)
+	var visit func(v reflect.Value)
+	visit = func(v reflect.Value) {
+		switch v.Kind() {
+		case reflect.Interface:
+			if !v.IsNil() {
+				visit(v.Elem())
+			}
+		case reflect.Ptr:
+			if v.IsNil() || !v.Type().Implements(nodeType) {
+				return
+			}
+			res[v.Interface().(ast.Node)] = true
+			v = v.Elem()
+			for i := 0; i < v.NumField(); i++ {
+				field := v.Type().Field(i)
+				if field.Name == "DeclarationList" || field.PkgPath != "" {
+					continue
+				}
+				if field.Type.Kind() == reflect.Struct {
+					// an embedded struct is a part of the same node
+					if !field.Anonymous {
+						visit(v.Field(i).Addr())
+					}
+					continue
+				}
+				visit(v.Field(i))
+			}
+		case reflect.Slice:
+			for i := 0; i < v.Len(); i++ {
+				visit(v.Index(i))
+			}
+		}
+	}
+	visit(reflect.ValueOf(node))
+	return res
+}
+
+type countingVisitor struct {
+	nodes map[ast.Node]int
+	depth int
+}
+
+func (v *countingVisitor) Visit(node ast.Node) ast.Visitor {
+	if node == nil {
+		v.depth--
+		return nil
+	}
+	v.nodes[node]++
+	v.depth++
+	return v
+}
+
+func TestWalk(t *testing.T) {
+	types := make(map[string]bool)
+	for _, prg := range corpus(t) {
+		v := &countingVisitor{nodes: make(map[ast.Node]int)}
+		ast.Walk(v, prg)
+		if v.depth != 0 {
+			t.Fatalf("Unbalanced Visit(nil) calls: %d", v.depth)
+		}
+		expected := reachable(prg)
+		for node := range expected {
+			types[reflect.TypeOf(node).Elem().Name()] = true
+			if v.nodes[node] != 1 {
+				t.Fatalf("%T at %d was visited %d times", node, node.Idx0(), v.nodes[node])
+			}
+		}
+		if len(v.nodes) != len(expected) {
+			t.Fatalf("Visited %d nodes, expected %d", len(v.nodes), len(expected))
+		}
+	}
+	for _, name := range []string{"ClassStaticBlock", "PrivateDotExpression", "OptionalChain", "Optional", "ObjectPattern",
+		"ArrayPattern", "MetaProperty", "TemplateElement", "ForLoopInitializerLexicalDecl", "ForDeclaration", "ForIntoVar"} {
+		if !types[name] {
+			t.Errorf("%s is not covered by the corpus", name)
+		}
+	}
+}
+
+func TestInspect(t *testing.T) {
+	prg, err := parser.ParseFile(nil, "", `HTTP.get(url); function f() { return HTTP.delete(url) } eval("x")`, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var found []string
+	ast.Inspect(prg, func(n ast.Node) bool {
+		switch n := n.(type) {
+		case *ast.DotExpression:
+			if obj, ok := n.Left.(*ast.Identifier); ok && obj.Name == "HTTP" && n.Identifier.Name == "delete" {
+				found = append(found, "HTTP.delete")
+			}
+		case *ast.CallExpression:
+			if id, ok := n.Callee.(*ast.Identifier); ok && id.Name == "eval" {
+				found = append(found, "eval")
+			}
+		case *ast.FunctionLiteral:
+			found = append(found, "function")
+		}
+		return true
+	})
+	if !reflect.DeepEqual(found, []string{"function", "HTTP.delete", "eval"}) {
+		t.Fatalf("Unexpected result: %v", found)
+	}
+
+	found = nil
+	ast.Inspect(prg, func(n ast.Node) bool {
+		if _, ok := n.(*ast.FunctionDeclaration); ok {
+			return false
+		}
+		if n, ok := n.(*ast.DotExpression); ok {
+			found = append(found, n.Identifier.Name.String())
+		}
+		return true
+	})
+	if !reflect.DeepEqual(found, []string{"get"}) {
+		t.Fatalf("Unexpected result: %v", found)
+	}
+}
+
+func TestTransformRoundTrip(t *testing.T) {
+	for _, prg := range corpus(t) {
+		expected, err := printer.Print(prg)
+		if err != nil {
+			t.Fatal(err)
+		}
+		count := 0
+		res := ast.Transform(prg, func(n ast.Node) ast.Node {
+			count++
+			return n
+		})
+		if res != prg {
+			t.Fatal("The root has been replaced")
+		}
+		if count != len(reachable(prg)) {
+			t.Fatalf("Transformed %d nodes, expected %d", count, len(reachable(prg)))
+		}
+		out, err := printer.Print(prg)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if out != expected {
+			t.Fatalf("Identity transform changed the program:\n%s\n---\n%s", expected, out)
+		}
+	}
+}
+
+func TestTransform(t *testing.T) {
+	prg, err := parser.ParseFile(nil, "", `
+	debugger;
+	class A { m() { return eval("1") } static { debugger } }
+	var o = {a: 1, b: 2};
+	switch (x) { case 1: debugger; default: HTTP.delete(url) }
+	`, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ast.Transform(prg, func(n ast.Node) ast.Node {
+		switch n := n.(type) {
+		case *ast.DebuggerStatement:
+			return nil
+		case *ast.Identifier:
+			if n.Name == "eval" {
+				return &ast.Identifier{Idx: n.Idx, Name: "safeEval"}
+			}
+		case *ast.PropertyKeyed:
+			if key, ok := n.Key.(*ast.StringLiteral); ok && key.Value == "a" {
+				return nil
+			}
+		case *ast.CaseStatement:
+			if n.Test != nil {
+				return nil
+			}
+		case *ast.DotExpression:
+			if n.Identifier.Name == "delete" {
+				n.Identifier.Name = "del"
+			}
+		}
+		return n
+	})
+	out, err := printer.Print(prg, printer.WithMinify)
+	if err != nil {
+		t.Fatal(err)
+	}
+	const expected = `class A{m(){return safeEval("1");}static{}}var o={b:2};switch(x){default:HTTP.del(url);}`
+	if out != expected {
+		t.Fatalf("Unexpected output: %s", out)
+	}
+	if sw := prg.Body[2].(*ast.SwitchStatement); sw.Default != 0 {
+		t.Fatalf("Unexpected default index: %d", sw.Default)
+	}
+
+	defer func() {
+		if x := recover(); x != "ast.Transform: *ast.EmptyStatement cannot be used in place of *ast.Identifier" {
+			t.Fatalf("Unexpected panic: %v", x)
+		}
+	}()
+	ast.Transform(prg, func(n ast.Node) ast.Node {
+		if _, ok := n.(*ast.Identifier); ok {
+			return &ast.EmptyStatement{}
+		}
+		return n
+	})
+}
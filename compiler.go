@@ -52,6 +52,7 @@ type CompilerError struct {
 
 type CompilerSyntaxError struct {
 	CompilerError
+	cause error
 }
 
 type CompilerReferenceError struct {
@@ -86,7 +87,8 @@ type compiler struct {
 
 	codeScratchpad []instruction
 
-	debug bool
+	debug    bool
+	coverage *coverageFile
 }
 
 type binding struct {
@@ -347,6 +349,12 @@ func (c *compiler) leaveBlock() {
 	c.block = c.block.outer
 }
 
+// Unwrap returns the parser.ErrorList the error has been created from, if any. When parsing with
+// parser.WithErrorRecovery it holds every syntax error with its range.
+func (e *CompilerSyntaxError) Unwrap() error {
+	return e.cause
+}
+
 func (e *CompilerSyntaxError) Error() string {
 	if e.File != nil {
 		return fmt.Sprintf("SyntaxError: %s at %s", e.Message, e.File.Position(e.Offset))
@@ -183,21 +183,25 @@ type compiledUnaryExpr struct {
 type compiledConditionalExpr struct {
 	baseCompiledExpr
 	test, consequent, alternate compiledExpr
+	arms                        []*uint64
 }
 
 type compiledLogicalOr struct {
 	baseCompiledExpr
 	left, right compiledExpr
+	arms        []*uint64
 }
 
 type compiledCoalesce struct {
 	baseCompiledExpr
 	left, right compiledExpr
+	arms        []*uint64
 }
 
 type compiledLogicalAnd struct {
 	baseCompiledExpr
 	left, right compiledExpr
+	arms        []*uint64
 }
 
 type compiledBinaryExpr struct {
@@ -1577,6 +1581,9 @@ func (e *compiledFunctionLiteral) compile() (prg *Program, name unistring.String
 		}
 	}
 
+	if e.c.coverage != nil && e.typ != funcClsInit && e.source != "" {
+		e.c.emitCoverageCount(e.c.coverage.fn(name.String(), e.offset, e.offset+len(e.source)))
+	}
 	e.c.compileFunctions(funcs)
 	if e.isGenerator {
 		e.c.emit(yieldEmpty)
@@ -2587,10 +2594,16 @@ func (e *compiledConditionalExpr) emitGetter(putOnStack bool) {
 	e.test.emitGetter(true)
 	j := len(e.c.p.code)
 	e.c.emit(nil)
+	if e.arms != nil {
+		e.c.emitCoverageCount(e.arms[0])
+	}
 	e.consequent.emitGetter(putOnStack)
 	j1 := len(e.c.p.code)
 	e.c.emit(nil)
 	e.c.p.code[j] = jne(len(e.c.p.code) - j)
+	if e.arms != nil {
+		e.c.emitCoverageCount(e.arms[1])
+	}
 	e.alternate.emitGetter(putOnStack)
 	e.c.p.code[j1] = jump(len(e.c.p.code) - j1)
 }
@@ -2601,6 +2614,9 @@ func (c *compiler) compileConditionalExpression(v *ast.ConditionalExpression) co
 		consequent: c.compileExpression(v.Consequent),
 		alternate:  c.compileExpression(v.Alternate),
 	}
+	if c.coverage != nil {
+		r.arms = c.coverBranch(coverageBranchCond, v, v.Consequent, v.Alternate)
+	}
 	r.init(c, v.Idx0())
 	return r
 }
@@ -2635,10 +2651,16 @@ func (e *compiledLogicalOr) emitGetter(putOnStack bool) {
 		}
 		return
 	}
+	if e.arms != nil {
+		e.c.emitCoverageCount(e.arms[0])
+	}
 	e.c.emitExpr(e.left, true)
 	j := len(e.c.p.code)
 	e.addSrcMap()
 	e.c.emit(nil)
+	if e.arms != nil {
+		e.c.emitCoverageCount(e.arms[1])
+	}
 	e.c.emitExpr(e.right, true)
 	e.c.p.code[j] = jeq1(len(e.c.p.code) - j)
 	if !putOnStack {
@@ -2676,10 +2698,16 @@ func (e *compiledCoalesce) emitGetter(putOnStack bool) {
 		}
 		return
 	}
+	if e.arms != nil {
+		e.c.emitCoverageCount(e.arms[0])
+	}
 	e.c.emitExpr(e.left, true)
 	j := len(e.c.p.code)
 	e.addSrcMap()
 	e.c.emit(nil)
+	if e.arms != nil {
+		e.c.emitCoverageCount(e.arms[1])
+	}
 	e.c.emitExpr(e.right, true)
 	e.c.p.code[j] = jcoalesc(len(e.c.p.code) - j)
 	if !putOnStack {
@@ -2717,10 +2745,16 @@ func (e *compiledLogicalAnd) emitGetter(putOnStack bool) {
 		}
 		return
 	}
+	if e.arms != nil {
+		e.c.emitCoverageCount(e.arms[0])
+	}
 	e.left.emitGetter(true)
 	j = len(e.c.p.code)
 	e.addSrcMap()
 	e.c.emit(nil)
+	if e.arms != nil {
+		e.c.emitCoverageCount(e.arms[1])
+	}
 	e.c.emitExpr(e.right, true)
 	e.c.p.code[j] = jneq1(len(e.c.p.code) - j)
 	if !putOnStack {
@@ -2850,6 +2884,7 @@ func (c *compiler) compileLogicalOr(left, right ast.Expression, idx file.Idx) co
 		right: c.compileExpression(right),
 	}
 	r.init(c, idx)
+	r.arms = c.coverLogical(r.left, left, right)
 	return r
 }
 
@@ -2859,6 +2894,7 @@ func (c *compiler) compileCoalesce(left, right ast.Expression, idx file.Idx) com
 		right: c.compileExpression(right),
 	}
 	r.init(c, idx)
+	r.arms = c.coverLogical(r.left, left, right)
 	return r
 }
 
@@ -2868,6 +2904,7 @@ func (c *compiler) compileLogicalAnd(left, right ast.Expression, idx file.Idx) c
 		right: c.compileExpression(right),
 	}
 	r.init(c, idx)
+	r.arms = c.coverLogical(r.left, left, right)
 	return r
 }
 
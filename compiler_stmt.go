@@ -8,6 +8,7 @@ import (
 )
 
 func (c *compiler) compileStatement(v ast.Statement, needResult bool) {
+	c.coverStatement(v)
 
 	switch v := v.(type) {
 	case *ast.BlockStatement:
@@ -707,15 +708,29 @@ func (c *compiler) compileIfStatement(v *ast.IfStatement, needResult bool) {
 		}
 		return
 	}
+	var arms []*uint64
+	if c.coverage != nil {
+		arms = c.coverBranch(coverageBranchIf, v, v.Consequent, v.Alternate)
+	}
 	test.emitGetter(true)
 	jmp := len(c.p.code)
 	c.emit(nil)
+	if arms != nil {
+		c.emitCoverageCount(arms[0])
+	}
 	c.compileIfBody(v.Consequent, needResult)
-	if v.Alternate != nil {
+	if v.Alternate != nil || arms != nil {
 		jmp1 := len(c.p.code)
 		c.emit(nil)
 		c.p.code[jmp] = jne(len(c.p.code) - jmp)
-		c.compileIfBody(v.Alternate, needResult)
+		if arms != nil {
+			c.emitCoverageCount(arms[1])
+		}
+		if v.Alternate != nil {
+			c.compileIfBody(v.Alternate, needResult)
+		} else if needResult {
+			c.emit(clearResult)
+		}
 		c.p.code[jmp1] = jump(len(c.p.code) - jmp1)
 	} else {
 		if needResult {
@@ -1105,10 +1120,22 @@ func (c *compiler) compileSwitchStatement(v *ast.SwitchStatement, needResult boo
 		c.emit(nil)
 	}
 
+	var arms []*uint64
+	if c.coverage != nil && len(v.Body) > 0 {
+		cases := make([]ast.Node, len(v.Body))
+		for i, s := range v.Body {
+			cases[i] = s
+		}
+		arms = c.coverBranch(coverageBranchSwitch, v, cases...)
+	}
+
 	for i, s := range v.Body {
 		if s.Test != nil || i != 0 {
 			c.p.code[jumps[i]] = jump(len(c.p.code) - jumps[i])
 		}
+		if arms != nil {
+			c.emitCoverageCount(arms[i])
+		}
 		c.compileStatements(s.Consequent, needResult)
 	}
 
@@ -200,6 +200,15 @@ func (r *Runtime) testScriptWithTestLib(script string, expectedResult Value, t *
 	r.testScript(script, expectedResult, t)
 }
 
+// newWithTestLib returns a new Runtime on which the test library is loaded, for the tests that run their own scripts.
+func newWithTestLib(t *testing.T) *Runtime {
+	r := New()
+	if _, err := r.RunProgram(testLib()); err != nil {
+		t.Fatal(err)
+	}
+	return r
+}
+
 func (r *Runtime) testScriptWithTestLibX(script string, expectedResult Value, t *testing.T) {
 	_, err := r.RunProgram(testLib())
 	if err != nil {
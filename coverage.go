@@ -0,0 +1,434 @@
+package goscript
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"sync"
+	"sync/atomic"
+
+	"github.com/rarnu/goscript/ast"
+	"github.com/rarnu/goscript/file"
+)
+
+// Coverage collects statement, branch and function hit counts of programs that have been compiled with
+// coverage instrumentation (see CompileWithCoverage and Runtime.SetCoverage). The collected data can be
+// exported in LCOV or Istanbul JSON format.
+//
+// A Coverage instance may be shared between several Programs and Runtimes, including concurrently running ones.
+// Data is collected per file name; compiling a different source under the same name discards the data
+// previously collected for that name.
+type Coverage struct {
+	mu    sync.Mutex
+	files map[string]*coverageFile
+	names []string
+}
+
+type coverageKind uint8
+
+const (
+	coverageKindStmt coverageKind = iota
+	coverageKindFunc
+	coverageKindBranch
+)
+
+// Branch types, as used by Istanbul.
+const (
+	coverageBranchIf     = "if"
+	coverageBranchCond   = "cond-expr"
+	coverageBranchBinary = "binary-expr"
+	coverageBranchSwitch = "switch"
+)
+
+type coverageKey struct {
+	kind       coverageKind
+	start, end int
+}
+
+type coverageRange struct {
+	start, end int // source offsets, end is exclusive
+	hits       uint64
+}
+
+type coverageFunc struct {
+	coverageRange
+	name string
+}
+
+type coverageBranch struct {
+	start, end int
+	typ        string
+	arms       []*coverageRange
+}
+
+type coverageFile struct {
+	cov      *Coverage
+	src      *file.File
+	stmts    []*coverageRange
+	funcs    []*coverageFunc
+	branches []*coverageBranch
+	index    map[coverageKey]int
+}
+
+// coverageCount is the instruction emitted by the compiler in coverage mode at the start of every
+// statement, function body and branch arm.
+type coverageCount struct {
+	hits *uint64
+}
+
+func (c coverageCount) exec(vm *vm) {
+	atomic.AddUint64(c.hits, 1)
+	vm.pc++
+}
+
+// NewCoverage creates an empty Coverage.
+func NewCoverage() *Coverage {
+	return &Coverage{
+		files: make(map[string]*coverageFile),
+	}
+}
+
+func (c *Coverage) file(src *file.File) *coverageFile {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	name := src.Name()
+	f := c.files[name]
+	if f == nil {
+		c.names = append(c.names, name)
+	}
+	if f == nil || f.src.Source() != src.Source() {
+		f = &coverageFile{
+			cov:   c,
+			src:   src,
+			index: make(map[coverageKey]int),
+		}
+		c.files[name] = f
+	}
+	return f
+}
+
+// Reset zeroes all hit counters while keeping the instrumented files.
+func (c *Coverage) Reset() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for _, f := range c.files {
+		for _, s := range f.stmts {
+			atomic.StoreUint64(&s.hits, 0)
+		}
+		for _, fn := range f.funcs {
+			atomic.StoreUint64(&fn.hits, 0)
+		}
+		for _, b := range f.branches {
+			for _, arm := range b.arms {
+				atomic.StoreUint64(&arm.hits, 0)
+			}
+		}
+	}
+}
+
+func (f *coverageFile) stmt(start, end int) *uint64 {
+	f.cov.mu.Lock()
+	defer f.cov.mu.Unlock()
+	key := coverageKey{kind: coverageKindStmt, start: start, end: end}
+	if idx, exists := f.index[key]; exists {
+		return &f.stmts[idx].hits
+	}
+	s := &coverageRange{start: start, end: end}
+	f.index[key] = len(f.stmts)
+	f.stmts = append(f.stmts, s)
+	return &s.hits
+}
+
+func (f *coverageFile) fn(name string, start, end int) *uint64 {
+	f.cov.mu.Lock()
+	defer f.cov.mu.Unlock()
+	key := coverageKey{kind: coverageKindFunc, start: start, end: end}
+	if idx, exists := f.index[key]; exists {
+		return &f.funcs[idx].hits
+	}
+	if name == "" {
+		name = "(anonymous_" + strconv.Itoa(len(f.funcs)) + ")"
+	}
+	fn := &coverageFunc{coverageRange: coverageRange{start: start, end: end}, name: name}
+	f.index[key] = len(f.funcs)
+	f.funcs = append(f.funcs, fn)
+	return &fn.hits
+}
+
+// branch registers a branch point with the given arms (each arm is a pair of start and end offsets)
+// and returns the hit counters of the arms.
+func (f *coverageFile) branch(typ string, start, end int, arms ...[2]int) []*uint64 {
+	f.cov.mu.Lock()
+	defer f.cov.mu.Unlock()
+	key := coverageKey{kind: coverageKindBranch, start: start, end: end}
+	idx, exists := f.index[key]
+	if !exists {
+		b := &coverageBranch{start: start, end: end, typ: typ}
+		for _, arm := range arms {
+			b.arms = append(b.arms, &coverageRange{start: arm[0], end: arm[1]})
+		}
+		idx = len(f.branches)
+		f.index[key] = idx
+		f.branches = append(f.branches, b)
+	}
+	b := f.branches[idx]
+	res := make([]*uint64, len(b.arms))
+	for i, arm := range b.arms {
+		res[i] = &arm.hits
+	}
+	return res
+}
+
+func (c *Coverage) sortedNames() []string {
+	names := make([]string, len(c.names))
+	copy(names, c.names)
+	sort.Strings(names)
+	return names
+}
+
+type coverageLine struct {
+	line int
+	hits uint64
+}
+
+func (f *coverageFile) lines() []coverageLine {
+	m := make(map[int]uint64)
+	for _, s := range f.stmts {
+		line := f.src.Position(s.start).Line
+		hits := atomic.LoadUint64(&s.hits)
+		if h, exists := m[line]; !exists || hits > h {
+			m[line] = hits
+		}
+	}
+	lines := make([]coverageLine, 0, len(m))
+	for line, hits := range m {
+		lines = append(lines, coverageLine{line: line, hits: hits})
+	}
+	sort.Slice(lines, func(i, j int) bool {
+		return lines[i].line < lines[j].line
+	})
+	return lines
+}
+
+// WriteLCOV writes the collected data in the LCOV tracefile format (as understood by genhtml and most CI tools).
+func (c *Coverage) WriteLCOV(w io.Writer) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	bw := bufio.NewWriter(w)
+	for _, name := range c.sortedNames() {
+		f := c.files[name]
+		fmt.Fprintf(bw, "TN:\nSF:%s\n", name)
+
+		fnHit := 0
+		for _, fn := range f.funcs {
+			fmt.Fprintf(bw, "FN:%d,%s\n", f.src.Position(fn.start).Line, fn.name)
+		}
+		for _, fn := range f.funcs {
+			hits := atomic.LoadUint64(&fn.hits)
+			if hits > 0 {
+				fnHit++
+			}
+			fmt.Fprintf(bw, "FNDA:%d,%s\n", hits, fn.name)
+		}
+		fmt.Fprintf(bw, "FNF:%d\nFNH:%d\n", len(f.funcs), fnHit)
+
+		brFound, brHit := 0, 0
+		for i, b := range f.branches {
+			line := f.src.Position(b.start).Line
+			for j, arm := range b.arms {
+				brFound++
+				hits := atomic.LoadUint64(&arm.hits)
+				if hits > 0 {
+					brHit++
+				}
+				fmt.Fprintf(bw, "BRDA:%d,%d,%d,%d\n", line, i, j, hits)
+			}
+		}
+		fmt.Fprintf(bw, "BRF:%d\nBRH:%d\n", brFound, brHit)
+
+		lines := f.lines()
+		lineHit := 0
+		for _, l := range lines {
+			if l.hits > 0 {
+				lineHit++
+			}
+			fmt.Fprintf(bw, "DA:%d,%d\n", l.line, l.hits)
+		}
+		fmt.Fprintf(bw, "LF:%d\nLH:%d\nend_of_record\n", len(lines), lineHit)
+	}
+	return bw.Flush()
+}
+
+type istanbulPosition struct {
+	Line   int `json:"line"`
+	Column int `json:"column"`
+}
+
+type istanbulLocation struct {
+	Start istanbulPosition `json:"start"`
+	End   istanbulPosition `json:"end"`
+}
+
+type istanbulFunction struct {
+	Name string           `json:"name"`
+	Decl istanbulLocation `json:"decl"`
+	Loc  istanbulLocation `json:"loc"`
+	Line int              `json:"line"`
+}
+
+type istanbulBranch struct {
+	Loc       istanbulLocation   `json:"loc"`
+	Type      string             `json:"type"`
+	Locations []istanbulLocation `json:"locations"`
+	Line      int                `json:"line"`
+}
+
+type istanbulFile struct {
+	Path         string                      `json:"path"`
+	StatementMap map[string]istanbulLocation `json:"statementMap"`
+	FnMap        map[string]istanbulFunction `json:"fnMap"`
+	BranchMap    map[string]istanbulBranch   `json:"branchMap"`
+	S            map[string]uint64           `json:"s"`
+	F            map[string]uint64           `json:"f"`
+	B            map[string][]uint64         `json:"b"`
+}
+
+func (f *coverageFile) istanbulPos(offset int) istanbulPosition {
+	p := f.src.Position(offset)
+	return istanbulPosition{Line: p.Line, Column: p.Column - 1}
+}
+
+func (f *coverageFile) istanbulLoc(start, end int) istanbulLocation {
+	return istanbulLocation{Start: f.istanbulPos(start), End: f.istanbulPos(end)}
+}
+
+// WriteIstanbul writes the collected data in the Istanbul (nyc) JSON coverage format.
+func (c *Coverage) WriteIstanbul(w io.Writer) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	res := make(map[string]*istanbulFile, len(c.files))
+	for _, name := range c.names {
+		f := c.files[name]
+		out := &istanbulFile{
+			Path:         name,
+			StatementMap: make(map[string]istanbulLocation, len(f.stmts)),
+			FnMap:        make(map[string]istanbulFunction, len(f.funcs)),
+			BranchMap:    make(map[string]istanbulBranch, len(f.branches)),
+			S:            make(map[string]uint64, len(f.stmts)),
+			F:            make(map[string]uint64, len(f.funcs)),
+			B:            make(map[string][]uint64, len(f.branches)),
+		}
+		for i, s := range f.stmts {
+			id := strconv.Itoa(i)
+			out.StatementMap[id] = f.istanbulLoc(s.start, s.end)
+			out.S[id] = atomic.LoadUint64(&s.hits)
+		}
+		for i, fn := range f.funcs {
+			id := strconv.Itoa(i)
+			loc := f.istanbulLoc(fn.start, fn.end)
+			out.FnMap[id] = istanbulFunction{
+				Name: fn.name,
+				Decl: loc,
+				Loc:  loc,
+				Line: loc.Start.Line,
+			}
+			out.F[id] = atomic.LoadUint64(&fn.hits)
+		}
+		for i, b := range f.branches {
+			id := strconv.Itoa(i)
+			loc := f.istanbulLoc(b.start, b.end)
+			br := istanbulBranch{
+				Loc:       loc,
+				Type:      b.typ,
+				Locations: make([]istanbulLocation, len(b.arms)),
+				Line:      loc.Start.Line,
+			}
+			hits := make([]uint64, len(b.arms))
+			for j, arm := range b.arms {
+				br.Locations[j] = f.istanbulLoc(arm.start, arm.end)
+				hits[j] = atomic.LoadUint64(&arm.hits)
+			}
+			out.BranchMap[id] = br
+			out.B[id] = hits
+		}
+		res[name] = out
+	}
+	return json.NewEncoder(w).Encode(res)
+}
+
+// SetCoverage enables coverage instrumentation for all scripts subsequently compiled by the Runtime
+// (i.e. with RunString() or RunScript(), code passed to eval() is not instrumented). Pass nil to disable it.
+func (r *Runtime) SetCoverage(cov *Coverage) {
+	r.coverage = cov
+}
+
+// Coverage returns the Coverage set with SetCoverage or nil.
+func (r *Runtime) Coverage() *Coverage {
+	return r.coverage
+}
+
+// CompileWithCoverage is like Compile but instruments the Program so that its statement, branch and function
+// hit counts are recorded into cov.
+func CompileWithCoverage(name, src string, strict bool, cov *Coverage) (*Program, error) {
+	return compile(name, src, strict, true, nil, false, cov)
+}
+
+// CompileASTWithCoverage is like CompileAST but instruments the Program so that its statement, branch and
+// function hit counts are recorded into cov.
+func CompileASTWithCoverage(prg *ast.Program, strict bool, cov *Coverage) (*Program, error) {
+	return compileAST(prg, strict, true, nil, false, cov)
+}
+
+// Compiler helpers.
+
+func (c *compiler) coverageOffsets(n ast.Node) (int, int) {
+	return int(n.Idx0()) - 1, int(n.Idx1()) - 1
+}
+
+func (c *compiler) emitCoverageCount(hits *uint64) {
+	c.emit(coverageCount{hits: hits})
+}
+
+// coverStatement emits a counter for the statement unless it's a statement that is not executed by itself.
+func (c *compiler) coverStatement(v ast.Statement) {
+	if c.coverage == nil {
+		return
+	}
+	switch v.(type) {
+	case *ast.BlockStatement, *ast.EmptyStatement, *ast.FunctionDeclaration:
+		return
+	}
+	start, end := c.coverageOffsets(v)
+	c.emitCoverageCount(c.coverage.stmt(start, end))
+}
+
+func (c *compiler) coverBranch(typ string, n ast.Node, arms ...ast.Node) []*uint64 {
+	start, end := c.coverageOffsets(n)
+	return c.coverBranchRange(typ, start, end, arms...)
+}
+
+// coverBranchRange registers a branch point, a nil arm stands for an implicit (e.g. missing else) arm.
+func (c *compiler) coverBranchRange(typ string, start, end int, arms ...ast.Node) []*uint64 {
+	offsets := make([][2]int, len(arms))
+	for i, arm := range arms {
+		if arm == nil {
+			offsets[i] = [2]int{start, end}
+		} else {
+			offsets[i][0], offsets[i][1] = c.coverageOffsets(arm)
+		}
+	}
+	return c.coverage.branch(typ, start, end, offsets...)
+}
+
+// coverLogical registers the operands of a logical expression, unless it can be folded into a constant.
+func (c *compiler) coverLogical(left compiledExpr, leftNode, rightNode ast.Expression) []*uint64 {
+	if c.coverage == nil || left.constant() {
+		return nil
+	}
+	start, _ := c.coverageOffsets(leftNode)
+	_, end := c.coverageOffsets(rightNode)
+	return c.coverBranchRange(coverageBranchBinary, start, end, leftNode, rightNode)
+}
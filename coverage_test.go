@@ -0,0 +1,162 @@
+package goscript
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+const coverageTestScript = `function sign(x) {
+	if (x > 0) {
+		return 1;
+	} else if (x < 0) {
+		return -1;
+	}
+	return 0;
+}
+function unused() {
+	return 42;
+}
+var a = sign(5) + sign(7);
+var b = a > 1 ? "big" : "small";
+var c = b || "none";
+switch (b) {
+case "big":
+	a++;
+case "huge":
+	a++;
+	break;
+default:
+	a = 0;
+}
+a;
+`
+
+func TestCoverage(t *testing.T) {
+	cov := NewCoverage()
+	prg, err := CompileWithCoverage("test.js", coverageTestScript, false, cov)
+	if err != nil {
+		t.Fatal(err)
+	}
+	vm := New()
+	v, err := vm.RunProgram(prg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !v.SameAs(intToValue(4)) {
+		t.Fatalf("Unexpected result: %v", v)
+	}
+
+	var buf bytes.Buffer
+	if err := cov.WriteLCOV(&buf); err != nil {
+		t.Fatal(err)
+	}
+	lcov := buf.String()
+	for _, line := range []string{
+		"SF:test.js",
+		"FN:1,sign",
+		"FN:9,unused",
+		"FNDA:2,sign",
+		"FNDA:0,unused",
+		"FNF:2",
+		"FNH:1",
+		"DA:2,2",
+		"DA:3,2",
+		"DA:4,0",
+		"DA:5,0",
+		"DA:7,0",
+		"DA:10,0",
+		"DA:12,1",
+		"DA:15,1",
+		"DA:17,1",
+		"DA:19,1",
+		"DA:22,0",
+		"DA:24,1",
+		"LF:15",
+		"LH:10",
+		"BRDA:2,0,0,2",
+		"BRDA:2,0,1,0",
+		"BRDA:4,1,0,0",
+		"BRDA:4,1,1,0",
+		"BRDA:13,2,0,1",
+		"BRDA:13,2,1,0",
+		"BRDA:14,3,0,1",
+		"BRDA:14,3,1,0",
+		"BRDA:15,4,0,1",
+		"BRDA:15,4,1,1",
+		"BRDA:15,4,2,0",
+		"end_of_record",
+	} {
+		if !strings.Contains(lcov, line+"\n") {
+			t.Errorf("Missing %q in:\n%s", line, lcov)
+		}
+	}
+
+	buf.Reset()
+	if err := cov.WriteIstanbul(&buf); err != nil {
+		t.Fatal(err)
+	}
+	var res map[string]*istanbulFile
+	if err := json.Unmarshal(buf.Bytes(), &res); err != nil {
+		t.Fatal(err)
+	}
+	f := res["test.js"]
+	if f == nil {
+		t.Fatal("test.js is missing")
+	}
+	if len(f.S) != len(f.StatementMap) || len(f.F) != 2 || len(f.B) != len(f.BranchMap) {
+		t.Fatalf("Inconsistent maps: %s", buf.String())
+	}
+	var sw string
+	for id, br := range f.BranchMap {
+		if br.Type == coverageBranchSwitch {
+			sw = id
+		}
+	}
+	if hits := f.B[sw]; len(hits) != 3 || hits[0] != 1 || hits[1] != 1 || hits[2] != 0 {
+		t.Fatalf("Unexpected switch hits: %v", hits)
+	}
+
+	// running the same program again accumulates the counters
+	if _, err := New().RunProgram(prg); err != nil {
+		t.Fatal(err)
+	}
+	buf.Reset()
+	_ = cov.WriteLCOV(&buf)
+	if !strings.Contains(buf.String(), "FNDA:4,sign\n") {
+		t.Fatalf("Counters were not accumulated:\n%s", buf.String())
+	}
+
+	cov.Reset()
+	buf.Reset()
+	_ = cov.WriteLCOV(&buf)
+	if !strings.Contains(buf.String(), "FNDA:0,sign\n") {
+		t.Fatalf("Counters were not reset:\n%s", buf.String())
+	}
+}
+
+func TestCoverageRuntime(t *testing.T) {
+	cov := NewCoverage()
+	vm := New()
+	vm.SetCoverage(cov)
+	_, err := vm.RunScript("rt.js", `
+	var x = 0;
+	for (var i = 0; i < 10; i++) {
+		x += i;
+	}
+	eval("x++");
+	`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var buf bytes.Buffer
+	_ = cov.WriteLCOV(&buf)
+	lcov := buf.String()
+	if !strings.Contains(lcov, "DA:4,10\n") {
+		t.Fatalf("Unexpected report:\n%s", lcov)
+	}
+	if strings.Contains(lcov, "<eval>") {
+		t.Fatalf("eval() code must not be instrumented:\n%s", lcov)
+	}
+}
@@ -1,58 +1,16 @@
 package goscript
 
 import (
-	"database/sql"
 	"fmt"
 	_ "gitee.com/chunanyong/dm"
 )
 
-type damengObject struct {
-	baseObject
-	db *sql.DB
-}
-
-func (r *Runtime) builtinDameng_close(call FunctionCall) Value {
-	thisObj := r.toObject(call.This)
-	mo, ok := thisObj.self.(*damengObject)
-	if !ok {
-		panic(r.NewTypeError("Method Dameng.prototype.close called on incompatible receiver %s", r.objectproto_toString(FunctionCall{This: thisObj})))
-	}
-	_ = mo.db.Close()
-	return _undefined
-}
-
-func (r *Runtime) builtinDameng_exec(call FunctionCall) Value {
-	thisObj := r.toObject(call.This)
-	mo, ok := thisObj.self.(*damengObject)
-	if !ok {
-		panic(r.NewTypeError("Method Dameng.prototype.exec called on incompatible receiver %s", r.objectproto_toString(FunctionCall{This: thisObj})))
-	}
-	_sql := call.Argument(0).toString().String()
-	_, err := mo.db.Exec(_sql)
-	return r.toBoolean(err == nil)
-}
-
-func (r *Runtime) builtinDameng_query(call FunctionCall) Value {
-	thisObj := r.toObject(call.This)
-	mo, ok := thisObj.self.(*damengObject)
-	if !ok {
-		panic(r.NewTypeError("Method Dameng.prototype.query called on incompatible receiver %s", r.objectproto_toString(FunctionCall{This: thisObj})))
-	}
-	_sql := call.Argument(0).toString().String()
-	rows, err := DatabaseQuery(mo.db, _sql)
-	if err != nil {
-		return _null
-	} else {
-		return r.ToValue(rows)
-	}
-}
-
 func (r *Runtime) builtin_newDameng(args []Value, newTarget *Object) *Object {
 	if newTarget == nil {
 		panic(r.needNew("Dameng"))
 	}
-	if len(args) != 4 {
-		panic("number of arguments must be 4")
+	if len(args) < 4 {
+		panic("number of arguments must be at least 4")
 	}
 
 	// 连接数据库
@@ -60,36 +18,13 @@ func (r *Runtime) builtin_newDameng(args []Value, newTarget *Object) *Object {
 	_port := args[1].ToInteger()
 	_user := args[2].toString().String()
 	_password := args[3].toString().String()
-	db, err := sql.Open("dm", fmt.Sprintf("dm://%s:%s@%s:%d", _user, _password, _host, _port))
-	if err != nil {
-		return nil
-	} else {
-		proto := r.getPrototypeFromCtor(newTarget, r.global.Dameng, r.global.DamengPrototype)
-		o := &Object{runtime: r}
-		mo := &damengObject{
-			baseObject: baseObject{
-				class:      classDameng,
-				val:        o,
-				prototype:  proto,
-				extensible: true,
-				values:     nil,
-			},
-			db: db,
-		}
-		o.self = mo
-		mo.init()
-		return o
-	}
+	dsn := fmt.Sprintf("dm://%s:%s@%s:%d", _user, _password, _host, _port)
+	proto := r.getPrototypeFromCtor(newTarget, r.global.Dameng, r.global.DamengPrototype)
+	return r.newDatabase("dm", dsn, args[4:], classDameng, proto)
 }
 
 func (r *Runtime) createDamengProto(val *Object) objectImpl {
-	o := newBaseObjectObj(val, r.global.ObjectPrototype, classObject)
-	o._putProp("constructor", r.global.Dameng, true, false, true)
-	o._putProp("close", r.newNativeFunc(r.builtinDameng_close, nil, "close", nil, 0), true, false, true)
-	o._putProp("exec", r.newNativeFunc(r.builtinDameng_exec, nil, "exec", nil, 1), true, false, true)
-	o._putProp("query", r.newNativeFunc(r.builtinDameng_query, nil, "query", nil, 1), true, false, true)
-	o._putSym(SymToStringTag, valueProp(asciiString(classDameng), false, false, true))
-	return o
+	return r.createConnectorProto(val, r.global.Dameng, classDameng)
 }
 
 func (r *Runtime) createDameng(val *Object) objectImpl {
@@ -2,58 +2,285 @@ package goscript
 
 import (
 	"database/sql"
+	"database/sql/driver"
+	"fmt"
+	"reflect"
+	"strconv"
 	"strings"
+	"sync"
+	"time"
+	"unicode"
+	"unicode/utf8"
 )
 
+var (
+	databaseDriversMu sync.RWMutex
+	// databaseDrivers maps the names accepted by the Database constructor to the names of the database/sql drivers.
+	databaseDrivers = map[string]string{
+		"dameng":     "dm",
+		"dm":         "dm",
+		"mssql":      "sqlserver",
+		"sqlserver":  "sqlserver",
+		"mysql":      "mysql",
+		"oracle":     "oracle",
+		"postgres":   "postgres",
+		"postgresql": "postgres",
+		"sqlite":     "sqlite",
+		"sqlite3":    "sqlite",
+	}
+)
+
+// RegisterDatabaseDriver makes a database/sql driver available to the Database constructor under name. Like
+// sql.Register, it panics if the name is already used.
+func RegisterDatabaseDriver(name string, d driver.Driver) {
+	databaseDriversMu.Lock()
+	defer databaseDriversMu.Unlock()
+	if _, exists := databaseDrivers[name]; exists {
+		panic("goscript: RegisterDatabaseDriver called twice for driver " + name)
+	}
+	sql.Register(name, d)
+	databaseDrivers[name] = name
+}
+
+// databaseDriver returns the name of the database/sql driver for name, which is either registered with
+// RegisterDatabaseDriver or directly with database/sql.
+func databaseDriver(name string) (string, bool) {
+	databaseDriversMu.RLock()
+	driverName, ok := databaseDrivers[name]
+	databaseDriversMu.RUnlock()
+	if !ok {
+		driverName = name
+	}
+	for _, d := range sql.Drivers() {
+		if d == driverName {
+			return driverName, true
+		}
+	}
+	return "", false
+}
+
+// sqlConn is what statements run on: a connection pool or a transaction.
+type sqlConn interface {
+	Exec(query string, args ...any) (sql.Result, error)
+	Query(query string, args ...any) (*sql.Rows, error)
+	Prepare(query string) (*sql.Stmt, error)
+}
+
+// databaseObject is a connection pool. The connectors such as Mysql or SQLite create it with their own class and
+// prototype, which inherits from Database.prototype.
+type databaseObject struct {
+	baseObject
+	db        *sql.DB
+	driver    string // the name of the database/sql driver, which selects the SQL dialect
+	camelCase bool   // whether the names of the columns are converted to camelCase
+}
+
+type sqlTransactionObject struct {
+	baseObject
+	tx        *sql.Tx
+	driver    string
+	camelCase bool
+}
+
 // migrate from gobase
 
+// DatabaseQuery runs a query and returns its rows keyed by camelCase column names, with the values converted to
+// strings by database/sql. A NULL is an empty string, see DatabaseQueryTyped to tell them apart.
 func DatabaseQuery(db *sql.DB, sql string, args ...any) ([]map[string]string, error) {
 	rows, err := db.Query(sql, args...)
 	if err != nil {
 		return nil, err
 	}
-	return fetchRows(rows, err)
+	return fetchStringRows(rows)
 }
 
-func fetchRows(rows *sql.Rows, err error) ([]map[string]string, error) {
-	if rows == nil || err != nil {
+// fetchStringRows reads and closes rows, whose values are scanned as strings.
+func fetchStringRows(rows *sql.Rows) ([]map[string]string, error) {
+	defer func() {
+		_ = rows.Close()
+	}()
+	fields, err := rows.Columns()
+	if err != nil {
 		return nil, err
 	}
-
-	fields, _ := rows.Columns()
 	for k, v := range fields {
 		fields[k] = camelCase(v)
 	}
-	columnsLength := len(fields)
+	values := make([]sql.NullString, len(fields))
+	args := make([]any, len(fields))
+	for i := range values {
+		args[i] = &values[i]
+	}
+	lists := make([]map[string]string, 0)
+	for rows.Next() {
+		if err := rows.Scan(args...); err != nil {
+			return nil, err
+		}
+		row := make(map[string]string, len(fields))
+		for i, field := range fields {
+			row[field] = values[i].String
+		}
+		lists = append(lists, row)
+	}
+	return lists, rows.Err()
+}
+
+// DatabaseQueryTyped runs a query and returns its rows keyed by camelCase column names. Unlike DatabaseQuery, a value
+// is nil, int64, float64, bool, string, time.Time or []byte, depending on the column type.
+func DatabaseQueryTyped(db *sql.DB, sql string, args ...any) ([]map[string]any, error) {
+	rows, err := db.Query(sql, args...)
+	if err != nil {
+		return nil, err
+	}
+	fields, records, err := fetchRows(rows, true)
+	if err != nil {
+		return nil, err
+	}
+	lists := make([]map[string]any, len(records))
+	for i, record := range records {
+		row := make(map[string]any, len(fields))
+		for j, field := range fields {
+			row[field] = record[j]
+		}
+		lists[i] = row
+	}
+	return lists, nil
+}
+
+// sqlRows reads the rows of a query, converting their values with columnValue.
+type sqlRows struct {
+	rows   *sql.Rows
+	types  []*sql.ColumnType
+	fields []string
+	values []any
+	args   []any
+}
 
-	values := make([]string, columnsLength)
-	args := make([]any, columnsLength)
-	for i := 0; i < columnsLength; i++ {
+// newSQLRows reads the columns of rows. The names of the fields are converted to camelCase if camel is set. rows is
+// closed on error.
+func newSQLRows(rows *sql.Rows, camel bool) (*sqlRows, error) {
+	types, err := rows.ColumnTypes()
+	if err != nil {
+		_ = rows.Close()
+		return nil, err
+	}
+	fields := make([]string, len(types))
+	for i, t := range types {
+		fields[i] = t.Name()
+		if camel {
+			fields[i] = camelCase(fields[i])
+		}
+	}
+	values := make([]any, len(types))
+	args := make([]any, len(types))
+	for i := range values {
 		args[i] = &values[i]
 	}
+	return &sqlRows{rows: rows, types: types, fields: fields, values: values, args: args}, nil
+}
 
-	index := 0
-	listLength := 100
-	lists := make([]map[string]string, listLength, listLength)
-	for rows.Next() {
-		if e := rows.Scan(args...); e == nil {
-			row := make(map[string]string, columnsLength)
-			for i, field := range fields {
-				row[field] = values[i]
-			}
+// next returns the next record, or nil at the end of the rows.
+func (s *sqlRows) next() ([]any, error) {
+	if !s.rows.Next() {
+		return nil, s.rows.Err()
+	}
+	if err := s.rows.Scan(s.args...); err != nil {
+		return nil, err
+	}
+	record := make([]any, len(s.types))
+	for i, v := range s.values {
+		record[i] = columnValue(s.types[i], v)
+	}
+	return record, nil
+}
 
-			if index < listLength {
-				lists[index] = row
-			} else {
-				lists = append(lists, row)
-			}
-			index++
+// fetchRows reads and closes rows. The names of the fields are converted to camelCase if camel is set.
+func fetchRows(rows *sql.Rows, camel bool) ([]string, [][]any, error) {
+	s, err := newSQLRows(rows, camel)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer func() {
+		_ = rows.Close()
+	}()
+	var records [][]any
+	for {
+		record, err := s.next()
+		if err != nil {
+			return nil, nil, err
 		}
+		if record == nil {
+			return s.fields, records, nil
+		}
+		records = append(records, record)
 	}
+}
 
-	_ = rows.Close()
+// columnValue converts a value scanned by the driver according to the type of its column. Drivers using a text
+// protocol return []byte for any type, which is parsed when the column is numeric or boolean. Decimals are kept as
+// strings so as not to lose precision.
+func columnValue(t *sql.ColumnType, v any) any {
+	switch v := v.(type) {
+	case int64:
+		if strings.Contains(strings.ToUpper(t.DatabaseTypeName()), "BOOL") {
+			return v != 0
+		}
+		return v
+	case nil, float64, bool, string, time.Time:
+		return v
+	case int:
+		return int64(v)
+	case int32:
+		return int64(v)
+	case float32:
+		return float64(v)
+	case []byte:
+		if isBinaryColumn(t) {
+			return append([]byte(nil), v...)
+		}
+		s := string(v)
+		if st := t.ScanType(); st != nil {
+			switch st.Kind() {
+			case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+				reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+				if n, err := strconv.ParseInt(s, 10, 64); err == nil {
+					return n
+				}
+			case reflect.Float32, reflect.Float64:
+				if f, err := strconv.ParseFloat(s, 64); err == nil {
+					return f
+				}
+			case reflect.Bool:
+				if b, err := strconv.ParseBool(s); err == nil {
+					return b
+				}
+			case reflect.Struct:
+				switch st {
+				case reflect.TypeOf(sql.NullInt64{}), reflect.TypeOf(sql.NullInt32{}), reflect.TypeOf(sql.NullInt16{}):
+					if n, err := strconv.ParseInt(s, 10, 64); err == nil {
+						return n
+					}
+				case reflect.TypeOf(sql.NullFloat64{}):
+					if f, err := strconv.ParseFloat(s, 64); err == nil {
+						return f
+					}
+				case reflect.TypeOf(sql.NullBool{}):
+					if b, err := strconv.ParseBool(s); err == nil {
+						return b
+					}
+				}
+			}
+		}
+		return s
+	default:
+		return fmt.Sprint(v)
+	}
+}
 
-	return lists[0:index], nil
+func isBinaryColumn(t *sql.ColumnType) bool {
+	name := strings.ToUpper(t.DatabaseTypeName())
+	return strings.Contains(name, "BLOB") || strings.Contains(name, "BINARY") || name == "BYTEA" || name == "IMAGE" ||
+		name == "RAW" || name == "LONG RAW"
 }
 
 func camelCase(str string) string {
@@ -61,13 +288,353 @@ func camelCase(str string) string {
 		items := strings.Split(str, "_")
 		arr := make([]string, len(items))
 		for k, v := range items {
-			if 0 == k {
+			if 0 == k || v == "" {
 				arr[k] = v
 			} else {
-				arr[k] = strings.ToTitle(v)
+				first, size := utf8.DecodeRuneInString(v)
+				arr[k] = string(unicode.ToUpper(first)) + v[size:]
 			}
 		}
 		str = strings.Join(arr, "")
 	}
 	return str
 }
+
+// rowsValue converts the rows returned by fetchRows to an array of objects whose properties are in column order.
+func (r *Runtime) rowsValue(fields []string, records [][]any) Value {
+	list := make([]Value, len(records))
+	for i, record := range records {
+		list[i] = r.rowValue(fields, record)
+	}
+	return r.newArrayValues(list)
+}
+
+func (r *Runtime) rowValue(fields []string, record []any) *Object {
+	row := r.NewObject()
+	for j, field := range fields {
+		_ = row.Set(field, r.columnToValue(record[j]))
+	}
+	return row
+}
+
+// columnToValue converts a value returned by columnValue. The integers beyond Number.MAX_SAFE_INTEGER are strings,
+// like decimals.
+func (r *Runtime) columnToValue(v any) Value {
+	switch v := v.(type) {
+	case nil:
+		return _null
+	case int64:
+		if v < -(maxInt-1) || v > maxInt-1 {
+			// beyond Number.MAX_SAFE_INTEGER, a number would round it
+			return asciiString(strconv.FormatInt(v, 10))
+		}
+		return intToValue(v)
+	case time.Time:
+		return r.newDateObject(v, true, r.global.DatePrototype)
+	case []byte:
+		return r.builtin_new(r.global.Uint8Array, []Value{r.NewArrayBuffer(v).toValue(r)})
+	default:
+		return r.ToValue(v)
+	}
+}
+
+// sqlParams exports the values bound to the placeholders of a statement. Binary data is bound as []byte.
+func (r *Runtime) sqlParams(args []Value) []any {
+	if len(args) == 0 {
+		return nil
+	}
+	params := make([]any, len(args))
+	for i, arg := range args {
+		if o, ok := arg.(*Object); ok {
+			switch o.self.(type) {
+			case *arrayBufferObject, *typedArrayObject, *dataViewObject:
+				params[i], _ = r.httpBytes(o)
+				continue
+			}
+		}
+		params[i] = arg.Export()
+	}
+	return params
+}
+
+// restArgs returns the arguments of call from the i-th on, which may be none.
+func restArgs(call FunctionCall, i int) []Value {
+	if len(call.Arguments) > i {
+		return call.Arguments[i:]
+	}
+	return nil
+}
+
+// sqlExec runs exec(sql, ...params) and returns {rowsAffected, lastInsertId}. Either is null if the driver does
+// not report it.
+func (r *Runtime) sqlExec(conn sqlConn, call FunctionCall) Value {
+	res, err := conn.Exec(call.Argument(0).toString().String(), r.sqlParams(restArgs(call, 1))...)
+	if err != nil {
+		panic(r.NewGoError(err))
+	}
+	return r.sqlResult(res)
+}
+
+// sqlResult converts the result of a statement to {rowsAffected, lastInsertId}.
+func (r *Runtime) sqlResult(res sql.Result) *Object {
+	o := r.NewObject()
+	if n, err := res.RowsAffected(); err == nil {
+		o.Set("rowsAffected", n)
+	} else {
+		o.Set("rowsAffected", _null)
+	}
+	if id, err := res.LastInsertId(); err == nil {
+		o.Set("lastInsertId", id)
+	} else {
+		o.Set("lastInsertId", _null)
+	}
+	return o
+}
+
+// sqlQuery runs query(sql, ...params) and returns the rows.
+func (r *Runtime) sqlQuery(conn sqlConn, camel bool, call FunctionCall) Value {
+	rows, err := conn.Query(call.Argument(0).toString().String(), r.sqlParams(restArgs(call, 1))...)
+	if err == nil {
+		var fields []string
+		var records [][]any
+		if fields, records, err = fetchRows(rows, camel); err == nil {
+			return r.rowsValue(fields, records)
+		}
+	}
+	panic(r.NewGoError(err))
+}
+
+// newDatabase opens a connection pool, see openDatabase.
+func (r *Runtime) newDatabase(driverName, dsn string, rest []Value, class string, proto *Object) *Object {
+	o := &Object{runtime: r}
+	do := &databaseObject{
+		baseObject: baseObject{
+			class:      class,
+			val:        o,
+			prototype:  proto,
+			extensible: true,
+			values:     nil,
+		},
+	}
+	r.openDatabase(do, driverName, dsn, rest)
+	o.self = do
+	do.init()
+	return o
+}
+
+// openDatabase opens the connection pool of do, see configureDatabase.
+func (r *Runtime) openDatabase(do *databaseObject, driverName, dsn string, rest []Value) {
+	name, ok := databaseDriver(driverName)
+	if !ok {
+		panic(r.NewTypeError("Unknown database driver: %s", driverName))
+	}
+	db, err := sql.Open(name, dsn)
+	if err != nil {
+		panic(r.NewGoError(err))
+	}
+	do.driver = name
+	r.configureDatabase(do, db, rest)
+}
+
+// configureDatabase sets the connection pool of do. rest are the arguments after the connection ones, the first of
+// which may be the options {maxOpen, maxIdle, connMaxLifetime, connMaxIdleTime, camelCase}. The durations are in
+// milliseconds or strings such as "5m". {camelCase: false} keeps the names of the columns as they are.
+func (r *Runtime) configureDatabase(do *databaseObject, db *sql.DB, rest []Value) {
+	do.db, do.camelCase = db, true
+	if len(rest) == 0 {
+		return
+	}
+	if opts, ok := rest[0].(*Object); ok {
+		if v := opts.self.getStr("maxOpen", nil); v != nil && v != _undefined {
+			db.SetMaxOpenConns(int(v.ToInteger()))
+		}
+		if v := opts.self.getStr("maxIdle", nil); v != nil && v != _undefined {
+			db.SetMaxIdleConns(int(v.ToInteger()))
+		}
+		if v := opts.self.getStr("connMaxLifetime", nil); v != nil && v != _undefined {
+			db.SetConnMaxLifetime(r.httpDuration(v))
+		}
+		if v := opts.self.getStr("connMaxIdleTime", nil); v != nil && v != _undefined {
+			db.SetConnMaxIdleTime(r.httpDuration(v))
+		}
+		if v := opts.self.getStr("camelCase", nil); v != nil && v != _undefined {
+			do.camelCase = v.ToBoolean()
+		}
+	}
+}
+
+func (r *Runtime) toDatabase(method string, call FunctionCall) *databaseObject {
+	thisObj := r.toObject(call.This)
+	switch do := thisObj.self.(type) {
+	case *databaseObject:
+		return do
+	case *postgresObject:
+		return &do.databaseObject
+	case *sqliteObject:
+		return &do.databaseObject
+	}
+	panic(r.NewTypeError("Method Database.prototype.%s called on incompatible receiver %s", method, r.objectproto_toString(FunctionCall{This: thisObj})))
+}
+
+func (r *Runtime) builtinDatabase_close(call FunctionCall) Value {
+	_ = r.toDatabase("close", call).db.Close()
+	return _undefined
+}
+
+func (r *Runtime) builtinDatabase_exec(call FunctionCall) Value {
+	return r.sqlExec(r.toDatabase("exec", call).db, call)
+}
+
+func (r *Runtime) builtinDatabase_query(call FunctionCall) Value {
+	do := r.toDatabase("query", call)
+	return r.sqlQuery(do.db, do.camelCase, call)
+}
+
+// builtinDatabase_cursor is cursor(sql, ...params), which returns a SQLCursor over the rows of the query.
+func (r *Runtime) builtinDatabase_cursor(call FunctionCall) Value {
+	do := r.toDatabase("cursor", call)
+	return r.sqlCursor(do.db, do.camelCase, call)
+}
+
+func (r *Runtime) builtinDatabase_begin(call FunctionCall) Value {
+	return r.sqlBegin(r.toDatabase("begin", call))
+}
+
+func (r *Runtime) builtinDatabase_ping(call FunctionCall) Value {
+	if err := r.toDatabase("ping", call).db.Ping(); err != nil {
+		panic(r.NewGoError(err))
+	}
+	return _undefined
+}
+
+func (r *Runtime) builtin_newDatabase(args []Value, newTarget *Object) *Object {
+	if newTarget == nil {
+		panic(r.needNew("Database"))
+	}
+	if len(args) < 2 {
+		panic(r.NewTypeError("Database requires a driver and a data source name"))
+	}
+	proto := r.getPrototypeFromCtor(newTarget, r.global.Database, r.global.DatabasePrototype)
+	return r.newDatabase(args[0].toString().String(), args[1].toString().String(), args[2:], classDatabase, proto)
+}
+
+func (r *Runtime) createDatabaseProto(val *Object) objectImpl {
+	o := newBaseObjectObj(val, r.global.ObjectPrototype, classObject)
+	o._putProp("constructor", r.global.Database, true, false, true)
+	o._putProp("close", r.newNativeFunc(r.builtinDatabase_close, nil, "close", nil, 0), true, false, true)
+	o._putProp("exec", r.newNativeFunc(r.builtinDatabase_exec, nil, "exec", nil, 1), true, false, true)
+	o._putProp("query", r.newNativeFunc(r.builtinDatabase_query, nil, "query", nil, 1), true, false, true)
+	o._putProp("cursor", r.newNativeFunc(r.builtinDatabase_cursor, nil, "cursor", nil, 1), true, false, true)
+	o._putProp("insertMany", r.newNativeFunc(r.builtinDatabase_insertMany, nil, "insertMany", nil, 2), true, false, true)
+	o._putProp("execBatch", r.newNativeFunc(r.builtinDatabase_execBatch, nil, "execBatch", nil, 2), true, false, true)
+	o._putProp("tables", r.newNativeFunc(r.builtinDatabase_tables, nil, "tables", nil, 0), true, false, true)
+	o._putProp("columns", r.newNativeFunc(r.builtinDatabase_columns, nil, "columns", nil, 1), true, false, true)
+	o._putProp("indexes", r.newNativeFunc(r.builtinDatabase_indexes, nil, "indexes", nil, 1), true, false, true)
+	o._putProp("foreignKeys", r.newNativeFunc(r.builtinDatabase_foreignKeys, nil, "foreignKeys", nil, 1), true, false, true)
+	r.putSQLBuilders(o, r.builtinDatabase_select, r.builtinDatabase_insert, r.builtinDatabase_upsert,
+		r.builtinDatabase_update, r.builtinDatabase_delete)
+	o._putProp("begin", r.newNativeFunc(r.builtinDatabase_begin, nil, "begin", nil, 0), true, false, true)
+	o._putProp("ping", r.newNativeFunc(r.builtinDatabase_ping, nil, "ping", nil, 0), true, false, true)
+	o._putSym(SymToStringTag, valueProp(asciiString(classDatabase), false, false, true))
+	return o
+}
+
+func (r *Runtime) createDatabase(val *Object) objectImpl {
+	o := r.newNativeConstructOnly(val, r.builtin_newDatabase, r.global.DatabasePrototype, "Database", 2)
+	return o
+}
+
+// createConnectorProto creates the prototype of a connector, which only adds its constructor and tag to
+// Database.prototype.
+func (r *Runtime) createConnectorProto(val, ctor *Object, class string) objectImpl {
+	o := newBaseObjectObj(val, r.global.DatabasePrototype, classObject)
+	o._putProp("constructor", ctor, true, false, true)
+	o._putSym(SymToStringTag, valueProp(asciiString(class), false, false, true))
+	return o
+}
+
+// sqlBegin starts a transaction on d.
+func (r *Runtime) sqlBegin(d *databaseObject) Value {
+	tx, err := d.db.Begin()
+	if err != nil {
+		panic(r.NewGoError(err))
+	}
+	o := &Object{runtime: r}
+	to := &sqlTransactionObject{
+		baseObject: baseObject{
+			class:      classSQLTransaction,
+			val:        o,
+			prototype:  r.global.SQLTransactionPrototype,
+			extensible: true,
+			values:     nil,
+		},
+		tx:        tx,
+		driver:    d.driver,
+		camelCase: d.camelCase,
+	}
+	o.self = to
+	to.init()
+	return o
+}
+
+func (r *Runtime) toSQLTransaction(method string, call FunctionCall) *sqlTransactionObject {
+	thisObj := r.toObject(call.This)
+	to, ok := thisObj.self.(*sqlTransactionObject)
+	if !ok {
+		panic(r.NewTypeError("Method SQLTransaction.prototype.%s called on incompatible receiver %s", method, r.objectproto_toString(FunctionCall{This: thisObj})))
+	}
+	return to
+}
+
+func (r *Runtime) builtinSQLTransaction_exec(call FunctionCall) Value {
+	return r.sqlExec(r.toSQLTransaction("exec", call).tx, call)
+}
+
+func (r *Runtime) builtinSQLTransaction_query(call FunctionCall) Value {
+	to := r.toSQLTransaction("query", call)
+	return r.sqlQuery(to.tx, to.camelCase, call)
+}
+
+func (r *Runtime) builtinSQLTransaction_cursor(call FunctionCall) Value {
+	to := r.toSQLTransaction("cursor", call)
+	return r.sqlCursor(to.tx, to.camelCase, call)
+}
+
+func (r *Runtime) builtinSQLTransaction_commit(call FunctionCall) Value {
+	if err := r.toSQLTransaction("commit", call).tx.Commit(); err != nil {
+		panic(r.NewGoError(err))
+	}
+	return _undefined
+}
+
+func (r *Runtime) builtinSQLTransaction_rollback(call FunctionCall) Value {
+	if err := r.toSQLTransaction("rollback", call).tx.Rollback(); err != nil {
+		panic(r.NewGoError(err))
+	}
+	return _undefined
+}
+
+func (r *Runtime) createSQLTransactionProto(val *Object) objectImpl {
+	o := newBaseObjectObj(val, r.global.ObjectPrototype, classObject)
+	o._putProp("exec", r.newNativeFunc(r.builtinSQLTransaction_exec, nil, "exec", nil, 1), true, false, true)
+	o._putProp("query", r.newNativeFunc(r.builtinSQLTransaction_query, nil, "query", nil, 1), true, false, true)
+	o._putProp("cursor", r.newNativeFunc(r.builtinSQLTransaction_cursor, nil, "cursor", nil, 1), true, false, true)
+	o._putProp("insertMany", r.newNativeFunc(r.builtinSQLTransaction_insertMany, nil, "insertMany", nil, 2), true, false, true)
+	o._putProp("execBatch", r.newNativeFunc(r.builtinSQLTransaction_execBatch, nil, "execBatch", nil, 2), true, false, true)
+	r.putSQLBuilders(o, r.builtinSQLTransaction_select, r.builtinSQLTransaction_insert, r.builtinSQLTransaction_upsert,
+		r.builtinSQLTransaction_update, r.builtinSQLTransaction_delete)
+	o._putProp("commit", r.newNativeFunc(r.builtinSQLTransaction_commit, nil, "commit", nil, 0), true, false, true)
+	o._putProp("rollback", r.newNativeFunc(r.builtinSQLTransaction_rollback, nil, "rollback", nil, 0), true, false, true)
+	o._putSym(SymToStringTag, valueProp(asciiString(classSQLTransaction), false, false, true))
+	return o
+}
+
+func (r *Runtime) initDatabase() {
+	r.global.DatabasePrototype = r.newLazyObject(r.createDatabaseProto)
+	r.global.Database = r.newLazyObject(r.createDatabase)
+	r.global.SQLTransactionPrototype = r.newLazyObject(r.createSQLTransactionProto)
+	r.global.SQLCursorPrototype = r.newLazyObject(r.createSQLCursorProto)
+	r.global.SQLAsyncCursorPrototype = r.newLazyObject(r.createSQLAsyncCursorProto)
+	r.global.SQLQueryPrototype = r.newLazyObject(r.createSQLQueryProto)
+	r.addToGlobal("Database", r.global.Database)
+}
@@ -0,0 +1,403 @@
+package goscript
+
+import (
+	"database/sql"
+	"fmt"
+	"github.com/rarnu/goscript/unistring"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// insertMany 与 execBatch 使用预编译语句批量写入，insertMany 按数据库方言生成多行 VALUES
+
+// sqlDialect is what insertMany needs to know about the SQL of a driver.
+type sqlDialect struct {
+	name        string
+	placeholder func(i int) string // the placeholder of the i-th parameter, from 1
+	maxParams   int                // the number of parameters of a statement
+	maxRows     int                // the number of rows of a VALUES list, 0 if unlimited
+	insertAll   bool               // whether rows are inserted with INSERT ALL instead of a VALUES list
+}
+
+func questionPlaceholder(int) string {
+	return "?"
+}
+
+// sqlDialectOf returns the dialect of a database/sql driver. Unknown drivers, such as the ones registered with
+// RegisterDatabaseDriver, are assumed to accept ? and multi-row VALUES.
+func sqlDialectOf(driver string) sqlDialect {
+	switch driver {
+	case "mysql":
+		return sqlDialect{name: driver, placeholder: questionPlaceholder, maxParams: 65535}
+	case "oracle":
+		return sqlDialect{name: driver, placeholder: func(i int) string { return ":" + strconv.Itoa(i) }, maxParams: 65535,
+			insertAll: true}
+	case "sqlserver":
+		return sqlDialect{name: driver, placeholder: func(i int) string { return "@p" + strconv.Itoa(i) }, maxParams: 2100,
+			maxRows: 1000}
+	case "dm":
+		return sqlDialect{name: driver, placeholder: questionPlaceholder, maxParams: 65535}
+	case "postgres":
+		return sqlDialect{name: driver, placeholder: func(i int) string { return "$" + strconv.Itoa(i) }, maxParams: 65535}
+	case "sqlite":
+		return sqlDialect{name: driver, placeholder: questionPlaceholder, maxParams: 32766}
+	}
+	return sqlDialect{name: driver, placeholder: questionPlaceholder, maxParams: 999}
+}
+
+// sqlIdentifier matches the names of tables, optionally qualified, and columns. They are not quoted, as quoting
+// makes them case-sensitive on Oracle or Dameng, so they are restricted to plain identifiers.
+var sqlIdentifier = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_$#]*(\.[A-Za-z_][A-Za-z0-9_$#]*)*$`)
+
+// insertSQL returns the statement inserting n rows into table. conflict is "", "ignore", "replace" or "update",
+// keys are the columns of the unique constraint, which are required by MERGE and ON CONFLICT ... DO UPDATE.
+func (d sqlDialect) insertSQL(table string, columns []string, n int, conflict string, keys []string) (string, error) {
+	var b strings.Builder
+	list := strings.Join(columns, ", ")
+	param := 0
+	values := func() {
+		b.WriteByte('(')
+		for j := range columns {
+			if j > 0 {
+				b.WriteString(", ")
+			}
+			param++
+			b.WriteString(d.placeholder(param))
+		}
+		b.WriteByte(')')
+	}
+	unsupported := fmt.Errorf("onConflict %q is not supported by the %s driver", conflict, d.name)
+	if (d.name == "sqlserver" || d.name == "oracle" || d.name == "dm") && (conflict == "ignore" || conflict == "update") {
+		return d.mergeSQL(table, columns, n, conflict, keys)
+	}
+	if d.insertAll {
+		if conflict != "" {
+			return "", unsupported
+		}
+		b.WriteString("INSERT ALL")
+		for i := 0; i < n; i++ {
+			b.WriteString(" INTO " + table + " (" + list + ") VALUES ")
+			values()
+		}
+		b.WriteString(" SELECT 1 FROM DUAL")
+		return b.String(), nil
+	}
+
+	var suffix string
+	switch {
+	case conflict == "":
+		b.WriteString("INSERT INTO ")
+	case d.name == "mysql" && conflict == "ignore":
+		b.WriteString("INSERT IGNORE INTO ")
+	case d.name == "mysql" && conflict == "replace":
+		b.WriteString("REPLACE INTO ")
+	case d.name == "mysql" && conflict == "update":
+		b.WriteString("INSERT INTO ")
+		set := make([]string, len(columns))
+		for i, c := range columns {
+			set[i] = c + " = VALUES(" + c + ")"
+		}
+		suffix = " ON DUPLICATE KEY UPDATE " + strings.Join(set, ", ")
+	case d.name == "sqlite" && (conflict == "ignore" || conflict == "replace"):
+		b.WriteString("INSERT OR " + strings.ToUpper(conflict) + " INTO ")
+	case (d.name == "sqlite" || d.name == "postgres") && conflict == "update":
+		if len(keys) == 0 {
+			return "", fmt.Errorf("onConflict \"update\" requires the keys of the unique constraint")
+		}
+		b.WriteString("INSERT INTO ")
+		var set []string
+		for _, c := range columns {
+			if !containsFold(keys, c) {
+				set = append(set, c+" = excluded."+c)
+			}
+		}
+		if len(set) == 0 {
+			suffix = " ON CONFLICT (" + strings.Join(keys, ", ") + ") DO NOTHING"
+		} else {
+			suffix = " ON CONFLICT (" + strings.Join(keys, ", ") + ") DO UPDATE SET " + strings.Join(set, ", ")
+		}
+	case d.name == "postgres" && conflict == "ignore":
+		b.WriteString("INSERT INTO ")
+		suffix = " ON CONFLICT DO NOTHING"
+	default:
+		return "", unsupported
+	}
+	b.WriteString(table + " (" + list + ") VALUES ")
+	for i := 0; i < n; i++ {
+		if i > 0 {
+			b.WriteString(", ")
+		}
+		values()
+	}
+	b.WriteString(suffix)
+	return b.String(), nil
+}
+
+func containsFold(list []string, s string) bool {
+	for _, item := range list {
+		if strings.EqualFold(item, s) {
+			return true
+		}
+	}
+	return false
+}
+
+// mergeSQL returns the MERGE statement inserting n rows into table, and updating the rows matching the keys if
+// conflict is "update".
+func (d sqlDialect) mergeSQL(table string, columns []string, n int, conflict string, keys []string) (string, error) {
+	if len(keys) == 0 {
+		return "", fmt.Errorf("onConflict %q requires the keys of the unique constraint", conflict)
+	}
+	var b strings.Builder
+	param := 0
+	b.WriteString("MERGE INTO " + table + " t USING (")
+	for i := 0; i < n; i++ {
+		if d.name == "sqlserver" {
+			if i == 0 {
+				b.WriteString("VALUES ")
+			} else {
+				b.WriteString(", ")
+			}
+			b.WriteByte('(')
+			for j := range columns {
+				if j > 0 {
+					b.WriteString(", ")
+				}
+				param++
+				b.WriteString(d.placeholder(param))
+			}
+			b.WriteByte(')')
+			continue
+		}
+		if i > 0 {
+			b.WriteString(" UNION ALL ")
+		}
+		b.WriteString("SELECT ")
+		for j, c := range columns {
+			if j > 0 {
+				b.WriteString(", ")
+			}
+			param++
+			b.WriteString(d.placeholder(param) + " " + c)
+		}
+		b.WriteString(" FROM DUAL")
+	}
+	b.WriteString(") s")
+	if d.name == "sqlserver" {
+		b.WriteString(" (" + strings.Join(columns, ", ") + ")")
+	}
+	on := make([]string, len(keys))
+	for i, k := range keys {
+		on[i] = "t." + k + " = s." + k
+	}
+	b.WriteString(" ON (" + strings.Join(on, " AND ") + ")")
+	var set []string
+	for _, c := range columns {
+		if !containsFold(keys, c) {
+			set = append(set, "t."+c+" = s."+c)
+		}
+	}
+	if conflict == "update" && len(set) > 0 {
+		b.WriteString(" WHEN MATCHED THEN UPDATE SET " + strings.Join(set, ", "))
+	}
+	values := make([]string, len(columns))
+	for i, c := range columns {
+		values[i] = "s." + c
+	}
+	b.WriteString(" WHEN NOT MATCHED THEN INSERT (" + strings.Join(columns, ", ") + ") VALUES (" +
+		strings.Join(values, ", ") + ")")
+	if d.name == "sqlserver" {
+		// a MERGE statement must be terminated by a semicolon
+		b.WriteByte(';')
+	}
+	return b.String(), nil
+}
+
+// chunkRows returns the number of rows of a chunk, which is limited by the parameters of a statement.
+func (d sqlDialect) chunkRows(chunkSize, columns int) int {
+	n := chunkSize
+	if max := d.maxParams / columns; n > max {
+		n = max
+	}
+	if d.maxRows > 0 && n > d.maxRows {
+		n = d.maxRows
+	}
+	if n < 1 {
+		n = 1
+	}
+	return n
+}
+
+func (r *Runtime) sqlStrings(v Value, what string) []string {
+	o := r.toObject(v)
+	list := make([]string, toLength(o.self.getStr("length", nil)))
+	for i := range list {
+		list[i] = nilSafe(o.self.getIdx(valueInt(i), nil)).String()
+		if !sqlIdentifier.MatchString(list[i]) {
+			panic(r.NewTypeError("Invalid %s: %s", what, list[i]))
+		}
+	}
+	return list
+}
+
+// sqlInsertMany runs insertMany(table, rows, {columns, chunkSize, onConflict, keys}) on conn. The rows are objects
+// keyed by the names of the columns or arrays of values in the order of the columns, which are the keys of the first
+// row by default. A chunk of up to chunkSize rows, 500 by default, is inserted by a statement with a multi-row
+// VALUES list, prepared once for all the chunks of the same size. onConflict is "ignore", "replace" or "update" where
+// the dialect supports it, keys are the columns of the unique constraint, see insertSQL. The
+// chunks are not atomic unless conn is a transaction. It returns an array of {rows, rowsAffected, lastInsertId}, one
+// per chunk.
+func (r *Runtime) sqlInsertMany(conn sqlConn, driver string, call FunctionCall) Value {
+	table := call.Argument(0).String()
+	if !sqlIdentifier.MatchString(table) {
+		panic(r.NewTypeError("Invalid table name: %s", table))
+	}
+	rowsObj := r.toObject(call.Argument(1))
+	n := int(toLength(rowsObj.self.getStr("length", nil)))
+	rows := make([]*Object, n)
+	for i := range rows {
+		rows[i] = r.toObject(nilSafe(rowsObj.self.getIdx(valueInt(i), nil)))
+	}
+
+	var columns, keys []string
+	chunkSize := 500
+	conflict := ""
+	if opts, ok := call.Argument(2).(*Object); ok {
+		if v := opts.self.getStr("columns", nil); v != nil && v != _undefined {
+			columns = r.sqlStrings(v, "column name")
+		}
+		if v := opts.self.getStr("chunkSize", nil); v != nil && v != _undefined {
+			if chunkSize = int(v.ToInteger()); chunkSize < 1 {
+				panic(r.newError(r.global.RangeError, "Invalid chunk size: %s", v.String()))
+			}
+		}
+		if v := opts.self.getStr("onConflict", nil); v != nil && v != _undefined {
+			switch conflict = v.String(); conflict {
+			case "ignore", "replace", "update":
+			default:
+				panic(r.NewTypeError("Invalid onConflict: %s", conflict))
+			}
+		}
+		if v := opts.self.getStr("keys", nil); v != nil && v != _undefined {
+			keys = r.sqlStrings(v, "key column")
+		}
+	}
+	if n == 0 {
+		return r.newArrayValues(nil)
+	}
+	if columns == nil {
+		if isArray(rows[0]) {
+			panic(r.NewTypeError("The columns are required for rows of arrays"))
+		}
+		keyValues := rows[0].self.stringKeys(false, nil)
+		columns = make([]string, len(keyValues))
+		for i, k := range keyValues {
+			if columns[i] = k.String(); !sqlIdentifier.MatchString(columns[i]) {
+				panic(r.NewTypeError("Invalid column name: %s", columns[i]))
+			}
+		}
+	}
+	if len(columns) == 0 {
+		panic(r.NewTypeError("No columns to insert"))
+	}
+
+	d := sqlDialectOf(driver)
+	size := d.chunkRows(chunkSize, len(columns))
+	values := make([]Value, len(columns))
+	var stmt *sql.Stmt
+	stmtRows := 0
+	defer func() {
+		if stmt != nil {
+			_ = stmt.Close()
+		}
+	}()
+	var results []Value
+	for start := 0; start < n; start += size {
+		end := start + size
+		if end > n {
+			end = n
+		}
+		if stmt == nil || end-start != stmtRows {
+			if stmt != nil {
+				_ = stmt.Close()
+				stmt = nil
+			}
+			query, err := d.insertSQL(table, columns, end-start, conflict, keys)
+			if err != nil {
+				panic(r.NewTypeError(err.Error()))
+			}
+			if stmt, err = conn.Prepare(query); err != nil {
+				panic(r.NewGoError(err))
+			}
+			stmtRows = end - start
+		}
+		params := make([]any, 0, (end-start)*len(columns))
+		for _, row := range rows[start:end] {
+			for j, column := range columns {
+				if isArray(row) {
+					values[j] = nilSafe(row.self.getIdx(valueInt(j), nil))
+				} else {
+					values[j] = nilSafe(row.self.getStr(unistring.NewFromString(column), nil))
+				}
+			}
+			params = append(params, r.sqlParams(values)...)
+		}
+		res, err := stmt.Exec(params...)
+		if err != nil {
+			panic(r.NewGoError(err))
+		}
+		result := r.sqlResult(res)
+		_ = result.Set("rows", end-start)
+		results = append(results, result)
+	}
+	return r.newArrayValues(results)
+}
+
+// sqlExecBatch runs execBatch(sql, paramsArray) on conn. The statement is prepared once and executed with each
+// array of parameters. It returns an array of {rowsAffected, lastInsertId}, one per execution.
+func (r *Runtime) sqlExecBatch(conn sqlConn, call FunctionCall) Value {
+	stmt, err := conn.Prepare(call.Argument(0).toString().String())
+	if err != nil {
+		panic(r.NewGoError(err))
+	}
+	defer func() {
+		_ = stmt.Close()
+	}()
+	paramsObj := r.toObject(call.Argument(1))
+	n := toLength(paramsObj.self.getStr("length", nil))
+	results := make([]Value, n)
+	for i := int64(0); i < n; i++ {
+		var args []Value
+		if params, ok := nilSafe(paramsObj.self.getIdx(valueInt(i), nil)).(*Object); ok {
+			args = make([]Value, toLength(params.self.getStr("length", nil)))
+			for j := range args {
+				args[j] = nilSafe(params.self.getIdx(valueInt(j), nil))
+			}
+		}
+		res, err := stmt.Exec(r.sqlParams(args)...)
+		if err != nil {
+			panic(r.NewGoError(err))
+		}
+		results[i] = r.sqlResult(res)
+	}
+	return r.newArrayValues(results)
+}
+
+func (r *Runtime) builtinDatabase_insertMany(call FunctionCall) Value {
+	do := r.toDatabase("insertMany", call)
+	return r.sqlInsertMany(do.db, do.driver, call)
+}
+
+func (r *Runtime) builtinDatabase_execBatch(call FunctionCall) Value {
+	return r.sqlExecBatch(r.toDatabase("execBatch", call).db, call)
+}
+
+func (r *Runtime) builtinSQLTransaction_insertMany(call FunctionCall) Value {
+	to := r.toSQLTransaction("insertMany", call)
+	return r.sqlInsertMany(to.tx, to.driver, call)
+}
+
+func (r *Runtime) builtinSQLTransaction_execBatch(call FunctionCall) Value {
+	return r.sqlExecBatch(r.toSQLTransaction("execBatch", call).tx, call)
+}
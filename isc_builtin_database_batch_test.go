@@ -0,0 +1,105 @@
+package goscript
+
+import (
+	"testing"
+)
+
+func TestSQLInsertMany(t *testing.T) {
+	vm := newWithTestLib(t)
+	_, err := vm.RunString(`
+	var db = new SQLite(":memory:");
+	db.exec("create table users (id integer primary key, name text, age integer)");
+	var rows = [];
+	for (var i = 1; i <= 7; i++) {
+		rows.push({id: i, name: "u" + i, age: i * 10});
+	}
+	var res = db.insertMany("users", rows, {chunkSize: 3});
+	assert.sameValue(res.length, 3, "chunks");
+	assert.sameValue(res.map(function (r) { return r.rows; }).join(), "3,3,1", "rows per chunk");
+	assert.sameValue(res[0].rowsAffected, 3, "rowsAffected");
+	assert.sameValue(db.query("select count(*) as n from users")[0].n, 7, "inserted");
+
+	try {
+		db.insertMany("users", [{id: 1, name: "dup", age: 0}]);
+		throw new Error("no error");
+	} catch (e) {
+		assert.sameValue(e.message.indexOf("UNIQUE") >= 0, true, "conflict: " + e.message);
+	}
+	res = db.insertMany("users", [{id: 1, name: "dup", age: 0}, {id: 8, name: "u8", age: 80}], {onConflict: "ignore"});
+	assert.sameValue(res[0].rowsAffected, 1, "ignore");
+	assert.sameValue(db.query("select name from users where id = 1")[0].name, "u1", "ignored");
+	db.insertMany("users", [{id: 1, name: "new", age: 1}], {onConflict: "update", keys: ["id"]});
+	assert.sameValue(db.query("select name from users where id = 1")[0].name, "new", "updated");
+	db.insertMany("users", [[2, "arr"]], {columns: ["id", "name"], onConflict: "replace"});
+	var u2 = db.query("select name, age from users where id = 2")[0];
+	assert.sameValue(u2.name + ":" + u2.age, "arr:null", "replaced");
+	db.insertMany("users", [{id: 9, name: "partial"}, {id: 10, age: 5}]);
+	assert.sameValue(db.query("select name from users where id = 10")[0].name, null, "missing value");
+
+	assert.sameValue(db.insertMany("users", []).length, 0, "no rows");
+	try {
+		db.insertMany("users; drop table users", rows);
+		throw new Error("no error");
+	} catch (e) {
+		assert.sameValue(e instanceof TypeError, true, "table name");
+	}
+	try {
+		db.insertMany("users", [{"id) values (1); --": 1}]);
+		throw new Error("no error");
+	} catch (e) {
+		assert.sameValue(e instanceof TypeError, true, "column name");
+	}
+
+	var tx = db.begin();
+	tx.insertMany("users", [{id: 20, name: "tx"}]);
+	res = tx.execBatch("update users set age = ? where id = ?", [[1, 20], [2, 3], [3, 404]]);
+	assert.sameValue(res.map(function (r) { return r.rowsAffected; }).join(), "1,1,0", "execBatch in transaction");
+	tx.rollback();
+	assert.sameValue(db.query("select count(*) as n from users where id = 20")[0].n, 0, "rollback");
+
+	res = db.execBatch("insert into users (id, name) values (?, ?)", [[30, "a"], [31, "b"]]);
+	assert.sameValue(res.length, 2, "execBatch");
+	assert.sameValue(res[1].lastInsertId, 31, "lastInsertId");
+	db.close();
+	`)
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestSQLDialectInsert(t *testing.T) {
+	columns := []string{"id", "name"}
+	for _, tc := range []struct {
+		driver, conflict, sql string
+	}{
+		{"mysql", "", "INSERT INTO t (id, name) VALUES (?, ?), (?, ?)"},
+		{"mysql", "ignore", "INSERT IGNORE INTO t (id, name) VALUES (?, ?), (?, ?)"},
+		{"mysql", "update", "INSERT INTO t (id, name) VALUES (?, ?), (?, ?) ON DUPLICATE KEY UPDATE id = VALUES(id), name = VALUES(name)"},
+		{"oracle", "", "INSERT ALL INTO t (id, name) VALUES (:1, :2) INTO t (id, name) VALUES (:3, :4) SELECT 1 FROM DUAL"},
+		{"sqlserver", "", "INSERT INTO t (id, name) VALUES (@p1, @p2), (@p3, @p4)"},
+		{"dm", "", "INSERT INTO t (id, name) VALUES (?, ?), (?, ?)"},
+		{"postgres", "update", "INSERT INTO t (id, name) VALUES ($1, $2), ($3, $4) ON CONFLICT (id) DO UPDATE SET name = excluded.name"},
+		{"sqlite", "replace", "INSERT OR REPLACE INTO t (id, name) VALUES (?, ?), (?, ?)"},
+		{"sqlserver", "update", "MERGE INTO t t USING (VALUES (@p1, @p2), (@p3, @p4)) s (id, name) ON (t.id = s.id) " +
+			"WHEN MATCHED THEN UPDATE SET t.name = s.name WHEN NOT MATCHED THEN INSERT (id, name) VALUES (s.id, s.name);"},
+		{"oracle", "ignore", "MERGE INTO t t USING (SELECT :1 id, :2 name FROM DUAL UNION ALL SELECT :3 id, :4 name FROM DUAL) s " +
+			"ON (t.id = s.id) WHEN NOT MATCHED THEN INSERT (id, name) VALUES (s.id, s.name)"},
+		{"oracle", "replace", ""},
+		{"sqlserver", "replace", ""},
+	} {
+		s, err := sqlDialectOf(tc.driver).insertSQL("t", columns, 2, tc.conflict, []string{"id"})
+		if tc.sql == "" {
+			if err == nil {
+				t.Errorf("%s %s: expected an error, got %s", tc.driver, tc.conflict, s)
+			}
+		} else if err != nil || s != tc.sql {
+			t.Errorf("%s %s: got %q, %v", tc.driver, tc.conflict, s, err)
+		}
+	}
+	if n := sqlDialectOf("sqlserver").chunkRows(5000, 3); n != 700 {
+		t.Errorf("sqlserver chunk: %d", n)
+	}
+	if n := sqlDialectOf("sqlserver").chunkRows(5000, 1); n != 1000 {
+		t.Errorf("sqlserver rows: %d", n)
+	}
+}
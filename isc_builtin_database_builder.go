@@ -0,0 +1,572 @@
+package goscript
+
+import (
+	"github.com/rarnu/goscript/unistring"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// SQLQuery 是链式的 SQL 构造器，按数据库方言引用标识符并绑定参数
+
+// sqlQueryObject is a statement built by select, insert, update, delete or upsert. The methods of the builder modify
+// it and return it, the statement is generated when it is run.
+type sqlQueryObject struct {
+	baseObject
+	conn      sqlConn
+	dialect   sqlDialect
+	camelCase bool
+
+	verb         string // "select", "insert", "update", "delete" or "upsert"
+	table        string
+	columns      []string // the selected expressions, or the columns of update
+	values       []Value  // the values of update
+	rows         []*Object
+	conflictKeys []string // the keys of upsert
+
+	where       []string // conditions with ? placeholders
+	whereParams []Value
+	orderBy     []string
+	limit       int64 // -1 if not set
+	offset      int64
+}
+
+var sqlSelectAlias = regexp.MustCompile(`(?i)^(.+?)\s+as\s+(\S+)$`)
+
+// quote quotes a name, which may be qualified. On Oracle and Dameng, plain identifiers are not quoted, so that they
+// are not case-sensitive.
+func (d sqlDialect) quote(name string) string {
+	parts := strings.Split(name, ".")
+	for i, p := range parts {
+		switch {
+		case p == "*" && i == len(parts)-1:
+		case d.name == "mysql":
+			parts[i] = "`" + strings.ReplaceAll(p, "`", "``") + "`"
+		case d.name == "sqlserver":
+			parts[i] = "[" + strings.ReplaceAll(p, "]", "]]") + "]"
+		case (d.name == "oracle" || d.name == "dm") && sqlIdentifier.MatchString(p):
+		default:
+			parts[i] = `"` + strings.ReplaceAll(p, `"`, `""`) + `"`
+		}
+	}
+	return strings.Join(parts, ".")
+}
+
+// selectExpression quotes a column of select, "name" or "name as alias". Any other expression, such as
+// "count(*) as n", is kept as it is except for its alias.
+func (d sqlDialect) selectExpression(expr string) string {
+	expr = strings.TrimSpace(expr)
+	if m := sqlSelectAlias.FindStringSubmatch(expr); m != nil {
+		return d.selectExpression(m[1]) + " AS " + d.quote(m[2])
+	}
+	if strings.ContainsAny(expr, " ()'\"`[]+-/,") {
+		return expr
+	}
+	return d.quote(expr)
+}
+
+// numberPlaceholders replaces the ? of a condition outside of quotes with the placeholders of the dialect, n is the
+// number of the placeholders before it.
+func (d sqlDialect) numberPlaceholders(s string, n *int) string {
+	var b strings.Builder
+	var quote rune
+	for _, c := range s {
+		switch {
+		case quote != 0:
+			if c == quote {
+				quote = 0
+			}
+		case c == '\'' || c == '"':
+			quote = c
+		case c == '?':
+			*n++
+			b.WriteString(d.placeholder(*n))
+			continue
+		}
+		b.WriteRune(c)
+	}
+	return b.String()
+}
+
+func (r *Runtime) newSQLQuery(conn sqlConn, driver string, camel bool, verb string) *sqlQueryObject {
+	o := &Object{runtime: r}
+	q := &sqlQueryObject{
+		baseObject: baseObject{
+			class:      classSQLQuery,
+			val:        o,
+			prototype:  r.global.SQLQueryPrototype,
+			extensible: true,
+			values:     nil,
+		},
+		conn:      conn,
+		dialect:   sqlDialectOf(driver),
+		camelCase: camel,
+		verb:      verb,
+		limit:     -1,
+	}
+	o.self = q
+	q.init()
+	return q
+}
+
+func (r *Runtime) toSQLQuery(method string, call FunctionCall) *sqlQueryObject {
+	thisObj := r.toObject(call.This)
+	q, ok := thisObj.self.(*sqlQueryObject)
+	if !ok {
+		panic(r.NewTypeError("Method SQLQuery.prototype.%s called on incompatible receiver %s", method, r.objectproto_toString(FunctionCall{This: thisObj})))
+	}
+	return q
+}
+
+// sqlRowsArgument returns the rows of insert or upsert, an object or an array of objects.
+func (r *Runtime) sqlRowsArgument(v Value) []*Object {
+	o := r.toObject(v)
+	if !isArray(o) {
+		return []*Object{o}
+	}
+	rows := make([]*Object, toLength(o.self.getStr("length", nil)))
+	for i := range rows {
+		rows[i] = r.toObject(nilSafe(o.self.getIdx(valueInt(i), nil)))
+	}
+	return rows
+}
+
+// sqlSelect is select(...columns), which selects all the columns without arguments.
+func (r *Runtime) sqlSelect(conn sqlConn, driver string, camel bool, call FunctionCall) Value {
+	q := r.newSQLQuery(conn, driver, camel, "select")
+	for _, arg := range call.Arguments {
+		q.columns = append(q.columns, arg.String())
+	}
+	return q.val
+}
+
+// sqlInsert is insert(table, rows), where rows is an object or an array of objects keyed by the names of the
+// columns, which are the keys of the first row.
+func (r *Runtime) sqlInsert(conn sqlConn, driver string, camel bool, call FunctionCall) Value {
+	q := r.newSQLQuery(conn, driver, camel, "insert")
+	q.table = call.Argument(0).String()
+	q.rows = r.sqlRowsArgument(call.Argument(1))
+	return q.val
+}
+
+// sqlUpsert is upsert(table, rows, keys), which inserts the rows or updates the ones whose keys, the columns of a
+// unique constraint, already exist.
+func (r *Runtime) sqlUpsert(conn sqlConn, driver string, camel bool, call FunctionCall) Value {
+	q := r.newSQLQuery(conn, driver, camel, "upsert")
+	q.table = call.Argument(0).String()
+	q.rows = r.sqlRowsArgument(call.Argument(1))
+	if keys, ok := call.Argument(2).(*Object); ok && isArray(keys) {
+		for i := int64(0); i < toLength(keys.self.getStr("length", nil)); i++ {
+			q.conflictKeys = append(q.conflictKeys, nilSafe(keys.self.getIdx(valueInt(i), nil)).String())
+		}
+	} else if k := call.Argument(2); k != _undefined {
+		q.conflictKeys = []string{k.String()}
+	}
+	if len(q.conflictKeys) == 0 {
+		panic(r.NewTypeError("upsert requires the keys of a unique constraint"))
+	}
+	return q.val
+}
+
+// sqlUpdate is update(table, values), where values is an object keyed by the names of the columns.
+func (r *Runtime) sqlUpdate(conn sqlConn, driver string, camel bool, call FunctionCall) Value {
+	q := r.newSQLQuery(conn, driver, camel, "update")
+	q.table = call.Argument(0).String()
+	values := r.toObject(call.Argument(1))
+	for _, k := range values.self.stringKeys(false, nil) {
+		q.columns = append(q.columns, k.String())
+		q.values = append(q.values, nilSafe(values.self.getStr(unistring.NewFromString(k.String()), nil)))
+	}
+	if len(q.columns) == 0 {
+		panic(r.NewTypeError("update requires the values of the columns"))
+	}
+	return q.val
+}
+
+// sqlDelete is delete(table).
+func (r *Runtime) sqlDelete(conn sqlConn, driver string, camel bool, call FunctionCall) Value {
+	q := r.newSQLQuery(conn, driver, camel, "delete")
+	q.table = call.Argument(0).String()
+	return q.val
+}
+
+func (r *Runtime) builtinSQLQuery_from(call FunctionCall) Value {
+	q := r.toSQLQuery("from", call)
+	q.table = call.Argument(0).String()
+	return q.val
+}
+
+// builtinSQLQuery_where is where(conditions) or where(sql, ...params). The conditions are an object whose properties
+// are compared to the columns: null is IS NULL and an array is IN. A condition in SQL binds its ? to params. The
+// conditions of several calls are combined with AND.
+func (r *Runtime) builtinSQLQuery_where(call FunctionCall) Value {
+	q := r.toSQLQuery("where", call)
+	arg := call.Argument(0)
+	if arg == _undefined || arg == _null {
+		panic(r.NewTypeError("The condition of where must be an object or SQL, got %s", arg.String()))
+	}
+	if o, ok := arg.(*Object); ok {
+		for _, k := range o.self.stringKeys(false, nil) {
+			column := q.dialect.quote(k.String())
+			v := nilSafe(o.self.getStr(unistring.NewFromString(k.String()), nil))
+			list, _ := v.(*Object)
+			switch {
+			case v == _null || v == _undefined:
+				q.where = append(q.where, column+" IS NULL")
+			case list != nil && isArray(list):
+				n := toLength(list.self.getStr("length", nil))
+				if n == 0 {
+					q.where = append(q.where, "1 = 0")
+					continue
+				}
+				placeholders := make([]string, n)
+				for i := range placeholders {
+					placeholders[i] = "?"
+					q.whereParams = append(q.whereParams, nilSafe(list.self.getIdx(valueInt(i), nil)))
+				}
+				q.where = append(q.where, column+" IN ("+strings.Join(placeholders, ", ")+")")
+			default:
+				q.where = append(q.where, column+" = ?")
+				q.whereParams = append(q.whereParams, v)
+			}
+		}
+		return q.val
+	}
+	q.where = append(q.where, "("+arg.String()+")")
+	q.whereParams = append(q.whereParams, restArgs(call, 1)...)
+	return q.val
+}
+
+// builtinSQLQuery_orderBy is orderBy(column, direction), where direction is "asc" or "desc". The column may also
+// be followed by its direction, as in "id desc".
+func (r *Runtime) builtinSQLQuery_orderBy(call FunctionCall) Value {
+	q := r.toSQLQuery("orderBy", call)
+	fields := strings.Fields(call.Argument(0).String())
+	if len(fields) == 0 || len(fields) > 2 {
+		panic(r.NewTypeError("Invalid order: %s", call.Argument(0).String()))
+	}
+	direction := ""
+	if len(fields) == 2 {
+		direction = fields[1]
+	}
+	if v := call.Argument(1); v != _undefined {
+		direction = v.String()
+	}
+	switch strings.ToUpper(direction) {
+	case "":
+		q.orderBy = append(q.orderBy, q.dialect.quote(fields[0]))
+	case "ASC", "DESC":
+		q.orderBy = append(q.orderBy, q.dialect.quote(fields[0])+" "+strings.ToUpper(direction))
+	default:
+		panic(r.NewTypeError("Invalid order direction: %s", direction))
+	}
+	return q.val
+}
+
+func (r *Runtime) sqlQueryCount(v Value) int64 {
+	n := v.ToInteger()
+	if n < 0 {
+		panic(r.newError(r.global.RangeError, "Invalid count: %s", v.String()))
+	}
+	return n
+}
+
+func (r *Runtime) builtinSQLQuery_limit(call FunctionCall) Value {
+	q := r.toSQLQuery("limit", call)
+	q.limit = r.sqlQueryCount(call.Argument(0))
+	return q.val
+}
+
+func (r *Runtime) builtinSQLQuery_offset(call FunctionCall) Value {
+	q := r.toSQLQuery("offset", call)
+	q.offset = r.sqlQueryCount(call.Argument(0))
+	return q.val
+}
+
+// build generates the statement and its parameters.
+func (q *sqlQueryObject) build() (string, []any) {
+	r := q.val.runtime
+	d := q.dialect
+	if q.table == "" {
+		panic(r.NewTypeError("The %s statement has no table", q.verb))
+	}
+	table := d.quote(q.table)
+	n := 0
+	var b strings.Builder
+	var params []Value
+	where := func() {
+		if len(q.where) > 0 {
+			b.WriteString(" WHERE " + d.numberPlaceholders(strings.Join(q.where, " AND "), &n))
+			params = append(params, q.whereParams...)
+		}
+	}
+
+	switch q.verb {
+	case "select":
+		b.WriteString("SELECT ")
+		if len(q.columns) == 0 {
+			b.WriteString("*")
+		}
+		for i, c := range q.columns {
+			if i > 0 {
+				b.WriteString(", ")
+			}
+			b.WriteString(d.selectExpression(c))
+		}
+		b.WriteString(" FROM " + table)
+		where()
+		orderBy := q.orderBy
+		if len(orderBy) == 0 && d.name == "sqlserver" && (q.limit >= 0 || q.offset > 0) {
+			// OFFSET ... FETCH requires an order
+			orderBy = []string{"(SELECT NULL)"}
+		}
+		if len(orderBy) > 0 {
+			b.WriteString(" ORDER BY " + strings.Join(orderBy, ", "))
+		}
+		q.writeLimit(&b)
+	case "insert", "upsert":
+		if len(q.rows) == 0 {
+			panic(r.NewTypeError("The %s statement has no rows", q.verb))
+		}
+		keys := q.rows[0].self.stringKeys(false, nil)
+		columns := make([]string, len(keys))
+		for i, k := range keys {
+			columns[i] = d.quote(k.String())
+		}
+		// the statement is not split, unlike the one of insertMany
+		if len(columns) > 0 && d.chunkRows(len(q.rows), len(columns)) < len(q.rows) {
+			panic(r.NewTypeError("The %s statement has too many rows for a single statement (%d rows of %d columns), "+
+				"use insertMany", q.verb, len(q.rows), len(columns)))
+		}
+		for _, row := range q.rows {
+			for _, k := range keys {
+				params = append(params, nilSafe(row.self.getStr(unistring.NewFromString(k.String()), nil)))
+			}
+		}
+		conflict := ""
+		keyColumns := make([]string, len(q.conflictKeys))
+		for i, k := range q.conflictKeys {
+			keyColumns[i] = d.quote(k)
+		}
+		if q.verb == "upsert" {
+			conflict = "update"
+		}
+		s, err := d.insertSQL(table, columns, len(q.rows), conflict, keyColumns)
+		if err != nil {
+			panic(r.NewTypeError(err.Error()))
+		}
+		b.WriteString(s)
+	case "update":
+		b.WriteString("UPDATE " + table + " SET ")
+		for i, c := range q.columns {
+			if i > 0 {
+				b.WriteString(", ")
+			}
+			n++
+			b.WriteString(d.quote(c) + " = " + d.placeholder(n))
+		}
+		params = append(params, q.values...)
+		where()
+	case "delete":
+		b.WriteString("DELETE FROM " + table)
+		where()
+	}
+	return b.String(), r.sqlParams(params)
+}
+
+// writeLimit writes the limit and the offset of a select.
+func (q *sqlQueryObject) writeLimit(b *strings.Builder) {
+	if q.limit < 0 && q.offset == 0 {
+		return
+	}
+	limit, offset := strconv.FormatInt(q.limit, 10), strconv.FormatInt(q.offset, 10)
+	switch q.dialect.name {
+	case "oracle", "sqlserver":
+		b.WriteString(" OFFSET " + offset + " ROWS")
+		if q.limit >= 0 {
+			b.WriteString(" FETCH NEXT " + limit + " ROWS ONLY")
+		}
+		return
+	}
+	if q.limit < 0 {
+		switch q.dialect.name {
+		case "postgres":
+			b.WriteString(" OFFSET " + offset)
+			return
+		case "mysql":
+			// the largest limit of MySQL
+			limit = "18446744073709551615"
+		default:
+			limit = "-1"
+		}
+	}
+	b.WriteString(" LIMIT " + limit)
+	if q.offset > 0 {
+		b.WriteString(" OFFSET " + offset)
+	}
+}
+
+// builtinSQLQuery_toSQL returns {sql, params}, the statement that would be run.
+func (r *Runtime) builtinSQLQuery_toSQL(call FunctionCall) Value {
+	s, params := r.toSQLQuery("toSQL", call).build()
+	o := r.NewObject()
+	_ = o.Set("sql", s)
+	values := make([]Value, len(params))
+	for i, p := range params {
+		if b, ok := p.([]byte); ok {
+			values[i] = r.columnToValue(b)
+		} else {
+			values[i] = r.ToValue(p)
+		}
+	}
+	_ = o.Set("params", r.newArrayValues(values))
+	return o
+}
+
+func (r *Runtime) toSelectQuery(method string, call FunctionCall) *sqlQueryObject {
+	q := r.toSQLQuery(method, call)
+	if q.verb != "select" {
+		panic(r.NewTypeError("SQLQuery.prototype.%s requires a select statement", method))
+	}
+	return q
+}
+
+// builtinSQLQuery_all runs a select and returns its rows.
+func (r *Runtime) builtinSQLQuery_all(call FunctionCall) Value {
+	q := r.toSelectQuery("all", call)
+	s, params := q.build()
+	rows, err := q.conn.Query(s, params...)
+	if err == nil {
+		var fields []string
+		var records [][]any
+		if fields, records, err = fetchRows(rows, q.camelCase); err == nil {
+			return r.rowsValue(fields, records)
+		}
+	}
+	panic(r.NewGoError(err))
+}
+
+// builtinSQLQuery_first runs a select and returns its first row, or null if there is none.
+func (r *Runtime) builtinSQLQuery_first(call FunctionCall) Value {
+	q := r.toSelectQuery("first", call)
+	s, params := q.build()
+	rows, err := q.conn.Query(s, params...)
+	if err != nil {
+		panic(r.NewGoError(err))
+	}
+	sr, err := newSQLRows(rows, q.camelCase)
+	if err != nil {
+		panic(r.NewGoError(err))
+	}
+	defer func() {
+		_ = rows.Close()
+	}()
+	record, err := sr.next()
+	if err != nil {
+		panic(r.NewGoError(err))
+	}
+	if record == nil {
+		return _null
+	}
+	return r.rowValue(sr.fields, record)
+}
+
+// builtinSQLQuery_cursor runs a select and returns a SQLCursor over its rows.
+func (r *Runtime) builtinSQLQuery_cursor(call FunctionCall) Value {
+	q := r.toSelectQuery("cursor", call)
+	s, params := q.build()
+	rows, err := q.conn.Query(s, params...)
+	if err != nil {
+		panic(r.NewGoError(err))
+	}
+	return r.newSQLCursor(rows, q.camelCase)
+}
+
+// builtinSQLQuery_exec runs an insert, update, delete or upsert and returns {rowsAffected, lastInsertId}.
+func (r *Runtime) builtinSQLQuery_exec(call FunctionCall) Value {
+	q := r.toSQLQuery("exec", call)
+	if q.verb == "select" {
+		panic(r.NewTypeError("SQLQuery.prototype.exec cannot run a select statement"))
+	}
+	s, params := q.build()
+	res, err := q.conn.Exec(s, params...)
+	if err != nil {
+		panic(r.NewGoError(err))
+	}
+	return r.sqlResult(res)
+}
+
+func (r *Runtime) builtinDatabase_select(call FunctionCall) Value {
+	do := r.toDatabase("select", call)
+	return r.sqlSelect(do.db, do.driver, do.camelCase, call)
+}
+
+func (r *Runtime) builtinDatabase_insert(call FunctionCall) Value {
+	do := r.toDatabase("insert", call)
+	return r.sqlInsert(do.db, do.driver, do.camelCase, call)
+}
+
+func (r *Runtime) builtinDatabase_upsert(call FunctionCall) Value {
+	do := r.toDatabase("upsert", call)
+	return r.sqlUpsert(do.db, do.driver, do.camelCase, call)
+}
+
+func (r *Runtime) builtinDatabase_update(call FunctionCall) Value {
+	do := r.toDatabase("update", call)
+	return r.sqlUpdate(do.db, do.driver, do.camelCase, call)
+}
+
+func (r *Runtime) builtinDatabase_delete(call FunctionCall) Value {
+	do := r.toDatabase("delete", call)
+	return r.sqlDelete(do.db, do.driver, do.camelCase, call)
+}
+
+func (r *Runtime) builtinSQLTransaction_select(call FunctionCall) Value {
+	to := r.toSQLTransaction("select", call)
+	return r.sqlSelect(to.tx, to.driver, to.camelCase, call)
+}
+
+func (r *Runtime) builtinSQLTransaction_insert(call FunctionCall) Value {
+	to := r.toSQLTransaction("insert", call)
+	return r.sqlInsert(to.tx, to.driver, to.camelCase, call)
+}
+
+func (r *Runtime) builtinSQLTransaction_upsert(call FunctionCall) Value {
+	to := r.toSQLTransaction("upsert", call)
+	return r.sqlUpsert(to.tx, to.driver, to.camelCase, call)
+}
+
+func (r *Runtime) builtinSQLTransaction_update(call FunctionCall) Value {
+	to := r.toSQLTransaction("update", call)
+	return r.sqlUpdate(to.tx, to.driver, to.camelCase, call)
+}
+
+func (r *Runtime) builtinSQLTransaction_delete(call FunctionCall) Value {
+	to := r.toSQLTransaction("delete", call)
+	return r.sqlDelete(to.tx, to.driver, to.camelCase, call)
+}
+
+// putSQLBuilders adds the methods starting a statement to the prototype of Database or SQLTransaction.
+func (r *Runtime) putSQLBuilders(o *baseObject, sel, insert, upsert, update, del func(FunctionCall) Value) {
+	o._putProp("select", r.newNativeFunc(sel, nil, "select", nil, 0), true, false, true)
+	o._putProp("insert", r.newNativeFunc(insert, nil, "insert", nil, 2), true, false, true)
+	o._putProp("upsert", r.newNativeFunc(upsert, nil, "upsert", nil, 3), true, false, true)
+	o._putProp("update", r.newNativeFunc(update, nil, "update", nil, 2), true, false, true)
+	o._putProp("delete", r.newNativeFunc(del, nil, "delete", nil, 1), true, false, true)
+}
+
+func (r *Runtime) createSQLQueryProto(val *Object) objectImpl {
+	o := newBaseObjectObj(val, r.global.ObjectPrototype, classObject)
+	o._putProp("from", r.newNativeFunc(r.builtinSQLQuery_from, nil, "from", nil, 1), true, false, true)
+	o._putProp("where", r.newNativeFunc(r.builtinSQLQuery_where, nil, "where", nil, 1), true, false, true)
+	o._putProp("orderBy", r.newNativeFunc(r.builtinSQLQuery_orderBy, nil, "orderBy", nil, 1), true, false, true)
+	o._putProp("limit", r.newNativeFunc(r.builtinSQLQuery_limit, nil, "limit", nil, 1), true, false, true)
+	o._putProp("offset", r.newNativeFunc(r.builtinSQLQuery_offset, nil, "offset", nil, 1), true, false, true)
+	o._putProp("toSQL", r.newNativeFunc(r.builtinSQLQuery_toSQL, nil, "toSQL", nil, 0), true, false, true)
+	o._putProp("all", r.newNativeFunc(r.builtinSQLQuery_all, nil, "all", nil, 0), true, false, true)
+	o._putProp("first", r.newNativeFunc(r.builtinSQLQuery_first, nil, "first", nil, 0), true, false, true)
+	o._putProp("cursor", r.newNativeFunc(r.builtinSQLQuery_cursor, nil, "cursor", nil, 0), true, false, true)
+	o._putProp("exec", r.newNativeFunc(r.builtinSQLQuery_exec, nil, "exec", nil, 0), true, false, true)
+	o._putSym(SymToStringTag, valueProp(asciiString(classSQLQuery), false, false, true))
+	return o
+}
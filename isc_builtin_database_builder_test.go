@@ -0,0 +1,111 @@
+package goscript
+
+import (
+	"testing"
+)
+
+func TestSQLQueryBuilder(t *testing.T) {
+	vm := newWithTestLib(t)
+	_, err := vm.RunString(`
+	var db = new SQLite(":memory:");
+	db.exec("create table users (id integer primary key, name text, status integer, \"order\" integer)");
+	var res = db.insert("users", [{id: 1, name: "a", status: 1, order: 3}, {id: 2, name: "b", status: 0, order: 2},
+		{id: 3, name: "c", status: 1, order: 1}]).exec();
+	assert.sameValue(res.rowsAffected, 3, "insert");
+
+	var q = db.select("id", "name").from("users").where({status: 1}).orderBy("order", "desc").limit(10);
+	assert.sameValue(Object.prototype.toString.call(q), "[object SQLQuery]", "toStringTag");
+	var s = q.toSQL();
+	assert.sameValue(s.sql, 'SELECT "id", "name" FROM "users" WHERE "status" = ? ORDER BY "order" DESC LIMIT 10', "sql");
+	assert.sameValue(s.params.join(), "1", "params");
+	assert.sameValue(q.all().map(function (r) { return r.name; }).join(), "a,c", "all");
+	assert.sameValue(db.select().from("users").where({id: [2, 3]}).where("name <> ?", "c").first().name, "b", "first");
+	assert.sameValue(db.select().from("users").where({id: 404}).first(), null, "no first");
+	assert.sameValue(db.select("count(*) as n").from("users").where({name: null}).first().n, 0, "expression");
+	assert.sameValue(db.select("id").from("users").where({id: []}).all().length, 0, "empty in");
+	assert.sameValue(db.select("id").from("users").orderBy("id").offset(1).all().length, 2, "offset without limit");
+	var ids = [];
+	for (var row of db.select("id").from("users").orderBy("id desc").limit(2).cursor()) {
+		ids.push(row.id);
+	}
+	assert.sameValue(ids.join(), "3,2", "cursor");
+
+	assert.sameValue(db.update("users", {status: 2}).where({status: 1}).exec().rowsAffected, 2, "update");
+	assert.sameValue(db.select("id").from("users").where("status = ? and name like '%?%'", 2).all().length, 0,
+		"quoted ? is not a parameter");
+	db.upsert("users", {id: 1, name: "z", status: 5, order: 0}, ["id"]).exec();
+	assert.sameValue(db.select("name").from("users").where({id: 1}).first().name, "z", "upsert");
+	assert.sameValue(db.delete("users").where({id: 2}).exec().rowsAffected, 1, "delete");
+
+	var tx = db.begin();
+	tx.insert("users", {id: 9, name: "tx"}).exec();
+	assert.sameValue(tx.select("id").from("users").where({id: 9}).all().length, 1, "transaction");
+	tx.rollback();
+	assert.sameValue(db.select("id").from("users").where({id: 9}).all().length, 0, "rollback");
+	try {
+		db.select("id").from("users").exec();
+		throw new Error("no error");
+	} catch (e) {
+		assert.sameValue(e instanceof TypeError, true, "exec of select");
+	}
+	[undefined, null].forEach(function (condition) {
+		try {
+			db.select("id").from("users").where(condition);
+			throw new Error("no error");
+		} catch (e) {
+			assert.sameValue(e instanceof TypeError, true, "where " + condition);
+		}
+	});
+	db.close();
+
+	var mysql = new Mysql("localhost", 3306, "u", "p", "d");
+	s = mysql.select("a", "b as c").from("db.t").where({x: 1}).where("y > ?", 2).orderBy("a").limit(5).offset(10).toSQL();
+	assert.sameValue(s.sql, "SELECT ` + "`a`, `b` AS `c` FROM `db`.`t` WHERE `x` = ? AND (y > ?) ORDER BY `a` LIMIT 5 OFFSET 10" + `", "mysql");
+	assert.sameValue(mysql.upsert("t", {id: 1, v: 2}, "id").toSQL().sql,
+		"INSERT INTO ` + "`t` (`id`, `v`) VALUES (?, ?) ON DUPLICATE KEY UPDATE `id` = VALUES(`id`), `v` = VALUES(`v`)" + `", "mysql upsert");
+	mysql.close();
+
+	var mssql = new Mssql("localhost", 1433, "u", "p", "d");
+	s = mssql.select().from("t").where({x: 1, y: [2, 3]}).limit(5).toSQL();
+	assert.sameValue(s.sql, "SELECT * FROM [t] WHERE [x] = @p1 AND [y] IN (@p2, @p3) ORDER BY (SELECT NULL) OFFSET 0 ROWS FETCH NEXT 5 ROWS ONLY", "mssql");
+	assert.sameValue(mssql.update("t", {v: 1}).where({id: 2}).toSQL().sql, "UPDATE [t] SET [v] = @p1 WHERE [id] = @p2", "mssql update");
+	var rows = [];
+	for (var i = 0; i < 1100; i++) {
+		rows.push({id: i, v: i});
+	}
+	try {
+		mssql.insert("t", rows).toSQL();
+		throw new Error("no error");
+	} catch (e) {
+		assert.sameValue(e instanceof TypeError, true, "too many parameters");
+	}
+	mssql.close();
+
+	var oracle = new Oracle("localhost", 1521, "u", "p", "xe");
+	s = oracle.select("id").from("t").where({id: 1, "Mixed Case": 2}).orderBy("id").limit(5).toSQL();
+	assert.sameValue(s.sql, 'SELECT id FROM t WHERE id = :1 AND "Mixed Case" = :2 ORDER BY id OFFSET 0 ROWS FETCH NEXT 5 ROWS ONLY', "oracle");
+	assert.sameValue(oracle.insert("t", [{a: 1}, {a: 2}]).toSQL().sql, "INSERT ALL INTO t (a) VALUES (:1) INTO t (a) VALUES (:2) SELECT 1 FROM DUAL", "oracle insert");
+	assert.sameValue(oracle.delete("t").where({a: null}).toSQL().sql, "DELETE FROM t WHERE a IS NULL", "oracle delete");
+	oracle.close();
+
+	var pg = new Postgres("localhost", 5432, "u", "p", "d");
+	assert.sameValue(pg.select().from("t").where({a: 1}).offset(3).toSQL().sql, 'SELECT * FROM "t" WHERE "a" = $1 OFFSET 3', "postgres");
+	pg.close();
+	`)
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestSQLQuoteDameng(t *testing.T) {
+	d := sqlDialectOf("dm")
+	if s := d.quote("sch.tbl"); s != "sch.tbl" {
+		t.Errorf("plain identifier: %s", s)
+	}
+	if s := d.quote(`a"b`); s != `"a""b"` {
+		t.Errorf("quoted identifier: %s", s)
+	}
+	if s := d.selectExpression("sum(v) as total"); s != "sum(v) AS total" {
+		t.Errorf("expression: %s", s)
+	}
+}
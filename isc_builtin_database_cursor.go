@@ -0,0 +1,229 @@
+package goscript
+
+import "database/sql"
+
+// SQLCursor 逐行读取查询结果，不会一次性把所有行读入内存
+
+// sqlCursorObject reads the rows of a query lazily. It holds a connection until it is closed, which happens at the
+// end of the rows, on an error, or when return, throw or close is called, e.g. by for-of on break or on an exception.
+type sqlCursorObject struct {
+	baseObject
+	rows *sqlRows // nil once closed
+}
+
+// sqlAsyncCursorObject is the async iterator of a cursor, whose methods return promises.
+type sqlAsyncCursorObject struct {
+	baseObject
+	cursor *sqlCursorObject
+}
+
+// sqlCursor runs cursor(sql, ...params) on conn.
+func (r *Runtime) sqlCursor(conn sqlConn, camel bool, call FunctionCall) Value {
+	rows, err := conn.Query(call.Argument(0).toString().String(), r.sqlParams(restArgs(call, 1))...)
+	if err != nil {
+		panic(r.NewGoError(err))
+	}
+	return r.newSQLCursor(rows, camel)
+}
+
+// newSQLCursor returns a SQLCursor over rows.
+func (r *Runtime) newSQLCursor(rows *sql.Rows, camel bool) Value {
+	s, err := newSQLRows(rows, camel)
+	if err != nil {
+		panic(r.NewGoError(err))
+	}
+	o := &Object{runtime: r}
+	co := &sqlCursorObject{
+		baseObject: baseObject{
+			class:      classSQLCursor,
+			val:        o,
+			prototype:  r.global.SQLCursorPrototype,
+			extensible: true,
+			values:     nil,
+		},
+		rows: s,
+	}
+	o.self = co
+	co.init()
+	return o
+}
+
+func (co *sqlCursorObject) close() {
+	if co.rows != nil {
+		_ = co.rows.rows.Close()
+		co.rows = nil
+	}
+}
+
+// next returns the next row, or nil at the end of the rows. The cursor is closed at the end or on an error.
+func (co *sqlCursorObject) next() *Object {
+	if co.rows == nil {
+		return nil
+	}
+	r := co.val.runtime
+	record, err := co.rows.next()
+	if err != nil {
+		co.close()
+		panic(r.NewGoError(err))
+	}
+	if record == nil {
+		co.close()
+		return nil
+	}
+	return r.rowValue(co.rows.fields, record)
+}
+
+// batch returns up to n rows, fewer only at the end of the rows.
+func (co *sqlCursorObject) batch(n int64) Value {
+	var list []Value
+	for i := int64(0); i < n; i++ {
+		row := co.next()
+		if row == nil {
+			break
+		}
+		list = append(list, row)
+	}
+	return co.val.runtime.newArrayValues(list)
+}
+
+func (r *Runtime) toSQLCursor(method string, call FunctionCall) *sqlCursorObject {
+	thisObj := r.toObject(call.This)
+	co, ok := thisObj.self.(*sqlCursorObject)
+	if !ok {
+		panic(r.NewTypeError("Method SQLCursor.prototype.%s called on incompatible receiver %s", method, r.objectproto_toString(FunctionCall{This: thisObj})))
+	}
+	return co
+}
+
+func (r *Runtime) cursorBatchSize(v Value) int64 {
+	n := v.ToInteger()
+	if n < 1 {
+		panic(r.newError(r.global.RangeError, "Invalid batch size: %s", v.String()))
+	}
+	return n
+}
+
+func (r *Runtime) builtinSQLCursor_next(call FunctionCall) Value {
+	if row := r.toSQLCursor("next", call).next(); row != nil {
+		return r.createIterResultObject(row, false)
+	}
+	return r.createIterResultObject(_undefined, true)
+}
+
+func (r *Runtime) builtinSQLCursor_return(call FunctionCall) Value {
+	r.toSQLCursor("return", call).close()
+	return r.createIterResultObject(call.Argument(0), true)
+}
+
+func (r *Runtime) builtinSQLCursor_throw(call FunctionCall) Value {
+	r.toSQLCursor("throw", call).close()
+	panic(call.Argument(0))
+}
+
+// builtinSQLCursor_batch is batch(n), which returns an array of up to n rows. The array is empty at the end of the
+// rows.
+func (r *Runtime) builtinSQLCursor_batch(call FunctionCall) Value {
+	co := r.toSQLCursor("batch", call)
+	return co.batch(r.cursorBatchSize(call.Argument(0)))
+}
+
+func (r *Runtime) builtinSQLCursor_close(call FunctionCall) Value {
+	r.toSQLCursor("close", call).close()
+	return _undefined
+}
+
+func (r *Runtime) builtinSQLCursor_asyncIterator(call FunctionCall) Value {
+	co := r.toSQLCursor("[Symbol.asyncIterator]", call)
+	o := &Object{runtime: r}
+	ao := &sqlAsyncCursorObject{
+		baseObject: baseObject{
+			class:      classSQLAsyncCursor,
+			val:        o,
+			prototype:  r.global.SQLAsyncCursorPrototype,
+			extensible: true,
+			values:     nil,
+		},
+		cursor: co,
+	}
+	o.self = ao
+	ao.init()
+	return o
+}
+
+// cursorPromise runs fn on the cursor of an async iterator and returns a promise of its result. The rows are read
+// when the method is called, the promise is already settled when it returns.
+func (r *Runtime) cursorPromise(call FunctionCall, method string, fn func(co *sqlCursorObject) Value) Value {
+	thisObj := r.toObject(call.This)
+	ao, ok := thisObj.self.(*sqlAsyncCursorObject)
+	if !ok {
+		panic(r.NewTypeError("Method SQLAsyncCursor.prototype.%s called on incompatible receiver %s", method, r.objectproto_toString(FunctionCall{This: thisObj})))
+	}
+	p, resolve, reject := r.NewPromise()
+	var v Value
+	if err := r.try(func() { v = fn(ao.cursor) }); err != nil {
+		reject(err.(*Exception).Value())
+	} else {
+		resolve(v)
+	}
+	return r.ToValue(p)
+}
+
+func (r *Runtime) builtinSQLAsyncCursor_next(call FunctionCall) Value {
+	return r.cursorPromise(call, "next", func(co *sqlCursorObject) Value {
+		if row := co.next(); row != nil {
+			return r.createIterResultObject(row, false)
+		}
+		return r.createIterResultObject(_undefined, true)
+	})
+}
+
+func (r *Runtime) builtinSQLAsyncCursor_return(call FunctionCall) Value {
+	return r.cursorPromise(call, "return", func(co *sqlCursorObject) Value {
+		co.close()
+		return r.createIterResultObject(call.Argument(0), true)
+	})
+}
+
+func (r *Runtime) builtinSQLAsyncCursor_throw(call FunctionCall) Value {
+	return r.cursorPromise(call, "throw", func(co *sqlCursorObject) Value {
+		co.close()
+		panic(call.Argument(0))
+	})
+}
+
+func (r *Runtime) builtinSQLAsyncCursor_batch(call FunctionCall) Value {
+	return r.cursorPromise(call, "batch", func(co *sqlCursorObject) Value {
+		return co.batch(r.cursorBatchSize(call.Argument(0)))
+	})
+}
+
+func (r *Runtime) builtinSQLAsyncCursor_close(call FunctionCall) Value {
+	return r.cursorPromise(call, "close", func(co *sqlCursorObject) Value {
+		co.close()
+		return _undefined
+	})
+}
+
+func (r *Runtime) createSQLCursorProto(val *Object) objectImpl {
+	o := newBaseObjectObj(val, r.getIteratorPrototype(), classObject)
+	o._putProp("next", r.newNativeFunc(r.builtinSQLCursor_next, nil, "next", nil, 0), true, false, true)
+	o._putProp("return", r.newNativeFunc(r.builtinSQLCursor_return, nil, "return", nil, 1), true, false, true)
+	o._putProp("throw", r.newNativeFunc(r.builtinSQLCursor_throw, nil, "throw", nil, 1), true, false, true)
+	o._putProp("batch", r.newNativeFunc(r.builtinSQLCursor_batch, nil, "batch", nil, 1), true, false, true)
+	o._putProp("close", r.newNativeFunc(r.builtinSQLCursor_close, nil, "close", nil, 0), true, false, true)
+	o._putSym(SymAsyncIterator, valueProp(r.newNativeFunc(r.builtinSQLCursor_asyncIterator, nil, "[Symbol.asyncIterator]", nil, 0), true, false, true))
+	o._putSym(SymToStringTag, valueProp(asciiString(classSQLCursor), false, false, true))
+	return o
+}
+
+func (r *Runtime) createSQLAsyncCursorProto(val *Object) objectImpl {
+	o := newBaseObjectObj(val, r.global.ObjectPrototype, classObject)
+	o._putProp("next", r.newNativeFunc(r.builtinSQLAsyncCursor_next, nil, "next", nil, 0), true, false, true)
+	o._putProp("return", r.newNativeFunc(r.builtinSQLAsyncCursor_return, nil, "return", nil, 1), true, false, true)
+	o._putProp("throw", r.newNativeFunc(r.builtinSQLAsyncCursor_throw, nil, "throw", nil, 1), true, false, true)
+	o._putProp("batch", r.newNativeFunc(r.builtinSQLAsyncCursor_batch, nil, "batch", nil, 1), true, false, true)
+	o._putProp("close", r.newNativeFunc(r.builtinSQLAsyncCursor_close, nil, "close", nil, 0), true, false, true)
+	o._putSym(SymAsyncIterator, valueProp(r.newNativeFunc(r.returnThis, nil, "[Symbol.asyncIterator]", nil, 0), true, false, true))
+	o._putSym(SymToStringTag, valueProp(asciiString(classSQLAsyncCursor), false, false, true))
+	return o
+}
@@ -0,0 +1,97 @@
+package goscript
+
+import (
+	"testing"
+)
+
+func TestSQLCursor(t *testing.T) {
+	vm := newWithTestLib(t)
+	_, err := vm.RunString(`
+	var db = new SQLite(":memory:");
+	db.exec("create table t (item_id integer, name text)");
+	for (var i = 1; i <= 10; i++) {
+		db.exec("insert into t values (?, ?)", i, "n" + i);
+	}
+
+	var cursor = db.cursor("select item_id, name from t where item_id > ? order by item_id", 0);
+	assert.sameValue(Object.prototype.toString.call(cursor), "[object SQLCursor]", "toStringTag");
+	assert.sameValue(cursor[Symbol.iterator](), cursor, "iterable");
+	var ids = [];
+	for (var row of cursor) {
+		ids.push(row.itemId);
+	}
+	assert.sameValue(ids.join(), "1,2,3,4,5,6,7,8,9,10", "for-of");
+	assert.sameValue(cursor.next().done, true, "exhausted");
+
+	cursor = db.cursor("select item_id from t order by item_id");
+	assert.sameValue(cursor.batch(4).length, 4, "batch");
+	assert.sameValue(cursor.batch(4).map(function (r) { return r.itemId; }).join(), "5,6,7,8", "next batch");
+	assert.sameValue(cursor.batch(4).length, 2, "last batch");
+	assert.sameValue(cursor.batch(4).length, 0, "empty batch");
+	cursor = db.cursor("select 1");
+	try {
+		cursor.batch(0);
+		throw new Error("no error");
+	} catch (e) {
+		assert.sameValue(e instanceof RangeError, true, "batch size");
+	}
+	cursor.close();
+	try {
+		db.cursor();
+		throw new Error("no error");
+	} catch (e) {
+		assert.sameValue(e.message !== "no error", true, "without arguments");
+	}
+
+	for (var row of db.cursor("select item_id from t")) {
+		break;
+	}
+	try {
+		for (var row of db.cursor("select item_id from t")) {
+			throw new Error("stop");
+		}
+	} catch (e) {
+		assert.sameValue(e.message, "stop", "exception");
+	}
+	cursor = db.cursor("select item_id from t");
+	assert.sameValue(cursor.return(1).value, 1, "return");
+	assert.sameValue(cursor.next().done, true, "returned");
+
+	var tx = db.begin();
+	tx.exec("insert into t values (11, 'n11')");
+	var n = 0;
+	for (var row of tx.cursor("select item_id from t")) {
+		n++;
+	}
+	assert.sameValue(n, 11, "transaction");
+	tx.rollback();
+
+	var result;
+	(async function () {
+		var it = db.cursor("select item_id from t order by item_id")[Symbol.asyncIterator]();
+		assert.sameValue(it[Symbol.asyncIterator](), it, "async iterable");
+		var sum = 0, r;
+		while (!(r = await it.next()).done) {
+			sum += r.value.itemId;
+		}
+		it = db.cursor("select item_id from t order by item_id")[Symbol.asyncIterator]();
+		var first = await it.batch(3);
+		await it.return();
+		try {
+			await db.cursor("select 1")[Symbol.asyncIterator]().throw(new Error("thrown"));
+		} catch (e) {
+			assert.sameValue(e.message, "thrown", "throw");
+		}
+		return sum + ":" + first.length;
+	})().then(function (v) { result = v; }, function (e) { result = e; });
+	`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result := vm.Get("result"); result == nil || result.String() != "55:3" {
+		t.Fatalf("async iterator: %v", result)
+	}
+	if inUse := vm.Get("db").(*Object).self.(*sqliteObject).db.Stats().InUse; inUse != 0 {
+		t.Fatalf("%d connections are still in use", inUse)
+	}
+}
@@ -0,0 +1,335 @@
+package goscript
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// tables、columns、indexes 与 foreignKeys 按数据库方言查询系统视图，返回表结构信息
+
+// schemaQueries are the catalog queries of a dialect. {schema} and {table} stand for the schema and the name of the
+// table, see bind. The queries return, in this order:
+//
+//   - tables: schema, name, type
+//   - columns: name, type, nullable, default, primary key, position
+//   - indexes: name, unique, primary, column, one row per column in index order
+//   - foreignKeys: name, column, referenced schema, referenced table, referenced column, one row per column
+type schemaQueries struct {
+	currentSchema                         string // the SQL expression of the default schema
+	tables, columns, indexes, foreignKeys string
+}
+
+var databaseSchemaQueries = map[string]schemaQueries{
+	"mysql": {
+		currentSchema: "DATABASE()",
+		tables: "SELECT TABLE_SCHEMA, TABLE_NAME, TABLE_TYPE FROM information_schema.TABLES WHERE TABLE_SCHEMA = {schema} " +
+			"ORDER BY TABLE_NAME",
+		columns: "SELECT COLUMN_NAME, COLUMN_TYPE, IS_NULLABLE, COLUMN_DEFAULT, COLUMN_KEY = 'PRI', ORDINAL_POSITION " +
+			"FROM information_schema.COLUMNS WHERE TABLE_SCHEMA = {schema} AND TABLE_NAME = {table} ORDER BY ORDINAL_POSITION",
+		indexes: "SELECT INDEX_NAME, NON_UNIQUE = 0, INDEX_NAME = 'PRIMARY', COLUMN_NAME FROM information_schema.STATISTICS " +
+			"WHERE TABLE_SCHEMA = {schema} AND TABLE_NAME = {table} ORDER BY INDEX_NAME, SEQ_IN_INDEX",
+		foreignKeys: "SELECT CONSTRAINT_NAME, COLUMN_NAME, REFERENCED_TABLE_SCHEMA, REFERENCED_TABLE_NAME, REFERENCED_COLUMN_NAME " +
+			"FROM information_schema.KEY_COLUMN_USAGE WHERE TABLE_SCHEMA = {schema} AND TABLE_NAME = {table} " +
+			"AND REFERENCED_TABLE_NAME IS NOT NULL ORDER BY CONSTRAINT_NAME, ORDINAL_POSITION",
+	},
+	"postgres": {
+		currentSchema: "current_schema()",
+		tables: "SELECT table_schema, table_name, table_type FROM information_schema.tables WHERE table_schema = {schema} " +
+			"ORDER BY table_name",
+		columns: "SELECT c.column_name, c.data_type, c.is_nullable, c.column_default, EXISTS (SELECT 1 " +
+			"FROM information_schema.table_constraints tc JOIN information_schema.key_column_usage k " +
+			"ON k.constraint_schema = tc.constraint_schema AND k.constraint_name = tc.constraint_name " +
+			"WHERE tc.constraint_type = 'PRIMARY KEY' AND tc.table_schema = c.table_schema AND tc.table_name = c.table_name " +
+			"AND k.column_name = c.column_name), c.ordinal_position FROM information_schema.columns c " +
+			"WHERE c.table_schema = {schema} AND c.table_name = {table} ORDER BY c.ordinal_position",
+		indexes: "SELECT i.relname, ix.indisunique, ix.indisprimary, a.attname FROM pg_index ix " +
+			"JOIN pg_class t ON t.oid = ix.indrelid JOIN pg_namespace n ON n.oid = t.relnamespace " +
+			"JOIN pg_class i ON i.oid = ix.indexrelid " +
+			"JOIN LATERAL unnest(ix.indkey) WITH ORDINALITY AS k(attnum, ord) ON true " +
+			"JOIN pg_attribute a ON a.attrelid = t.oid AND a.attnum = k.attnum " +
+			"WHERE n.nspname = {schema} AND t.relname = {table} ORDER BY i.relname, k.ord",
+		foreignKeys: "SELECT c.conname, a.attname, rn.nspname, rt.relname, ra.attname FROM pg_constraint c " +
+			"JOIN pg_class t ON t.oid = c.conrelid JOIN pg_namespace n ON n.oid = t.relnamespace " +
+			"JOIN pg_class rt ON rt.oid = c.confrelid JOIN pg_namespace rn ON rn.oid = rt.relnamespace " +
+			"JOIN LATERAL unnest(c.conkey, c.confkey) WITH ORDINALITY AS k(attnum, refnum, ord) ON true " +
+			"JOIN pg_attribute a ON a.attrelid = c.conrelid AND a.attnum = k.attnum " +
+			"JOIN pg_attribute ra ON ra.attrelid = c.confrelid AND ra.attnum = k.refnum " +
+			"WHERE c.contype = 'f' AND n.nspname = {schema} AND t.relname = {table} ORDER BY c.conname, k.ord",
+	},
+	"sqlserver": {
+		currentSchema: "SCHEMA_NAME()",
+		tables: "SELECT SCHEMA_NAME(schema_id), name, type FROM sys.objects WHERE type IN ('U', 'V') AND is_ms_shipped = 0 " +
+			"AND schema_id = SCHEMA_ID({schema}) ORDER BY name",
+		columns: "SELECT c.name, TYPE_NAME(c.user_type_id), c.is_nullable, OBJECT_DEFINITION(c.default_object_id), " +
+			"CAST(CASE WHEN EXISTS (SELECT 1 FROM sys.indexes i JOIN sys.index_columns ic " +
+			"ON ic.object_id = i.object_id AND ic.index_id = i.index_id WHERE i.is_primary_key = 1 " +
+			"AND i.object_id = c.object_id AND ic.column_id = c.column_id) THEN 1 ELSE 0 END AS bit), c.column_id " +
+			"FROM sys.columns c WHERE c.object_id = OBJECT_ID(QUOTENAME({schema}) + '.' + QUOTENAME({table})) " +
+			"ORDER BY c.column_id",
+		indexes: "SELECT i.name, i.is_unique, i.is_primary_key, c.name FROM sys.indexes i " +
+			"JOIN sys.index_columns ic ON ic.object_id = i.object_id AND ic.index_id = i.index_id " +
+			"JOIN sys.columns c ON c.object_id = ic.object_id AND c.column_id = ic.column_id " +
+			"WHERE i.object_id = OBJECT_ID(QUOTENAME({schema}) + '.' + QUOTENAME({table})) AND ic.is_included_column = 0 " +
+			"ORDER BY i.name, ic.key_ordinal",
+		foreignKeys: "SELECT f.name, pc.name, SCHEMA_NAME(rt.schema_id), rt.name, rc.name FROM sys.foreign_keys f " +
+			"JOIN sys.foreign_key_columns fc ON fc.constraint_object_id = f.object_id " +
+			"JOIN sys.columns pc ON pc.object_id = fc.parent_object_id AND pc.column_id = fc.parent_column_id " +
+			"JOIN sys.tables rt ON rt.object_id = fc.referenced_object_id " +
+			"JOIN sys.columns rc ON rc.object_id = fc.referenced_object_id AND rc.column_id = fc.referenced_column_id " +
+			"WHERE f.parent_object_id = OBJECT_ID(QUOTENAME({schema}) + '.' + QUOTENAME({table})) " +
+			"ORDER BY f.name, fc.constraint_column_id",
+	},
+	// the names are stored in upper case unless they were quoted
+	"oracle": oracleSchemaQueries,
+	"dm":     oracleSchemaQueries,
+	"sqlite": {
+		currentSchema: "'main'",
+		tables: "SELECT schema, name, type FROM pragma_table_list WHERE schema = {schema} AND type IN ('table', 'view') " +
+			"AND name NOT LIKE 'sqlite\\_%' ESCAPE '\\' ORDER BY name",
+		columns: "SELECT name, type, \"notnull\" = 0, dflt_value, pk > 0, cid + 1 FROM pragma_table_info({table}, {schema}) " +
+			"ORDER BY cid",
+		indexes: "SELECT il.name, il.\"unique\", il.origin = 'pk', ii.name FROM pragma_index_list({table}, {schema}) il " +
+			"JOIN pragma_index_info(il.name, {schema}) ii ORDER BY il.name, ii.seqno",
+		// the foreign keys have no names, see builtinDatabase_foreignKeys
+		foreignKeys: "SELECT 'fk_' || {table} || '_' || id, \"from\", {schema}, \"table\", \"to\" " +
+			"FROM pragma_foreign_key_list({table}, {schema}) ORDER BY id, seq",
+	},
+}
+
+var oracleSchemaQueries = schemaQueries{
+	currentSchema: "USER",
+	tables: "SELECT OWNER, TABLE_NAME, 'TABLE' FROM ALL_TABLES WHERE OWNER = UPPER({schema}) " +
+		"UNION ALL SELECT OWNER, VIEW_NAME, 'VIEW' FROM ALL_VIEWS WHERE OWNER = UPPER({schema}) ORDER BY 2",
+	columns: "SELECT c.COLUMN_NAME, c.DATA_TYPE, c.NULLABLE, c.DATA_DEFAULT, CASE WHEN EXISTS (SELECT 1 " +
+		"FROM ALL_CONSTRAINTS k JOIN ALL_CONS_COLUMNS kc ON kc.OWNER = k.OWNER AND kc.CONSTRAINT_NAME = k.CONSTRAINT_NAME " +
+		"WHERE k.CONSTRAINT_TYPE = 'P' AND k.OWNER = c.OWNER AND k.TABLE_NAME = c.TABLE_NAME " +
+		"AND kc.COLUMN_NAME = c.COLUMN_NAME) THEN 1 ELSE 0 END, c.COLUMN_ID FROM ALL_TAB_COLUMNS c " +
+		"WHERE c.OWNER = UPPER({schema}) AND c.TABLE_NAME = UPPER({table}) ORDER BY c.COLUMN_ID",
+	indexes: "SELECT i.INDEX_NAME, CASE WHEN i.UNIQUENESS = 'UNIQUE' THEN 1 ELSE 0 END, CASE WHEN EXISTS (SELECT 1 " +
+		"FROM ALL_CONSTRAINTS k WHERE k.CONSTRAINT_TYPE = 'P' AND k.OWNER = i.TABLE_OWNER AND k.INDEX_NAME = i.INDEX_NAME) " +
+		"THEN 1 ELSE 0 END, ic.COLUMN_NAME FROM ALL_INDEXES i " +
+		"JOIN ALL_IND_COLUMNS ic ON ic.INDEX_OWNER = i.OWNER AND ic.INDEX_NAME = i.INDEX_NAME " +
+		"WHERE i.TABLE_OWNER = UPPER({schema}) AND i.TABLE_NAME = UPPER({table}) ORDER BY i.INDEX_NAME, ic.COLUMN_POSITION",
+	foreignKeys: "SELECT c.CONSTRAINT_NAME, cc.COLUMN_NAME, r.OWNER, r.TABLE_NAME, rc.COLUMN_NAME FROM ALL_CONSTRAINTS c " +
+		"JOIN ALL_CONS_COLUMNS cc ON cc.OWNER = c.OWNER AND cc.CONSTRAINT_NAME = c.CONSTRAINT_NAME " +
+		"JOIN ALL_CONSTRAINTS r ON r.OWNER = c.R_OWNER AND r.CONSTRAINT_NAME = c.R_CONSTRAINT_NAME " +
+		"JOIN ALL_CONS_COLUMNS rc ON rc.OWNER = r.OWNER AND rc.CONSTRAINT_NAME = r.CONSTRAINT_NAME " +
+		"AND rc.POSITION = cc.POSITION " +
+		"WHERE c.CONSTRAINT_TYPE = 'R' AND c.OWNER = UPPER({schema}) AND c.TABLE_NAME = UPPER({table}) " +
+		"ORDER BY c.CONSTRAINT_NAME, cc.POSITION",
+}
+
+// bind replaces {schema} and {table} in query with the placeholders of the dialect, or {schema} with the default
+// schema if schema is empty, and returns the parameters in the order of the placeholders.
+func (q schemaQueries) bind(d sqlDialect, query, schema, table string) (string, []any) {
+	var b strings.Builder
+	var params []any
+	for {
+		i := strings.IndexByte(query, '{')
+		if i < 0 {
+			b.WriteString(query)
+			return b.String(), params
+		}
+		b.WriteString(query[:i])
+		query = query[i:]
+		switch {
+		case strings.HasPrefix(query, "{schema}"):
+			query = query[len("{schema}"):]
+			if schema == "" {
+				b.WriteString(q.currentSchema)
+				continue
+			}
+			params = append(params, schema)
+		case strings.HasPrefix(query, "{table}"):
+			query = query[len("{table}"):]
+			params = append(params, table)
+		default:
+			b.WriteByte('{')
+			query = query[1:]
+			continue
+		}
+		b.WriteString(d.placeholder(len(params)))
+	}
+}
+
+func schemaString(v any) string {
+	switch v := v.(type) {
+	case nil:
+		return ""
+	case string:
+		return v
+	case []byte:
+		return string(v)
+	}
+	return fmt.Sprint(v)
+}
+
+func schemaBool(v any) bool {
+	switch v := v.(type) {
+	case bool:
+		return v
+	case int64:
+		return v != 0
+	case float64:
+		return v != 0
+	}
+	switch strings.ToUpper(strings.TrimSpace(schemaString(v))) {
+	case "1", "Y", "YES", "TRUE", "T":
+		return true
+	}
+	return false
+}
+
+func schemaInt(v any) int64 {
+	switch v := v.(type) {
+	case int64:
+		return v
+	case float64:
+		return int64(v)
+	}
+	n, _ := strconv.ParseInt(strings.TrimSpace(schemaString(v)), 10, 64)
+	return n
+}
+
+// splitTable splits the name of a table qualified by its schema.
+func splitTable(table string) (string, string) {
+	if i := strings.LastIndexByte(table, '.'); i >= 0 {
+		return table[:i], table[i+1:]
+	}
+	return "", table
+}
+
+// schemaQuery runs a catalog query of the dialect of do.
+func (r *Runtime) schemaQuery(do *databaseObject, method, schema, table string, query func(q schemaQueries) string) [][]any {
+	q, ok := databaseSchemaQueries[do.driver]
+	if !ok {
+		panic(r.NewTypeError("Database.prototype.%s is not supported by the %s driver", method, do.driver))
+	}
+	s, params := q.bind(sqlDialectOf(do.driver), query(q), schema, table)
+	rows, err := do.db.Query(s, params...)
+	if err != nil {
+		panic(r.NewGoError(err))
+	}
+	_, records, err := fetchRows(rows, false)
+	if err != nil {
+		panic(r.NewGoError(err))
+	}
+	return records
+}
+
+// builtinDatabase_tables is tables(schema), which returns [{schema, name, type}] where type is "table" or "view".
+// The schema is the default one of the connection if omitted.
+func (r *Runtime) builtinDatabase_tables(call FunctionCall) Value {
+	do := r.toDatabase("tables", call)
+	var schema string
+	if v := call.Argument(0); v != _undefined && v != _null {
+		schema = v.String()
+	}
+	records := r.schemaQuery(do, "tables", schema, "", func(q schemaQueries) string { return q.tables })
+	list := make([]Value, len(records))
+	for i, record := range records {
+		o := r.NewObject()
+		_ = o.Set("schema", schemaString(record[0]))
+		_ = o.Set("name", schemaString(record[1]))
+		typ := "table"
+		if t := strings.ToUpper(strings.TrimSpace(schemaString(record[2]))); strings.Contains(t, "VIEW") || t == "V" {
+			typ = "view"
+		}
+		_ = o.Set("type", typ)
+		list[i] = o
+	}
+	return r.newArrayValues(list)
+}
+
+// builtinDatabase_columns is columns(table), where table may be qualified by its schema, which returns [{name, type,
+// nullable, defaultValue, primaryKey, position}] in the order of the columns. defaultValue is the SQL expression of
+// the default value or null.
+func (r *Runtime) builtinDatabase_columns(call FunctionCall) Value {
+	do := r.toDatabase("columns", call)
+	schema, table := splitTable(call.Argument(0).String())
+	records := r.schemaQuery(do, "columns", schema, table, func(q schemaQueries) string { return q.columns })
+	list := make([]Value, len(records))
+	for i, record := range records {
+		o := r.NewObject()
+		_ = o.Set("name", schemaString(record[0]))
+		_ = o.Set("type", schemaString(record[1]))
+		_ = o.Set("nullable", schemaBool(record[2]))
+		if record[3] == nil {
+			_ = o.Set("defaultValue", _null)
+		} else {
+			_ = o.Set("defaultValue", strings.TrimSpace(schemaString(record[3])))
+		}
+		_ = o.Set("primaryKey", schemaBool(record[4]))
+		_ = o.Set("position", schemaInt(record[5]))
+		list[i] = o
+	}
+	return r.newArrayValues(list)
+}
+
+// builtinDatabase_indexes is indexes(table), which returns [{name, columns, unique, primary}].
+func (r *Runtime) builtinDatabase_indexes(call FunctionCall) Value {
+	do := r.toDatabase("indexes", call)
+	schema, table := splitTable(call.Argument(0).String())
+	records := r.schemaQuery(do, "indexes", schema, table, func(q schemaQueries) string { return q.indexes })
+	var list []Value
+	var o *Object
+	var name string
+	var columns []Value
+	for _, record := range records {
+		if n := schemaString(record[0]); o == nil || n != name {
+			if o != nil {
+				_ = o.Set("columns", r.newArrayValues(columns))
+			}
+			name, columns = n, nil
+			o = r.NewObject()
+			_ = o.Set("name", name)
+			_ = o.Set("unique", schemaBool(record[1]))
+			_ = o.Set("primary", schemaBool(record[2]))
+			list = append(list, o)
+		}
+		columns = append(columns, newStringValue(schemaString(record[3])))
+	}
+	if o != nil {
+		_ = o.Set("columns", r.newArrayValues(columns))
+	}
+	return r.newArrayValues(list)
+}
+
+// builtinDatabase_foreignKeys is foreignKeys(table), which returns [{name, columns, refSchema, refTable,
+// refColumns}]. SQLite does not keep the names of the foreign keys, the name is then fk_<table>_<id>, where id is the
+// number of the foreign key in the table, which may change when the table is altered.
+func (r *Runtime) builtinDatabase_foreignKeys(call FunctionCall) Value {
+	do := r.toDatabase("foreignKeys", call)
+	schema, table := splitTable(call.Argument(0).String())
+	records := r.schemaQuery(do, "foreignKeys", schema, table, func(q schemaQueries) string { return q.foreignKeys })
+	var list []Value
+	var o *Object
+	var name string
+	var columns, refColumns []Value
+	flush := func() {
+		if o != nil {
+			_ = o.Set("columns", r.newArrayValues(columns))
+			_ = o.Set("refColumns", r.newArrayValues(refColumns))
+		}
+	}
+	for _, record := range records {
+		if n := schemaString(record[0]); o == nil || n != name {
+			flush()
+			name, columns, refColumns = n, nil, nil
+			o = r.NewObject()
+			_ = o.Set("name", name)
+			_ = o.Set("refSchema", schemaString(record[2]))
+			_ = o.Set("refTable", schemaString(record[3]))
+			list = append(list, o)
+		}
+		columns = append(columns, newStringValue(schemaString(record[1])))
+		if record[4] == nil {
+			refColumns = append(refColumns, _null)
+		} else {
+			refColumns = append(refColumns, newStringValue(schemaString(record[4])))
+		}
+	}
+	flush()
+	return r.newArrayValues(list)
+}
@@ -0,0 +1,83 @@
+package goscript
+
+import (
+	"testing"
+)
+
+func TestDatabaseSchema(t *testing.T) {
+	registerTestSQLite()
+	vm := newWithTestLib(t)
+	_, err := vm.RunString(`
+	var db = new SQLite(":memory:");
+	db.exec("create table users (id integer primary key, email text not null unique, name text default 'anon')");
+	db.exec("create table orders (id integer, user_id integer references users (id), email text, total real, " +
+		"primary key (id, user_id), foreign key (user_id, email) references users (id, email))");
+	db.exec("create index orders_total on orders (total, id)");
+	db.exec("create view big_orders as select * from orders where total > 100");
+
+	var tables = db.tables();
+	assert.sameValue(tables.map(function (t) { return t.name + ":" + t.type; }).join(), "big_orders:view,orders:table,users:table", "tables");
+	assert.sameValue(tables[0].schema, "main", "schema");
+	assert.sameValue(db.tables("main").length, 3, "tables of a schema");
+
+	var columns = db.columns("users");
+	assert.sameValue(columns.map(function (c) { return c.name; }).join(), "id,email,name", "columns");
+	assert.sameValue(columns[0].type, "INTEGER", "type");
+	assert.sameValue(columns[0].primaryKey, true, "primary key");
+	assert.sameValue(columns[1].nullable, false, "not null");
+	assert.sameValue(columns[2].nullable, true, "nullable");
+	assert.sameValue(columns[2].defaultValue, "'anon'", "default");
+	assert.sameValue(columns[1].defaultValue, null, "no default");
+	assert.sameValue(columns[2].position, 3, "position");
+	assert.sameValue(db.columns("main.users").length, 3, "qualified table");
+	assert.sameValue(db.columns("missing").length, 0, "missing table");
+
+	var indexes = db.indexes("orders");
+	var total = indexes.filter(function (i) { return i.name === "orders_total"; })[0];
+	assert.sameValue(total.columns.join(), "total,id", "index columns");
+	assert.sameValue(total.unique, false, "not unique");
+	var pk = indexes.filter(function (i) { return i.primary; })[0];
+	assert.sameValue(pk.columns.join(), "id,user_id", "primary index");
+	assert.sameValue(pk.unique, true, "unique primary index");
+	assert.sameValue(db.indexes("users").filter(function (i) { return i.unique && !i.primary; })[0].columns.join(), "email", "unique index");
+
+	var fks = db.foreignKeys("orders");
+	assert.sameValue(fks.length, 2, "foreign keys");
+	var composite = fks.filter(function (f) { return f.columns.length === 2; })[0];
+	assert.sameValue(composite.columns.join(), "user_id,email", "columns");
+	assert.sameValue(composite.refTable, "users", "refTable");
+	assert.sameValue(composite.refColumns.join(), "id,email", "refColumns");
+	assert.sameValue(/^fk_orders_[0-9]+$/.test(composite.name), true, "synthesized name: " + composite.name);
+	db.close();
+
+	try {
+		new Database("test-sqlite", ":memory:").tables();
+		throw new Error("no error");
+	} catch (e) {
+		assert.sameValue(e instanceof TypeError, true, "unknown dialect");
+	}
+	`)
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestSchemaQueriesBind(t *testing.T) {
+	q := databaseSchemaQueries["oracle"]
+	s, params := q.bind(sqlDialectOf("oracle"), "OWNER = UPPER({schema}) AND TABLE_NAME = UPPER({table}) {x}", "", "t")
+	if s != "OWNER = UPPER(USER) AND TABLE_NAME = UPPER(:1) {x}" || len(params) != 1 || params[0] != "t" {
+		t.Errorf("default schema: %s %v", s, params)
+	}
+	q = databaseSchemaQueries["sqlserver"]
+	s, params = q.bind(sqlDialectOf("sqlserver"), q.columns, "sales", "orders")
+	if len(params) != 2 || params[0] != "sales" || params[1] != "orders" {
+		t.Errorf("params: %v", params)
+	}
+	for driver, q := range databaseSchemaQueries {
+		for _, query := range []string{q.tables, q.columns, q.indexes, q.foreignKeys} {
+			if s, _ := q.bind(sqlDialectOf(driver), query, "s", "t"); s == "" {
+				t.Errorf("%s: empty query", driver)
+			}
+		}
+	}
+}
@@ -0,0 +1,68 @@
+package goscript
+
+import (
+	"path/filepath"
+	"sync"
+	"testing"
+
+	"modernc.org/sqlite"
+)
+
+var registerTestDriver sync.Once
+
+// registerTestSQLite registers SQLite as "test-sqlite", a driver of no known dialect.
+func registerTestSQLite() {
+	registerTestDriver.Do(func() {
+		RegisterDatabaseDriver("test-sqlite", &sqlite.Driver{})
+	})
+}
+
+func TestDatabase(t *testing.T) {
+	registerTestSQLite()
+	func() {
+		defer func() {
+			if recover() == nil {
+				t.Error("registering a driver twice did not panic")
+			}
+		}()
+		RegisterDatabaseDriver("test-sqlite", &sqlite.Driver{})
+	}()
+
+	vm := newWithTestLib(t)
+	_ = vm.Set("path", filepath.Join(t.TempDir(), "test.db"))
+	_, err := vm.RunString(`
+	var db = new Database("sqlite", path, {maxOpen: 4, maxIdle: 2, connMaxLifetime: "1m", connMaxIdleTime: 30000});
+	assert.sameValue(Object.prototype.toString.call(db), "[object Database]", "toStringTag");
+	db.ping();
+	db.exec("create table t (v integer)");
+	db.exec("insert into t values (?)", 1);
+	db.close();
+
+	db = new Database("test-sqlite", path);
+	assert.sameValue(db.query("select v from t")[0].v, 1, "registered driver");
+	db.close();
+
+	var lite = new SQLite(path);
+	assert.sameValue(lite instanceof Database, true, "connector inherits from Database");
+	assert.sameValue(lite instanceof SQLite, true, "connector instance");
+	assert.sameValue(Object.prototype.toString.call(lite), "[object SQLite]", "connector toStringTag");
+	assert.sameValue(lite.query("select v from t").length, 1, "connector query");
+	lite.close();
+
+	try {
+		new Database("nope", "");
+		throw new Error("no error");
+	} catch (e) {
+		assert.sameValue(e instanceof TypeError, true, "unknown driver");
+	}
+	try {
+		Database.prototype.query.call({}, "select 1");
+		throw new Error("no error");
+	} catch (e) {
+		assert.sameValue(e instanceof TypeError, true, "incompatible receiver");
+	}
+	`)
+	if err != nil {
+		t.Fatal(err)
+	}
+}
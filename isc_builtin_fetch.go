@@ -0,0 +1,873 @@
+package goscript
+
+import (
+	c0 "context"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// HTTP.fetch 以及 WHATWG 的 Headers、Request、Response、AbortController 和 AbortSignal。
+// fetch 的 I/O 在新的 goroutine 上进行，通过 Runtime 的 Scheduler（eventloop）在 Runtime 的 goroutine 上完成 Promise。
+// 响应的 body 在完成之前就全部读取，因此 text()、json() 和 arrayBuffer() 不会再阻塞。
+
+type fetchHeadersObject struct {
+	baseObject
+	header http.Header
+}
+
+// fetchBody is the body of a Request or a Response, nil if there is none.
+type fetchBody struct {
+	data []byte
+	used bool
+}
+
+type fetchRequestObject struct {
+	baseObject
+	fetchBody
+	method, url, redirect string
+	headers               *fetchHeadersObject
+	signal                *abortSignalObject // nil if there is none
+}
+
+type fetchResponseObject struct {
+	baseObject
+	fetchBody
+	status     int
+	statusText string
+	url        string
+	redirected bool
+	typ        string
+	headers    *fetchHeadersObject
+}
+
+type abortSignalObject struct {
+	baseObject
+	aborted   bool
+	reason    Value
+	cancels   map[int]func() // the cancel functions of the pending fetches, see addCancel
+	lastID    int
+	listeners []Value
+}
+
+type abortControllerObject struct {
+	baseObject
+	signal *abortSignalObject
+}
+
+// Headers
+
+func isHeaderToken(s string) bool {
+	if s == "" {
+		return false
+	}
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if c <= ' ' || c >= 0x7f || strings.IndexByte(`"(),/:;<=>?@[\]{}`, c) >= 0 {
+			return false
+		}
+	}
+	return true
+}
+
+func (r *Runtime) headerName(v Value) string {
+	name := v.String()
+	if !isHeaderToken(name) {
+		panic(r.NewTypeError("Invalid header name: %s", name))
+	}
+	return http.CanonicalHeaderKey(name)
+}
+
+func (r *Runtime) headerValue(v Value) string {
+	value := strings.Trim(v.String(), " \t\r\n")
+	if strings.ContainsAny(value, "\r\n\x00") {
+		panic(r.NewTypeError("Invalid header value: %s", value))
+	}
+	return value
+}
+
+func (r *Runtime) newFetchHeaders(header http.Header) *fetchHeadersObject {
+	o := &Object{runtime: r}
+	h := &fetchHeadersObject{
+		baseObject: baseObject{class: classHeaders, val: o, prototype: r.global.HeadersPrototype, extensible: true, values: nil},
+		header:     header,
+	}
+	o.self = h
+	h.init()
+	return h
+}
+
+// fillHeaders adds the headers of init, which is a Headers object, an iterable of [name, value] pairs or a record.
+func (r *Runtime) fillHeaders(h http.Header, init Value) {
+	if IsUndefined(init) || IsNull(init) {
+		return
+	}
+	o := r.toObject(init)
+	if src, ok := o.self.(*fetchHeadersObject); ok {
+		for k, v := range src.header {
+			h[k] = append(h[k], v...)
+		}
+		return
+	}
+	if m := toMethod(o.self.getSym(SymIterator, nil)); m != nil {
+		r.getIterator(o, m).iterate(func(item Value) {
+			pair := r.toObject(item)
+			if toLength(pair.Get("length")) != 2 {
+				panic(r.NewTypeError("Header pairs must contain exactly two items"))
+			}
+			h.Add(r.headerName(pair.Get("0")), r.headerValue(pair.Get("1")))
+		})
+		return
+	}
+	for _, k := range o.Keys() {
+		h.Add(r.headerName(newStringValue(k)), r.headerValue(o.Get(k)))
+	}
+}
+
+func (r *Runtime) builtin_newHeaders(args []Value, newTarget *Object) *Object {
+	if newTarget == nil {
+		panic(r.needNew("Headers"))
+	}
+	h := r.newFetchHeaders(http.Header{})
+	h.prototype = r.getPrototypeFromCtor(newTarget, r.global.Headers, r.global.HeadersPrototype)
+	if len(args) > 0 {
+		r.fillHeaders(h.header, args[0])
+	}
+	return h.val
+}
+
+func (r *Runtime) toFetchHeaders(v Value, method string) *fetchHeadersObject {
+	thisObj := r.toObject(v)
+	h, ok := thisObj.self.(*fetchHeadersObject)
+	if !ok {
+		panic(r.NewTypeError("Method Headers.prototype.%s called on incompatible receiver %s", method, r.objectproto_toString(FunctionCall{This: thisObj})))
+	}
+	return h
+}
+
+func (r *Runtime) builtinHeaders_append(call FunctionCall) Value {
+	h := r.toFetchHeaders(call.This, "append")
+	h.header.Add(r.headerName(call.Argument(0)), r.headerValue(call.Argument(1)))
+	return _undefined
+}
+
+func (r *Runtime) builtinHeaders_delete(call FunctionCall) Value {
+	h := r.toFetchHeaders(call.This, "delete")
+	h.header.Del(r.headerName(call.Argument(0)))
+	return _undefined
+}
+
+func (r *Runtime) builtinHeaders_get(call FunctionCall) Value {
+	h := r.toFetchHeaders(call.This, "get")
+	values := h.header.Values(r.headerName(call.Argument(0)))
+	if len(values) == 0 {
+		return _null
+	}
+	return newStringValue(strings.Join(values, ", "))
+}
+
+func (r *Runtime) builtinHeaders_getSetCookie(call FunctionCall) Value {
+	h := r.toFetchHeaders(call.This, "getSetCookie")
+	values := h.header.Values("Set-Cookie")
+	list := make([]Value, len(values))
+	for i, v := range values {
+		list[i] = newStringValue(v)
+	}
+	return r.newArrayValues(list)
+}
+
+func (r *Runtime) builtinHeaders_has(call FunctionCall) Value {
+	h := r.toFetchHeaders(call.This, "has")
+	return r.toBoolean(len(h.header.Values(r.headerName(call.Argument(0)))) > 0)
+}
+
+func (r *Runtime) builtinHeaders_set(call FunctionCall) Value {
+	h := r.toFetchHeaders(call.This, "set")
+	h.header.Set(r.headerName(call.Argument(0)), r.headerValue(call.Argument(1)))
+	return _undefined
+}
+
+// entries returns the headers sorted by their lower case names with the values combined, except for Set-Cookie.
+func (h *fetchHeadersObject) entries() (names, values []string) {
+	keys := make([]string, 0, len(h.header))
+	for k := range h.header {
+		keys = append(keys, k)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		return strings.ToLower(keys[i]) < strings.ToLower(keys[j])
+	})
+	for _, k := range keys {
+		name := strings.ToLower(k)
+		if k == "Set-Cookie" {
+			for _, v := range h.header[k] {
+				names, values = append(names, name), append(values, v)
+			}
+		} else {
+			names, values = append(names, name), append(values, strings.Join(h.header[k], ", "))
+		}
+	}
+	return
+}
+
+func (r *Runtime) builtinHeaders_forEach(call FunctionCall) Value {
+	h := r.toFetchHeaders(call.This, "forEach")
+	fn := r.toCallable(call.Argument(0))
+	names, values := h.entries()
+	for i, name := range names {
+		fn(FunctionCall{This: call.Argument(1), Arguments: []Value{newStringValue(values[i]), newStringValue(name), h.val}})
+	}
+	return _undefined
+}
+
+func (r *Runtime) headersIterator(call FunctionCall, method string, kind iterationKind) Value {
+	h := r.toFetchHeaders(call.This, method)
+	names, values := h.entries()
+	list := make([]Value, len(names))
+	for i, name := range names {
+		switch kind {
+		case iterationKindKey:
+			list[i] = newStringValue(name)
+		case iterationKindValue:
+			list[i] = newStringValue(values[i])
+		default:
+			list[i] = r.newArrayValues([]Value{newStringValue(name), newStringValue(values[i])})
+		}
+	}
+	return r.createArrayIterator(r.newArrayValues(list), iterationKindValue)
+}
+
+func (r *Runtime) builtinHeaders_entries(call FunctionCall) Value {
+	return r.headersIterator(call, "entries", iterationKindKeyValue)
+}
+
+func (r *Runtime) builtinHeaders_keys(call FunctionCall) Value {
+	return r.headersIterator(call, "keys", iterationKindKey)
+}
+
+func (r *Runtime) builtinHeaders_values(call FunctionCall) Value {
+	return r.headersIterator(call, "values", iterationKindValue)
+}
+
+// Body
+
+// fetchBodyInit converts a body to bytes and its default content type: a string is text, an ArrayBuffer, a typed
+// array or a DataView are bytes, anything else is converted to a string.
+func (r *Runtime) fetchBodyInit(v Value) ([]byte, string) {
+	if b, ok := r.httpBytes(v); ok {
+		if _, isString := v.(valueString); !isString {
+			return append([]byte(nil), b...), ""
+		}
+	}
+	return []byte(v.String()), "text/plain;charset=UTF-8"
+}
+
+func (r *Runtime) toFetchBody(v Value, method string) *fetchBody {
+	thisObj := r.toObject(v)
+	switch o := thisObj.self.(type) {
+	case *fetchRequestObject:
+		return &o.fetchBody
+	case *fetchResponseObject:
+		return &o.fetchBody
+	}
+	panic(r.NewTypeError("Method %s called on incompatible receiver %s", method, r.objectproto_toString(FunctionCall{This: thisObj})))
+}
+
+// fetchBodyPromise consumes the body and returns a promise of its conversion.
+func (r *Runtime) fetchBodyPromise(call FunctionCall, method string, convert func([]byte) Value) Value {
+	b := r.toFetchBody(call.This, method)
+	p, resolve, reject := r.NewPromise()
+	if b.used {
+		reject(r.NewTypeError("Body has already been consumed"))
+	} else {
+		b.used = true
+		if err := r.try(func() { resolve(convert(b.data)) }); err != nil {
+			reject(err.(*Exception).Value())
+		}
+	}
+	return r.ToValue(p)
+}
+
+func (r *Runtime) builtinFetchBody_text(call FunctionCall) Value {
+	return r.fetchBodyPromise(call, "text", func(b []byte) Value {
+		return newStringValue(string(b))
+	})
+}
+
+func (r *Runtime) builtinFetchBody_json(call FunctionCall) Value {
+	return r.fetchBodyPromise(call, "json", func(b []byte) Value {
+		return r.builtinJSON_parse(FunctionCall{Arguments: []Value{newStringValue(string(b))}})
+	})
+}
+
+func (r *Runtime) builtinFetchBody_arrayBuffer(call FunctionCall) Value {
+	return r.fetchBodyPromise(call, "arrayBuffer", func(b []byte) Value {
+		return r.NewArrayBuffer(append([]byte(nil), b...)).toValue(r)
+	})
+}
+
+func (r *Runtime) builtinFetchBody_getBodyUsed(call FunctionCall) Value {
+	return r.toBoolean(r.toFetchBody(call.This, "bodyUsed").used)
+}
+
+// Request
+
+func (r *Runtime) newFetchRequest(proto *Object) *fetchRequestObject {
+	o := &Object{runtime: r}
+	req := &fetchRequestObject{
+		baseObject: baseObject{class: classRequest, val: o, prototype: proto, extensible: true, values: nil},
+		method:     http.MethodGet,
+		redirect:   "follow",
+	}
+	o.self = req
+	req.init()
+	return req
+}
+
+// publish sets the properties of the request once it is complete.
+func (req *fetchRequestObject) publish() {
+	req._putProp("method", newStringValue(req.method), false, true, true)
+	req._putProp("url", newStringValue(req.url), false, true, true)
+	req._putProp("headers", req.headers.val, false, true, true)
+	req._putProp("redirect", newStringValue(req.redirect), false, true, true)
+	if req.signal == nil {
+		req.signal = req.val.runtime.newAbortSignal()
+	}
+	req._putProp("signal", req.signal.val, false, true, true)
+}
+
+func (r *Runtime) builtin_newRequest(args []Value, newTarget *Object) *Object {
+	if newTarget == nil {
+		panic(r.needNew("Request"))
+	}
+	req := r.newFetchRequest(r.getPrototypeFromCtor(newTarget, r.global.Request, r.global.RequestPrototype))
+	input, init := Value(_undefined), Value(_undefined)
+	if len(args) > 0 {
+		input = args[0]
+	}
+	if len(args) > 1 {
+		init = args[1]
+	}
+	header := http.Header{}
+	if src, ok := input.(*Object); ok && src.self != nil {
+		if src, ok := src.self.(*fetchRequestObject); ok {
+			req.method, req.url, req.redirect, req.signal = src.method, src.url, src.redirect, src.signal
+			req.data = src.data
+			header = src.headers.header.Clone()
+		}
+	}
+	if req.url == "" {
+		u, err := url.Parse(input.String())
+		if err != nil || !u.IsAbs() {
+			panic(r.NewTypeError("Invalid URL: %s", input.String()))
+		}
+		req.url = u.String()
+	}
+	if !IsUndefined(init) && !IsNull(init) {
+		o := r.toObject(init)
+		if v := httpOption(o, "method"); v != nil {
+			req.method = strings.ToUpper(v.String())
+			if !isHeaderToken(req.method) {
+				panic(r.NewTypeError("Invalid method: %s", v.String()))
+			}
+		}
+		if v := httpOption(o, "headers"); v != nil {
+			header = http.Header{}
+			r.fillHeaders(header, v)
+		}
+		if v := httpOption(o, "redirect"); v != nil {
+			switch req.redirect = v.String(); req.redirect {
+			case "follow", "manual", "error":
+			default:
+				panic(r.NewTypeError("Invalid redirect mode: %s", req.redirect))
+			}
+		}
+		if v := o.Get("signal"); v != nil && !IsUndefined(v) {
+			req.signal = nil
+			if !IsNull(v) {
+				s, ok := r.toObject(v).self.(*abortSignalObject)
+				if !ok {
+					panic(r.NewTypeError("signal is not an AbortSignal"))
+				}
+				req.signal = s
+			}
+		}
+		if v := httpOption(o, "body"); v != nil {
+			var contentType string
+			req.data, contentType = r.fetchBodyInit(v)
+			if contentType != "" && header.Get("Content-Type") == "" {
+				header.Set("Content-Type", contentType)
+			}
+		}
+	}
+	if req.data != nil && (req.method == http.MethodGet || req.method == http.MethodHead) {
+		panic(r.NewTypeError("Request with GET/HEAD method cannot have body"))
+	}
+	req.headers = r.newFetchHeaders(header)
+	req.publish()
+	return req.val
+}
+
+func (r *Runtime) builtinRequest_clone(call FunctionCall) Value {
+	thisObj := r.toObject(call.This)
+	src, ok := thisObj.self.(*fetchRequestObject)
+	if !ok {
+		panic(r.NewTypeError("Method Request.prototype.clone called on incompatible receiver %s", r.objectproto_toString(FunctionCall{This: thisObj})))
+	}
+	if src.used {
+		panic(r.NewTypeError("Request body is already used"))
+	}
+	req := r.newFetchRequest(src.prototype)
+	req.method, req.url, req.redirect, req.signal, req.data = src.method, src.url, src.redirect, src.signal, src.data
+	req.headers = r.newFetchHeaders(src.headers.header.Clone())
+	req.publish()
+	return req.val
+}
+
+// Response
+
+func (r *Runtime) newFetchResponse(proto *Object, status int, statusText string, header http.Header, data []byte) *fetchResponseObject {
+	o := &Object{runtime: r}
+	resp := &fetchResponseObject{
+		baseObject: baseObject{class: classResponse, val: o, prototype: proto, extensible: true, values: nil},
+		fetchBody:  fetchBody{data: data},
+		status:     status,
+		statusText: statusText,
+		typ:        "default",
+		headers:    r.newFetchHeaders(header),
+	}
+	o.self = resp
+	resp.init()
+	return resp
+}
+
+// publish sets the properties of the response once it is complete.
+func (resp *fetchResponseObject) publish() {
+	r := resp.val.runtime
+	resp._putProp("status", intToValue(int64(resp.status)), false, true, true)
+	resp._putProp("statusText", newStringValue(resp.statusText), false, true, true)
+	resp._putProp("ok", r.toBoolean(resp.status >= 200 && resp.status < 300), false, true, true)
+	resp._putProp("url", newStringValue(resp.url), false, true, true)
+	resp._putProp("redirected", r.toBoolean(resp.redirected), false, true, true)
+	resp._putProp("type", newStringValue(resp.typ), false, true, true)
+	resp._putProp("headers", resp.headers.val, false, true, true)
+}
+
+// responseInit converts the init argument of the Response constructor: {status, statusText, headers}.
+func (r *Runtime) responseInit(init Value) (int, string, http.Header) {
+	status, statusText, header := 200, "", http.Header{}
+	if IsUndefined(init) || IsNull(init) {
+		return status, statusText, header
+	}
+	o := r.toObject(init)
+	if v := httpOption(o, "status"); v != nil {
+		status = int(v.ToInteger())
+		if status < 200 || status > 599 {
+			panic(r.newError(r.global.RangeError, "Invalid status: %d", status))
+		}
+	}
+	if v := httpOption(o, "statusText"); v != nil {
+		statusText = v.String()
+	}
+	if v := httpOption(o, "headers"); v != nil {
+		r.fillHeaders(header, v)
+	}
+	return status, statusText, header
+}
+
+func (r *Runtime) builtin_newResponse(args []Value, newTarget *Object) *Object {
+	if newTarget == nil {
+		panic(r.needNew("Response"))
+	}
+	body, init := Value(_undefined), Value(_undefined)
+	if len(args) > 0 {
+		body = args[0]
+	}
+	if len(args) > 1 {
+		init = args[1]
+	}
+	status, statusText, header := r.responseInit(init)
+	var data []byte
+	if !IsUndefined(body) && !IsNull(body) {
+		var contentType string
+		data, contentType = r.fetchBodyInit(body)
+		if contentType != "" && header.Get("Content-Type") == "" {
+			header.Set("Content-Type", contentType)
+		}
+	}
+	resp := r.newFetchResponse(r.getPrototypeFromCtor(newTarget, r.global.Response, r.global.ResponsePrototype), status, statusText, header, data)
+	resp.publish()
+	return resp.val
+}
+
+// builtinResponse_json is Response.json(data, init).
+func (r *Runtime) builtinResponse_json(call FunctionCall) Value {
+	s := r.builtinJSON_stringify(FunctionCall{Arguments: []Value{call.Argument(0)}})
+	if IsUndefined(s) {
+		panic(r.NewTypeError("The data is not JSON serializable"))
+	}
+	status, statusText, header := r.responseInit(call.Argument(1))
+	if header.Get("Content-Type") == "" {
+		header.Set("Content-Type", "application/json")
+	}
+	resp := r.newFetchResponse(r.global.ResponsePrototype, status, statusText, header, []byte(s.String()))
+	resp.publish()
+	return resp.val
+}
+
+// builtinResponse_error is Response.error().
+func (r *Runtime) builtinResponse_error(FunctionCall) Value {
+	resp := r.newFetchResponse(r.global.ResponsePrototype, 0, "", http.Header{}, nil)
+	resp.typ = "error"
+	resp.publish()
+	return resp.val
+}
+
+func (r *Runtime) builtinResponse_clone(call FunctionCall) Value {
+	thisObj := r.toObject(call.This)
+	src, ok := thisObj.self.(*fetchResponseObject)
+	if !ok {
+		panic(r.NewTypeError("Method Response.prototype.clone called on incompatible receiver %s", r.objectproto_toString(FunctionCall{This: thisObj})))
+	}
+	if src.used {
+		panic(r.NewTypeError("Response body is already used"))
+	}
+	resp := r.newFetchResponse(src.prototype, src.status, src.statusText, src.headers.header.Clone(), src.data)
+	resp.url, resp.redirected, resp.typ = src.url, src.redirected, src.typ
+	resp.publish()
+	return resp.val
+}
+
+// AbortController and AbortSignal
+
+func (r *Runtime) newAbortSignal() *abortSignalObject {
+	o := &Object{runtime: r}
+	s := &abortSignalObject{
+		baseObject: baseObject{class: classAbortSignal, val: o, prototype: r.global.AbortSignalPrototype, extensible: true, values: nil},
+		reason:     _undefined,
+	}
+	o.self = s
+	s.init()
+	return s
+}
+
+func (r *Runtime) newAbortError() Value {
+	e := r.newError(r.global.Error, "This operation was aborted").(*Object)
+	e.self._putProp("name", asciiString("AbortError"), true, false, true)
+	return e
+}
+
+// abort aborts the signal: it cancels the pending fetches and calls the onabort handler and the listeners.
+func (s *abortSignalObject) abort(reason Value) {
+	if s.aborted {
+		return
+	}
+	r := s.val.runtime
+	if reason == nil || IsUndefined(reason) {
+		reason = r.newAbortError()
+	}
+	s.aborted, s.reason = true, reason
+	for _, cancel := range s.cancels {
+		cancel()
+	}
+	s.cancels = nil
+	event := r.NewObject()
+	_ = event.Set("type", "abort")
+	_ = event.Set("target", s.val)
+	if fn, ok := AssertFunction(s.val.Get("onabort")); ok {
+		_, _ = fn(s.val, event)
+	}
+	for _, listener := range s.listeners {
+		r.toCallable(listener)(FunctionCall{This: s.val, Arguments: []Value{event}})
+	}
+}
+
+// addCancel adds the cancel function of a pending fetch, which is called if the signal is aborted. The returned
+// function removes it once the fetch is finished, so that a long-lived signal does not retain the finished fetches.
+func (s *abortSignalObject) addCancel(cancel func()) func() {
+	if s.cancels == nil {
+		s.cancels = make(map[int]func())
+	}
+	s.lastID++
+	id := s.lastID
+	s.cancels[id] = cancel
+	return func() {
+		delete(s.cancels, id)
+	}
+}
+
+func (r *Runtime) toAbortSignal(v Value, method string) *abortSignalObject {
+	thisObj := r.toObject(v)
+	s, ok := thisObj.self.(*abortSignalObject)
+	if !ok {
+		panic(r.NewTypeError("Method AbortSignal.prototype.%s called on incompatible receiver %s", method, r.objectproto_toString(FunctionCall{This: thisObj})))
+	}
+	return s
+}
+
+func (r *Runtime) builtinAbortSignal_getAborted(call FunctionCall) Value {
+	return r.toBoolean(r.toAbortSignal(call.This, "aborted").aborted)
+}
+
+func (r *Runtime) builtinAbortSignal_getReason(call FunctionCall) Value {
+	return r.toAbortSignal(call.This, "reason").reason
+}
+
+func (r *Runtime) builtinAbortSignal_throwIfAborted(call FunctionCall) Value {
+	if s := r.toAbortSignal(call.This, "throwIfAborted"); s.aborted {
+		panic(s.reason)
+	}
+	return _undefined
+}
+
+func (r *Runtime) builtinAbortSignal_addEventListener(call FunctionCall) Value {
+	s := r.toAbortSignal(call.This, "addEventListener")
+	if call.Argument(0).String() == "abort" {
+		if _, ok := AssertFunction(call.Argument(1)); ok {
+			s.listeners = append(s.listeners, call.Argument(1))
+		}
+	}
+	return _undefined
+}
+
+func (r *Runtime) builtinAbortSignal_removeEventListener(call FunctionCall) Value {
+	s := r.toAbortSignal(call.This, "removeEventListener")
+	if call.Argument(0).String() == "abort" {
+		for i, listener := range s.listeners {
+			if listener.SameAs(call.Argument(1)) {
+				s.listeners = append(s.listeners[:i:i], s.listeners[i+1:]...)
+				break
+			}
+		}
+	}
+	return _undefined
+}
+
+// builtinAbortSignal_abort is AbortSignal.abort(reason), it returns an aborted signal.
+func (r *Runtime) builtinAbortSignal_abort(call FunctionCall) Value {
+	s := r.newAbortSignal()
+	s.abort(call.Argument(0))
+	return s.val
+}
+
+func (r *Runtime) builtin_newAbortSignal([]Value, *Object) *Object {
+	panic(r.NewTypeError("Illegal constructor"))
+}
+
+func (r *Runtime) builtin_newAbortController(args []Value, newTarget *Object) *Object {
+	if newTarget == nil {
+		panic(r.needNew("AbortController"))
+	}
+	o := &Object{runtime: r}
+	c := &abortControllerObject{
+		baseObject: baseObject{class: classAbortController, val: o, prototype: r.getPrototypeFromCtor(newTarget, r.global.AbortController, r.global.AbortControllerPrototype), extensible: true, values: nil},
+		signal:     r.newAbortSignal(),
+	}
+	o.self = c
+	c.init()
+	c._putProp("signal", c.signal.val, false, true, true)
+	return o
+}
+
+func (r *Runtime) builtinAbortController_abort(call FunctionCall) Value {
+	thisObj := r.toObject(call.This)
+	c, ok := thisObj.self.(*abortControllerObject)
+	if !ok {
+		panic(r.NewTypeError("Method AbortController.prototype.abort called on incompatible receiver %s", r.objectproto_toString(FunctionCall{This: thisObj})))
+	}
+	c.signal.abort(call.Argument(0))
+	return _undefined
+}
+
+// HTTP.fetch
+
+func (r *Runtime) builtinHTTP_fetch(call FunctionCall) Value {
+	return r.fetchWith(nil, call)
+}
+
+// fetchWith implements fetch with the client hc, or the shared client if hc is nil.
+func (r *Runtime) fetchWith(hc *httpClientObject, call FunctionCall) Value {
+	p, resolve, reject := r.NewPromise()
+	args := call.Arguments
+	if s, ok := call.Argument(0).(valueString); ok && hc != nil && hc.base != nil {
+		// a relative URL is resolved against the base URL of the client
+		if u, err := url.Parse(s.String()); err == nil {
+			args = append([]Value{newStringValue(hc.base.ResolveReference(u).String())}, args[1:]...)
+		}
+	}
+	var req *fetchRequestObject
+	if err := r.try(func() {
+		req = r.builtin_newRequest(args, r.global.Request).self.(*fetchRequestObject)
+	}); err != nil {
+		reject(err.(*Exception).Value())
+		return r.ToValue(p)
+	}
+	if req.signal.aborted {
+		reject(req.signal.reason)
+		return r.ToValue(p)
+	}
+
+	ctx, cancel := c0.WithCancel(c0.Background())
+	removeCancel := req.signal.addCancel(cancel)
+	hr := &httpRequest{
+		method:          req.method,
+		url:             req.url,
+		header:          req.headers.header.Clone(),
+		body:            req.data,
+		followRedirects: req.redirect == "follow",
+	}
+	client := hc.clientFor(hr)
+	r.runAsync(func() func() {
+		resp, err := hr.do(ctx, client)
+		var data []byte
+		if err == nil {
+			data, err = io.ReadAll(resp.Body)
+			_ = resp.Body.Close()
+		}
+		return func() {
+			cancel()
+			removeCancel()
+			switch {
+			case req.signal.aborted:
+				reject(req.signal.reason)
+			case err != nil:
+				reject(r.NewTypeError("fetch failed: %v", err))
+			case req.redirect == "error" && resp.StatusCode >= 300 && resp.StatusCode < 400 && resp.Header.Get("Location") != "":
+				reject(r.NewTypeError("fetch failed: unexpected redirect to %s", resp.Header.Get("Location")))
+			default:
+				statusText := strings.TrimPrefix(resp.Status, strconv.Itoa(resp.StatusCode)+" ")
+				res := r.newFetchResponse(r.global.ResponsePrototype, resp.StatusCode, statusText, resp.Header, data)
+				res.url = resp.Request.URL.String()
+				res.redirected = res.url != hr.url
+				res.typ = "basic"
+				res.publish()
+				resolve(res.val)
+			}
+		}
+	})
+	return r.ToValue(p)
+}
+
+// prototypes
+
+func (r *Runtime) createHeadersProto(val *Object) objectImpl {
+	o := newBaseObjectObj(val, r.global.ObjectPrototype, classObject)
+	o._putProp("constructor", r.global.Headers, true, false, true)
+	o._putProp("append", r.newNativeFunc(r.builtinHeaders_append, nil, "append", nil, 2), true, false, true)
+	o._putProp("delete", r.newNativeFunc(r.builtinHeaders_delete, nil, "delete", nil, 1), true, false, true)
+	o._putProp("get", r.newNativeFunc(r.builtinHeaders_get, nil, "get", nil, 1), true, false, true)
+	o._putProp("getSetCookie", r.newNativeFunc(r.builtinHeaders_getSetCookie, nil, "getSetCookie", nil, 0), true, false, true)
+	o._putProp("has", r.newNativeFunc(r.builtinHeaders_has, nil, "has", nil, 1), true, false, true)
+	o._putProp("set", r.newNativeFunc(r.builtinHeaders_set, nil, "set", nil, 2), true, false, true)
+	o._putProp("forEach", r.newNativeFunc(r.builtinHeaders_forEach, nil, "forEach", nil, 1), true, false, true)
+	o._putProp("keys", r.newNativeFunc(r.builtinHeaders_keys, nil, "keys", nil, 0), true, false, true)
+	o._putProp("values", r.newNativeFunc(r.builtinHeaders_values, nil, "values", nil, 0), true, false, true)
+	entriesFunc := r.newNativeFunc(r.builtinHeaders_entries, nil, "entries", nil, 0)
+	o._putProp("entries", entriesFunc, true, false, true)
+	o._putSym(SymIterator, valueProp(entriesFunc, true, false, true))
+	o._putSym(SymToStringTag, valueProp(asciiString(classHeaders), false, false, true))
+	return o
+}
+
+func (r *Runtime) putFetchBodyMethods(o *baseObject) {
+	o._putProp("text", r.newNativeFunc(r.builtinFetchBody_text, nil, "text", nil, 0), true, false, true)
+	o._putProp("json", r.newNativeFunc(r.builtinFetchBody_json, nil, "json", nil, 0), true, false, true)
+	o._putProp("arrayBuffer", r.newNativeFunc(r.builtinFetchBody_arrayBuffer, nil, "arrayBuffer", nil, 0), true, false, true)
+	o.setOwnStr("bodyUsed", &valueProperty{
+		getterFunc:   r.newNativeFunc(r.builtinFetchBody_getBodyUsed, nil, "get bodyUsed", nil, 0),
+		accessor:     true,
+		configurable: true,
+	}, true)
+}
+
+func (r *Runtime) createRequestProto(val *Object) objectImpl {
+	o := newBaseObjectObj(val, r.global.ObjectPrototype, classObject)
+	o._putProp("constructor", r.global.Request, true, false, true)
+	o._putProp("clone", r.newNativeFunc(r.builtinRequest_clone, nil, "clone", nil, 0), true, false, true)
+	r.putFetchBodyMethods(o)
+	o._putSym(SymToStringTag, valueProp(asciiString(classRequest), false, false, true))
+	return o
+}
+
+func (r *Runtime) createResponseProto(val *Object) objectImpl {
+	o := newBaseObjectObj(val, r.global.ObjectPrototype, classObject)
+	o._putProp("constructor", r.global.Response, true, false, true)
+	o._putProp("clone", r.newNativeFunc(r.builtinResponse_clone, nil, "clone", nil, 0), true, false, true)
+	r.putFetchBodyMethods(o)
+	o._putSym(SymToStringTag, valueProp(asciiString(classResponse), false, false, true))
+	return o
+}
+
+func (r *Runtime) createAbortSignalProto(val *Object) objectImpl {
+	o := newBaseObjectObj(val, r.global.ObjectPrototype, classObject)
+	o._putProp("constructor", r.global.AbortSignal, true, false, true)
+	o.setOwnStr("aborted", &valueProperty{
+		getterFunc:   r.newNativeFunc(r.builtinAbortSignal_getAborted, nil, "get aborted", nil, 0),
+		accessor:     true,
+		configurable: true,
+	}, true)
+	o.setOwnStr("reason", &valueProperty{
+		getterFunc:   r.newNativeFunc(r.builtinAbortSignal_getReason, nil, "get reason", nil, 0),
+		accessor:     true,
+		configurable: true,
+	}, true)
+	o._putProp("throwIfAborted", r.newNativeFunc(r.builtinAbortSignal_throwIfAborted, nil, "throwIfAborted", nil, 0), true, false, true)
+	o._putProp("addEventListener", r.newNativeFunc(r.builtinAbortSignal_addEventListener, nil, "addEventListener", nil, 2), true, false, true)
+	o._putProp("removeEventListener", r.newNativeFunc(r.builtinAbortSignal_removeEventListener, nil, "removeEventListener", nil, 2), true, false, true)
+	o._putProp("onabort", _null, true, true, true)
+	o._putSym(SymToStringTag, valueProp(asciiString(classAbortSignal), false, false, true))
+	return o
+}
+
+func (r *Runtime) createAbortControllerProto(val *Object) objectImpl {
+	o := newBaseObjectObj(val, r.global.ObjectPrototype, classObject)
+	o._putProp("constructor", r.global.AbortController, true, false, true)
+	o._putProp("abort", r.newNativeFunc(r.builtinAbortController_abort, nil, "abort", nil, 1), true, false, true)
+	o._putSym(SymToStringTag, valueProp(asciiString(classAbortController), false, false, true))
+	return o
+}
+
+func (r *Runtime) createHeaders(val *Object) objectImpl {
+	return r.newNativeConstructOnly(val, r.builtin_newHeaders, r.global.HeadersPrototype, "Headers", 0)
+}
+
+func (r *Runtime) createRequest(val *Object) objectImpl {
+	return r.newNativeConstructOnly(val, r.builtin_newRequest, r.global.RequestPrototype, "Request", 1)
+}
+
+func (r *Runtime) createResponse(val *Object) objectImpl {
+	o := r.newNativeConstructOnly(val, r.builtin_newResponse, r.global.ResponsePrototype, "Response", 0)
+	o._putProp("json", r.newNativeFunc(r.builtinResponse_json, nil, "json", nil, 1), true, false, true)
+	o._putProp("error", r.newNativeFunc(r.builtinResponse_error, nil, "error", nil, 0), true, false, true)
+	return o
+}
+
+func (r *Runtime) createAbortSignal(val *Object) objectImpl {
+	o := r.newNativeConstructOnly(val, r.builtin_newAbortSignal, r.global.AbortSignalPrototype, "AbortSignal", 0)
+	o._putProp("abort", r.newNativeFunc(r.builtinAbortSignal_abort, nil, "abort", nil, 1), true, false, true)
+	return o
+}
+
+func (r *Runtime) createAbortController(val *Object) objectImpl {
+	return r.newNativeConstructOnly(val, r.builtin_newAbortController, r.global.AbortControllerPrototype, "AbortController", 0)
+}
+
+func (r *Runtime) initFetch() {
+	r.global.HeadersPrototype = r.newLazyObject(r.createHeadersProto)
+	r.global.Headers = r.newLazyObject(r.createHeaders)
+	r.addToGlobal("Headers", r.global.Headers)
+	r.global.RequestPrototype = r.newLazyObject(r.createRequestProto)
+	r.global.Request = r.newLazyObject(r.createRequest)
+	r.addToGlobal("Request", r.global.Request)
+	r.global.ResponsePrototype = r.newLazyObject(r.createResponseProto)
+	r.global.Response = r.newLazyObject(r.createResponse)
+	r.addToGlobal("Response", r.global.Response)
+	r.global.AbortSignalPrototype = r.newLazyObject(r.createAbortSignalProto)
+	r.global.AbortSignal = r.newLazyObject(r.createAbortSignal)
+	r.addToGlobal("AbortSignal", r.global.AbortSignal)
+	r.global.AbortControllerPrototype = r.newLazyObject(r.createAbortControllerProto)
+	r.global.AbortController = r.newLazyObject(r.createAbortController)
+	r.addToGlobal("AbortController", r.global.AbortController)
+}
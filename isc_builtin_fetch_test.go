@@ -0,0 +1,121 @@
+package goscript
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestFetch(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		switch req.URL.Path {
+		case "/echo":
+			body, _ := io.ReadAll(req.Body)
+			w.Header().Add("Set-Cookie", "a=1")
+			w.Header().Add("Set-Cookie", "b=2")
+			w.Header().Set("X-Method", req.Method)
+			w.Header().Set("X-Type", req.Header.Get("Content-Type"))
+			w.WriteHeader(http.StatusCreated)
+			_, _ = w.Write(body)
+		case "/redirect":
+			http.Redirect(w, req, "/echo", http.StatusFound)
+		}
+	}))
+	defer srv.Close()
+
+	vm := newWithTestLib(t)
+	_ = vm.Set("base", srv.URL)
+	_, err := vm.RunString(`
+	var h = new Headers({"Content-Type": "text/plain"});
+	h.append("x-a", "1");
+	h.append("X-A", "2");
+	assert.sameValue(h.get("x-a"), "1, 2", "combined");
+	assert.sameValue(h.has("CONTENT-TYPE"), true, "has");
+	h.delete("content-type");
+	assert.sameValue(h.get("content-type"), null, "deleted");
+	assert.sameValue(JSON.stringify([...new Headers([["b", "2"], ["a", "1"]])]), '[["a","1"],["b","2"]]', "sorted entries");
+	assert.sameValue([...new Headers(h).keys()].join(), "x-a", "copy");
+	var threw = false;
+	try { h.set("bad name", "x"); } catch (e) { threw = e instanceof TypeError; }
+	assert.sameValue(threw, true, "invalid name");
+
+	var r = new Response("hello", {status: 404, headers: {"X-A": "1"}});
+	assert.sameValue(r.status, 404, "status");
+	assert.sameValue(r.ok, false, "ok");
+	assert.sameValue(r.headers.get("content-type"), "text/plain;charset=UTF-8", "default type");
+	assert.sameValue(Response.json({a: 1}).headers.get("Content-Type"), "application/json", "Response.json");
+
+	var req = new Request(base + "/echo", {method: "post", body: "data"});
+	assert.sameValue(req.method, "POST", "request method");
+	assert.sameValue(req.signal.aborted, false, "signal");
+
+	var results = [], keptSignal;
+	(async function() {
+		var res = await HTTP.fetch(req);
+		assert.sameValue(res.status, 201, "fetch status");
+		assert.sameValue(res.statusText, "Created", "statusText");
+		assert.sameValue(res.headers.get("x-method"), "POST", "fetch method");
+		assert.sameValue(res.headers.get("x-type"), "text/plain;charset=UTF-8", "fetch body type");
+		assert.sameValue(res.headers.getSetCookie().join(), "a=1,b=2", "set-cookie");
+		assert.sameValue(res.bodyUsed, false, "bodyUsed");
+		var copy = res.clone();
+		assert.sameValue(await res.text(), "data", "text");
+		assert.sameValue(res.bodyUsed, true, "bodyUsed after text");
+		assert.sameValue((await copy.arrayBuffer()).byteLength, 4, "arrayBuffer");
+		try {
+			await res.text();
+			throw new Error("no error");
+		} catch (e) {
+			assert.sameValue(e instanceof TypeError, true, "consumed body");
+		}
+
+		res = await HTTP.fetch(base + "/redirect", {method: "PUT", body: new Uint8Array([1, 2])});
+		assert.sameValue(res.redirected, true, "redirected");
+		assert.sameValue(res.url, base + "/echo", "final url");
+		res = await HTTP.fetch(base + "/redirect", {redirect: "manual"});
+		assert.sameValue(res.status, 302, "manual redirect");
+		try {
+			await HTTP.fetch(base + "/redirect", {redirect: "error"});
+			throw new Error("no error");
+		} catch (e) {
+			assert.sameValue(e instanceof TypeError, true, "redirect error");
+		}
+
+		var controller = new AbortController();
+		var events = 0;
+		controller.signal.addEventListener("abort", function() { events++; });
+		controller.abort();
+		assert.sameValue(events, 1, "abort event");
+		try {
+			await HTTP.fetch(base + "/echo", {signal: controller.signal});
+			throw new Error("no error");
+		} catch (e) {
+			assert.sameValue(e.name, "AbortError", "aborted");
+		}
+		var kept = new AbortController();
+		for (var i = 0; i < 3; i++) {
+			await HTTP.fetch(base + "/echo", {signal: kept.signal});
+		}
+		keptSignal = kept.signal;
+		try {
+			await HTTP.fetch("http://127.0.0.1:1/");
+			throw new Error("no error");
+		} catch (e) {
+			assert.sameValue(e instanceof TypeError, true, "network error");
+		}
+		results.push("done");
+	})().catch(function(e) { results.push(String(e)); });
+	results;
+	`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res := vm.Get("results").Export().([]any); len(res) != 1 || res[0] != "done" {
+		t.Fatal(res)
+	}
+	// the finished fetches are removed from their signal
+	if s := vm.Get("keptSignal").(*Object).self.(*abortSignalObject); len(s.cancels) != 0 {
+		t.Fatalf("%d fetches retained by the signal", len(s.cancels))
+	}
+}
@@ -1,10 +1,19 @@
 package goscript
 
 import (
+	"bytes"
+	c0 "context"
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	h0 "encoding/hex"
 	"encoding/json"
 	"fmt"
 	"io"
+	"mime/multipart"
 	"net/http"
+	"net/url"
+	"os"
 	"reflect"
 	"strconv"
 	"strings"
@@ -128,30 +137,31 @@ func (r *Runtime) initHttp() {
 	HTTP._putProp("put", r.newNativeFunc(r.builtinHTTP_put, nil, "put", nil, 4), true, false, true)
 	HTTP._putProp("delete", r.newNativeFunc(r.builtinHTTP_delete, nil, "delete", nil, 3), true, false, true)
 	HTTP._putProp("patch", r.newNativeFunc(r.builtinHTTP_patch, nil, "patch", nil, 4), true, false, true)
+	HTTP._putProp("request", r.newNativeFunc(r.builtinHTTP_request, nil, "request", nil, 1), true, false, true)
+	HTTP._putProp("fetch", r.newNativeFunc(r.builtinHTTP_fetch, nil, "fetch", nil, 1), true, false, true)
+	HTTP._putProp("multipart", r.newNativeFunc(r.builtinHTTP_multipart, nil, "multipart", nil, 0), true, false, true)
+	HTTP._putProp("postMultipart", r.newNativeFunc(r.builtinHTTP_postMultipart, nil, "postMultipart", nil, 4), true, false, true)
+	HTTP._putProp("download", r.newNativeFunc(r.builtinHTTP_download, nil, "download", nil, 3), true, false, true)
+	HTTP._putProp("client", r.newNativeFunc(r.builtinHTTP_client, nil, "client", nil, 1), true, false, true)
+	HTTP._putProp("createServer", r.newNativeFunc(r.builtinHTTP_createServer, nil, "createServer", nil, 1), true, false, true)
 
 	r.addToGlobal("HTTP", HTTP.val)
+	// HTTPResponse、HTTPMultipart、HTTPClient 和 HTTPServer 没有构造函数，只由 HTTP 的函数创建
+	r.global.HTTPResponsePrototype = r.newLazyObject(r.createHTTPResponseProto)
+	r.global.HTTPMultipartPrototype = r.newLazyObject(r.createHTTPMultipartProto)
+	r.global.HTTPClientPrototype = r.newLazyObject(r.createHTTPClientProto)
+	r.global.HTTPServerPrototype = r.newLazyObject(r.createHTTPServerProto)
+	r.global.HTTPServerRequestPrototype = r.newLazyObject(r.createHTTPServerRequestProto)
+	r.global.HTTPServerResponsePrototype = r.newLazyObject(r.createHTTPServerResponseProto)
 }
 
 // migrate from gobase
 
 var httpClient = createHTTPClient()
 
+// createHTTPClient 创建共享的 HTTP 客户端，需要 Transport 配置的脚本使用 HTTP.client 创建独立的客户端
 func createHTTPClient() *http.Client {
-	client := &http.Client{}
-	// HTTP 客户端配置
-	// client.Timeout, _ = time.ParseDuration("5s")
-	//transport := &http.Transport{}
-	//transport.TLSHandshakeTimeout, _ = time.ParseDuration("10s")
-	//transport.DisableCompression = true
-	//transport.MaxIdleConns = 100
-	//transport.MaxIdleConns = 100
-	//transport.MaxIdleConnsPerHost = 100
-	//transport.MaxConnsPerHost = 100
-	//transport.IdleConnTimeout, _ = time.ParseDuration("90s")
-	//transport.ResponseHeaderTimeout, _ = time.ParseDuration("15s")
-	//transport.ExpectContinueTimeout, _ = time.ParseDuration("1s")
-	//client.Transport = transport
-	return client
+	return &http.Client{}
 }
 
 func urlWithParameter(url string, parameterMap map[string]string) string {
@@ -299,3 +309,464 @@ func privateHttpPatch(url string, header http.Header, parameterMap map[string]st
 	httpRequest.Header.Add("Content-Type", "application/json; charset=utf-8")
 	return callHttp(httpRequest)
 }
+
+// HTTP.request
+
+type httpResponseObject struct {
+	baseObject
+	resp     *http.Response
+	body     []byte
+	complete bool // the body has been read completely and closed
+}
+
+// httpRequest is a request built from the options of HTTP.request.
+type httpRequest struct {
+	method          string
+	url             string
+	header          http.Header
+	query           url.Values
+	body            []byte
+	contentType     string
+	timeout         time.Duration
+	followRedirects bool
+	proxy           *url.URL
+	tls             *tls.Config
+	tlsKey          string // identifies the tls option, see httpTLSOption
+	stream          bool
+	parts           []multipartPart // a multipart body, which is streamed instead of body
+	boundary        string
+}
+
+func httpOption(o *Object, name string) Value {
+	v := o.Get(name)
+	if v == nil || IsUndefined(v) || IsNull(v) {
+		return nil
+	}
+	return v
+}
+
+// httpValues converts an object whose values are strings or arrays of strings to url.Values.
+func (r *Runtime) httpValues(v Value) url.Values {
+	values := url.Values{}
+	o := r.toObject(v)
+	for _, k := range o.Keys() {
+		item := o.Get(k)
+		if a, ok := item.(*Object); ok && a.self.className() == classArray {
+			for _, e := range a.Export().([]any) {
+				values.Add(k, fmt.Sprintf("%v", e))
+			}
+		} else if item != nil && !IsUndefined(item) && !IsNull(item) {
+			values.Add(k, item.String())
+		}
+	}
+	return values
+}
+
+// httpHeader converts a headers option. The names are canonicalized, so that a header set by a script replaces the
+// default one whatever its case.
+func (r *Runtime) httpHeader(v Value) http.Header {
+	header := http.Header{}
+	for k, list := range r.httpValues(v) {
+		for _, s := range list {
+			header.Add(k, s)
+		}
+	}
+	return header
+}
+
+// httpBytes returns the contents of an ArrayBuffer, a typed array, a DataView or a string.
+func (r *Runtime) httpBytes(v Value) ([]byte, bool) {
+	if o, ok := v.(*Object); ok {
+		switch b := o.self.(type) {
+		case *arrayBufferObject:
+			return b.data, true
+		case *typedArrayObject:
+			start := b.offset * b.elemSize
+			return b.viewedArrayBuf.data[start : start+b.length*b.elemSize], true
+		case *dataViewObject:
+			return b.viewedArrayBuf.data[b.byteOffset : b.byteOffset+b.byteLen], true
+		}
+		return nil, false
+	}
+	return []byte(v.String()), true
+}
+
+// httpDuration converts a number of milliseconds or a duration string such as "5s".
+func (r *Runtime) httpDuration(v Value) time.Duration {
+	if _, ok := v.(valueString); ok {
+		d, err := time.ParseDuration(v.String())
+		if err != nil {
+			panic(r.NewTypeError("Invalid duration: %s", v.String()))
+		}
+		return d
+	}
+	return time.Duration(v.ToFloat() * float64(time.Millisecond))
+}
+
+// httpTLSConfig converts the tls option: {insecureSkipVerify, serverName, ca, cert, key, caFile, certFile, keyFile}.
+// The certificates and the key are PEM encoded, given inline or as the paths of files. The CA bundle replaces the
+// system roots.
+func (r *Runtime) httpTLSConfig(v Value) *tls.Config {
+	cfg, _ := r.httpTLSOption(v)
+	return cfg
+}
+
+// httpTLSOption is httpTLSConfig, which also returns a key that is the same for the options giving the same
+// configuration, so that the transports using it can be shared.
+func (r *Runtime) httpTLSOption(v Value) (*tls.Config, string) {
+	o := r.toObject(v)
+	cfg := &tls.Config{}
+	fingerprint := sha256.New()
+	if s := httpOption(o, "insecureSkipVerify"); s != nil {
+		cfg.InsecureSkipVerify = s.ToBoolean()
+	}
+	if s := httpOption(o, "serverName"); s != nil {
+		cfg.ServerName = s.String()
+	}
+	_, _ = fmt.Fprintf(fingerprint, "%t %q", cfg.InsecureSkipVerify, cfg.ServerName)
+	pem := func(name string) []byte {
+		var b []byte
+		if s := httpOption(o, name); s != nil {
+			b = []byte(s.String())
+		} else if s := httpOption(o, name+"File"); s != nil {
+			var err error
+			if b, err = os.ReadFile(s.String()); err != nil {
+				panic(r.NewGoError(err))
+			}
+		}
+		_, _ = fmt.Fprintf(fingerprint, " %d:", len(b))
+		_, _ = fingerprint.Write(b)
+		return b
+	}
+	if ca := pem("ca"); ca != nil {
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(ca) {
+			panic(r.NewTypeError("Invalid tls.ca: no PEM certificates found"))
+		}
+		cfg.RootCAs = pool
+	}
+	cert, key := pem("cert"), pem("key")
+	if cert != nil && key != nil {
+		pair, err := tls.X509KeyPair(cert, key)
+		if err != nil {
+			panic(r.NewTypeError("Invalid tls.cert or tls.key: %v", err))
+		}
+		cfg.Certificates = []tls.Certificate{pair}
+	} else if cert != nil || key != nil {
+		panic(r.NewTypeError("tls.cert and tls.key must be given together"))
+	}
+	return cfg, h0.EncodeToString(fingerprint.Sum(nil))
+}
+
+// httpBody encodes the body according to bodyType, which is inferred from the body if it is empty.
+func (r *Runtime) httpBody(req *httpRequest, body Value, bodyType string) {
+	if bodyType == "" {
+		bodyType = "json"
+		if _, ok := body.(valueString); ok {
+			bodyType = "text"
+		} else if o, ok := body.(*Object); ok {
+			switch o.self.(type) {
+			case *arrayBufferObject, *typedArrayObject, *dataViewObject:
+				bodyType = "bytes"
+			case *httpMultipartObject:
+				bodyType = "multipart"
+			}
+		}
+	}
+	switch bodyType {
+	case "json":
+		s := r.builtinJSON_stringify(FunctionCall{Arguments: []Value{body}})
+		req.body = []byte(s.String())
+		req.contentType = "application/json; charset=utf-8"
+	case "text":
+		req.body = []byte(body.String())
+		req.contentType = "text/plain; charset=utf-8"
+	case "bytes":
+		b, ok := r.httpBytes(body)
+		if !ok {
+			panic(r.NewTypeError("The body of type bytes must be an ArrayBuffer, a typed array, a DataView or a string"))
+		}
+		req.body = b
+		req.contentType = "application/octet-stream"
+	case "form":
+		req.body = []byte(r.httpValues(body).Encode())
+		req.contentType = "application/x-www-form-urlencoded"
+	case "multipart":
+		req.setMultipart(r.multipartParts(r.toObject(body)))
+	default:
+		panic(r.NewTypeError("Unknown bodyType: %s", bodyType))
+	}
+}
+
+// parseHttpRequest converts the arguments of HTTP.request: either an options object or a URL followed by an
+// optional options object.
+func (r *Runtime) parseHttpRequest(call FunctionCall) *httpRequest {
+	req := &httpRequest{method: http.MethodGet, header: http.Header{}, followRedirects: true}
+	var o *Object
+	if s, ok := call.Argument(0).(valueString); ok {
+		req.url = s.String()
+		if opts := call.Argument(1); !IsUndefined(opts) && !IsNull(opts) {
+			o = r.toObject(opts)
+		}
+	} else {
+		o = r.toObject(call.Argument(0))
+	}
+	if o != nil {
+		r.httpRequestOptions(req, o)
+	}
+	return req
+}
+
+// httpRequestOptions applies the options of HTTP.request.
+func (r *Runtime) httpRequestOptions(req *httpRequest, o *Object) {
+	if v := httpOption(o, "url"); v != nil {
+		req.url = v.String()
+	}
+	if v := httpOption(o, "method"); v != nil {
+		req.method = strings.ToUpper(v.String())
+	}
+	if v := httpOption(o, "headers"); v != nil {
+		req.header = r.httpHeader(v)
+	}
+	if v := httpOption(o, "query"); v != nil {
+		req.query = r.httpValues(v)
+	}
+	if v := httpOption(o, "body"); v != nil {
+		bodyType := ""
+		if t := httpOption(o, "bodyType"); t != nil {
+			bodyType = t.String()
+		}
+		r.httpBody(req, v, bodyType)
+	}
+	if v := httpOption(o, "timeout"); v != nil {
+		req.timeout = r.httpDuration(v)
+	}
+	if v := httpOption(o, "followRedirects"); v != nil {
+		req.followRedirects = v.ToBoolean()
+	}
+	if v := httpOption(o, "proxy"); v != nil {
+		u, err := url.Parse(v.String())
+		if err != nil {
+			panic(r.NewTypeError("Invalid proxy: %v", err))
+		}
+		req.proxy = u
+	}
+	if v := httpOption(o, "tls"); v != nil {
+		req.tls, req.tlsKey = r.httpTLSOption(v)
+	}
+	if v := httpOption(o, "stream"); v != nil {
+		req.stream = v.ToBoolean()
+	}
+}
+
+// client returns the client for the request. It is base unless the request has its own options. The transport of a
+// request with a proxy or a TLS configuration is derived by transports, see httpTransports.
+func (req *httpRequest) client(base *http.Client, transports *httpTransports) *http.Client {
+	if req.timeout == 0 && req.followRedirects && req.proxy == nil && req.tls == nil {
+		return base
+	}
+	c := *base
+	if req.timeout != 0 {
+		c.Timeout = req.timeout
+	}
+	if !req.followRedirects {
+		c.CheckRedirect = func(*http.Request, []*http.Request) error {
+			return http.ErrUseLastResponse
+		}
+	}
+	if req.proxy != nil || req.tls != nil {
+		c.Transport = transports.derive(base.Transport, req)
+	}
+	return &c
+}
+
+func (req *httpRequest) do(ctx c0.Context, c *http.Client) (*http.Response, error) {
+	u, err := url.Parse(req.url)
+	if err != nil {
+		return nil, err
+	}
+	if len(req.query) > 0 {
+		q := u.Query()
+		for k, v := range req.query {
+			q[k] = append(q[k], v...)
+		}
+		u.RawQuery = q.Encode()
+	}
+	var body io.Reader
+	var pw *io.PipeWriter
+	if req.parts != nil {
+		var pr *io.PipeReader
+		pr, pw = io.Pipe()
+		body = pr
+	} else if req.body != nil {
+		body = bytes.NewReader(req.body)
+	}
+	httpReq, err := http.NewRequestWithContext(ctx, req.method, u.String(), body)
+	if err != nil {
+		return nil, err
+	}
+	if pw != nil {
+		// the parts are written once the request exists, the writer stops when the client closes the body
+		w := multipart.NewWriter(pw)
+		_ = w.SetBoundary(req.boundary)
+		go func() {
+			_ = pw.CloseWithError(writeMultipart(w, req.parts))
+		}()
+	}
+	httpReq.Header = req.header
+	if req.contentType != "" && httpReq.Header.Get("Content-Type") == "" {
+		httpReq.Header.Set("Content-Type", req.contentType)
+	}
+	return c.Do(httpReq)
+}
+
+func (r *Runtime) builtinHTTP_request(call FunctionCall) Value {
+	return r.httpRequestWith(nil, r.parseHttpRequest(call))
+}
+
+// httpRequestWith sends a request of HTTP.request with the client hc, or the shared client if hc is nil.
+func (r *Runtime) httpRequestWith(hc *httpClientObject, req *httpRequest) Value {
+	resp, err := req.do(c0.Background(), hc.clientFor(req))
+	if err != nil {
+		panic(r.NewGoError(err))
+	}
+	return r.newHttpResponse(resp, req.stream)
+}
+
+// newHttpResponse wraps a response. Unless it is streamed, the body is read at once and the connection is released.
+func (r *Runtime) newHttpResponse(resp *http.Response, stream bool) *Object {
+	o := &Object{runtime: r}
+	obj := &httpResponseObject{
+		baseObject: baseObject{class: classHTTPResponse, val: o, prototype: r.global.HTTPResponsePrototype, extensible: true, values: nil},
+		resp:       resp,
+	}
+	o.self = obj
+	obj.init()
+	if !stream {
+		if err := obj.readAll(); err != nil {
+			panic(r.NewGoError(err))
+		}
+	}
+
+	headers := r.NewObject()
+	for k, v := range resp.Header {
+		values := make([]Value, len(v))
+		for i, s := range v {
+			values[i] = newStringValue(s)
+		}
+		_ = headers.Set(strings.ToLower(k), r.newArrayValues(values))
+	}
+	obj._putProp("status", intToValue(int64(resp.StatusCode)), false, true, true)
+	obj._putProp("statusText", newStringValue(http.StatusText(resp.StatusCode)), false, true, true)
+	obj._putProp("ok", r.toBoolean(resp.StatusCode >= 200 && resp.StatusCode < 300), false, true, true)
+	obj._putProp("url", newStringValue(resp.Request.URL.String()), false, true, true)
+	obj._putProp("headers", headers, false, true, true)
+	return o
+}
+
+// readAll reads the rest of the body and closes it.
+func (o *httpResponseObject) readAll() error {
+	if o.complete {
+		return nil
+	}
+	o.complete = true
+	defer o.resp.Body.Close()
+	b, err := io.ReadAll(o.resp.Body)
+	o.body = append(o.body, b...)
+	return err
+}
+
+func (r *Runtime) toHttpResponse(v Value, method string) *httpResponseObject {
+	thisObj := r.toObject(v)
+	o, ok := thisObj.self.(*httpResponseObject)
+	if !ok {
+		panic(r.NewTypeError("Method HTTPResponse.prototype.%s called on incompatible receiver %s", method, r.objectproto_toString(FunctionCall{This: thisObj})))
+	}
+	return o
+}
+
+func (r *Runtime) httpResponseBody(call FunctionCall, method string) []byte {
+	o := r.toHttpResponse(call.This, method)
+	if err := o.readAll(); err != nil {
+		panic(r.NewGoError(err))
+	}
+	return o.body
+}
+
+func (r *Runtime) builtinHTTPResponse_text(call FunctionCall) Value {
+	return newStringValue(string(r.httpResponseBody(call, "text")))
+}
+
+func (r *Runtime) builtinHTTPResponse_json(call FunctionCall) Value {
+	return r.builtinJSON_parse(FunctionCall{Arguments: []Value{newStringValue(string(r.httpResponseBody(call, "json")))}})
+}
+
+func (r *Runtime) builtinHTTPResponse_bytes(call FunctionCall) Value {
+	b := r.httpResponseBody(call, "bytes")
+	return r.NewArrayBuffer(append([]byte(nil), b...)).toValue(r)
+}
+
+// builtinHTTPResponse_header returns the values of a header joined with ", ", or null if it is not present.
+func (r *Runtime) builtinHTTPResponse_header(call FunctionCall) Value {
+	o := r.toHttpResponse(call.This, "header")
+	values := o.resp.Header.Values(call.Argument(0).String())
+	if len(values) == 0 {
+		return _null
+	}
+	return newStringValue(strings.Join(values, ", "))
+}
+
+// builtinHTTPResponse_read reads the next chunk (at most size bytes, 32 KiB by default) of a streamed body as an
+// ArrayBuffer. It returns null at the end of the body.
+func (r *Runtime) builtinHTTPResponse_read(call FunctionCall) Value {
+	o := r.toHttpResponse(call.This, "read")
+	if o.complete {
+		if len(o.body) == 0 {
+			return _null
+		}
+		b := o.body
+		o.body = nil
+		return r.NewArrayBuffer(b).toValue(r)
+	}
+	size := 32 * 1024
+	if v := call.Argument(0); !IsUndefined(v) {
+		size = int(v.ToInteger())
+		if size <= 0 {
+			panic(r.newError(r.global.RangeError, "Invalid size: %d", size))
+		}
+	}
+	buf := make([]byte, size)
+	n, err := io.ReadFull(o.resp.Body, buf)
+	if err == io.EOF || err == io.ErrUnexpectedEOF {
+		o.complete = true
+		_ = o.resp.Body.Close()
+	} else if err != nil {
+		panic(r.NewGoError(err))
+	}
+	if n == 0 {
+		return _null
+	}
+	return r.NewArrayBuffer(buf[:n]).toValue(r)
+}
+
+// builtinHTTPResponse_close discards the rest of a streamed body.
+func (r *Runtime) builtinHTTPResponse_close(call FunctionCall) Value {
+	o := r.toHttpResponse(call.This, "close")
+	if !o.complete {
+		o.complete = true
+		_ = o.resp.Body.Close()
+	}
+	return _undefined
+}
+
+func (r *Runtime) createHTTPResponseProto(val *Object) objectImpl {
+	o := newBaseObjectObj(val, r.global.ObjectPrototype, classObject)
+	o._putProp("text", r.newNativeFunc(r.builtinHTTPResponse_text, nil, "text", nil, 0), true, false, true)
+	o._putProp("json", r.newNativeFunc(r.builtinHTTPResponse_json, nil, "json", nil, 0), true, false, true)
+	o._putProp("bytes", r.newNativeFunc(r.builtinHTTPResponse_bytes, nil, "bytes", nil, 0), true, false, true)
+	o._putProp("header", r.newNativeFunc(r.builtinHTTPResponse_header, nil, "header", nil, 1), true, false, true)
+	o._putProp("read", r.newNativeFunc(r.builtinHTTPResponse_read, nil, "read", nil, 1), true, false, true)
+	o._putProp("close", r.newNativeFunc(r.builtinHTTPResponse_close, nil, "close", nil, 0), true, false, true)
+	o._putSym(SymToStringTag, valueProp(asciiString(classHTTPResponse), false, false, true))
+	return o
+}
@@ -0,0 +1,521 @@
+package goscript
+
+import (
+	"crypto/md5"
+	"crypto/rand"
+	"crypto/sha256"
+	h0 "encoding/hex"
+	"fmt"
+	"hash"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+)
+
+// HTTP.client 创建的独立客户端，包括 Transport、TLS、代理和认证的配置
+
+// httpClientObject is a client created by HTTP.client. Relative URLs are resolved against base and the default
+// headers are added to the requests that do not set them.
+type httpClientObject struct {
+	baseObject
+	client     *http.Client
+	base       *url.URL
+	header     http.Header
+	transports httpTransports
+}
+
+// clientFor prepares req for the client and returns the client that sends it. A nil client is the shared one.
+func (hc *httpClientObject) clientFor(req *httpRequest) *http.Client {
+	if hc == nil {
+		return req.client(httpClient, nil)
+	}
+	if hc.base != nil {
+		if u, err := url.Parse(req.url); err == nil {
+			req.url = hc.base.ResolveReference(u).String()
+		}
+	}
+	for k, v := range hc.header {
+		if _, ok := req.header[k]; !ok {
+			req.header[k] = append([]string(nil), v...)
+		}
+	}
+	return req.client(hc.client, &hc.transports)
+}
+
+// httpTransportKey identifies the proxy and the TLS configuration of a derived transport.
+type httpTransportKey struct {
+	proxy, tls string
+}
+
+// httpTransports are the transports derived from the one of a client for the requests with their own proxy or TLS
+// configuration. They are kept by the clients of HTTP.client, so that their connections, and the challenge of digest
+// authentication, are reused. The nil value is used for the shared client, whose derived transports are used by a
+// single request and do not keep their connections.
+type httpTransports struct {
+	mu         sync.Mutex
+	transports map[httpTransportKey]http.RoundTripper
+}
+
+func (ts *httpTransports) derive(base http.RoundTripper, req *httpRequest) http.RoundTripper {
+	configure := func(transport *http.Transport) {
+		if ts == nil {
+			transport.DisableKeepAlives = true
+		}
+		if req.proxy != nil {
+			transport.Proxy = http.ProxyURL(req.proxy)
+		}
+		if req.tls != nil {
+			transport.TLSClientConfig = req.tls
+		}
+	}
+	if ts == nil {
+		return withTransport(base, configure)
+	}
+	key := httpTransportKey{tls: req.tlsKey}
+	if req.proxy != nil {
+		key.proxy = req.proxy.String()
+	}
+	ts.mu.Lock()
+	defer ts.mu.Unlock()
+	rt, ok := ts.transports[key]
+	if !ok {
+		if ts.transports == nil {
+			ts.transports = make(map[httpTransportKey]http.RoundTripper)
+		}
+		rt = withTransport(base, configure)
+		ts.transports[key] = rt
+	}
+	return rt
+}
+
+// closeIdleConnections closes the idle connections of the derived transports.
+func (ts *httpTransports) closeIdleConnections() {
+	ts.mu.Lock()
+	defer ts.mu.Unlock()
+	for _, rt := range ts.transports {
+		if t, ok := rt.(interface{ CloseIdleConnections() }); ok {
+			t.CloseIdleConnections()
+		}
+	}
+}
+
+// withTransport returns a copy of the transport rt modified by f. An authenticating transport keeps authenticating.
+func withTransport(rt http.RoundTripper, f func(*http.Transport)) http.RoundTripper {
+	switch t := rt.(type) {
+	case *http.Transport:
+		c := t.Clone()
+		f(c)
+		return c
+	case *authTransport:
+		return &authTransport{base: withTransport(t.base, f), scheme: t.scheme, username: t.username, password: t.password, token: t.token,
+			host: t.host}
+	}
+	c := http.DefaultTransport.(*http.Transport).Clone()
+	f(c)
+	return c
+}
+
+// authTransport adds the credentials to the requests without an Authorization header, if they are sent to the host
+// of the baseURL or to the host of the first request of a chain of redirects. Like the header removed by http.Client,
+// the credentials are not sent to the other hosts a request is redirected to.
+type authTransport struct {
+	base                      http.RoundTripper
+	scheme                    string // basic, bearer or digest
+	username, password, token string
+	host                      string // the host of the baseURL, if any
+
+	mu        sync.Mutex
+	challenge *digestChallenge // the last digest challenge of the server, reused until it is rejected
+}
+
+// allowed reports whether the credentials may be sent with req.
+func (t *authTransport) allowed(req *http.Request) bool {
+	if t.host != "" && strings.EqualFold(req.URL.Host, t.host) {
+		return true
+	}
+	first := req
+	for first.Response != nil && first.Response.Request != nil {
+		first = first.Response.Request
+	}
+	return strings.EqualFold(req.URL.Host, first.URL.Host)
+}
+
+// CloseIdleConnections closes the idle connections of the underlying transport, it is called by
+// http.Client.CloseIdleConnections.
+func (t *authTransport) CloseIdleConnections() {
+	if c, ok := t.base.(interface{ CloseIdleConnections() }); ok {
+		c.CloseIdleConnections()
+	}
+}
+
+func (t *authTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if req.Header.Get("Authorization") != "" || !t.allowed(req) {
+		return t.base.RoundTrip(req)
+	}
+	switch t.scheme {
+	case "basic":
+		req = req.Clone(req.Context())
+		req.SetBasicAuth(t.username, t.password)
+	case "bearer":
+		req = req.Clone(req.Context())
+		req.Header.Set("Authorization", "Bearer "+t.token)
+	case "digest":
+		return t.digest(req)
+	}
+	return t.base.RoundTrip(req)
+}
+
+// digest sends the request with the known challenge, if any. When the server answers with a new challenge, the
+// request is sent again if its body can be replayed.
+func (t *authTransport) digest(req *http.Request) (*http.Response, error) {
+	t.mu.Lock()
+	c := t.challenge
+	t.mu.Unlock()
+	first := req
+	if c != nil {
+		first = req.Clone(req.Context())
+		first.Header.Set("Authorization", c.authorization(req, t.username, t.password))
+	}
+	resp, err := t.base.RoundTrip(first)
+	if err != nil || resp.StatusCode != http.StatusUnauthorized {
+		return resp, err
+	}
+	c = nil
+	for _, h := range resp.Header.Values("WWW-Authenticate") {
+		if c = parseDigestChallenge(h); c != nil {
+			break
+		}
+	}
+	if c == nil || (req.Body != nil && req.Body != http.NoBody && req.GetBody == nil) {
+		return resp, nil
+	}
+	retry := req.Clone(req.Context())
+	if req.GetBody != nil {
+		if retry.Body, err = req.GetBody(); err != nil {
+			return resp, nil
+		}
+	}
+	_, _ = io.Copy(io.Discard, resp.Body)
+	_ = resp.Body.Close()
+	t.mu.Lock()
+	t.challenge = c
+	t.mu.Unlock()
+	retry.Header.Set("Authorization", c.authorization(req, t.username, t.password))
+	return t.base.RoundTrip(retry)
+}
+
+// digestChallenge is a WWW-Authenticate challenge of the Digest scheme (RFC 7616).
+type digestChallenge struct {
+	realm, nonce, opaque, algorithm string
+	qop                             bool // qop=auth is supported
+
+	mu sync.Mutex
+	nc int
+}
+
+func parseDigestChallenge(h string) *digestChallenge {
+	if len(h) < 7 || !strings.EqualFold(h[:7], "digest ") {
+		return nil
+	}
+	params := make(map[string]string)
+	s := h[7:]
+	for s != "" {
+		s = strings.TrimLeft(s, " ,")
+		i := strings.IndexByte(s, '=')
+		if i < 0 {
+			break
+		}
+		key := strings.ToLower(strings.TrimSpace(s[:i]))
+		s = s[i+1:]
+		var value string
+		if strings.HasPrefix(s, `"`) {
+			var b strings.Builder
+			i = 1
+			for ; i < len(s) && s[i] != '"'; i++ {
+				if s[i] == '\\' && i+1 < len(s) {
+					i++
+				}
+				b.WriteByte(s[i])
+			}
+			if i < len(s) {
+				i++
+			}
+			value, s = b.String(), s[i:]
+		} else {
+			i = strings.IndexByte(s, ',')
+			if i < 0 {
+				i = len(s)
+			}
+			value, s = strings.TrimSpace(s[:i]), s[i:]
+		}
+		params[key] = value
+	}
+	c := &digestChallenge{realm: params["realm"], nonce: params["nonce"], opaque: params["opaque"], algorithm: params["algorithm"]}
+	if c.nonce == "" {
+		return nil
+	}
+	for _, q := range strings.Split(params["qop"], ",") {
+		if strings.TrimSpace(q) == "auth" {
+			c.qop = true
+		}
+	}
+	return c
+}
+
+func (c *digestChallenge) hash() hash.Hash {
+	if strings.HasPrefix(strings.ToUpper(c.algorithm), "SHA-256") {
+		return sha256.New()
+	}
+	return md5.New()
+}
+
+func (c *digestChallenge) h(s string) string {
+	h := c.hash()
+	_, _ = io.WriteString(h, s)
+	return h0.EncodeToString(h.Sum(nil))
+}
+
+// authorization computes the Authorization header of a request.
+func (c *digestChallenge) authorization(req *http.Request, username, password string) string {
+	uri := req.URL.RequestURI()
+	var cnonce [8]byte
+	_, _ = rand.Read(cnonce[:])
+	cn := h0.EncodeToString(cnonce[:])
+	c.mu.Lock()
+	c.nc++
+	nc := fmt.Sprintf("%08x", c.nc)
+	c.mu.Unlock()
+
+	ha1 := c.h(username + ":" + c.realm + ":" + password)
+	if strings.HasSuffix(strings.ToLower(c.algorithm), "-sess") {
+		ha1 = c.h(ha1 + ":" + c.nonce + ":" + cn)
+	}
+	ha2 := c.h(req.Method + ":" + uri)
+	var b strings.Builder
+	fmt.Fprintf(&b, `Digest username="%s", realm="%s", nonce="%s", uri="%s"`, username, c.realm, c.nonce, uri)
+	if c.qop {
+		fmt.Fprintf(&b, `, qop=auth, nc=%s, cnonce="%s", response="%s"`, nc, cn, c.h(ha1+":"+c.nonce+":"+nc+":"+cn+":auth:"+ha2))
+	} else {
+		fmt.Fprintf(&b, `, response="%s"`, c.h(ha1+":"+c.nonce+":"+ha2))
+	}
+	if c.algorithm != "" {
+		fmt.Fprintf(&b, ", algorithm=%s", c.algorithm)
+	}
+	if c.opaque != "" {
+		fmt.Fprintf(&b, `, opaque="%s"`, c.opaque)
+	}
+	return b.String()
+}
+
+// newHttpTransport converts the transport options of HTTP.client.
+func (r *Runtime) newHttpTransport(o *Object) *http.Transport {
+	t := http.DefaultTransport.(*http.Transport).Clone()
+	if v := httpOption(o, "proxy"); v != nil {
+		if _, ok := v.(valueBool); ok && !v.ToBoolean() {
+			t.Proxy = nil
+		} else {
+			u, err := url.Parse(v.String())
+			if err != nil || u.Host == "" {
+				panic(r.NewTypeError("Invalid proxy: %s", v.String()))
+			}
+			switch u.Scheme {
+			case "http", "https", "socks5", "socks5h":
+			default:
+				panic(r.NewTypeError("Unsupported proxy scheme: %s", u.Scheme))
+			}
+			t.Proxy = http.ProxyURL(u)
+		}
+	}
+	if v := httpOption(o, "tls"); v != nil {
+		t.TLSClientConfig = r.httpTLSConfig(v)
+	}
+	if v := httpOption(o, "maxIdleConns"); v != nil {
+		t.MaxIdleConns = int(v.ToInteger())
+	}
+	if v := httpOption(o, "maxIdleConnsPerHost"); v != nil {
+		t.MaxIdleConnsPerHost = int(v.ToInteger())
+	}
+	if v := httpOption(o, "maxConnsPerHost"); v != nil {
+		t.MaxConnsPerHost = int(v.ToInteger())
+	}
+	if v := httpOption(o, "idleConnTimeout"); v != nil {
+		t.IdleConnTimeout = r.httpDuration(v)
+	}
+	if v := httpOption(o, "tlsHandshakeTimeout"); v != nil {
+		t.TLSHandshakeTimeout = r.httpDuration(v)
+	}
+	if v := httpOption(o, "responseHeaderTimeout"); v != nil {
+		t.ResponseHeaderTimeout = r.httpDuration(v)
+	}
+	if v := httpOption(o, "expectContinueTimeout"); v != nil {
+		t.ExpectContinueTimeout = r.httpDuration(v)
+	}
+	if v := httpOption(o, "disableCompression"); v != nil {
+		t.DisableCompression = v.ToBoolean()
+	}
+	if v := httpOption(o, "disableKeepAlives"); v != nil {
+		t.DisableKeepAlives = v.ToBoolean()
+	}
+	return t
+}
+
+// newAuthTransport converts the auth option: {type, username, password, token}. The type is basic, bearer or
+// digest, it defaults to bearer if there is a token and to basic otherwise.
+func (r *Runtime) newAuthTransport(base http.RoundTripper, v Value) *authTransport {
+	o := r.toObject(v)
+	t := &authTransport{base: base}
+	if s := httpOption(o, "username"); s != nil {
+		t.username = s.String()
+	}
+	if s := httpOption(o, "password"); s != nil {
+		t.password = s.String()
+	}
+	if s := httpOption(o, "token"); s != nil {
+		t.token = s.String()
+	}
+	if s := httpOption(o, "type"); s != nil {
+		t.scheme = strings.ToLower(s.String())
+	} else if t.token != "" {
+		t.scheme = "bearer"
+	} else {
+		t.scheme = "basic"
+	}
+	switch t.scheme {
+	case "basic", "digest":
+	case "bearer":
+		if t.token == "" {
+			panic(r.NewTypeError("The bearer auth requires a token"))
+		}
+	default:
+		panic(r.NewTypeError("Unknown auth type: %s", t.scheme))
+	}
+	return t
+}
+
+// builtinHTTP_client creates a client with its own connections: client({baseURL, headers, timeout, followRedirects,
+// proxy, tls, auth, maxIdleConns, maxIdleConnsPerHost, maxConnsPerHost, idleConnTimeout, tlsHandshakeTimeout,
+// responseHeaderTimeout, expectContinueTimeout, disableCompression, disableKeepAlives}). The proxy is an http, https
+// or socks5 URL, or false to ignore the proxy of the environment.
+func (r *Runtime) builtinHTTP_client(call FunctionCall) Value {
+	o := &Object{runtime: r}
+	hc := &httpClientObject{
+		baseObject: baseObject{class: classHTTPClient, val: o, prototype: r.global.HTTPClientPrototype, extensible: true, values: nil},
+		client:     &http.Client{},
+		header:     http.Header{},
+	}
+	o.self = hc
+	hc.init()
+
+	opts := r.NewObject()
+	if v := call.Argument(0); !IsUndefined(v) && !IsNull(v) {
+		opts = r.toObject(v)
+	}
+	if v := httpOption(opts, "baseURL"); v != nil {
+		u, err := url.Parse(v.String())
+		if err != nil || !u.IsAbs() {
+			panic(r.NewTypeError("Invalid baseURL: %s", v.String()))
+		}
+		hc.base = u
+	}
+	var rt http.RoundTripper = r.newHttpTransport(opts)
+	if v := httpOption(opts, "auth"); v != nil {
+		at := r.newAuthTransport(rt, v)
+		if hc.base != nil {
+			at.host = hc.base.Host
+		}
+		rt = at
+	}
+	hc.client.Transport = rt
+	if v := httpOption(opts, "headers"); v != nil {
+		hc.header = r.httpHeader(v)
+	}
+	if v := httpOption(opts, "timeout"); v != nil {
+		hc.client.Timeout = r.httpDuration(v)
+	}
+	if v := httpOption(opts, "followRedirects"); v != nil && !v.ToBoolean() {
+		hc.client.CheckRedirect = func(*http.Request, []*http.Request) error {
+			return http.ErrUseLastResponse
+		}
+	}
+	return o
+}
+
+func (r *Runtime) toHttpClient(v Value, method string) *httpClientObject {
+	thisObj := r.toObject(v)
+	hc, ok := thisObj.self.(*httpClientObject)
+	if !ok {
+		panic(r.NewTypeError("Method HTTPClient.prototype.%s called on incompatible receiver %s", method, r.objectproto_toString(FunctionCall{This: thisObj})))
+	}
+	return hc
+}
+
+// builtinHTTPClient_request is HTTP.request with the client.
+func (r *Runtime) builtinHTTPClient_request(call FunctionCall) Value {
+	hc := r.toHttpClient(call.This, "request")
+	return r.httpRequestWith(hc, r.parseHttpRequest(call))
+}
+
+// httpClientMethod returns a shortcut of request: method(url, opts), or method(url, body, opts) if the method has a
+// body.
+func (r *Runtime) httpClientMethod(method string, hasBody bool) func(FunctionCall) Value {
+	name := strings.ToLower(method)
+	return func(call FunctionCall) Value {
+		hc := r.toHttpClient(call.This, name)
+		req := &httpRequest{method: method, url: call.Argument(0).String(), header: http.Header{}, followRedirects: true}
+		opts := call.Argument(1)
+		if hasBody {
+			opts = call.Argument(2)
+		}
+		if !IsUndefined(opts) && !IsNull(opts) {
+			o := r.toObject(opts)
+			r.httpRequestOptions(req, o)
+			req.method = method
+		}
+		if body := call.Argument(1); hasBody && !IsUndefined(body) && !IsNull(body) {
+			bodyType := ""
+			if o, ok := opts.(*Object); ok {
+				if t := httpOption(o, "bodyType"); t != nil {
+					bodyType = t.String()
+				}
+			}
+			r.httpBody(req, body, bodyType)
+		}
+		return r.httpRequestWith(hc, req)
+	}
+}
+
+// builtinHTTPClient_fetch is HTTP.fetch with the client.
+func (r *Runtime) builtinHTTPClient_fetch(call FunctionCall) Value {
+	return r.fetchWith(r.toHttpClient(call.This, "fetch"), call)
+}
+
+// builtinHTTPClient_download is HTTP.download with the client.
+func (r *Runtime) builtinHTTPClient_download(call FunctionCall) Value {
+	return r.downloadWith(r.toHttpClient(call.This, "download"), call)
+}
+
+// builtinHTTPClient_close closes the idle connections of the client.
+func (r *Runtime) builtinHTTPClient_close(call FunctionCall) Value {
+	hc := r.toHttpClient(call.This, "close")
+	hc.client.CloseIdleConnections()
+	hc.transports.closeIdleConnections()
+	return _undefined
+}
+
+func (r *Runtime) createHTTPClientProto(val *Object) objectImpl {
+	o := newBaseObjectObj(val, r.global.ObjectPrototype, classObject)
+	o._putProp("request", r.newNativeFunc(r.builtinHTTPClient_request, nil, "request", nil, 1), true, false, true)
+	o._putProp("get", r.newNativeFunc(r.httpClientMethod(http.MethodGet, false), nil, "get", nil, 2), true, false, true)
+	o._putProp("head", r.newNativeFunc(r.httpClientMethod(http.MethodHead, false), nil, "head", nil, 2), true, false, true)
+	o._putProp("delete", r.newNativeFunc(r.httpClientMethod(http.MethodDelete, false), nil, "delete", nil, 2), true, false, true)
+	o._putProp("post", r.newNativeFunc(r.httpClientMethod(http.MethodPost, true), nil, "post", nil, 3), true, false, true)
+	o._putProp("put", r.newNativeFunc(r.httpClientMethod(http.MethodPut, true), nil, "put", nil, 3), true, false, true)
+	o._putProp("patch", r.newNativeFunc(r.httpClientMethod(http.MethodPatch, true), nil, "patch", nil, 3), true, false, true)
+	o._putProp("fetch", r.newNativeFunc(r.builtinHTTPClient_fetch, nil, "fetch", nil, 1), true, false, true)
+	o._putProp("download", r.newNativeFunc(r.builtinHTTPClient_download, nil, "download", nil, 3), true, false, true)
+	o._putProp("close", r.newNativeFunc(r.builtinHTTPClient_close, nil, "close", nil, 0), true, false, true)
+	o._putSym(SymToStringTag, valueProp(asciiString(classHTTPClient), false, false, true))
+	return o
+}
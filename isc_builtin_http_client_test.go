@@ -0,0 +1,248 @@
+package goscript
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/md5"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	h0 "encoding/hex"
+	"encoding/pem"
+	"io"
+	"log"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestHTTPClient(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		body, _ := io.ReadAll(req.Body)
+		_, _ = io.WriteString(w, req.Method+" "+req.URL.RequestURI()+" "+req.Header.Get("X-App")+" "+
+			req.Header.Get("Authorization")+" "+string(body))
+	}))
+	defer srv.Close()
+
+	vm := newWithTestLib(t)
+	_ = vm.Set("base", srv.URL)
+	_, err := vm.RunString(`
+	var c = HTTP.client({baseURL: base + "/api/", headers: {"X-App": "app"}, timeout: "5s", maxIdleConnsPerHost: 4});
+	assert.sameValue(Object.prototype.toString.call(c), "[object HTTPClient]", "toStringTag");
+	assert.sameValue(c.get("items?a=1").text(), "GET /api/items?a=1 app  ", "get");
+	assert.sameValue(c.request({url: "/root", headers: {"X-App": "other"}}).text(), "GET /root other  ", "request");
+	assert.sameValue(c.post("items", {a: 1}).text(), 'POST /api/items app  {"a":1}', "post");
+	assert.sameValue(c.put("items/1", "x", {bodyType: "text"}).text(), "PUT /api/items/1 app  x", "put");
+	assert.sameValue(c.delete("items/1").text(), "DELETE /api/items/1 app  ", "delete");
+	assert.sameValue(c.head("items").status, 200, "head");
+
+	var basic = HTTP.client({auth: {username: "u", password: "p"}});
+	assert.sameValue(basic.get(base).text(), "GET /  Basic dTpw ", "basic");
+	assert.sameValue(basic.get(base, {headers: {Authorization: "Token x"}}).text(), "GET /  Token x ", "explicit");
+	var bearer = HTTP.client({auth: {token: "t"}});
+	assert.sameValue(bearer.get(base, {timeout: 1000}).text(), "GET /  Bearer t ", "bearer with request options");
+
+	var fetched;
+	c.fetch("f").then(function (res) { return res.text(); }).then(function (s) { fetched = s; });
+
+	var threw = false;
+	try {
+		HTTP.client({proxy: "ftp://proxy:21"});
+	} catch (e) {
+		threw = e instanceof TypeError;
+	}
+	assert.sameValue(threw, true, "proxy scheme");
+	`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// the promise jobs run after the script
+	if _, err = vm.RunString(`assert.sameValue(fetched, "GET /api/f app  ", "fetch"); c.close()`); err != nil {
+		t.Fatal(err)
+	}
+	if httpClient.Transport != nil || httpClient.Timeout != 0 {
+		t.Fatal("The shared client was modified")
+	}
+}
+
+func TestHTTPClientRedirect(t *testing.T) {
+	echo := func(w http.ResponseWriter, req *http.Request) {
+		_, _ = io.WriteString(w, req.URL.Path+" "+req.Header.Get("Authorization"))
+	}
+	other := httptest.NewServer(http.HandlerFunc(echo))
+	defer other.Close()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		switch req.URL.Path {
+		case "/away":
+			http.Redirect(w, req, other.URL+"/landed", http.StatusFound)
+		case "/here":
+			http.Redirect(w, req, "/landed", http.StatusFound)
+		default:
+			echo(w, req)
+		}
+	}))
+	defer srv.Close()
+
+	vm := newWithTestLib(t)
+	_ = vm.Set("base", srv.URL)
+	_, err := vm.RunString(`
+	["basic", "bearer"].forEach(function (type) {
+		var c = HTTP.client({baseURL: base, auth: {type: type, username: "u", password: "p", token: "t"}});
+		var auth = type === "basic" ? "Basic dTpw" : "Bearer t";
+		assert.sameValue(c.get("here").text(), "/landed " + auth, type + " same host");
+		assert.sameValue(c.get("away").text(), "/landed ", type + " other host");
+		c.close();
+	});
+	`)
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestHTTPClientDigest(t *testing.T) {
+	const realm, nonce = "test", "abc123"
+	h := func(s string) string {
+		sum := md5.Sum([]byte(s))
+		return h0.EncodeToString(sum[:])
+	}
+	challenges := 0
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		params := make(map[string]string)
+		auth := req.Header.Get("Authorization")
+		for _, p := range strings.Split(strings.TrimPrefix(auth, "Digest "), ", ") {
+			if k, v, ok := strings.Cut(p, "="); ok {
+				params[k] = strings.Trim(v, `"`)
+			}
+		}
+		ha1 := h("user:" + realm + ":secret")
+		ha2 := h(req.Method + ":" + params["uri"])
+		expected := h(ha1 + ":" + nonce + ":" + params["nc"] + ":" + params["cnonce"] + ":auth:" + ha2)
+		if !strings.HasPrefix(auth, "Digest ") || params["response"] != expected || params["uri"] != req.URL.RequestURI() {
+			challenges++
+			w.Header().Set("WWW-Authenticate", `Digest realm="`+realm+`", qop="auth,auth-int", nonce="`+nonce+`", opaque="o"`)
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		body, _ := io.ReadAll(req.Body)
+		_, _ = io.WriteString(w, "ok "+params["nc"]+" "+string(body))
+	}))
+	defer srv.Close()
+
+	vm := newWithTestLib(t)
+	_ = vm.Set("base", srv.URL)
+	_, err := vm.RunString(`
+	var c = HTTP.client({auth: {type: "digest", username: "user", password: "secret"}});
+	assert.sameValue(c.get(base + "/a?x=1").text(), "ok 00000001 ", "challenge");
+	assert.sameValue(c.post(base + "/b", "body", {bodyType: "text"}).text(), "ok 00000002 body", "reused challenge");
+	// the transport derived for a TLS configuration is kept with its challenge
+	assert.sameValue(c.get(base + "/c", {tls: {insecureSkipVerify: true}}).text(), "ok 00000001 ", "derived challenge");
+	assert.sameValue(c.get(base + "/c", {tls: {insecureSkipVerify: true}}).text(), "ok 00000002 ", "reused derived challenge");
+	c.close();
+	var wrong = HTTP.client({auth: {type: "digest", username: "user", password: "wrong"}});
+	assert.sameValue(wrong.get(base).status, 401, "wrong password");
+	`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if challenges != 4 {
+		t.Fatalf("Unexpected number of challenges: %d", challenges)
+	}
+}
+
+func TestHTTPClientTLS(t *testing.T) {
+	srv := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		name := "none"
+		if len(req.TLS.PeerCertificates) > 0 {
+			name = req.TLS.PeerCertificates[0].Subject.CommonName
+		}
+		_, _ = io.WriteString(w, name)
+	}))
+	srv.TLS = &tls.Config{ClientAuth: tls.RequestClientCert}
+	srv.Config.ErrorLog = log.New(io.Discard, "", 0) // the failed handshake of the untrusted client
+	srv.StartTLS()
+	defer srv.Close()
+
+	dir := t.TempDir()
+	ca := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: srv.Certificate().Raw})
+	caFile := filepath.Join(dir, "ca.pem")
+	if err := os.WriteFile(caFile, ca, 0644); err != nil {
+		t.Fatal(err)
+	}
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tmpl := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "client"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	keyDer, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	certFile, keyFile := filepath.Join(dir, "cert.pem"), filepath.Join(dir, "key.pem")
+	_ = os.WriteFile(certFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0644)
+	_ = os.WriteFile(keyFile, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDer}), 0600)
+
+	vm := newWithTestLib(t)
+	_ = vm.Set("base", srv.URL)
+	_ = vm.Set("ca", string(ca))
+	_ = vm.Set("caFile", caFile)
+	_ = vm.Set("certFile", certFile)
+	_ = vm.Set("keyFile", keyFile)
+	_, err = vm.RunString(`
+	var threw = false;
+	try {
+		HTTP.client().get(base);
+	} catch (e) {
+		threw = true;
+	}
+	assert.sameValue(threw, true, "unknown authority");
+	assert.sameValue(HTTP.client({tls: {insecureSkipVerify: true}}).get(base).text(), "none", "insecure");
+	assert.sameValue(HTTP.client({tls: {ca: ca}}).get(base).text(), "none", "ca");
+	var mtls = HTTP.client({tls: {caFile: caFile, certFile: certFile, keyFile: keyFile}});
+	assert.sameValue(mtls.get(base).text(), "client", "client certificate");
+	assert.sameValue(mtls.get(base, {timeout: "5s"}).text(), "client", "client certificate with request options");
+	`)
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestHTTPClientProxy(t *testing.T) {
+	var proxied []string
+	proxy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		proxied = append(proxied, req.URL.String())
+		_, _ = io.WriteString(w, "via proxy")
+	}))
+	defer proxy.Close()
+
+	vm := New()
+	_ = vm.Set("proxy", proxy.URL)
+	_, err := vm.RunString(`
+	var c = HTTP.client({proxy: proxy});
+	var text = c.get("http://example.invalid/path").text();
+	if (text !== "via proxy") {
+		throw new Error("Unexpected response: " + text);
+	}
+	`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(proxied) != 1 || proxied[0] != "http://example.invalid/path" {
+		t.Fatalf("Unexpected proxied requests: %v", proxied)
+	}
+}
@@ -0,0 +1,835 @@
+package goscript
+
+import (
+	c0 "context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"path"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/rarnu/goscript/unistring"
+)
+
+// HTTP.createServer 创建的内嵌 HTTP 服务器。处理函数由 Scheduler.Post 调度到 Runtime 的 goroutine 上运行，因此需要
+// Scheduler（如 eventloop）
+
+// defaultMaxBodySize is the default limit of the size of the request bodies read by a server.
+const defaultMaxBodySize = 10 << 20
+
+// httpServeState is the state of the HTTP servers of a Runtime.
+type httpServeState struct {
+	mu      sync.Mutex
+	servers map[*httpServerObject]*httpListening
+}
+
+// httpListening is a listening server.
+type httpListening struct {
+	server  *http.Server
+	sockets *httpSockets
+	done    func(func()) // completes the operation that keeps the scheduler running
+}
+
+// httpRoute is a route of a server. The segments of the pattern are literals, :name parameters or a final *, which
+// matches the rest of the path.
+type httpRoute struct {
+	method    string // empty for all methods
+	segments  []string
+	handler   Value
+	upgrade   bool     // a WebSocket route
+	protocols []string // the subprotocols of a WebSocket route
+}
+
+type httpStatic struct {
+	prefix  string
+	handler http.Handler
+}
+
+// httpServerObject is a server created by HTTP.createServer. It is also the http.Handler of HTTPHandler.
+type httpServerObject struct {
+	baseObject
+	runtime     *Runtime
+	handler     Value // the handler of the requests without a route, may be nil
+	onError     Value
+	maxBodySize int64
+
+	readTimeout, readHeaderTimeout, writeTimeout, idleTimeout time.Duration
+
+	mu      sync.RWMutex
+	routes  []*httpRoute
+	statics []httpStatic
+
+	sockets httpSockets
+}
+
+// httpServerRequestObject is the request passed to a handler. The body is read before the handler is called.
+type httpServerRequestObject struct {
+	baseObject
+	req  *http.Request
+	body []byte
+}
+
+// httpServerResponseObject is the response passed to a handler. It is written once the handler has finished it.
+type httpServerResponseObject struct {
+	baseObject
+	status   int
+	header   http.Header
+	body     []byte
+	file     string // the file to serve instead of body
+	finished bool
+	done     chan struct{}
+}
+
+func splitRoutePath(p string) []string {
+	p = strings.Trim(p, "/")
+	if p == "" {
+		return nil
+	}
+	return strings.Split(p, "/")
+}
+
+// match returns the parameters of the route if it matches the path.
+func (rt *httpRoute) match(segments []string) (map[string]string, bool) {
+	params := make(map[string]string)
+	for i, s := range rt.segments {
+		if s == "*" && i == len(rt.segments)-1 {
+			params["*"] = strings.Join(segments[i:], "/")
+			return params, true
+		}
+		if i >= len(segments) {
+			return nil, false
+		}
+		if strings.HasPrefix(s, ":") {
+			params[s[1:]] = segments[i]
+		} else if s != segments[i] {
+			return nil, false
+		}
+	}
+	return params, len(segments) == len(rt.segments)
+}
+
+// route finds the route of a request, params is nil if there is none. Then allowed lists the methods of the routes
+// that match the path.
+func (s *httpServerObject) route(req *http.Request) (route *httpRoute, params map[string]string, allowed []string) {
+	segments := splitRoutePath(req.URL.Path)
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	for _, rt := range s.routes {
+		p, ok := rt.match(segments)
+		if !ok {
+			continue
+		}
+		if rt.method == "" || rt.method == req.Method || (rt.method == http.MethodGet && req.Method == http.MethodHead) {
+			return rt, p, nil
+		}
+		allowed = append(allowed, rt.method)
+	}
+	return nil, nil, allowed
+}
+
+func (s *httpServerObject) static(req *http.Request) http.Handler {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	var h http.Handler
+	n := -1
+	for _, st := range s.statics {
+		if strings.HasPrefix(req.URL.Path, st.prefix) && len(st.prefix) > n {
+			h, n = st.handler, len(st.prefix)
+		}
+	}
+	return h
+}
+
+func (s *httpServerObject) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	rt, params, allowed := s.route(req)
+	handler := s.handler
+	if rt != nil {
+		if rt.upgrade {
+			s.serveWebSocket(w, req, rt, params)
+			return
+		}
+		handler = rt.handler
+	} else {
+		// no route, the static files come before the handler of the server
+		if st := s.static(req); st != nil && (req.Method == http.MethodGet || req.Method == http.MethodHead) {
+			st.ServeHTTP(w, req)
+			return
+		}
+		if len(allowed) > 0 {
+			w.Header().Set("Allow", strings.Join(allowed, ", "))
+			http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+			return
+		}
+		if handler == nil {
+			http.NotFound(w, req)
+			return
+		}
+	}
+	body, err := io.ReadAll(http.MaxBytesReader(w, req.Body, s.maxBodySize))
+	if err != nil {
+		http.Error(w, http.StatusText(http.StatusRequestEntityTooLarge), http.StatusRequestEntityTooLarge)
+		return
+	}
+
+	r := s.runtime
+	res := &httpServerResponseObject{status: http.StatusOK, header: http.Header{}, done: make(chan struct{})}
+	r.dispatch(func() {
+		s.handle(handler, params, req, body, res)
+	})
+	select {
+	case <-res.done:
+	case <-req.Context().Done():
+		return
+	}
+	for k, v := range res.header {
+		w.Header()[k] = v
+	}
+	if res.file != "" {
+		http.ServeFile(w, req, res.file)
+		return
+	}
+	if res.header.Get("Content-Length") == "" {
+		w.Header().Set("Content-Length", strconv.Itoa(len(res.body)))
+	}
+	w.WriteHeader(res.status)
+	_, _ = w.Write(res.body)
+}
+
+// dispatch runs fn on the goroutine of the Runtime. The servers and the WebSockets require a scheduler, which is
+// checked when they are created.
+func (r *Runtime) dispatch(fn func()) {
+	r.scheduler.Post(fn)
+}
+
+// handle calls the handler on the goroutine of the Runtime. A handler finishes the response with one of the methods
+// of the response, by returning a value to send or by returning a promise of it.
+func (s *httpServerObject) handle(handler Value, params map[string]string, req *http.Request, body []byte, res *httpServerResponseObject) {
+	r := s.runtime
+	reqObj := r.newHttpServerRequest(req, body, params)
+	resObj := r.newHttpServerResponse(res)
+	fail := func(err Value) {
+		if s.onError != nil {
+			if f, ok := AssertFunction(s.onError); ok {
+				_, _ = f(_undefined, err, reqObj)
+			}
+		}
+		if !res.finished {
+			res.header = http.Header{}
+			res.finish(http.StatusInternalServerError, []byte(http.StatusText(http.StatusInternalServerError)), "text/plain; charset=utf-8")
+		}
+	}
+	settle := func(v Value) {
+		if res.finished || v == nil || IsUndefined(v) {
+			return
+		}
+		if err := r.try(func() { r.httpSend(res, v) }); err != nil {
+			fail(err.(*Exception).Value())
+		}
+	}
+	var ret Value
+	// the reactions to the promise of an async handler are run before runWrapped returns
+	err := r.runWrapped(func() {
+		ret = r.toCallable(handler)(FunctionCall{This: _undefined, Arguments: []Value{reqObj, resObj}})
+		if o, ok := ret.(*Object); ok {
+			if p, ok := o.self.(*Promise); ok {
+				ret = nil
+				r.performPromiseThen(p,
+					r.newNativeFunc(func(call FunctionCall) Value {
+						settle(call.Argument(0))
+						return _undefined
+					}, nil, "", nil, 1),
+					r.newNativeFunc(func(call FunctionCall) Value {
+						fail(call.Argument(0))
+						return _undefined
+					}, nil, "", nil, 1), nil)
+			}
+		}
+	})
+	if err != nil {
+		var ex *Exception
+		if errors.As(err, &ex) {
+			fail(ex.Value())
+		} else {
+			fail(r.NewGoError(err))
+		}
+		return
+	}
+	settle(ret)
+}
+
+// finish completes the response. It is called on the goroutine of the Runtime.
+func (res *httpServerResponseObject) finish(status int, body []byte, contentType string) {
+	res.status, res.body, res.finished = status, body, true
+	if contentType != "" && res.header.Get("Content-Type") == "" {
+		res.header.Set("Content-Type", contentType)
+	}
+	close(res.done)
+}
+
+// httpSend finishes a response with a value: a string, bytes or a value sent as JSON.
+func (r *Runtime) httpSend(res *httpServerResponseObject, v Value) {
+	switch {
+	case v == nil || IsUndefined(v) || IsNull(v):
+		res.finish(res.status, nil, "")
+	case isString(v):
+		res.finish(res.status, []byte(v.String()), "text/plain; charset=utf-8")
+	default:
+		if o, ok := v.(*Object); ok {
+			switch o.self.(type) {
+			case *arrayBufferObject, *typedArrayObject, *dataViewObject:
+				b, _ := r.httpBytes(v)
+				res.finish(res.status, append([]byte(nil), b...), "application/octet-stream")
+				return
+			}
+		}
+		r.httpSendJSON(res, v)
+	}
+}
+
+func (r *Runtime) httpSendJSON(res *httpServerResponseObject, v Value) {
+	s := r.builtinJSON_stringify(FunctionCall{Arguments: []Value{v}})
+	var body []byte
+	if !IsUndefined(s) {
+		body = []byte(s.String())
+	}
+	res.finish(res.status, body, "application/json; charset=utf-8")
+}
+
+func isString(v Value) bool {
+	_, ok := v.(valueString)
+	return ok
+}
+
+// request
+
+func (r *Runtime) newHttpServerRequest(req *http.Request, body []byte, params map[string]string) *Object {
+	o := &Object{runtime: r}
+	obj := &httpServerRequestObject{
+		baseObject: baseObject{class: classHTTPServerRequest, val: o, prototype: r.global.HTTPServerRequestPrototype, extensible: true, values: nil},
+		req:        req,
+		body:       body,
+	}
+	o.self = obj
+	obj.init()
+
+	headers := r.NewObject()
+	for k, v := range req.Header {
+		_ = headers.Set(strings.ToLower(k), strings.Join(v, ", "))
+	}
+	query := r.NewObject()
+	for k, v := range req.URL.Query() {
+		if len(v) == 1 {
+			_ = query.Set(k, v[0])
+		} else {
+			_ = query.Set(k, v)
+		}
+	}
+	p := r.NewObject()
+	for k, v := range params {
+		_ = p.Set(k, v)
+	}
+	protocol := "http"
+	if req.TLS != nil {
+		protocol = "https"
+	}
+	obj._putProp("method", newStringValue(req.Method), false, true, false)
+	obj._putProp("url", newStringValue(req.URL.RequestURI()), false, true, false)
+	obj._putProp("path", newStringValue(req.URL.Path), false, true, false)
+	obj._putProp("query", query, false, true, false)
+	obj._putProp("params", p, false, true, false)
+	obj._putProp("headers", headers, false, true, false)
+	obj._putProp("host", newStringValue(req.Host), false, true, false)
+	obj._putProp("remoteAddr", newStringValue(req.RemoteAddr), false, true, false)
+	obj._putProp("protocol", asciiString(protocol), false, true, false)
+	return o
+}
+
+func (r *Runtime) toHttpServerRequest(v Value, method string) *httpServerRequestObject {
+	thisObj := r.toObject(v)
+	req, ok := thisObj.self.(*httpServerRequestObject)
+	if !ok {
+		panic(r.NewTypeError("Method HTTPServerRequest.prototype.%s called on incompatible receiver %s", method, r.objectproto_toString(FunctionCall{This: thisObj})))
+	}
+	return req
+}
+
+func (r *Runtime) builtinHTTPServerRequest_text(call FunctionCall) Value {
+	return newStringValue(string(r.toHttpServerRequest(call.This, "text").body))
+}
+
+func (r *Runtime) builtinHTTPServerRequest_json(call FunctionCall) Value {
+	body := r.toHttpServerRequest(call.This, "json").body
+	return r.builtinJSON_parse(FunctionCall{Arguments: []Value{newStringValue(string(body))}})
+}
+
+func (r *Runtime) builtinHTTPServerRequest_bytes(call FunctionCall) Value {
+	body := r.toHttpServerRequest(call.This, "bytes").body
+	return r.NewArrayBuffer(append([]byte(nil), body...)).toValue(r)
+}
+
+// builtinHTTPServerRequest_header returns the values of a header joined by ", ", or null.
+func (r *Runtime) builtinHTTPServerRequest_header(call FunctionCall) Value {
+	req := r.toHttpServerRequest(call.This, "header")
+	v := req.req.Header.Values(call.Argument(0).String())
+	if len(v) == 0 {
+		return _null
+	}
+	return newStringValue(strings.Join(v, ", "))
+}
+
+// response
+
+func (r *Runtime) newHttpServerResponse(res *httpServerResponseObject) *Object {
+	o := &Object{runtime: r}
+	res.baseObject = baseObject{class: classHTTPServerResponse, val: o, prototype: r.global.HTTPServerResponsePrototype, extensible: true, values: nil}
+	o.self = res
+	res.init()
+	return o
+}
+
+// toHttpServerResponse returns the response of a method. Unless the method only reads the response, the response must
+// not be finished.
+func (r *Runtime) toHttpServerResponse(v Value, method string, modify bool) *httpServerResponseObject {
+	thisObj := r.toObject(v)
+	res, ok := thisObj.self.(*httpServerResponseObject)
+	if !ok {
+		panic(r.NewTypeError("Method HTTPServerResponse.prototype.%s called on incompatible receiver %s", method, r.objectproto_toString(FunctionCall{This: thisObj})))
+	}
+	if modify && res.finished {
+		panic(r.NewTypeError("The response has already been sent"))
+	}
+	return res
+}
+
+func (r *Runtime) builtinHTTPServerResponse_status(call FunctionCall) Value {
+	res := r.toHttpServerResponse(call.This, "status", true)
+	status := call.Argument(0).ToInteger()
+	if status < 100 || status > 999 {
+		panic(r.newError(r.global.RangeError, "Invalid status code: %d", status))
+	}
+	res.status = int(status)
+	return call.This
+}
+
+// builtinHTTPServerResponse_setHeader sets a header. An array sets several values.
+func (r *Runtime) builtinHTTPServerResponse_setHeader(call FunctionCall) Value {
+	res := r.toHttpServerResponse(call.This, "setHeader", true)
+	name := call.Argument(0).String()
+	if !isHeaderToken(name) {
+		panic(r.NewTypeError("Invalid header name: %s", name))
+	}
+	res.header.Del(name)
+	v := call.Argument(1)
+	if a, ok := v.(*Object); ok && a.self.className() == classArray {
+		for _, k := range a.Keys() {
+			res.header.Add(name, a.Get(k).String())
+		}
+	} else {
+		res.header.Set(name, v.String())
+	}
+	return call.This
+}
+
+func (r *Runtime) builtinHTTPServerResponse_getHeader(call FunctionCall) Value {
+	res := r.toHttpServerResponse(call.This, "getHeader", false)
+	v := res.header.Values(call.Argument(0).String())
+	if len(v) == 0 {
+		return _null
+	}
+	return newStringValue(strings.Join(v, ", "))
+}
+
+func (r *Runtime) builtinHTTPServerResponse_removeHeader(call FunctionCall) Value {
+	res := r.toHttpServerResponse(call.This, "removeHeader", true)
+	res.header.Del(call.Argument(0).String())
+	return call.This
+}
+
+// builtinHTTPServerResponse_send sends a string, bytes or a value as JSON.
+func (r *Runtime) builtinHTTPServerResponse_send(call FunctionCall) Value {
+	r.httpSend(r.toHttpServerResponse(call.This, "send", true), call.Argument(0))
+	return _undefined
+}
+
+func (r *Runtime) builtinHTTPServerResponse_json(call FunctionCall) Value {
+	r.httpSendJSON(r.toHttpServerResponse(call.This, "json", true), call.Argument(0))
+	return _undefined
+}
+
+func (r *Runtime) builtinHTTPServerResponse_end(call FunctionCall) Value {
+	res := r.toHttpServerResponse(call.This, "end", true)
+	if v := call.Argument(0); !IsUndefined(v) {
+		r.httpSend(res, v)
+	} else {
+		res.finish(res.status, nil, "")
+	}
+	return _undefined
+}
+
+// builtinHTTPServerResponse_redirect redirects to a URL: redirect(url, status = 302).
+func (r *Runtime) builtinHTTPServerResponse_redirect(call FunctionCall) Value {
+	res := r.toHttpServerResponse(call.This, "redirect", true)
+	status := http.StatusFound
+	if v := call.Argument(1); !IsUndefined(v) {
+		status = int(v.ToInteger())
+	}
+	res.header.Set("Location", call.Argument(0).String())
+	res.finish(status, nil, "")
+	return _undefined
+}
+
+// builtinHTTPServerResponse_sendFile sends a file with the content type of its extension. The file is read when the
+// response is written, a missing file is a 404.
+func (r *Runtime) builtinHTTPServerResponse_sendFile(call FunctionCall) Value {
+	res := r.toHttpServerResponse(call.This, "sendFile", true)
+	res.file = call.Argument(0).String()
+	if res.file == "" {
+		panic(r.NewTypeError("The path must not be empty"))
+	}
+	res.finish(res.status, nil, "")
+	return _undefined
+}
+
+func (r *Runtime) builtinHTTPServerResponse_getFinished(call FunctionCall) Value {
+	return r.toBoolean(r.toHttpServerResponse(call.This, "finished", false).finished)
+}
+
+// server
+
+// builtinHTTP_createServer creates a server: createServer(handler) or createServer(options, handler). The options
+// are {maxBodySize, readTimeout, readHeaderTimeout, writeTimeout, idleTimeout, onError}, onError is called with the
+// error and the request when a handler throws or its promise is rejected. The handler, which may be omitted if the
+// server has routes, is called with the requests that do not match a route.
+func (r *Runtime) builtinHTTP_createServer(call FunctionCall) Value {
+	var opts *Object
+	handler := call.Argument(0)
+	if o, ok := handler.(*Object); ok && !isCallableValue(handler) {
+		opts = o
+		handler = call.Argument(1)
+	}
+	s := r.newHttpServer(handler)
+	if opts != nil {
+		if v := httpOption(opts, "maxBodySize"); v != nil {
+			s.maxBodySize = v.ToInteger()
+		}
+		if v := httpOption(opts, "readTimeout"); v != nil {
+			s.readTimeout = r.httpDuration(v)
+		}
+		if v := httpOption(opts, "readHeaderTimeout"); v != nil {
+			s.readHeaderTimeout = r.httpDuration(v)
+		}
+		if v := httpOption(opts, "writeTimeout"); v != nil {
+			s.writeTimeout = r.httpDuration(v)
+		}
+		if v := httpOption(opts, "idleTimeout"); v != nil {
+			s.idleTimeout = r.httpDuration(v)
+		}
+		if v := httpOption(opts, "onError"); v != nil {
+			s.onError = r.toCallableValue(v)
+		}
+	}
+	return s.val
+}
+
+func isCallableValue(v Value) bool {
+	_, ok := AssertFunction(v)
+	return ok
+}
+
+func (r *Runtime) toCallableValue(v Value) Value {
+	r.toCallable(v)
+	return v
+}
+
+func (r *Runtime) newHttpServer(handler Value) *httpServerObject {
+	o := &Object{runtime: r}
+	s := &httpServerObject{
+		baseObject:  baseObject{class: classHTTPServer, val: o, prototype: r.global.HTTPServerPrototype, extensible: true, values: nil},
+		runtime:     r,
+		maxBodySize: defaultMaxBodySize,
+	}
+	if !IsUndefined(handler) && !IsNull(handler) {
+		s.handler = r.toCallableValue(handler)
+	}
+	o.self = s
+	s.init()
+	return s
+}
+
+func (r *Runtime) toHttpServer(v Value, method string) *httpServerObject {
+	thisObj := r.toObject(v)
+	s, ok := thisObj.self.(*httpServerObject)
+	if !ok {
+		panic(r.NewTypeError("Method HTTPServer.prototype.%s called on incompatible receiver %s", method, r.objectproto_toString(FunctionCall{This: thisObj})))
+	}
+	return s
+}
+
+func (r *Runtime) addHttpRoute(s *httpServerObject, method string, pattern, handler Value) *httpRoute {
+	p := pattern.String()
+	if !strings.HasPrefix(p, "/") {
+		panic(r.NewTypeError("The pattern must start with /: %s", p))
+	}
+	rt := &httpRoute{method: method, segments: splitRoutePath(p), handler: r.toCallableValue(handler)}
+	s.mu.Lock()
+	s.routes = append(s.routes, rt)
+	s.mu.Unlock()
+	return rt
+}
+
+// builtinHTTPServer_route adds a route: route(method, pattern, handler). A pattern is a path whose segments may be
+// :name parameters or a final *, the values are in req.params. The routes are matched in the order they are added.
+func (r *Runtime) builtinHTTPServer_route(call FunctionCall) Value {
+	s := r.toHttpServer(call.This, "route")
+	method := strings.ToUpper(call.Argument(0).String())
+	if method == "*" {
+		method = ""
+	}
+	r.addHttpRoute(s, method, call.Argument(1), call.Argument(2))
+	return call.This
+}
+
+// httpServerMethod returns the shortcut of route for a method, all for every method.
+func (r *Runtime) httpServerMethod(method string) func(FunctionCall) Value {
+	name := strings.ToLower(method)
+	if method == "" {
+		name = "all"
+	}
+	return func(call FunctionCall) Value {
+		r.addHttpRoute(r.toHttpServer(call.This, name), method, call.Argument(0), call.Argument(1))
+		return call.This
+	}
+}
+
+// builtinHTTPServer_static serves the files of a directory under a path prefix: static(prefix, dir).
+func (r *Runtime) builtinHTTPServer_static(call FunctionCall) Value {
+	s := r.toHttpServer(call.This, "static")
+	prefix := path.Clean("/" + call.Argument(0).String())
+	dir := call.Argument(1).String()
+	if dir == "" {
+		panic(r.NewTypeError("The directory must not be empty"))
+	}
+	h := http.FileServer(http.Dir(dir))
+	if prefix != "/" {
+		h = http.StripPrefix(prefix, h)
+		prefix += "/"
+	}
+	s.mu.Lock()
+	s.statics = append(s.statics, httpStatic{prefix: prefix, handler: h})
+	s.mu.Unlock()
+	return call.This
+}
+
+// builtinHTTPServer_listen starts listening on an address, such as ":8080" or "127.0.0.1:0", or on a port. The
+// address, port and url properties are set to the actual address. It requires a scheduler, which keeps running until
+// the server is closed.
+func (r *Runtime) builtinHTTPServer_listen(call FunctionCall) Value {
+	s := r.toHttpServer(call.This, "listen")
+	if r.scheduler == nil {
+		panic(r.NewTypeError("HTTPServer.prototype.listen requires an event loop"))
+	}
+	addr := call.Argument(0)
+	address := ":0"
+	if _, ok := addr.(valueString); !ok && !IsUndefined(addr) && !IsNull(addr) {
+		address = ":" + strconv.FormatInt(addr.ToInteger(), 10)
+	} else if !IsUndefined(addr) && !IsNull(addr) {
+		address = addr.String()
+	}
+	r.httpServe.mu.Lock()
+	_, listening := r.httpServe.servers[s]
+	r.httpServe.mu.Unlock()
+	if listening {
+		panic(r.NewTypeError("The server is already listening"))
+	}
+	ln, err := net.Listen("tcp", address)
+	if err != nil {
+		panic(r.NewGoError(err))
+	}
+	tcp := ln.Addr().(*net.TCPAddr)
+	host := "localhost"
+	if !tcp.IP.IsUnspecified() {
+		host = tcp.IP.String()
+	}
+	s._putProp("address", newStringValue(tcp.String()), false, true, true)
+	s._putProp("port", intToValue(int64(tcp.Port)), false, true, true)
+	s._putProp("url", newStringValue("http://"+net.JoinHostPort(host, strconv.Itoa(tcp.Port))), false, true, true)
+
+	l := &httpListening{sockets: &s.sockets, server: &http.Server{Handler: s, ReadTimeout: s.readTimeout, ReadHeaderTimeout: s.readHeaderTimeout,
+		WriteTimeout: s.writeTimeout, IdleTimeout: s.idleTimeout}, done: r.scheduler.Schedule()}
+	r.httpServe.mu.Lock()
+	if r.httpServe.servers == nil {
+		r.httpServe.servers = make(map[*httpServerObject]*httpListening)
+	}
+	r.httpServe.servers[s] = l
+	r.httpServe.mu.Unlock()
+	srv := l.server
+	go func() {
+		_ = srv.Serve(ln)
+	}()
+	return call.This
+}
+
+// listening returns the listening server and removes it from the listening servers.
+func (s *httpServerObject) listening() *httpListening {
+	r := s.runtime
+	r.httpServe.mu.Lock()
+	defer r.httpServe.mu.Unlock()
+	l := r.httpServe.servers[s]
+	delete(r.httpServe.servers, s)
+	return l
+}
+
+// shutdown stops a server gracefully: it stops listening, waits for the requests in progress and closes the
+// WebSockets, whose hijacked connections are not closed by Shutdown. It may be called from any goroutine. then is run
+// on the goroutine of the Runtime.
+func (l *httpListening) shutdown(ctx c0.Context, then func()) error {
+	err := l.server.Shutdown(ctx)
+	if err1 := l.sockets.shutdown(ctx); err == nil {
+		err = err1
+	}
+	l.done(then)
+	return err
+}
+
+// builtinHTTPServer_close stops the server gracefully and returns a promise that is resolved when the requests in
+// progress are finished.
+func (r *Runtime) builtinHTTPServer_close(call FunctionCall) Value {
+	l := r.toHttpServer(call.This, "close").listening()
+	p, resolve, _ := r.NewPromise()
+	if l == nil {
+		resolve(_undefined)
+	} else {
+		go func() {
+			_ = l.shutdown(c0.Background(), func() {
+				resolve(_undefined)
+			})
+		}()
+	}
+	return r.ToValue(p)
+}
+
+// Go API
+
+// HTTPHandler returns an http.Handler that serves the requests with a script: a handler function (req, res) or a
+// server created by HTTP.createServer, with its routes. The handlers are posted to the scheduler (see SetScheduler),
+// which must be running, e.g. an eventloop.EventLoop started with Start.
+func (r *Runtime) HTTPHandler(v Value) (http.Handler, error) {
+	if r.scheduler == nil {
+		return nil, errors.New("HTTPHandler requires a scheduler")
+	}
+	var h http.Handler
+	err := r.try(func() {
+		if o, ok := v.(*Object); ok {
+			if s, ok := o.self.(*httpServerObject); ok {
+				h = s
+				return
+			}
+		}
+		h = r.newHttpServer(v)
+	})
+	return h, err
+}
+
+// MountHTTPHandler mounts a script handler (see HTTPHandler) on mux. If the pattern ends with a /, the path of the
+// subtree is stripped, i.e. the script sees the paths relative to the pattern.
+func (r *Runtime) MountHTTPHandler(mux *http.ServeMux, pattern string, v Value) error {
+	h, err := r.HTTPHandler(v)
+	if err != nil {
+		return err
+	}
+	if strings.HasSuffix(pattern, "/") && pattern != "/" {
+		prefix := pattern[:len(pattern)-1]
+		if i := strings.IndexByte(prefix, '/'); i > 0 {
+			// a pattern with a host
+			prefix = prefix[i:]
+		}
+		h = http.StripPrefix(prefix, h)
+	}
+	mux.Handle(pattern, h)
+	return nil
+}
+
+// ShutdownHTTPServers stops the servers listening with HTTP.createServer gracefully: they stop listening and the
+// requests in progress are finished, unless ctx is done first. It may be called from any goroutine, the scheduler
+// must keep running until ShutdownHTTPServers returns.
+func (r *Runtime) ShutdownHTTPServers(ctx c0.Context) error {
+	r.httpServe.mu.Lock()
+	servers := make([]*httpListening, 0, len(r.httpServe.servers))
+	for s, l := range r.httpServe.servers {
+		servers = append(servers, l)
+		delete(r.httpServe.servers, s)
+	}
+	r.httpServe.mu.Unlock()
+
+	var wg sync.WaitGroup
+	errs := make([]error, len(servers))
+	for i, s := range servers {
+		wg.Add(1)
+		go func(i int, l *httpListening) {
+			defer wg.Done()
+			errs[i] = l.shutdown(ctx, func() {})
+		}(i, s)
+	}
+	wg.Wait()
+	for _, err := range errs {
+		if err != nil {
+			return fmt.Errorf("shutting down the HTTP servers: %w", err)
+		}
+	}
+	return nil
+}
+
+// prototypes
+
+func (r *Runtime) createHTTPServerProto(val *Object) objectImpl {
+	o := newBaseObjectObj(val, r.global.ObjectPrototype, classObject)
+	o._putProp("route", r.newNativeFunc(r.builtinHTTPServer_route, nil, "route", nil, 3), true, false, true)
+	for _, m := range []string{http.MethodGet, http.MethodPost, http.MethodPut, http.MethodPatch, http.MethodDelete, ""} {
+		name := unistring.String(strings.ToLower(m))
+		if m == "" {
+			name = "all"
+		}
+		o._putProp(name, r.newNativeFunc(r.httpServerMethod(m), nil, name, nil, 2), true, false, true)
+	}
+	o._putProp("ws", r.newNativeFunc(r.builtinHTTPServer_ws, nil, "ws", nil, 2), true, false, true)
+	o._putProp("static", r.newNativeFunc(r.builtinHTTPServer_static, nil, "static", nil, 2), true, false, true)
+	o._putProp("listen", r.newNativeFunc(r.builtinHTTPServer_listen, nil, "listen", nil, 1), true, false, true)
+	o._putProp("close", r.newNativeFunc(r.builtinHTTPServer_close, nil, "close", nil, 0), true, false, true)
+	o._putSym(SymToStringTag, valueProp(asciiString(classHTTPServer), false, false, true))
+	return o
+}
+
+func (r *Runtime) createHTTPServerRequestProto(val *Object) objectImpl {
+	o := newBaseObjectObj(val, r.global.ObjectPrototype, classObject)
+	o._putProp("text", r.newNativeFunc(r.builtinHTTPServerRequest_text, nil, "text", nil, 0), true, false, true)
+	o._putProp("json", r.newNativeFunc(r.builtinHTTPServerRequest_json, nil, "json", nil, 0), true, false, true)
+	o._putProp("bytes", r.newNativeFunc(r.builtinHTTPServerRequest_bytes, nil, "bytes", nil, 0), true, false, true)
+	o._putProp("header", r.newNativeFunc(r.builtinHTTPServerRequest_header, nil, "header", nil, 1), true, false, true)
+	o._putSym(SymToStringTag, valueProp(asciiString(classHTTPServerRequest), false, false, true))
+	return o
+}
+
+func (r *Runtime) createHTTPServerResponseProto(val *Object) objectImpl {
+	o := newBaseObjectObj(val, r.global.ObjectPrototype, classObject)
+	o._putProp("status", r.newNativeFunc(r.builtinHTTPServerResponse_status, nil, "status", nil, 1), true, false, true)
+	o._putProp("setHeader", r.newNativeFunc(r.builtinHTTPServerResponse_setHeader, nil, "setHeader", nil, 2), true, false, true)
+	o._putProp("getHeader", r.newNativeFunc(r.builtinHTTPServerResponse_getHeader, nil, "getHeader", nil, 1), true, false, true)
+	o._putProp("removeHeader", r.newNativeFunc(r.builtinHTTPServerResponse_removeHeader, nil, "removeHeader", nil, 1), true, false, true)
+	o._putProp("send", r.newNativeFunc(r.builtinHTTPServerResponse_send, nil, "send", nil, 1), true, false, true)
+	o._putProp("json", r.newNativeFunc(r.builtinHTTPServerResponse_json, nil, "json", nil, 1), true, false, true)
+	o._putProp("end", r.newNativeFunc(r.builtinHTTPServerResponse_end, nil, "end", nil, 0), true, false, true)
+	o._putProp("redirect", r.newNativeFunc(r.builtinHTTPServerResponse_redirect, nil, "redirect", nil, 1), true, false, true)
+	o._putProp("sendFile", r.newNativeFunc(r.builtinHTTPServerResponse_sendFile, nil, "sendFile", nil, 1), true, false, true)
+	o.setOwnStr("finished", &valueProperty{
+		getterFunc:   r.newNativeFunc(r.builtinHTTPServerResponse_getFinished, nil, "get finished", nil, 0),
+		accessor:     true,
+		configurable: true,
+	}, true)
+	o._putSym(SymToStringTag, valueProp(asciiString(classHTTPServerResponse), false, false, true))
+	return o
+}
@@ -0,0 +1,191 @@
+package goscript
+
+import (
+	c0 "context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// startScheduler sets a testScheduler whose jobs run on a goroutine of their own until the test ends. The returned
+// function runs fn among the jobs and waits for it, the Runtime must not be used otherwise.
+func startScheduler(t *testing.T, vm *Runtime) func(fn func()) {
+	s := &testScheduler{jobs: make(chan func(), 16)}
+	vm.SetScheduler(s)
+	stop := make(chan struct{})
+	go func() {
+		for {
+			select {
+			case job := <-s.jobs:
+				job()
+			case <-stop:
+				return
+			}
+		}
+	}()
+	t.Cleanup(func() { close(stop) })
+	return func(fn func()) {
+		done := make(chan struct{})
+		s.Post(func() {
+			defer close(done)
+			fn()
+		})
+		<-done
+	}
+}
+
+// runScript runs a script among the jobs of the scheduler.
+func runScript(run func(func()), vm *Runtime, src string) (v Value, err error) {
+	run(func() {
+		v, err = vm.RunString(src)
+	})
+	return
+}
+
+func TestHTTPServer(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "a.txt"), []byte("static file"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	vm := New()
+	run := startScheduler(t, vm)
+	_ = vm.Set("dir", dir)
+	_, err := runScript(run, vm, `
+	var errors = [];
+	var server = HTTP.createServer({maxBodySize: 64, onError: function (e, req) { errors.push(req.path + ": " + e.message); }},
+		function (req, res) {
+			res.status(404).send("no route for " + req.method + " " + req.path);
+		});
+	server.get("/users/:id", function (req, res) {
+		res.setHeader("X-Id", req.params.id).json({id: req.params.id, q: req.query.q, tags: req.query.tag});
+	});
+	server.post("/echo", function (req) {
+		return {type: req.headers["content-type"], body: req.json(), length: req.bytes().byteLength};
+	});
+	server.get("/async", async function (req) {
+		var v = await Promise.resolve(req.header("X-A"));
+		return "async " + v;
+	});
+	server.get("/throw", function () { throw new Error("boom"); });
+	server.get("/reject", async function () { throw new Error("rejected"); });
+	server.get("/redirect", function (req, res) { res.redirect("/users/1", 301); });
+	server.get("/file", function (req, res) { res.sendFile(dir + "/a.txt"); });
+	server.all("/files/*", function (req, res) { res.end(req.params["*"]); });
+	server.static("/assets", dir);
+	server.listen("127.0.0.1:0");
+	`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var base string
+	run(func() {
+		base = vm.Get("server").ToObject(vm).Get("url").String()
+	})
+	if !strings.HasPrefix(base, "http://127.0.0.1:") {
+		t.Fatalf("Unexpected url: %s", base)
+	}
+
+	client := &http.Client{CheckRedirect: func(*http.Request, []*http.Request) error { return http.ErrUseLastResponse }}
+	do := func(method, path, body string, header ...string) (int, http.Header, string) {
+		t.Helper()
+		req, _ := http.NewRequest(method, base+path, strings.NewReader(body))
+		for i := 0; i < len(header); i += 2 {
+			req.Header.Set(header[i], header[i+1])
+		}
+		resp, err := client.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer resp.Body.Close()
+		b, _ := io.ReadAll(resp.Body)
+		return resp.StatusCode, resp.Header, string(b)
+	}
+	check := func(method, path, body string, status int, expected string, header ...string) http.Header {
+		t.Helper()
+		sc, h, b := do(method, path, body, header...)
+		if sc != status || b != expected {
+			t.Fatalf("%s %s: expected %d %q, got %d %q", method, path, status, expected, sc, b)
+		}
+		return h
+	}
+
+	h := check("GET", "/users/42?q=x&tag=a&tag=b", "", 200, `{"id":"42","q":"x","tags":["a","b"]}`)
+	if h.Get("X-Id") != "42" || h.Get("Content-Type") != "application/json; charset=utf-8" {
+		t.Fatalf("Unexpected headers: %v", h)
+	}
+	check("POST", "/echo", `{"a":1}`, 200, `{"type":"application/json","body":{"a":1},"length":7}`, "Content-Type", "application/json")
+	check("POST", "/echo", strings.Repeat("x", 100), 413, "Request Entity Too Large\n")
+	check("GET", "/async", "", 200, "async v", "X-A", "v")
+	check("GET", "/throw", "", 500, "Internal Server Error")
+	check("GET", "/reject", "", 500, "Internal Server Error")
+	if h := check("GET", "/redirect", "", 301, ""); h.Get("Location") != "/users/1" {
+		t.Fatalf("Unexpected location: %s", h.Get("Location"))
+	}
+	check("GET", "/file", "", 200, "static file")
+	check("DELETE", "/files/a/b", "", 200, "a/b")
+	check("GET", "/assets/a.txt", "", 200, "static file")
+	if h := check("DELETE", "/users/1", "", 405, "Method Not Allowed\n"); h.Get("Allow") != "GET" {
+		t.Fatalf("Unexpected Allow: %s", h.Get("Allow"))
+	}
+	check("GET", "/other", "", 404, "no route for GET /other")
+
+	if _, err := runScript(run, vm, `
+	if (errors.join() !== "/throw: boom,/reject: rejected") {
+		throw new Error("Unexpected errors: " + errors.join());
+	}
+	`); err != nil {
+		t.Fatal(err)
+	}
+	if err := vm.ShutdownHTTPServers(c0.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := http.Get(base); err == nil {
+		t.Fatal("The server is still listening")
+	}
+}
+
+func TestMountHTTPHandler(t *testing.T) {
+	vm := New()
+	if _, err := vm.HTTPHandler(vm.ToValue(func() {})); err == nil {
+		t.Fatal("Expected an error without a scheduler")
+	}
+	run := startScheduler(t, vm)
+	handler, err := runScript(run, vm, `
+	(function (req, res) {
+		res.setHeader("Content-Type", "text/plain").send(req.path + " " + req.text());
+	})
+	`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	mux := http.NewServeMux()
+	run(func() {
+		err = vm.MountHTTPHandler(mux, "/script/", handler)
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	run(func() {
+		err = vm.MountHTTPHandler(mux, "/other", vm.ToValue(1))
+	})
+	if err == nil {
+		t.Fatal("Expected an error for a handler that is not a function")
+	}
+	srv := httptest.NewServer(mux)
+	defer srv.Close()
+
+	resp, err := http.Post(srv.URL+"/script/hook", "text/plain", strings.NewReader("payload"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, _ := io.ReadAll(resp.Body)
+	_ = resp.Body.Close()
+	if string(b) != "/hook payload" || resp.Header.Get("Content-Type") != "text/plain" {
+		t.Fatalf("Unexpected response: %q %v", b, resp.Header)
+	}
+}
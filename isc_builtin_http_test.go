@@ -0,0 +1,112 @@
+package goscript
+
+import (
+	"crypto/tls"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func TestHTTPRequest(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		switch req.URL.Path {
+		case "/echo":
+			body, _ := io.ReadAll(req.Body)
+			w.Header().Add("X-Multi", "a")
+			w.Header().Add("X-Multi", "b")
+			w.Header().Set("Content-Type", "application/json")
+			_, _ = io.WriteString(w, `{"method":"`+req.Method+`","query":"`+req.URL.RawQuery+`","type":"`+
+				strings.Split(req.Header.Get("Content-Type"), ";")[0]+`","types":`+strconv.Itoa(len(req.Header.Values("Content-Type")))+`,"token":"`+req.Header.Get("X-Token")+
+				`","length":`+strconv.Itoa(len(body))+`}`)
+		case "/form":
+			_ = req.ParseMultipartForm(1 << 20)
+			f, h, _ := req.FormFile("file")
+			content, _ := io.ReadAll(f)
+			_, _ = io.WriteString(w, req.FormValue("name")+"|"+h.Filename+"|"+string(content))
+		case "/redirect":
+			http.Redirect(w, req, "/echo", http.StatusFound)
+		case "/missing":
+			w.WriteHeader(http.StatusNotFound)
+			_, _ = io.WriteString(w, "no such thing")
+		}
+	}))
+	defer srv.Close()
+
+	vm := newWithTestLib(t)
+	_ = vm.Set("base", srv.URL)
+	_, err := vm.RunString(`
+	var res = HTTP.request({method: "post", url: base + "/echo", query: {a: [1, 2], b: "x y"},
+		headers: {"X-Token": "t"}, body: {hello: "world"}});
+	assert.sameValue(res.status, 200, "status");
+	assert.sameValue(res.ok, true, "ok");
+	var data = res.json();
+	assert.sameValue(data.method, "POST", "method");
+	assert.sameValue(data.query, "a=1&a=2&b=x+y", "query");
+	assert.sameValue(data.type, "application/json", "json type");
+	assert.sameValue(data.token, "t", "header");
+	assert.sameValue(data.length, 17, "json length");
+	assert.sameValue(res.headers["x-multi"].join(), "a,b", "multi-value header");
+	assert.sameValue(res.header("X-MULTI"), "a, b", "header()");
+	assert.sameValue(res.header("X-None"), null, "missing header");
+	assert.sameValue(res.text(), JSON.stringify(data), "text after json");
+	assert.sameValue(res.bytes().byteLength, res.text().length, "bytes");
+
+	data = HTTP.request(base + "/echo", {method: "PUT", headers: {"content-type": "text/xml"}, body: {a: 1}}).json();
+	assert.sameValue(data.type + " " + data.types, "text/xml 1", "lower-case content-type");
+	data = HTTP.request(base + "/echo", {method: "PUT", body: "plain"}).json();
+	assert.sameValue(data.type, "text/plain", "text type");
+	data = HTTP.request(base + "/echo", {method: "PUT", body: new Uint8Array([1, 2, 3])}).json();
+	assert.sameValue(data.type, "application/octet-stream", "bytes type");
+	assert.sameValue(data.length, 3, "bytes length");
+	data = HTTP.request(base + "/echo", {method: "PUT", body: {a: 1}, bodyType: "form"}).json();
+	assert.sameValue(data.type, "application/x-www-form-urlencoded", "form type");
+
+	res = HTTP.request({method: "POST", url: base + "/form", bodyType: "multipart",
+		body: {name: "n", file: {filename: "f.txt", content: "content"}}});
+	assert.sameValue(res.text(), "n|f.txt|content", "multipart");
+
+	res = HTTP.request(base + "/missing");
+	assert.sameValue(res.status, 404, "404");
+	assert.sameValue(res.ok, false, "404 ok");
+	assert.sameValue(res.text(), "no such thing", "404 body");
+
+	res = HTTP.request(base + "/redirect", {followRedirects: false});
+	assert.sameValue(res.status, 302, "no redirect");
+	assert.sameValue(res.header("Location"), "/echo", "location");
+	res = HTTP.request(base + "/redirect", {timeout: "5s"});
+	assert.sameValue(res.status, 200, "redirect");
+	assert.sameValue(res.url, base + "/echo", "final url");
+
+	res = HTTP.request(base + "/missing", {stream: true});
+	var chunks = [];
+	for (var chunk; (chunk = res.read(5)) !== null;) {
+		chunks.push(chunk.byteLength);
+	}
+	assert.sameValue(chunks.join(), "5,5,3", "stream");
+
+	var caught;
+	try {
+		HTTP.request("http://127.0.0.1:1/");
+	} catch (e) {
+		caught = e;
+	}
+	assert.sameValue(caught instanceof GoError, true, "network error");
+	`)
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestHTTPRequestTransport(t *testing.T) {
+	req := &httpRequest{followRedirects: true, tls: &tls.Config{InsecureSkipVerify: true}}
+	transport, ok := req.client(httpClient, nil).Transport.(*http.Transport)
+	if !ok || !transport.DisableKeepAlives || !transport.TLSClientConfig.InsecureSkipVerify {
+		t.Fatal("The transport of a request with its own TLS configuration must not keep its connections")
+	}
+	if httpClient.Transport != nil && httpClient.Transport.(*http.Transport).DisableKeepAlives {
+		t.Fatal("The shared transport was modified")
+	}
+}
@@ -0,0 +1,369 @@
+package goscript
+
+import (
+	c0 "context"
+	"fmt"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"net/textproto"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// multipart/form-data 的上传以及 HTTP.download 的流式下载
+
+// multipartPart is a field or a file of a multipart body. The content of a file is either data or the file at path,
+// which is read only when the body is sent.
+type multipartPart struct {
+	name, value string
+	file        bool
+	path        string
+	data        []byte
+	filename    string
+	contentType string
+}
+
+type httpMultipartObject struct {
+	baseObject
+	parts []multipartPart
+}
+
+func multipartEscape(s string) string {
+	return strings.NewReplacer("\\", "\\\\", `"`, "\\\"").Replace(s)
+}
+
+// writeMultipart writes the parts and closes w.
+func writeMultipart(w *multipart.Writer, parts []multipartPart) error {
+	for _, p := range parts {
+		if !p.file {
+			if err := w.WriteField(p.name, p.value); err != nil {
+				return err
+			}
+			continue
+		}
+		h := textproto.MIMEHeader{}
+		h.Set("Content-Disposition", fmt.Sprintf(`form-data; name="%s"; filename="%s"`, multipartEscape(p.name), multipartEscape(p.filename)))
+		h.Set("Content-Type", p.contentType)
+		part, err := w.CreatePart(h)
+		if err != nil {
+			return err
+		}
+		if p.path == "" {
+			_, err = part.Write(p.data)
+		} else {
+			err = copyFile(part, p.path)
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return w.Close()
+}
+
+func copyFile(w io.Writer, path string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	_, err = io.Copy(w, f)
+	return err
+}
+
+// setMultipart makes parts the body of the request.
+func (req *httpRequest) setMultipart(parts []multipartPart) {
+	w := multipart.NewWriter(io.Discard)
+	req.parts, req.boundary, req.contentType = parts, w.Boundary(), w.FormDataContentType()
+	if req.parts == nil {
+		req.parts = []multipartPart{}
+	}
+}
+
+// filePart converts the options {filename, contentType} of a file part. The file name defaults to the base name of
+// the path and the content type to the one of the file name extension.
+func (r *Runtime) filePart(name, path string, data []byte, opts Value) multipartPart {
+	p := multipartPart{name: name, file: true, path: path, data: data, filename: filepath.Base(path)}
+	if path == "" {
+		p.filename = name
+	} else if _, err := os.Stat(path); err != nil {
+		panic(r.NewGoError(err))
+	}
+	if opts != nil && !IsUndefined(opts) && !IsNull(opts) {
+		o := r.toObject(opts)
+		if v := httpOption(o, "filename"); v != nil {
+			p.filename = v.String()
+		}
+		if v := httpOption(o, "contentType"); v != nil {
+			p.contentType = v.String()
+		}
+	}
+	if p.contentType == "" {
+		p.contentType = mime.TypeByExtension(filepath.Ext(p.filename))
+	}
+	if p.contentType == "" {
+		p.contentType = "application/octet-stream"
+	}
+	return p
+}
+
+// multipartParts converts a multipart body: an HTTP.multipart() builder or an object whose values are fields. A field
+// is a string, an array of fields or a file, which is {path, filename, contentType} or {content, filename,
+// contentType} where content is a string or bytes.
+func (r *Runtime) multipartParts(o *Object) []multipartPart {
+	if m, ok := o.self.(*httpMultipartObject); ok {
+		return m.parts
+	}
+	var parts []multipartPart
+	var add func(name string, v Value)
+	add = func(name string, v Value) {
+		if v == nil || IsUndefined(v) || IsNull(v) {
+			return
+		}
+		f, ok := v.(*Object)
+		if !ok {
+			parts = append(parts, multipartPart{name: name, value: v.String()})
+			return
+		}
+		if f.self.className() == classArray {
+			for _, k := range f.Keys() {
+				add(name, f.Get(k))
+			}
+			return
+		}
+		if path := httpOption(f, "path"); path != nil {
+			parts = append(parts, r.filePart(name, path.String(), nil, f))
+			return
+		}
+		content := httpOption(f, "content")
+		if content == nil {
+			panic(r.NewTypeError("The multipart field %s has neither a path nor content", name))
+		}
+		data, ok := r.httpBytes(content)
+		if !ok {
+			panic(r.NewTypeError("The content of the multipart field %s must be a string or bytes", name))
+		}
+		parts = append(parts, r.filePart(name, "", data, f))
+	}
+	for _, k := range o.Keys() {
+		add(k, o.Get(k))
+	}
+	return parts
+}
+
+func (r *Runtime) toHttpMultipart(v Value, method string) *httpMultipartObject {
+	thisObj := r.toObject(v)
+	m, ok := thisObj.self.(*httpMultipartObject)
+	if !ok {
+		panic(r.NewTypeError("Method HTTPMultipart.prototype.%s called on incompatible receiver %s", method, r.objectproto_toString(FunctionCall{This: thisObj})))
+	}
+	return m
+}
+
+// builtinHTTPMultipart_field adds a field: field(name, value).
+func (r *Runtime) builtinHTTPMultipart_field(call FunctionCall) Value {
+	m := r.toHttpMultipart(call.This, "field")
+	m.parts = append(m.parts, multipartPart{name: call.Argument(0).String(), value: call.Argument(1).String()})
+	return m.val
+}
+
+// builtinHTTPMultipart_file adds the file at a path, which is read when the body is sent: file(name, path, {filename,
+// contentType}).
+func (r *Runtime) builtinHTTPMultipart_file(call FunctionCall) Value {
+	m := r.toHttpMultipart(call.This, "file")
+	m.parts = append(m.parts, r.filePart(call.Argument(0).String(), call.Argument(1).String(), nil, call.Argument(2)))
+	return m.val
+}
+
+// builtinHTTPMultipart_bytes adds a file with the given content, an ArrayBuffer, a typed array or a string:
+// bytes(name, data, {filename, contentType}).
+func (r *Runtime) builtinHTTPMultipart_bytes(call FunctionCall) Value {
+	m := r.toHttpMultipart(call.This, "bytes")
+	data, ok := r.httpBytes(call.Argument(1))
+	if !ok {
+		panic(r.NewTypeError("The content must be an ArrayBuffer, a typed array, a DataView or a string"))
+	}
+	name := call.Argument(0).String()
+	m.parts = append(m.parts, r.filePart(name, "", append([]byte(nil), data...), call.Argument(2)))
+	return m.val
+}
+
+// builtinHTTP_multipart creates an empty multipart body builder.
+func (r *Runtime) builtinHTTP_multipart(FunctionCall) Value {
+	o := &Object{runtime: r}
+	m := &httpMultipartObject{
+		baseObject: baseObject{class: classHTTPMultipart, val: o, prototype: r.global.HTTPMultipartPrototype, extensible: true, values: nil},
+	}
+	o.self = m
+	m.init()
+	return o
+}
+
+// builtinHTTP_postMultipart is the multipart counterpart of HTTP.postForm: postMultipart(url, header, params, body),
+// where body is an HTTP.multipart() builder or an object of fields.
+func (r *Runtime) builtinHTTP_postMultipart(call FunctionCall) Value {
+	u, h, p, _ := parseHttpParams(call)
+	req := &httpRequest{method: http.MethodPost, url: urlWithParameter(u, p), header: http.Header{}, followRedirects: true}
+	// canonicalized like the headers of HTTP.request, so that a header of the script replaces the default one
+	for k, list := range h {
+		for _, s := range list {
+			req.header.Add(k, s)
+		}
+	}
+	if body := call.Argument(3); !IsUndefined(body) && !IsNull(body) {
+		req.setMultipart(r.multipartParts(r.toObject(body)))
+	} else {
+		req.setMultipart(nil)
+	}
+	resp, err := req.do(c0.Background(), httpClient)
+	sc, hd, body, err := doParseResponse(resp, err)
+	return parseHttpResult(r, sc, hd, body, err)
+}
+
+// HTTP.download
+
+// downloadProgressInterval is the minimum interval between two calls of the onProgress callback of HTTP.download.
+const downloadProgressInterval = 100 * time.Millisecond
+
+// builtinHTTP_download streams a response to a file: download(url, path, opts). The options are the ones of
+// HTTP.request plus resume, which continues an incomplete file with a Range request, and onProgress, which is called
+// with the number of bytes of the file and the expected total (-1 if unknown). It returns {status, path, size,
+// resumed}. A response that is not 2xx is an error, the file is not touched. So is a resumed download whose range does
+// not start at the end of the file, or whose file is not the size of the resource when the range is not satisfiable.
+func (r *Runtime) builtinHTTP_download(call FunctionCall) Value {
+	return r.downloadWith(nil, call)
+}
+
+// contentRange parses a Content-Range header, "bytes first-last/length" or "bytes */length". first is -1 for the
+// latter or an invalid header, length is -1 if it is unknown.
+func contentRange(s string) (first, length int64) {
+	first, length = -1, -1
+	s = strings.TrimPrefix(s, "bytes ")
+	i := strings.IndexByte(s, '/')
+	if i < 0 {
+		return
+	}
+	if n, err := strconv.ParseInt(s[i+1:], 10, 64); err == nil {
+		length = n
+	}
+	if j := strings.IndexByte(s[:i], '-'); j >= 0 {
+		if n, err := strconv.ParseInt(s[:j], 10, 64); err == nil {
+			first = n
+		}
+	}
+	return
+}
+
+// downloadWith implements HTTP.download with the client hc, or the shared client if hc is nil.
+func (r *Runtime) downloadWith(hc *httpClientObject, call FunctionCall) Value {
+	req := &httpRequest{method: http.MethodGet, url: call.Argument(0).String(), header: http.Header{}, followRedirects: true}
+	path := call.Argument(1).String()
+	resume := false
+	var onProgress func(FunctionCall) Value
+	if opts := call.Argument(2); !IsUndefined(opts) && !IsNull(opts) {
+		o := r.toObject(opts)
+		r.httpRequestOptions(req, o)
+		if v := httpOption(o, "resume"); v != nil {
+			resume = v.ToBoolean()
+		}
+		if v := httpOption(o, "onProgress"); v != nil {
+			onProgress = r.toCallable(v)
+		}
+	}
+
+	var offset int64
+	if resume {
+		if fi, err := os.Stat(path); err == nil && fi.Size() > 0 {
+			offset = fi.Size()
+			req.header.Set("Range", fmt.Sprintf("bytes=%d-", offset))
+		}
+	}
+	resp, err := req.do(c0.Background(), hc.clientFor(req))
+	if err != nil {
+		panic(r.NewGoError(err))
+	}
+	defer resp.Body.Close()
+
+	result := func(size int64, resumed bool) Value {
+		return r.ToValue(map[string]any{
+			"status":  resp.StatusCode,
+			"path":    path,
+			"size":    size,
+			"resumed": resumed,
+		})
+	}
+	flag := os.O_WRONLY | os.O_CREATE | os.O_TRUNC
+	first, length := contentRange(resp.Header.Get("Content-Range"))
+	switch {
+	case offset > 0 && resp.StatusCode == http.StatusRequestedRangeNotSatisfiable && length == offset:
+		// the file is already complete
+		return result(offset, true)
+	case offset > 0 && resp.StatusCode == http.StatusPartialContent:
+		if first != offset {
+			panic(r.NewGoError(&NetError{ErrMsg: fmt.Sprintf("download failed: the range starts at %d instead of %d",
+				first, offset)}))
+		}
+		flag = os.O_WRONLY | os.O_APPEND
+	case resp.StatusCode >= 200 && resp.StatusCode < 300:
+		// the server does not support ranges, start over
+		offset = 0
+	default:
+		panic(r.NewGoError(&NetError{ErrMsg: "download failed: " + resp.Status}))
+	}
+
+	total := int64(-1)
+	if resp.ContentLength >= 0 {
+		total = offset + resp.ContentLength
+	}
+	f, err := os.OpenFile(path, flag, 0644)
+	if err != nil {
+		panic(r.NewGoError(err))
+	}
+	defer f.Close()
+
+	size := offset
+	progress := func() {
+		if onProgress != nil {
+			onProgress(FunctionCall{Arguments: []Value{intToValue(size), intToValue(total)}})
+		}
+	}
+	var last time.Time
+	buf := make([]byte, 32*1024)
+	for {
+		n, err := resp.Body.Read(buf)
+		if n > 0 {
+			if _, werr := f.Write(buf[:n]); werr != nil {
+				panic(r.NewGoError(werr))
+			}
+			size += int64(n)
+			if now := time.Now(); now.Sub(last) >= downloadProgressInterval {
+				last = now
+				progress()
+			}
+		}
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			panic(r.NewGoError(err))
+		}
+	}
+	if err := f.Close(); err != nil {
+		panic(r.NewGoError(err))
+	}
+	progress()
+	return result(size, resp.StatusCode == http.StatusPartialContent)
+}
+
+func (r *Runtime) createHTTPMultipartProto(val *Object) objectImpl {
+	o := newBaseObjectObj(val, r.global.ObjectPrototype, classObject)
+	o._putProp("field", r.newNativeFunc(r.builtinHTTPMultipart_field, nil, "field", nil, 2), true, false, true)
+	o._putProp("file", r.newNativeFunc(r.builtinHTTPMultipart_file, nil, "file", nil, 3), true, false, true)
+	o._putProp("bytes", r.newNativeFunc(r.builtinHTTPMultipart_bytes, nil, "bytes", nil, 3), true, false, true)
+	o._putSym(SymToStringTag, valueProp(asciiString(classHTTPMultipart), false, false, true))
+	return o
+}
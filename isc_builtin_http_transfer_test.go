@@ -0,0 +1,226 @@
+package goscript
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestHTTPMultipart(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		if req.URL.Path == "/types" {
+			_, _ = io.WriteString(w, strings.Join(req.Header.Values("Content-Type"), ","))
+			return
+		}
+		if err := req.ParseMultipartForm(1 << 20); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		var s []string
+		for _, name := range []string{"name", "tag"} {
+			s = append(s, name+"="+strings.Join(req.MultipartForm.Value[name], ","))
+		}
+		for _, name := range []string{"doc", "raw"} {
+			for _, h := range req.MultipartForm.File[name] {
+				f, _ := h.Open()
+				content, _ := io.ReadAll(f)
+				s = append(s, name+"="+h.Filename+":"+h.Header.Get("Content-Type")+":"+string(content))
+			}
+		}
+		_, _ = io.WriteString(w, strings.Join(s, "|"))
+	}))
+	defer srv.Close()
+
+	dir := t.TempDir()
+	doc := filepath.Join(dir, "doc.txt")
+	if err := os.WriteFile(doc, []byte("from file"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	vm := newWithTestLib(t)
+	_ = vm.Set("base", srv.URL)
+	_ = vm.Set("doc", doc)
+	_, err := vm.RunString(`
+	var buf = new Uint8Array([104, 105]).buffer;
+	var form = HTTP.multipart().field("name", "n").field("tag", "a").field("tag", "b")
+		.file("doc", doc).bytes("raw", buf, {filename: "raw.bin"});
+	assert.sameValue(Object.prototype.toString.call(form), "[object HTTPMultipart]", "toStringTag");
+	var expected = "name=n|tag=a,b|doc=doc.txt:text/plain; charset=utf-8:from file|raw=raw.bin:application/octet-stream:hi";
+	assert.sameValue(HTTP.request({method: "POST", url: base, body: form}).text(), expected, "builder");
+
+	var res = HTTP.request({method: "POST", url: base, bodyType: "multipart", body: {
+		name: "n", tag: ["a", "b"],
+		doc: {path: doc, contentType: "text/plain; charset=utf-8"},
+		raw: {content: buf, filename: "raw.bin"},
+	}});
+	assert.sameValue(res.text(), expected, "object");
+
+	var legacy = HTTP.postMultipart(base, null, null, form);
+	assert.sameValue(legacy.statusCode, 200, "postMultipart status");
+	assert.sameValue(legacy.text, expected, "postMultipart body");
+	legacy = HTTP.postMultipart(base + "/types", {"content-type": "multipart/mixed"}, null, form);
+	assert.sameValue(legacy.text, "multipart/mixed", "postMultipart header replaced");
+
+	var threw = false;
+	try {
+		HTTP.multipart().file("doc", doc + ".missing");
+	} catch (e) {
+		threw = true;
+	}
+	assert.sameValue(threw, true, "missing file");
+	`)
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestHTTPDownload(t *testing.T) {
+	content := bytes.Repeat([]byte("0123456789"), 10000)
+	var ranges []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		switch req.URL.Path {
+		case "/file":
+			ranges = append(ranges, req.Header.Get("Range"))
+			http.ServeContent(w, req, "file", time.Time{}, bytes.NewReader(content))
+		case "/plain":
+			_, _ = w.Write(content)
+		case "/shifted":
+			// a range that does not start where it was requested
+			w.Header().Set("Content-Range", fmt.Sprintf("bytes 10-%d/%d", len(content)-1, len(content)))
+			w.WriteHeader(http.StatusPartialContent)
+			_, _ = w.Write(content[10:])
+		default:
+			http.NotFound(w, req)
+		}
+	}))
+	defer srv.Close()
+
+	dir := t.TempDir()
+	path := filepath.Join(dir, "file")
+	vm := newWithTestLib(t)
+	_ = vm.Set("base", srv.URL)
+	_ = vm.Set("path", path)
+	run := func(src string) {
+		t.Helper()
+		if _, err := vm.RunString(src); err != nil {
+			t.Fatal(err)
+		}
+	}
+	check := func() {
+		t.Helper()
+		b, err := os.ReadFile(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(b, content) {
+			t.Fatalf("Unexpected content of %d bytes", len(b))
+		}
+	}
+	run(`
+	var progress = [];
+	var res = HTTP.download(base + "/file", path, {onProgress: function (received, total) {
+		progress.push([received, total]);
+	}});
+	assert.sameValue(res.status, 200, "status");
+	assert.sameValue(res.size, 100000, "size");
+	assert.sameValue(res.resumed, false, "resumed");
+	assert.sameValue(res.path, path, "path");
+	var last = progress[progress.length - 1];
+	assert.sameValue(last[0], 100000, "progress received");
+	assert.sameValue(last[1], 100000, "progress total");
+	`)
+	check()
+
+	// resume a truncated file
+	if err := os.Truncate(path, 40000); err != nil {
+		t.Fatal(err)
+	}
+	run(`
+	var totals = [];
+	res = HTTP.download(base + "/file", path, {resume: true, onProgress: function (received, total) {
+		totals.push(total);
+	}});
+	assert.sameValue(res.status, 206, "resume status");
+	assert.sameValue(res.size, 100000, "resume size");
+	assert.sameValue(res.resumed, true, "resume resumed");
+	assert.sameValue(totals[0], 100000, "resume total");
+	`)
+	check()
+	if r := ranges[len(ranges)-1]; r != "bytes=40000-" {
+		t.Fatalf("Unexpected range: %q", r)
+	}
+
+	// the file is complete
+	run(`
+	res = HTTP.download(base + "/file", path, {resume: true});
+	assert.sameValue(res.status, 416, "complete status");
+	assert.sameValue(res.size, 100000, "complete size");
+	`)
+	check()
+
+	// the server ignores the range, the file is written again
+	if err := os.Truncate(path, 10); err != nil {
+		t.Fatal(err)
+	}
+	run(`
+	res = HTTP.download(base + "/plain", path, {resume: true});
+	assert.sameValue(res.status, 200, "restart status");
+	assert.sameValue(res.resumed, false, "restart resumed");
+	`)
+	check()
+
+	// the file is not changed when the server returns another range, or when it is larger than the resource
+	if err := os.Truncate(path, 40000); err != nil {
+		t.Fatal(err)
+	}
+	run(`
+	var threw = false;
+	try {
+		HTTP.download(base + "/shifted", path, {resume: true});
+	} catch (e) {
+		threw = true;
+	}
+	assert.sameValue(threw, true, "shifted range");
+	`)
+	if fi, err := os.Stat(path); err != nil || fi.Size() != 40000 {
+		t.Fatalf("The file was changed: %v", err)
+	}
+	run(`HTTP.download(base + "/file", path)`)
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, _ = f.WriteString("extra")
+	_ = f.Close()
+	run(`
+	threw = false;
+	try {
+		HTTP.download(base + "/file", path, {resume: true});
+	} catch (e) {
+		threw = true;
+	}
+	assert.sameValue(threw, true, "larger file");
+	`)
+	if fi, err := os.Stat(path); err != nil || fi.Size() != int64(len(content))+5 {
+		t.Fatalf("The file was changed: %v", err)
+	}
+	run(`HTTP.download(base + "/file", path)`)
+
+	run(`
+	var threw = false;
+	try {
+		HTTP.download(base + "/missing", path);
+	} catch (e) {
+		threw = true;
+	}
+	assert.sameValue(threw, true, "404");
+	`)
+	check()
+}
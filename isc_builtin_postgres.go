@@ -0,0 +1,294 @@
+package goscript
+
+import (
+	"github.com/lib/pq"
+	"github.com/rarnu/goscript/unistring"
+	"net"
+	"net/url"
+	"strconv"
+	"time"
+)
+
+// Postgres 除了 Database 的方法之外，还支持 LISTEN/NOTIFY 与 COPY FROM
+
+// postgresObject is a connection pool to PostgreSQL. The listener of LISTEN/NOTIFY uses a connection of its own,
+// which is opened by the first listen and closed when there are no more channels.
+type postgresObject struct {
+	databaseObject
+	dsn string
+
+	listener  *pq.Listener
+	callbacks map[string][]Value
+	done      func(func()) // completes the operation that keeps the scheduler running while listening
+}
+
+func (r *Runtime) toPostgres(method string, call FunctionCall) *postgresObject {
+	thisObj := r.toObject(call.This)
+	po, ok := thisObj.self.(*postgresObject)
+	if !ok {
+		panic(r.NewTypeError("Method Postgres.prototype.%s called on incompatible receiver %s", method, r.objectproto_toString(FunctionCall{This: thisObj})))
+	}
+	return po
+}
+
+// startListener connects the listener, it waits for the connection so that the notifications sent after listen
+// returns are received.
+func (po *postgresObject) startListener() {
+	r := po.val.runtime
+	connected := make(chan error, 1)
+	l := pq.NewListener(po.dsn, 100*time.Millisecond, 10*time.Second, func(ev pq.ListenerEventType, err error) {
+		select {
+		case connected <- err:
+		default:
+		}
+	})
+	if err := <-connected; err != nil {
+		_ = l.Close()
+		panic(r.NewGoError(err))
+	}
+	po.listener = l
+	po.callbacks = make(map[string][]Value)
+	po.done = r.scheduler.Schedule()
+	go func() {
+		// Notify is closed by Close. A nil notification is sent after a reconnection, as notifications may have been
+		// lost in the meantime.
+		for n := range l.Notify {
+			if n != nil {
+				n := n
+				r.scheduler.Post(func() {
+					po.dispatch(n)
+				})
+			}
+		}
+	}()
+}
+
+func (po *postgresObject) stopListener() {
+	if po.listener == nil {
+		return
+	}
+	_ = po.listener.Close()
+	po.listener, po.callbacks = nil, nil
+	po.done(func() {})
+	po.done = nil
+}
+
+// dispatch calls the callbacks of the channel of a notification with {channel, payload, pid}. As for the events of
+// WebSocket, the exceptions of the callbacks are ignored.
+func (po *postgresObject) dispatch(n *pq.Notification) {
+	r := po.val.runtime
+	event := r.NewObject()
+	_ = event.Set("channel", n.Channel)
+	_ = event.Set("payload", n.Extra)
+	_ = event.Set("pid", n.BePid)
+	for _, callback := range append([]Value(nil), po.callbacks[n.Channel]...) {
+		if fn, ok := AssertFunction(callback); ok {
+			_, _ = fn(po.val, event)
+		}
+	}
+}
+
+// builtinPostgres_listen is listen(channel, callback). The callback is called on the event loop for each
+// notification of the channel.
+func (r *Runtime) builtinPostgres_listen(call FunctionCall) Value {
+	po := r.toPostgres("listen", call)
+	if r.scheduler == nil {
+		panic(r.NewTypeError("Postgres.prototype.listen requires an event loop"))
+	}
+	channel := call.Argument(0).String()
+	callback := call.Argument(1)
+	if _, ok := AssertFunction(callback); !ok {
+		panic(r.NewTypeError("The callback must be a function"))
+	}
+	if po.listener == nil {
+		po.startListener()
+	}
+	if _, ok := po.callbacks[channel]; !ok {
+		if err := po.listener.Listen(channel); err != nil {
+			if len(po.callbacks) == 0 {
+				po.stopListener()
+			}
+			panic(r.NewGoError(err))
+		}
+	}
+	po.callbacks[channel] = append(po.callbacks[channel], callback)
+	return _undefined
+}
+
+// builtinPostgres_unlisten is unlisten(channel, callback). Without a callback, all the callbacks of the channel are
+// removed.
+func (r *Runtime) builtinPostgres_unlisten(call FunctionCall) Value {
+	po := r.toPostgres("unlisten", call)
+	channel := call.Argument(0).String()
+	callbacks, ok := po.callbacks[channel]
+	if !ok {
+		return _undefined
+	}
+	if callback := call.Argument(1); callback != _undefined {
+		for i, cb := range callbacks {
+			if cb.SameAs(callback) {
+				callbacks = append(callbacks[:i:i], callbacks[i+1:]...)
+				break
+			}
+		}
+	} else {
+		callbacks = nil
+	}
+	if len(callbacks) > 0 {
+		po.callbacks[channel] = callbacks
+		return _undefined
+	}
+	delete(po.callbacks, channel)
+	if len(po.callbacks) == 0 {
+		po.stopListener()
+	} else if err := po.listener.Unlisten(channel); err != nil {
+		panic(r.NewGoError(err))
+	}
+	return _undefined
+}
+
+// builtinPostgres_notify is notify(channel, payload).
+func (r *Runtime) builtinPostgres_notify(call FunctionCall) Value {
+	po := r.toPostgres("notify", call)
+	if _, err := po.db.Exec("SELECT pg_notify($1, $2)", call.Argument(0).String(), call.Argument(1).String()); err != nil {
+		panic(r.NewGoError(err))
+	}
+	return _undefined
+}
+
+// builtinPostgres_copyFrom is copyFrom(table, columns, rows). It loads the rows with COPY FROM STDIN in a
+// transaction and returns their number. A row is an array of values in the order of the columns or an object keyed
+// by the names of the columns.
+func (r *Runtime) builtinPostgres_copyFrom(call FunctionCall) Value {
+	po := r.toPostgres("copyFrom", call)
+	table := call.Argument(0).String()
+	columnsObj := r.toObject(call.Argument(1))
+	columns := make([]string, toLength(columnsObj.self.getStr("length", nil)))
+	for i := range columns {
+		columns[i] = nilSafe(columnsObj.self.getIdx(valueInt(i), nil)).String()
+	}
+	rowsObj := r.toObject(call.Argument(2))
+	n := toLength(rowsObj.self.getStr("length", nil))
+
+	tx, err := po.db.Begin()
+	if err != nil {
+		panic(r.NewGoError(err))
+	}
+	fail := func(err error) {
+		_ = tx.Rollback()
+		panic(r.NewGoError(err))
+	}
+	stmt, err := tx.Prepare(pq.CopyIn(table, columns...))
+	if err != nil {
+		fail(err)
+	}
+	values := make([]Value, len(columns))
+	for i := int64(0); i < n; i++ {
+		row := r.toObject(nilSafe(rowsObj.self.getIdx(valueInt(i), nil)))
+		for j, column := range columns {
+			if isArray(row) {
+				values[j] = nilSafe(row.self.getIdx(valueInt(j), nil))
+			} else {
+				values[j] = nilSafe(row.self.getStr(unistring.NewFromString(column), nil))
+			}
+		}
+		if _, err := stmt.Exec(r.sqlParams(values)...); err != nil {
+			_ = stmt.Close()
+			fail(err)
+		}
+	}
+	if _, err := stmt.Exec(); err != nil {
+		_ = stmt.Close()
+		fail(err)
+	}
+	if err := stmt.Close(); err != nil {
+		fail(err)
+	}
+	if err := tx.Commit(); err != nil {
+		panic(r.NewGoError(err))
+	}
+	return intToValue(n)
+}
+
+func (r *Runtime) builtinPostgres_close(call FunctionCall) Value {
+	po := r.toPostgres("close", call)
+	po.stopListener()
+	_ = po.db.Close()
+	return _undefined
+}
+
+// builtin_newPostgres is new Postgres(host, port, user, password, dbname, options). In addition to the options of
+// Database, sslmode is the SSL mode of lib/pq, "disable" by default.
+func (r *Runtime) builtin_newPostgres(args []Value, newTarget *Object) *Object {
+	if newTarget == nil {
+		panic(r.needNew("Postgres"))
+	}
+	if len(args) < 5 {
+		panic("number of arguments must be at least 5")
+	}
+
+	// 连接数据库
+	_host := args[0].toString().String()
+	_port := args[1].ToInteger()
+	_user := args[2].toString().String()
+	_password := args[3].toString().String()
+	_dbname := args[4].toString().String()
+	sslmode := "disable"
+	if len(args) > 5 {
+		if opts, ok := args[5].(*Object); ok {
+			if v := opts.self.getStr("sslmode", nil); v != nil && v != _undefined {
+				sslmode = v.String()
+			}
+		}
+	}
+	u := url.URL{
+		Scheme:   "postgres",
+		User:     url.UserPassword(_user, _password),
+		Host:     net.JoinHostPort(_host, strconv.FormatInt(_port, 10)),
+		Path:     "/" + _dbname,
+		RawQuery: url.Values{"sslmode": {sslmode}}.Encode(),
+	}
+	dsn := u.String()
+
+	proto := r.getPrototypeFromCtor(newTarget, r.global.Postgres, r.global.PostgresPrototype)
+	o := &Object{runtime: r}
+	po := &postgresObject{
+		databaseObject: databaseObject{
+			baseObject: baseObject{
+				class:      classPostgres,
+				val:        o,
+				prototype:  proto,
+				extensible: true,
+				values:     nil,
+			},
+		},
+		dsn: dsn,
+	}
+	r.openDatabase(&po.databaseObject, "postgres", dsn, args[5:])
+	o.self = po
+	po.init()
+	return o
+}
+
+func (r *Runtime) createPostgresProto(val *Object) objectImpl {
+	o := newBaseObjectObj(val, r.global.DatabasePrototype, classObject)
+	o._putProp("constructor", r.global.Postgres, true, false, true)
+	o._putProp("close", r.newNativeFunc(r.builtinPostgres_close, nil, "close", nil, 0), true, false, true)
+	o._putProp("listen", r.newNativeFunc(r.builtinPostgres_listen, nil, "listen", nil, 2), true, false, true)
+	o._putProp("unlisten", r.newNativeFunc(r.builtinPostgres_unlisten, nil, "unlisten", nil, 1), true, false, true)
+	o._putProp("notify", r.newNativeFunc(r.builtinPostgres_notify, nil, "notify", nil, 2), true, false, true)
+	o._putProp("copyFrom", r.newNativeFunc(r.builtinPostgres_copyFrom, nil, "copyFrom", nil, 3), true, false, true)
+	o._putSym(SymToStringTag, valueProp(asciiString(classPostgres), false, false, true))
+	return o
+}
+
+func (r *Runtime) createPostgres(val *Object) objectImpl {
+	o := r.newNativeConstructOnly(val, r.builtin_newPostgres, r.global.PostgresPrototype, "Postgres", 5)
+	return o
+}
+
+func (r *Runtime) initPostgres() {
+	r.global.PostgresPrototype = r.newLazyObject(r.createPostgresProto)
+	r.global.Postgres = r.newLazyObject(r.createPostgres)
+	r.addToGlobal("Postgres", r.global.Postgres)
+}
@@ -0,0 +1,722 @@
+package goscript
+
+import (
+	"bufio"
+	c0 "context"
+	"database/sql"
+	"encoding/binary"
+	h0 "encoding/hex"
+	"fmt"
+	"io"
+	"net"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// pgFake is a PostgreSQL server at the protocol level, which runs the statements on SQLite. It supports what lib/pq
+// uses: the simple and the extended query protocols, COPY FROM STDIN and LISTEN/NOTIFY.
+type pgFake struct {
+	ln net.Listener
+	db *sql.DB
+
+	mu        sync.Mutex
+	listeners map[string]map[*pgFakeConn]struct{}
+	pid       int32
+}
+
+type pgFakeConn struct {
+	fake *pgFake
+	pid  int32
+	conn net.Conn
+	in   *bufio.Reader
+	sql  *sql.Conn
+
+	wmu sync.Mutex // notifications are sent from the goroutines of other connections
+	tx  byte       // transaction status of ReadyForQuery
+
+	// the unnamed statement and portal of the extended protocol
+	query   string
+	columns []pgColumn
+	params  []any
+	formats []int16
+	failed  bool // an error occurred, the messages are ignored until Sync
+}
+
+type pgColumn struct {
+	name string
+	oid  int32
+}
+
+var (
+	pgParamRe  = regexp.MustCompile(`\$(\d+)`)
+	pgCopyRe   = regexp.MustCompile(`(?i)^COPY\s+"?(\w+)"?\s*\(([^)]*)\)\s+FROM\s+STDIN`)
+	pgListenRe = regexp.MustCompile(`(?i)^(UN)?LISTEN\s+"?(\w+)"?$`)
+	pgNotifyRe = regexp.MustCompile(`(?i)^SELECT\s+pg_notify\(\$1,\s*\$2\)$`)
+)
+
+func newPgFake(t *testing.T) *pgFake {
+	db, err := sql.Open("sqlite", "file:"+filepath.Join(t.TempDir(), "pg.db")+"?_pragma=busy_timeout(5000)")
+	if err != nil {
+		t.Fatal(err)
+	}
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	f := &pgFake{ln: ln, db: db, listeners: make(map[string]map[*pgFakeConn]struct{})}
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			go f.serve(conn)
+		}
+	}()
+	t.Cleanup(func() {
+		_ = ln.Close()
+		_ = db.Close()
+	})
+	return f
+}
+
+func (f *pgFake) port() int {
+	return f.ln.Addr().(*net.TCPAddr).Port
+}
+
+func (f *pgFake) notify(channel, payload string, from int32) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	for c := range f.listeners[channel] {
+		c.send('A', pgInt32(from), pgString(channel), pgString(payload))
+	}
+}
+
+func (f *pgFake) serve(conn net.Conn) {
+	c := &pgFakeConn{fake: f, conn: conn, in: bufio.NewReader(conn), tx: 'I'}
+	defer func() {
+		f.mu.Lock()
+		for _, conns := range f.listeners {
+			delete(conns, c)
+		}
+		f.mu.Unlock()
+		_ = conn.Close()
+	}()
+	for {
+		var n int32
+		if binary.Read(c.in, binary.BigEndian, &n) != nil {
+			return
+		}
+		msg := make([]byte, n-4)
+		if _, err := io.ReadFull(c.in, msg); err != nil {
+			return
+		}
+		if binary.BigEndian.Uint32(msg) != 80877103 { // not an SSLRequest
+			break
+		}
+		_, _ = conn.Write([]byte{'N'})
+	}
+	var err error
+	if c.sql, err = f.db.Conn(c0.Background()); err != nil {
+		return
+	}
+	defer c.sql.Close()
+	f.mu.Lock()
+	f.pid++
+	c.pid = f.pid
+	f.mu.Unlock()
+	c.send('R', pgInt32(0))
+	c.send('S', pgString("server_version"), pgString("14.0"))
+	c.send('K', pgInt32(c.pid), pgInt32(0))
+	c.ready()
+
+	for {
+		typ, err := c.in.ReadByte()
+		if err != nil {
+			return
+		}
+		var n int32
+		if binary.Read(c.in, binary.BigEndian, &n) != nil {
+			return
+		}
+		msg := make([]byte, n-4)
+		if _, err := io.ReadFull(c.in, msg); err != nil {
+			return
+		}
+		if c.failed && typ != 'S' {
+			continue
+		}
+		switch typ {
+		case 'Q':
+			c.simpleQuery(strings.TrimSuffix(string(msg), "\x00"))
+		case 'P':
+			parts := strings.SplitN(string(msg), "\x00", 3)
+			c.query = parts[1]
+			c.send('1')
+		case 'D':
+			c.describe()
+		case 'B':
+			c.bind(msg)
+		case 'E':
+			c.execute()
+		case 'C':
+			c.send('3')
+		case 'S':
+			c.failed = false
+			c.ready()
+		case 'H':
+		case 'X':
+			return
+		}
+	}
+}
+
+func (c *pgFakeConn) send(typ byte, parts ...[]byte) {
+	size := 4
+	for _, p := range parts {
+		size += len(p)
+	}
+	buf := append(make([]byte, 0, size+1), typ)
+	buf = append(buf, pgInt32(int32(size))...)
+	for _, p := range parts {
+		buf = append(buf, p...)
+	}
+	c.wmu.Lock()
+	_, _ = c.conn.Write(buf)
+	c.wmu.Unlock()
+}
+
+func (c *pgFakeConn) ready() {
+	c.send('Z', []byte{c.tx})
+}
+
+func (c *pgFakeConn) fail(err error) {
+	code := "XX000"
+	if strings.Contains(err.Error(), "no such table") {
+		code = "42P01"
+	}
+	c.send('E', []byte("SERROR\x00"), []byte("C"+code+"\x00"), []byte("M"+err.Error()+"\x00"), []byte{0})
+	c.failed = true
+}
+
+func (c *pgFakeConn) simpleQuery(q string) {
+	c.query, c.params, c.formats = q, nil, nil
+	defer c.ready()
+	if m := pgCopyRe.FindStringSubmatch(q); m != nil {
+		c.copyIn(m[1], strings.Split(strings.ReplaceAll(m[2], `"`, ""), ","))
+		return
+	}
+	if m := pgListenRe.FindStringSubmatch(q); m != nil {
+		f := c.fake
+		f.mu.Lock()
+		if m[1] == "" {
+			if f.listeners[m[2]] == nil {
+				f.listeners[m[2]] = make(map[*pgFakeConn]struct{})
+			}
+			f.listeners[m[2]][c] = struct{}{}
+		} else {
+			delete(f.listeners[m[2]], c)
+		}
+		f.mu.Unlock()
+		c.send('C', pgString(strings.ToUpper(m[1])+"LISTEN"))
+		return
+	}
+	c.columns = c.describeColumns()
+	if c.failed {
+		c.failed = false
+		return
+	}
+	if len(c.columns) > 0 {
+		c.send('T', c.rowDescription()...)
+	}
+	c.execute()
+	c.failed = false
+}
+
+func (c *pgFakeConn) describe() {
+	var params []byte
+	n := 0
+	for _, m := range pgParamRe.FindAllStringSubmatch(c.query, -1) {
+		if i, _ := strconv.Atoi(m[1]); i > n {
+			n = i
+		}
+	}
+	params = append(params, byte(n>>8), byte(n))
+	for i := 0; i < n; i++ {
+		params = append(params, pgInt32(0)...)
+	}
+	c.send('t', params)
+	if c.columns = c.describeColumns(); c.failed {
+		return
+	}
+	if len(c.columns) > 0 {
+		c.send('T', c.rowDescription()...)
+	} else {
+		c.send('n')
+	}
+}
+
+// describeColumns runs a query with null parameters in a savepoint to find its columns.
+func (c *pgFakeConn) describeColumns() []pgColumn {
+	word := strings.ToUpper(strings.Fields(c.query + " x")[0])
+	if word != "SELECT" && word != "WITH" && word != "VALUES" && !strings.Contains(strings.ToUpper(c.query), "RETURNING") {
+		return nil
+	}
+	if pgNotifyRe.MatchString(c.query) {
+		return []pgColumn{{name: "pg_notify", oid: 2278}}
+	}
+	ctx := c0.Background()
+	_, _ = c.sql.ExecContext(ctx, "SAVEPOINT describe")
+	defer func() {
+		_, _ = c.sql.ExecContext(ctx, "ROLLBACK TO describe")
+		_, _ = c.sql.ExecContext(ctx, "RELEASE describe")
+	}()
+	args := make([]any, len(pgParamRe.FindAllString(c.query, -1)))
+	rows, err := c.sql.QueryContext(ctx, pgParamRe.ReplaceAllString(c.query, "?$1"), args...)
+	if err != nil {
+		c.fail(err)
+		return nil
+	}
+	defer rows.Close()
+	types, _ := rows.ColumnTypes()
+	values := make([]any, len(types))
+	ptrs := make([]any, len(types))
+	for i := range values {
+		ptrs[i] = &values[i]
+	}
+	if rows.Next() {
+		_ = rows.Scan(ptrs...)
+	}
+	columns := make([]pgColumn, len(types))
+	for i, t := range types {
+		columns[i] = pgColumn{name: t.Name(), oid: pgOid(t.DatabaseTypeName(), values[i])}
+	}
+	return columns
+}
+
+func pgOid(decl string, v any) int32 {
+	decl = strings.ToUpper(decl)
+	switch {
+	case strings.Contains(decl, "INT"):
+		return 20
+	case strings.Contains(decl, "REAL"), strings.Contains(decl, "FLOA"), strings.Contains(decl, "DOUB"):
+		return 701
+	case strings.Contains(decl, "BOOL"):
+		return 16
+	case strings.Contains(decl, "BLOB"):
+		return 17
+	case strings.Contains(decl, "DATE"), strings.Contains(decl, "TIME"):
+		return 1184
+	case decl != "":
+		return 25
+	}
+	switch v.(type) {
+	case int64:
+		return 20
+	case float64:
+		return 701
+	case []byte:
+		return 17
+	case time.Time:
+		return 1184
+	}
+	return 25
+}
+
+func (c *pgFakeConn) rowDescription() [][]byte {
+	parts := [][]byte{{byte(len(c.columns) >> 8), byte(len(c.columns))}}
+	for _, col := range c.columns {
+		parts = append(parts, pgString(col.name), pgInt32(0), []byte{0, 0}, pgInt32(col.oid), []byte{0xff, 0xff},
+			pgInt32(-1), []byte{0, 0})
+	}
+	return parts
+}
+
+func (c *pgFakeConn) bind(msg []byte) {
+	rd := newPgReader(msg)
+	rd.cstring()
+	rd.cstring()
+	paramFormats := make([]int16, rd.int16())
+	for i := range paramFormats {
+		paramFormats[i] = rd.int16()
+	}
+	c.params = make([]any, rd.int16())
+	for i := range c.params {
+		n := rd.int32()
+		if n < 0 {
+			continue
+		}
+		s := string(rd.next(int(n)))
+		if n, err := strconv.ParseInt(s, 10, 64); err == nil {
+			c.params[i] = n
+		} else if f, err := strconv.ParseFloat(s, 64); err == nil {
+			c.params[i] = f
+		} else {
+			c.params[i] = s
+		}
+	}
+	c.formats = make([]int16, rd.int16())
+	for i := range c.formats {
+		c.formats[i] = rd.int16()
+	}
+	c.send('2')
+}
+
+func (c *pgFakeConn) execute() {
+	ctx := c0.Background()
+	q := pgParamRe.ReplaceAllString(c.query, "?$1")
+	if pgNotifyRe.MatchString(c.query) {
+		c.fake.notify(fmt.Sprint(c.params[0]), fmt.Sprint(c.params[1]), c.pid)
+		c.send('D', []byte{0, 1}, pgInt32(0))
+		c.send('C', pgString("SELECT 1"))
+		return
+	}
+	word := strings.ToUpper(strings.Fields(c.query + " x")[0])
+	switch word {
+	case "BEGIN", "START":
+		// lib/pq adds the access mode, such as BEGIN READ WRITE
+		q, word, c.tx = "BEGIN", "BEGIN", 'T'
+	case "COMMIT", "END", "ROLLBACK":
+		c.tx = 'I'
+	}
+	if strings.TrimSpace(c.query) == "" {
+		c.send('I')
+		return
+	}
+	if len(c.columns) == 0 {
+		res, err := c.sql.ExecContext(ctx, q, c.params...)
+		if err != nil {
+			c.fail(err)
+			return
+		}
+		n, _ := res.RowsAffected()
+		switch word {
+		case "INSERT":
+			c.send('C', pgString(fmt.Sprintf("INSERT 0 %d", n)))
+		case "UPDATE", "DELETE":
+			c.send('C', pgString(fmt.Sprintf("%s %d", word, n)))
+		case "CREATE", "DROP", "ALTER":
+			c.send('C', pgString(strings.ToUpper(strings.Join(strings.Fields(c.query)[:2], " "))))
+		default:
+			c.send('C', pgString(word))
+		}
+		return
+	}
+	rows, err := c.sql.QueryContext(ctx, q, c.params...)
+	if err != nil {
+		c.fail(err)
+		return
+	}
+	defer rows.Close()
+	values := make([]any, len(c.columns))
+	ptrs := make([]any, len(values))
+	for i := range values {
+		ptrs[i] = &values[i]
+	}
+	count := 0
+	for rows.Next() {
+		if err := rows.Scan(ptrs...); err != nil {
+			c.fail(err)
+			return
+		}
+		parts := [][]byte{{byte(len(values) >> 8), byte(len(values))}}
+		for i, v := range values {
+			b := c.format(i, v)
+			if b == nil {
+				parts = append(parts, pgInt32(-1))
+			} else {
+				parts = append(parts, pgInt32(int32(len(b))), b)
+			}
+		}
+		c.send('D', parts...)
+		count++
+	}
+	if word == "SELECT" || word == "WITH" || word == "VALUES" {
+		c.send('C', pgString(fmt.Sprintf("SELECT %d", count)))
+	} else {
+		c.send('C', pgString(fmt.Sprintf("%s 0 %d", word, count)))
+	}
+}
+
+// format encodes the value of the column i in the format requested by Bind.
+func (c *pgFakeConn) format(i int, v any) []byte {
+	if v == nil {
+		return nil
+	}
+	bin := false
+	if len(c.formats) == 1 {
+		bin = c.formats[0] == 1
+	} else if i < len(c.formats) {
+		bin = c.formats[i] == 1
+	}
+	switch c.columns[i].oid {
+	case 20:
+		n, _ := strconv.ParseInt(fmt.Sprint(v), 10, 64)
+		if bin {
+			b := make([]byte, 8)
+			binary.BigEndian.PutUint64(b, uint64(n))
+			return b
+		}
+		return []byte(strconv.FormatInt(n, 10))
+	case 16:
+		if s := fmt.Sprint(v); s == "0" || s == "false" {
+			return []byte("f")
+		}
+		return []byte("t")
+	case 17:
+		b, _ := v.([]byte)
+		if bin {
+			return b
+		}
+		return []byte(`\x` + h0.EncodeToString(b))
+	case 1184:
+		if t, ok := v.(time.Time); ok {
+			return []byte(t.Format("2006-01-02 15:04:05.999999-07:00"))
+		}
+	}
+	switch v := v.(type) {
+	case []byte:
+		return v
+	case float64:
+		return []byte(strconv.FormatFloat(v, 'g', -1, 64))
+	}
+	return []byte(fmt.Sprint(v))
+}
+
+// copyIn reads the data of COPY FROM STDIN in the text format and inserts it.
+func (c *pgFakeConn) copyIn(table string, columns []string) {
+	for i := range columns {
+		columns[i] = strings.TrimSpace(columns[i])
+	}
+	header := []byte{0, byte(len(columns) >> 8), byte(len(columns))}
+	for range columns {
+		header = append(header, 0, 0)
+	}
+	c.send('G', header)
+	var data []byte
+	for {
+		typ, err := c.in.ReadByte()
+		if err != nil {
+			return
+		}
+		var n int32
+		_ = binary.Read(c.in, binary.BigEndian, &n)
+		msg := make([]byte, n-4)
+		if _, err := io.ReadFull(c.in, msg); err != nil {
+			return
+		}
+		if typ == 'd' {
+			data = append(data, msg...)
+			continue
+		}
+		if typ == 'f' {
+			c.fail(fmt.Errorf("COPY failed: %s", msg))
+			return
+		}
+		break
+	}
+	q := fmt.Sprintf("INSERT INTO %s (%s) VALUES (%s)", table, strings.Join(columns, ", "),
+		strings.TrimSuffix(strings.Repeat("?, ", len(columns)), ", "))
+	count := 0
+	for _, line := range strings.Split(strings.TrimSuffix(string(data), "\n"), "\n") {
+		if line == "" {
+			continue
+		}
+		fields := strings.Split(line, "\t")
+		args := make([]any, len(fields))
+		for i, field := range fields {
+			if field != `\N` {
+				args[i] = strings.NewReplacer(`\\`, `\`, `\t`, "\t", `\n`, "\n", `\r`, "\r").Replace(field)
+			}
+		}
+		if _, err := c.sql.ExecContext(c0.Background(), q, args...); err != nil {
+			c.fail(err)
+			return
+		}
+		count++
+	}
+	c.send('C', pgString(fmt.Sprintf("COPY %d", count)))
+}
+
+func pgInt32(n int32) []byte {
+	b := make([]byte, 4)
+	binary.BigEndian.PutUint32(b, uint32(n))
+	return b
+}
+
+func pgString(s string) []byte {
+	return append([]byte(s), 0)
+}
+
+type pgReader []byte
+
+func newPgReader(b []byte) *pgReader {
+	r := pgReader(b)
+	return &r
+}
+
+func (r *pgReader) next(n int) []byte {
+	b := (*r)[:n]
+	*r = (*r)[n:]
+	return b
+}
+
+func (r *pgReader) cstring() string {
+	i := strings.IndexByte(string(*r), 0)
+	return string(r.next(i + 1)[:i])
+}
+
+func (r *pgReader) int16() int16 {
+	return int16(binary.BigEndian.Uint16(r.next(2)))
+}
+
+func (r *pgReader) int32() int32 {
+	return int32(binary.BigEndian.Uint32(r.next(4)))
+}
+
+// testScheduler runs the jobs of the Runtime on the goroutine of the test.
+type testScheduler struct {
+	jobs chan func()
+}
+
+func (s *testScheduler) Schedule() func(func()) {
+	return func(fn func()) {
+		s.jobs <- fn
+	}
+}
+
+func (s *testScheduler) Post(fn func()) {
+	s.jobs <- fn
+}
+
+// runUntil runs the jobs until done returns true.
+func (s *testScheduler) runUntil(t *testing.T, done func() bool) {
+	timeout := time.After(5 * time.Second)
+	for !done() {
+		select {
+		case job := <-s.jobs:
+			job()
+		case <-timeout:
+			t.Fatal("timeout")
+		}
+	}
+}
+
+func TestPostgres(t *testing.T) {
+	fake := newPgFake(t)
+	vm := newWithTestLib(t)
+	_ = vm.Set("port", fake.port())
+	_, err := vm.RunString(`
+	var db = new Postgres("127.0.0.1", port, "postgres", "p@ss:word", "test");
+	assert.sameValue(db instanceof Database, true, "inherits from Database");
+	assert.sameValue(Object.prototype.toString.call(db), "[object Postgres]", "toStringTag");
+	db.exec("CREATE TABLE users (id INTEGER PRIMARY KEY, user_name TEXT, score REAL, active BOOLEAN)");
+	var res = db.exec("INSERT INTO users (id, user_name, score, active) VALUES ($1, $2, $3, 1)", 1, "alice", 1.5);
+	assert.sameValue(res.rowsAffected, 1, "rowsAffected");
+	assert.sameValue(res.lastInsertId, null, "lastInsertId");
+	db.exec("INSERT INTO users (id, user_name, score, active) VALUES ($1, $2, $3, 0)", 2, "bob", null);
+
+	var rows = db.query("SELECT id, user_name, score, active FROM users WHERE id >= $1 ORDER BY id", 1);
+	assert.sameValue(rows.length, 2, "rows");
+	assert.sameValue(rows[0].id, 1, "int8");
+	assert.sameValue(rows[0].userName, "alice", "text");
+	assert.sameValue(rows[0].score, 1.5, "float8");
+	assert.sameValue(rows[0].active, true, "bool");
+	assert.sameValue(rows[1].score, null, "null");
+	assert.sameValue(rows[1].active, false, "false");
+	assert.sameValue(db.query("SELECT user_name FROM users WHERE user_name = $2 AND id = $1", 2, "bob").length, 1, "$n order");
+
+	var tx = db.begin();
+	tx.exec("UPDATE users SET score = $1 WHERE id = $2", 3, 2);
+	tx.rollback();
+	assert.sameValue(db.query("SELECT score FROM users WHERE id = 2")[0].score, null, "rollback");
+	tx = db.begin();
+	tx.exec("UPDATE users SET score = $1 WHERE id = $2", 3, 2);
+	tx.commit();
+	assert.sameValue(db.query("SELECT score FROM users WHERE id = 2")[0].score, 3, "commit");
+
+	assert.sameValue(db.copyFrom("users", ["id", "user_name", "score"], [[3, "carol\tc", 2.5], {id: 4, user_name: null, score: 1}]), 2, "copyFrom");
+	rows = db.query("SELECT id, user_name FROM users WHERE id > $1 ORDER BY id", 2);
+	assert.sameValue(rows.length, 2, "copied rows");
+	assert.sameValue(rows[0].userName, "carol\tc", "copied text");
+	assert.sameValue(rows[1].userName, null, "copied null");
+
+	try {
+		db.query("SELECT * FROM missing WHERE id = $1", 1);
+		throw new Error("no error");
+	} catch (e) {
+		assert.sameValue(e instanceof Error, true, "error type");
+		assert.sameValue(e.message.indexOf("no such table") >= 0, true, "driver message: " + e.message);
+	}
+	try {
+		db.listen("events", function() {});
+		throw new Error("no error");
+	} catch (e) {
+		assert.sameValue(e instanceof TypeError, true, "listen without event loop");
+	}
+	db.close();
+	`)
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestPostgresListen(t *testing.T) {
+	fake := newPgFake(t)
+	s := &testScheduler{jobs: make(chan func(), 16)}
+	vm := New()
+	vm.SetScheduler(s)
+	var received []string
+	_ = vm.Set("port", fake.port())
+	_ = vm.Set("received", func(channel, payload string) {
+		received = append(received, channel+":"+payload)
+	})
+	_, err := vm.RunString(`
+	var db = new Postgres("127.0.0.1", port, "postgres", "", "test");
+	function onEvent(n) {
+		received(n.channel, n.payload);
+	}
+	db.listen("events", onEvent);
+	db.listen("other", function(n) {
+		received(n.channel, n.payload + "!");
+	});
+	db.notify("events", "a");
+	db.notify("other", "b");
+	`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	s.runUntil(t, func() bool { return len(received) == 2 })
+	if received[0] != "events:a" || received[1] != "other:b!" {
+		t.Fatal(received)
+	}
+
+	_, err = vm.RunString(`
+	db.unlisten("events", onEvent);
+	db.notify("events", "c");
+	db.notify("other", "d");
+	`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	s.runUntil(t, func() bool { return len(received) == 3 })
+	if received[2] != "other:d!" {
+		t.Fatal(received)
+	}
+
+	// the listener stops with the last channel
+	if _, err = vm.RunString(`db.unlisten("other"); db.close();`); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case job := <-s.jobs:
+		job()
+	case <-time.After(time.Second):
+		t.Fatal("the scheduler was not released")
+	}
+}
@@ -0,0 +1,522 @@
+package goscript
+
+import (
+	c0 "context"
+	"fmt"
+	"github.com/rarnu/goscript/unistring"
+	"github.com/redis/go-redis/v9"
+	"math"
+	"math/big"
+	"strconv"
+)
+
+// Redis 的扩展命令，命令出错时抛出异常
+
+// redisReply converts the reply of a command.
+type redisReply func(r *Runtime, v any) Value
+
+// redisCommand is a command shared by the clients and the pipelines. Unlike the older methods of the clients, which
+// return null or -1, the commands throw on errors.
+type redisCommand struct {
+	name    string
+	length  int
+	prepare func(r *Runtime, call FunctionCall) ([]any, redisReply)
+}
+
+// redisPipelineObject queues commands and sends them at once, wrapped in MULTI/EXEC for a transaction.
+type redisPipelineObject struct {
+	baseObject
+	p       redis.Pipeliner
+	cmds    []*redis.Cmd
+	replies []redisReply
+}
+
+var redisCommands = []redisCommand{
+	// string
+	{"incr", 1, redisSimple("INCR", redisValue)},
+	{"decr", 1, redisSimple("DECR", redisValue)},
+	{"incrBy", 2, redisSimple("INCRBY", redisValue)},
+	{"decrBy", 2, redisSimple("DECRBY", redisValue)},
+	{"incrByFloat", 2, redisSimple("INCRBYFLOAT", redisFloat)},
+	{"mget", 1, redisSimple("MGET", redisValue)},
+	{"mset", 1, redisPairArgs("MSET", 0, redisBool)},
+	{"setnx", 3, redisSetNX},
+	// key
+	{"exists", 1, redisSimple("EXISTS", redisValue)},
+	{"expire", 2, redisSimple("PEXPIRE", redisBool)},
+	{"expireAt", 2, redisExpireAt},
+	{"persist", 1, redisSimple("PERSIST", redisBool)},
+	{"pttl", 1, redisSimple("PTTL", redisValue)},
+	{"scan", 2, redisScan("SCAN", 0, "keys", redisValue)},
+	// list
+	{"rpush", 2, redisSimple("RPUSH", redisValue)},
+	{"rpop", 1, redisSimple("RPOP", redisValue)},
+	// set
+	{"sscan", 3, redisScan("SSCAN", 1, "members", redisValue)},
+	// hash
+	{"hscan", 3, redisScan("HSCAN", 1, "fields", redisHash)},
+	// sorted set
+	{"zadd", 3, redisZAdd},
+	{"zrem", 2, redisSimple("ZREM", redisValue)},
+	{"zscore", 2, redisSimple("ZSCORE", redisFloat)},
+	{"zincrBy", 3, redisSimple("ZINCRBY", redisFloat)},
+	{"zcard", 1, redisSimple("ZCARD", redisValue)},
+	{"zcount", 3, redisSimple("ZCOUNT", redisValue)},
+	{"zrank", 2, redisSimple("ZRANK", redisValue)},
+	{"zrevRank", 2, redisSimple("ZREVRANK", redisValue)},
+	{"zrange", 4, redisZRange},
+	{"zrangeByScore", 4, redisZRangeByScore},
+	{"zremRangeByScore", 3, redisSimple("ZREMRANGEBYSCORE", redisValue)},
+	{"zscan", 3, redisScan("ZSCAN", 1, "members", redisScored)},
+	// generic
+	{"do", 1, redisDo},
+}
+
+// redisPipelineCommands are the older methods of the clients which can also be queued in a pipeline.
+var redisPipelineCommands = []redisCommand{
+	{"get", 1, redisSimple("GET", redisValue)},
+	{"set", 3, redisSet},
+	{"del", 1, redisSimple("DEL", redisValue)},
+	{"lpush", 2, redisSimple("LPUSH", redisValue)},
+	{"lpop", 1, redisSimple("LPOP", redisValue)},
+	{"lrange", 3, redisSimple("LRANGE", redisValue)},
+	{"llen", 1, redisSimple("LLEN", redisValue)},
+	{"sadd", 2, redisSimple("SADD", redisValue)},
+	{"srem", 2, redisSimple("SREM", redisValue)},
+	{"smembers", 1, redisSimple("SMEMBERS", redisValue)},
+	{"sisMember", 2, redisSimple("SISMEMBER", redisBool)},
+	{"hget", 2, redisSimple("HGET", redisValue)},
+	{"hset", 3, redisPairArgs("HSET", 1, redisValue)},
+	{"hdel", 2, redisSimple("HDEL", redisValue)},
+	{"hgetAll", 1, redisSimple("HGETALL", redisHash)},
+}
+
+// redisArg converts an argument of a command. Array buffers and typed arrays are sent as bytes.
+func (r *Runtime) redisArg(v Value) any {
+	if o, ok := v.(*Object); ok {
+		if b, ok := r.httpBytes(o); ok {
+			return b
+		}
+	}
+	if f, ok := v.(valueFloat); ok && math.IsInf(float64(f), 0) {
+		if f > 0 {
+			return "+inf"
+		}
+		return "-inf"
+	}
+	return v.Export()
+}
+
+// redisArgs converts arguments, the elements of an array are passed as separate arguments.
+func (r *Runtime) redisArgs(args []any, values []Value) []any {
+	for _, v := range values {
+		if o, ok := v.(*Object); ok && isArray(o) {
+			for i := int64(0); i < toLength(o.self.getStr("length", nil)); i++ {
+				args = append(args, r.redisArg(nilSafe(o.self.getIdx(valueInt(i), nil))))
+			}
+			continue
+		}
+		args = append(args, r.redisArg(v))
+	}
+	return args
+}
+
+// redisSimple passes all the arguments of the call to cmd.
+func redisSimple(cmd string, reply redisReply) func(r *Runtime, call FunctionCall) ([]any, redisReply) {
+	return func(r *Runtime, call FunctionCall) ([]any, redisReply) {
+		return r.redisArgs([]any{cmd}, call.Arguments), reply
+	}
+}
+
+// redisPairArgs passes the field-value pairs of an object argument after n leading arguments, e.g.
+// mset({a: 1, b: 2}) or hset(key, {f: 1}). Other arguments are passed as they are.
+func redisPairArgs(cmd string, n int, reply redisReply) func(r *Runtime, call FunctionCall) ([]any, redisReply) {
+	return func(r *Runtime, call FunctionCall) ([]any, redisReply) {
+		if len(call.Arguments) < n {
+			panic(r.NewTypeError("Redis command %s requires at least %d arguments", cmd, n+1))
+		}
+		args := r.redisArgs([]any{cmd}, call.Arguments[:n])
+		if o, ok := call.Argument(n).(*Object); ok && len(call.Arguments) == n+1 && !isArray(o) {
+			for _, k := range o.self.stringKeys(false, nil) {
+				args = append(args, k.String(), r.redisArg(nilSafe(o.self.getStr(k.string(), nil))))
+			}
+			return args, reply
+		}
+		if len(call.Arguments) > n {
+			args = r.redisArgs(args, call.Arguments[n:])
+		}
+		return args, reply
+	}
+}
+
+func redisSet(r *Runtime, call FunctionCall) ([]any, redisReply) {
+	args := []any{"SET", call.Argument(0).String(), r.redisArg(call.Argument(1))}
+	if ms := call.Argument(2).ToInteger(); ms > 0 {
+		args = append(args, "PX", ms)
+	}
+	return args, redisBool
+}
+
+// redisSetNX is setnx(key, value, ms), which sets the key only if it does not exist, with an expiry if ms > 0.
+func redisSetNX(r *Runtime, call FunctionCall) ([]any, redisReply) {
+	args, reply := redisSet(r, call)
+	return append(args, "NX"), reply
+}
+
+// redisExpireAt is expireAt(key, time), where time is a Date or a number of milliseconds since the epoch.
+func redisExpireAt(r *Runtime, call FunctionCall) ([]any, redisReply) {
+	return []any{"PEXPIREAT", call.Argument(0).String(), int64(call.Argument(1).ToFloat())}, redisBool
+}
+
+// redisZAdd is zadd(key, score, member, ...) or zadd(key, {member: score}).
+func redisZAdd(r *Runtime, call FunctionCall) ([]any, redisReply) {
+	if len(call.Arguments) < 2 {
+		panic(r.NewTypeError("Redis command ZADD requires at least 2 arguments"))
+	}
+	args := []any{"ZADD", call.Argument(0).String()}
+	if o, ok := call.Argument(1).(*Object); ok && !isArray(o) {
+		for _, k := range o.self.stringKeys(false, nil) {
+			args = append(args, r.redisArg(nilSafe(o.self.getStr(k.string(), nil))), k.String())
+		}
+		return args, redisValue
+	}
+	return r.redisArgs(args, call.Arguments[1:]), redisValue
+}
+
+// redisZRange is zrange(key, start, stop, {withScores, rev}).
+func redisZRange(r *Runtime, call FunctionCall) ([]any, redisReply) {
+	cmd := "ZRANGE"
+	opts := r.redisOptions(call.Argument(3))
+	if opts.bool("rev") {
+		cmd = "ZREVRANGE"
+	}
+	args := []any{cmd, call.Argument(0).String(), call.Argument(1).ToInteger(), call.Argument(2).ToInteger()}
+	if opts.bool("withScores") {
+		return append(args, "WITHSCORES"), redisScored
+	}
+	return args, redisValue
+}
+
+// redisZRangeByScore is zrangeByScore(key, min, max, {withScores, offset, count}).
+func redisZRangeByScore(r *Runtime, call FunctionCall) ([]any, redisReply) {
+	args := []any{"ZRANGEBYSCORE", call.Argument(0).String(), r.redisArg(call.Argument(1)), r.redisArg(call.Argument(2))}
+	opts := r.redisOptions(call.Argument(3))
+	reply := redisValue
+	if opts.bool("withScores") {
+		args = append(args, "WITHSCORES")
+		reply = redisScored
+	}
+	if count := opts.get("count"); count != nil {
+		args = append(args, "LIMIT", opts.get("offset").ToInteger(), count.ToInteger())
+	}
+	return args, reply
+}
+
+// redisScan returns the arguments of scan(cursor, {match, count, type}) or of xscan(key, cursor, {match, count}).
+// The reply is {cursor, <field>: items}, where cursor is a string that is "0" when the iteration is complete.
+func redisScan(cmd string, n int, field string, items redisReply) func(r *Runtime, call FunctionCall) ([]any, redisReply) {
+	return func(r *Runtime, call FunctionCall) ([]any, redisReply) {
+		args := []any{cmd}
+		if n > 0 {
+			args = append(args, call.Argument(0).String())
+		}
+		cursor := call.Argument(n)
+		if cursor == _undefined || cursor == _null {
+			cursor = asciiString("0")
+		}
+		args = append(args, cursor.String())
+		opts := r.redisOptions(call.Argument(n + 1))
+		if match := opts.get("match"); match != nil {
+			args = append(args, "MATCH", match.String())
+		}
+		if count := opts.get("count"); count != nil {
+			args = append(args, "COUNT", count.ToInteger())
+		}
+		if typ := opts.get("type"); typ != nil && n == 0 {
+			args = append(args, "TYPE", typ.String())
+		}
+		return args, func(r *Runtime, v any) Value {
+			reply, ok := v.([]any)
+			if !ok || len(reply) != 2 {
+				return redisValue(r, v)
+			}
+			o := r.NewObject()
+			_ = o.Set("cursor", fmt.Sprint(reply[0]))
+			_ = o.Set(field, items(r, reply[1]))
+			return o
+		}
+	}
+}
+
+// redisDo is do(cmd, ...args), which runs any command and converts its reply by type.
+func redisDo(r *Runtime, call FunctionCall) ([]any, redisReply) {
+	if len(call.Arguments) == 0 {
+		panic(r.NewTypeError("Redis command is required"))
+	}
+	return r.redisArgs(nil, call.Arguments), redisValue
+}
+
+type redisOptions struct {
+	o *Object
+}
+
+func (r *Runtime) redisOptions(v Value) redisOptions {
+	if o, ok := v.(*Object); ok {
+		return redisOptions{o}
+	}
+	return redisOptions{}
+}
+
+// get returns the option, or nil if it is not set.
+func (opts redisOptions) get(name string) Value {
+	if opts.o == nil {
+		return nil
+	}
+	if v := opts.o.self.getStr(unistring.NewFromString(name), nil); v != nil && v != _undefined && v != _null {
+		return v
+	}
+	return nil
+}
+
+func (opts redisOptions) bool(name string) bool {
+	v := opts.get(name)
+	return v != nil && v.ToBoolean()
+}
+
+// redisValue converts a reply by type: nil to null, integers and doubles to numbers, arrays to arrays and maps to
+// objects.
+func redisValue(r *Runtime, v any) Value {
+	switch v := v.(type) {
+	case nil:
+		return _null
+	case string:
+		return newStringValue(v)
+	case int64:
+		return intToValue(v)
+	case float64:
+		return floatToValue(v)
+	case bool:
+		return r.toBoolean(v)
+	case *big.Int:
+		return newStringValue(v.String())
+	case error:
+		return r.NewGoError(v)
+	case []any:
+		list := make([]Value, len(v))
+		for i, item := range v {
+			list[i] = redisValue(r, item)
+		}
+		return r.newArrayValues(list)
+	case map[any]any:
+		o := r.NewObject()
+		for k, item := range v {
+			_ = o.Set(fmt.Sprint(k), redisValue(r, item))
+		}
+		return o
+	}
+	return r.ToValue(v)
+}
+
+// redisBool converts an integer reply such as the one of EXPIRE, or a status reply such as the one of SET.
+func redisBool(r *Runtime, v any) Value {
+	switch v := v.(type) {
+	case nil:
+		return valueFalse
+	case int64:
+		return r.toBoolean(v != 0)
+	case string:
+		return r.toBoolean(v == "OK")
+	}
+	return redisValue(r, v)
+}
+
+// redisFloat converts a double reply, which is a string before RESP3.
+func redisFloat(r *Runtime, v any) Value {
+	if s, ok := v.(string); ok {
+		f, err := strconv.ParseFloat(s, 64)
+		if err != nil {
+			return newStringValue(s)
+		}
+		return floatToValue(f)
+	}
+	return redisValue(r, v)
+}
+
+// redisPairs returns the pairs of a map reply, which is a flat array before RESP3.
+func redisPairs(v any) [][2]any {
+	var pairs [][2]any
+	switch v := v.(type) {
+	case map[any]any:
+		for k, item := range v {
+			pairs = append(pairs, [2]any{k, item})
+		}
+	case []any:
+		for i := 0; i < len(v); i++ {
+			if pair, ok := v[i].([]any); ok && len(pair) == 2 {
+				pairs = append(pairs, [2]any{pair[0], pair[1]})
+			} else if i+1 < len(v) {
+				pairs = append(pairs, [2]any{v[i], v[i+1]})
+				i++
+			}
+		}
+	}
+	return pairs
+}
+
+// redisHash converts the field-value pairs of HGETALL or HSCAN to an object.
+func redisHash(r *Runtime, v any) Value {
+	o := r.NewObject()
+	for _, pair := range redisPairs(v) {
+		_ = o.Set(fmt.Sprint(pair[0]), redisValue(r, pair[1]))
+	}
+	return o
+}
+
+// redisScored converts the member-score pairs of a sorted set to [{member, score}].
+func redisScored(r *Runtime, v any) Value {
+	pairs := redisPairs(v)
+	list := make([]Value, len(pairs))
+	for i, pair := range pairs {
+		o := r.NewObject()
+		_ = o.Set("member", redisValue(r, pair[0]))
+		_ = o.Set("score", redisFloat(r, pair[1]))
+		list[i] = o
+	}
+	return r.newArrayValues(list)
+}
+
+// do runs a raw command. A nil reply is returned as a nil value instead of an error.
+func (ro *redisObject) do(args ...any) (any, error) {
+	v, err := ro.cli.Do(c0.Background(), args...).Result()
+	if err == redis.Nil {
+		return nil, nil
+	}
+	return v, err
+}
+
+func (r *Runtime) toRedis(method string, call FunctionCall) *redisObject {
+	thisObj := r.toObject(call.This)
+	ro, ok := thisObj.self.(*redisObject)
+	if !ok {
+		panic(r.NewTypeError("Method Redis.prototype.%s called on incompatible receiver %s", method, r.objectproto_toString(FunctionCall{This: thisObj})))
+	}
+	return ro
+}
+
+func (r *Runtime) redisCommandFunc(c redisCommand) func(call FunctionCall) Value {
+	return func(call FunctionCall) Value {
+		ro := r.toRedis(c.name, call)
+		args, reply := c.prepare(r, call)
+		v, err := ro.do(args...)
+		if err != nil {
+			panic(r.NewGoError(err))
+		}
+		return reply(r, v)
+	}
+}
+
+func (r *Runtime) redisPipelineFunc(tx bool) func(call FunctionCall) Value {
+	return func(call FunctionCall) Value {
+		var p redis.Pipeliner
+		if tx {
+			p = r.toRedis("multi", call).cli.TxPipeline()
+		} else {
+			p = r.toRedis("pipeline", call).cli.Pipeline()
+		}
+		o := &Object{runtime: r}
+		po := &redisPipelineObject{
+			baseObject: baseObject{
+				class:      classRedisPipeline,
+				val:        o,
+				prototype:  r.global.RedisPipelinePrototype,
+				extensible: true,
+				values:     nil,
+			},
+			p: p,
+		}
+		o.self = po
+		po.init()
+		return o
+	}
+}
+
+// putRedisCommands adds the commands, pipeline() and multi() to the prototype of a Redis client.
+func (r *Runtime) putRedisCommands(o *baseObject) {
+	for _, c := range redisCommands {
+		o._putProp(unistring.NewFromString(c.name), r.newNativeFunc(r.redisCommandFunc(c), nil, unistring.NewFromString(c.name), nil, c.length), true, false, true)
+	}
+	o._putProp("pipeline", r.newNativeFunc(r.redisPipelineFunc(false), nil, "pipeline", nil, 0), true, false, true)
+	o._putProp("multi", r.newNativeFunc(r.redisPipelineFunc(true), nil, "multi", nil, 0), true, false, true)
+}
+
+func (r *Runtime) toRedisPipeline(method string, call FunctionCall) *redisPipelineObject {
+	thisObj := r.toObject(call.This)
+	po, ok := thisObj.self.(*redisPipelineObject)
+	if !ok {
+		panic(r.NewTypeError("Method RedisPipeline.prototype.%s called on incompatible receiver %s", method, r.objectproto_toString(FunctionCall{This: thisObj})))
+	}
+	return po
+}
+
+// redisQueueFunc queues a command in the pipeline and returns the pipeline, so that the calls can be chained.
+func (r *Runtime) redisQueueFunc(c redisCommand) func(call FunctionCall) Value {
+	return func(call FunctionCall) Value {
+		po := r.toRedisPipeline(c.name, call)
+		args, reply := c.prepare(r, call)
+		po.cmds = append(po.cmds, po.p.Do(c0.Background(), args...))
+		po.replies = append(po.replies, reply)
+		return call.This
+	}
+}
+
+// builtinRedisPipeline_exec sends the queued commands and returns their replies in order. A command that failed
+// has an error in place of its reply. The error returned by Exec is the first error of the commands, which is also
+// set on them.
+func (r *Runtime) builtinRedisPipeline_exec(call FunctionCall) Value {
+	po := r.toRedisPipeline("exec", call)
+	_, _ = po.p.Exec(c0.Background())
+	list := make([]Value, len(po.cmds))
+	for i, cmd := range po.cmds {
+		v, err := cmd.Result()
+		switch {
+		case err == redis.Nil:
+			list[i] = po.replies[i](r, nil)
+		case err != nil:
+			list[i] = r.NewGoError(err)
+		default:
+			list[i] = po.replies[i](r, v)
+		}
+	}
+	po.cmds, po.replies = nil, nil
+	return r.newArrayValues(list)
+}
+
+func (r *Runtime) builtinRedisPipeline_discard(call FunctionCall) Value {
+	po := r.toRedisPipeline("discard", call)
+	po.p.Discard()
+	po.cmds, po.replies = nil, nil
+	return _undefined
+}
+
+func (r *Runtime) builtinRedisPipeline_length(call FunctionCall) Value {
+	return intToValue(int64(len(r.toRedisPipeline("length", call).replies)))
+}
+
+func (r *Runtime) createRedisPipelineProto(val *Object) objectImpl {
+	o := newBaseObjectObj(val, r.global.ObjectPrototype, classObject)
+	for _, list := range [][]redisCommand{redisPipelineCommands, redisCommands} {
+		for _, c := range list {
+			o._putProp(unistring.NewFromString(c.name), r.newNativeFunc(r.redisQueueFunc(c), nil, unistring.NewFromString(c.name), nil, c.length), true, false, true)
+		}
+	}
+	o._putProp("exec", r.newNativeFunc(r.builtinRedisPipeline_exec, nil, "exec", nil, 0), true, false, true)
+	o._putProp("discard", r.newNativeFunc(r.builtinRedisPipeline_discard, nil, "discard", nil, 0), true, false, true)
+	o.setOwnStr("length", &valueProperty{
+		getterFunc:   r.newNativeFunc(r.builtinRedisPipeline_length, nil, "get length", nil, 0),
+		accessor:     true,
+		writable:     true,
+		configurable: true,
+	}, true)
+	o._putSym(SymToStringTag, valueProp(asciiString(classRedisPipeline), false, false, true))
+	return o
+}
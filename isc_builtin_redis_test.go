@@ -0,0 +1,219 @@
+package goscript
+
+import (
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRedisCommands(t *testing.T) {
+	mr := miniredis.RunT(t)
+	port, _ := strconv.Atoi(mr.Port())
+	for _, ctor := range []string{
+		`new Redis(host, port, "", 0)`,
+		`new Redis("redis://" + host + ":" + port + "/0")`,
+		`new Redis({host: host, port: port, protocol: 2, poolSize: 2, dialTimeout: "1s"})`,
+		`new Redis({addrs: [host + ":" + port], cluster: true})`,
+		`new RedisV8(host, port, "", 0)`,
+		`new RedisCluster(host + ":" + port, "")`,
+	} {
+		mr.FlushAll()
+		vm := newWithTestLib(t)
+		_ = vm.Set("host", mr.Host())
+		_ = vm.Set("port", port)
+		_, err := vm.RunString(`
+		var redis = ` + ctor + `;
+		assert.sameValue(redis instanceof Redis, true, "instanceof");
+		assert.sameValue(redis.incr("n"), 1, "incr");
+		assert.sameValue(redis.incrBy("n", 10), 11, "incrBy");
+		assert.sameValue(redis.decr("n"), 10, "decr");
+		assert.sameValue(redis.decrBy("n", 4), 6, "decrBy");
+		assert.sameValue(redis.incrByFloat("f", 1.5), 1.5, "incrByFloat");
+		assert.sameValue(redis.mset({a: "1", b: "2"}), true, "mset");
+		assert.sameValue(redis.mget("a", "missing", "b").join(), "1,,2", "mget");
+		assert.sameValue(redis.mget(["a", "b"]).length, 2, "mget array");
+		assert.sameValue(redis.mget("missing")[0], null, "mget null");
+		assert.sameValue(redis.exists("a", "b", "missing"), 2, "exists");
+		assert.sameValue(redis.setnx("lock", "1", 1000), true, "setnx");
+		assert.sameValue(redis.setnx("lock", "2"), false, "setnx existing");
+		assert.sameValue(redis.get("lock"), "1", "setnx value");
+
+		assert.sameValue(redis.expire("a", 60000), true, "expire");
+		assert.sameValue(redis.pttl("a") > 0, true, "pttl");
+		assert.sameValue(redis.persist("a"), true, "persist");
+		assert.sameValue(redis.pttl("a"), -1, "persisted");
+		assert.sameValue(redis.expireAt("b", new Date(Date.now() + 60000)), true, "expireAt");
+		assert.sameValue(redis.expire("missing", 1000), false, "expire missing");
+
+		assert.sameValue(redis.zadd("z", 1, "one", 2, "two"), 2, "zadd");
+		assert.sameValue(redis.zadd("z", {three: 3, half: 0.5}), 2, "zadd object");
+		assert.sameValue(redis.zcard("z"), 4, "zcard");
+		assert.sameValue(redis.zscore("z", "half"), 0.5, "zscore");
+		assert.sameValue(redis.zscore("z", "missing"), null, "zscore missing");
+		assert.sameValue(redis.zincrBy("z", 2, "one"), 3, "zincrBy");
+		assert.sameValue(redis.zrank("z", "half"), 0, "zrank");
+		assert.sameValue(redis.zrevRank("z", "half"), 3, "zrevRank");
+		assert.sameValue(redis.zrank("z", "missing"), null, "zrank missing");
+		assert.sameValue(redis.zcount("z", 1, Infinity), 3, "zcount");
+		assert.sameValue(redis.zrange("z", 0, -1).join(), "half,two,one,three", "zrange");
+		assert.sameValue(redis.zrange("z", 0, 0, {rev: true})[0], "three", "zrange rev");
+		var scored = redis.zrange("z", 0, 1, {withScores: true});
+		assert.sameValue(scored[1].member + "=" + scored[1].score, "two=2", "zrange withScores");
+		assert.sameValue(redis.zrangeByScore("z", "(1", 3, {offset: 1, count: 1}).join(), "one", "zrangeByScore");
+		assert.sameValue(redis.zrangeByScore("z", -Infinity, 1, {withScores: true})[0].score, 0.5, "zrangeByScore withScores");
+		assert.sameValue(redis.zrem("z", ["half", "missing"]), 1, "zrem");
+		assert.sameValue(redis.zremRangeByScore("z", 0, 2), 1, "zremRangeByScore");
+		[function () { redis.zadd(); }, function () { redis.pipeline().zadd("z"); }].forEach(function (zadd) {
+			try {
+				zadd();
+				throw new Error("no error");
+			} catch (e) {
+				assert.sameValue(e instanceof TypeError, true, "zadd without arguments");
+			}
+		});
+
+		for (var i = 0; i < 20; i++) {
+			redis.set("scan:" + i, i, 0);
+		}
+		var cursor = "0", keys = [];
+		do {
+			var page = redis.scan(cursor, {match: "scan:*", count: 5});
+			assert.sameValue(typeof page.cursor, "string", "scan cursor");
+			keys = keys.concat(page.keys);
+			cursor = page.cursor;
+		} while (cursor !== "0");
+		assert.sameValue(keys.length, 20, "scan");
+		redis.hset("h", "f1", "v1");
+		redis.hset("h", "f2", "v2");
+		assert.sameValue(redis.hscan("h", 0).fields.f2, "v2", "hscan");
+		redis.sadd("s", "m1");
+		assert.sameValue(redis.sscan("s", "0", {match: "m*"}).members[0], "m1", "sscan");
+		assert.sameValue(redis.zscan("z").members[0].score, 3, "zscan");
+		assert.sameValue(redis.rpush("l", "x", "y"), 2, "rpush");
+		assert.sameValue(redis.rpop("l"), "y", "rpop");
+
+		assert.sameValue(redis.do("SET", "raw", 42), "OK", "do status");
+		assert.sameValue(redis.do("GET", "raw"), "42", "do bulk");
+		assert.sameValue(redis.do("GET", "missing"), null, "do nil");
+		assert.sameValue(redis.do("DEL", ["raw", "missing"]), 1, "do array argument");
+		assert.sameValue(redis.do("ZRANGE", "z", 0, -1).join(), "one,three", "do array");
+		var hash = redis.do("HGETALL", "h");
+		assert.sameValue(Array.isArray(hash) ? hash.length : hash.f1, Array.isArray(hash) ? 4 : "v1", "do map");
+		try {
+			redis.do("NOSUCHCOMMAND");
+			throw new Error("no error");
+		} catch (e) {
+			assert.sameValue(e.message.indexOf("unknown command") >= 0, true, "do error: " + e.message);
+		}
+		try {
+			redis.incr("h");
+			throw new Error("no error");
+		} catch (e) {
+			assert.sameValue(e.message.indexOf("WRONGTYPE") >= 0, true, "command error: " + e.message);
+		}
+
+		var p = redis.pipeline();
+		assert.sameValue(Object.prototype.toString.call(p), "[object RedisPipeline]", "toStringTag");
+		assert.sameValue(p.set("p", "1").incr("p").get("p").incr("h").hgetAll("h"), p, "chain");
+		assert.sameValue(p.length, 5, "length");
+		var res = p.exec();
+		assert.sameValue(res.length, 5, "exec");
+		assert.sameValue(res[0], true, "pipeline set");
+		assert.sameValue(res[1], 2, "pipeline incr");
+		assert.sameValue(res[2], "2", "pipeline get");
+		assert.sameValue(res[3] instanceof Error, true, "pipeline error");
+		assert.sameValue(res[4].f1, "v1", "pipeline hgetAll");
+		assert.sameValue(p.length, 0, "reset");
+		assert.sameValue(p.exec().length, 0, "empty");
+
+		var m = redis.multi();
+		m.incr("counter").incr("counter").zadd("tz", 1, "a").do("GET", "counter");
+		res = m.exec();
+		assert.sameValue(res.join(), "1,2,1,2", "multi");
+		m.incr("counter");
+		m.discard();
+		assert.sameValue(m.length, 0, "discard");
+		assert.sameValue(redis.get("counter"), "2", "discarded");
+		redis.close();
+		`)
+		if err != nil {
+			t.Fatalf("%s: %v", ctor, err)
+		}
+	}
+}
+
+func TestRedisClientOptions(t *testing.T) {
+	vm := New()
+	parse := func(src string) (*redis.UniversalOptions, bool) {
+		v, err := vm.RunString(src)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return vm.redisClientOptions(v.Export().([]Value))
+	}
+	_ = vm.Set("args", func(call FunctionCall) Value {
+		return vm.ToValue(call.Arguments)
+	})
+
+	opts, cluster := parse(`args("rediss://user:secret@h1:6380/2?dial_timeout=3s", {poolSize: 7, readTimeout: 1500})`)
+	if cluster || len(opts.Addrs) != 1 || opts.Addrs[0] != "h1:6380" || opts.Username != "user" || opts.Password != "secret" ||
+		opts.DB != 2 || opts.TLSConfig == nil || opts.DialTimeout != 3*time.Second || opts.PoolSize != 7 ||
+		opts.ReadTimeout != 1500*time.Millisecond {
+		t.Fatalf("url: %+v", opts)
+	}
+	opts, cluster = parse(`args("redis://h1:7000?addr=h2:7001&read_only=true")`)
+	if !cluster || strings.Join(opts.Addrs, ",") != "h1:7000,h2:7001" || !opts.ReadOnly {
+		t.Fatalf("cluster url: %+v", opts)
+	}
+	opts, cluster = parse(`args("h1:7000;h2:7001")`)
+	if cluster || strings.Join(opts.Addrs, ",") != "h1:7000,h2:7001" {
+		t.Fatalf("addrs: %+v", opts)
+	}
+	opts, cluster = parse(`args({addrs: ["s1:26379", "s2:26379"], masterName: "mymaster", sentinelPassword: "sp", db: 1, tls: true})`)
+	if cluster || opts.MasterName != "mymaster" || opts.SentinelPassword != "sp" || opts.DB != 1 || opts.TLSConfig == nil {
+		t.Fatalf("sentinel: %+v", opts)
+	}
+	if _, ok := newRedisClient(opts, false).(*redis.Client); !ok {
+		t.Fatal("sentinel: expected a failover client")
+	}
+	opts, cluster = parse(`args({host: "h1", cluster: true, tls: {insecureSkipVerify: true}})`)
+	if !cluster || opts.Addrs[0] != "h1:6379" || !opts.TLSConfig.InsecureSkipVerify {
+		t.Fatalf("cluster: %+v", opts)
+	}
+	if _, ok := newRedisClient(opts, cluster).(*redis.ClusterClient); !ok {
+		t.Fatal("cluster: expected a cluster client")
+	}
+
+	_, err := vm.RunString(`
+	[
+		function () { new Redis({host: "127.0.0.1", port: 1, dialTimeout: 100}); },
+		function () { new Redis("127.0.0.1", 1, "", 0); },
+		function () { new RedisV8("127.0.0.1", 1, "", 0); },
+		function () { new RedisCluster("127.0.0.1:1", ""); },
+		function () { new RedisClusterV8("127.0.0.1:1", ""); },
+	].forEach(function (connect) {
+		try {
+			connect();
+			throw new Error("no error");
+		} catch (e) {
+			if (e.message === "no error" || !(e instanceof GoError)) {
+				throw e;
+			}
+		}
+	});
+	try {
+		new Redis("http://localhost");
+		throw new Error("no error");
+	} catch (e) {
+		if (!(e instanceof TypeError)) {
+			throw e;
+		}
+	}
+	`)
+	if err != nil {
+		t.Fatal(err)
+	}
+}
@@ -1,69 +1,455 @@
 package goscript
 
 import (
+	c0 "context"
 	"database/sql"
-	_ "github.com/mattn/go-sqlite3"
+	"database/sql/driver"
+	"fmt"
+	"github.com/rarnu/goscript/unistring"
+	"modernc.org/sqlite"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
 )
 
+// SQLite 使用纯 Go 实现的 modernc.org/sqlite，不需要 cgo
+
+// sqliteObject is a SQLite database. Its connections are opened by connector, which attaches the databases of
+// attach. An in-memory database is shared by the connections and kept open by keeper.
 type sqliteObject struct {
-	baseObject
-	db *sql.DB
+	databaseObject
+	connector *sqliteConnector
+	keeper    driver.Conn
+	maxIdle   int
 }
 
-func (r *Runtime) builtinSQLite_close(call FunctionCall) Value {
+type sqliteAttachment struct {
+	path, name string
+}
+
+// sqliteConnector opens the connections of a database and attaches the databases to each of them.
+type sqliteConnector struct {
+	dsn string
+
+	mu       sync.Mutex
+	attached []sqliteAttachment
+}
+
+// sqliteDriver is the driver registered by modernc.org/sqlite, to which the SQL functions are added.
+var sqliteDriver = func() driver.Driver {
+	db, _ := sql.Open("sqlite", "")
+	return db.Driver()
+}()
+
+// sqliteMemories numbers the in-memory databases.
+var sqliteMemories int64
+
+func (c *sqliteConnector) Connect(ctx c0.Context) (driver.Conn, error) {
+	conn, err := sqliteDriver.Open(c.dsn)
+	if err != nil {
+		return nil, err
+	}
+	c.mu.Lock()
+	attached := append([]sqliteAttachment(nil), c.attached...)
+	c.mu.Unlock()
+	for _, a := range attached {
+		_, err := conn.(driver.ExecerContext).ExecContext(ctx, "ATTACH DATABASE ? AS "+sqliteQuote(a.name),
+			[]driver.NamedValue{{Ordinal: 1, Value: a.path}})
+		if err != nil {
+			_ = conn.Close()
+			return nil, err
+		}
+	}
+	return conn, nil
+}
+
+func (c *sqliteConnector) Driver() driver.Driver {
+	return sqliteDriver
+}
+
+func sqliteQuote(name string) string {
+	return `"` + strings.ReplaceAll(name, `"`, `""`) + `"`
+}
+
+// reconnect closes the idle connections before a change of the attachments or functions, so that the next statements
+// run on new connections. It is refused while a transaction holds a connection, which would not be updated.
+func (so *sqliteObject) reconnect() {
+	r := so.val.runtime
+	if so.db.Stats().InUse > 0 {
+		panic(r.NewTypeError("Cannot change a SQLite database while a transaction is open"))
+	}
+	so.db.SetMaxIdleConns(0)
+	so.db.SetMaxIdleConns(so.maxIdle)
+}
+
+func (r *Runtime) toSQLite(method string, call FunctionCall) *sqliteObject {
 	thisObj := r.toObject(call.This)
-	mo, ok := thisObj.self.(*sqliteObject)
+	so, ok := thisObj.self.(*sqliteObject)
 	if !ok {
-		panic(r.NewTypeError("Method SQLite.prototype.close called on incompatible receiver %s", r.objectproto_toString(FunctionCall{This: thisObj})))
+		panic(r.NewTypeError("Method SQLite.prototype.%s called on incompatible receiver %s", method, r.objectproto_toString(FunctionCall{This: thisObj})))
+	}
+	return so
+}
+
+// builtinSQLite_attach is attach(path, name).
+func (r *Runtime) builtinSQLite_attach(call FunctionCall) Value {
+	so := r.toSQLite("attach", call)
+	a := sqliteAttachment{path: call.Argument(0).String(), name: call.Argument(1).String()}
+	so.reconnect()
+	so.connector.mu.Lock()
+	for _, attached := range so.connector.attached {
+		if strings.EqualFold(attached.name, a.name) {
+			so.connector.mu.Unlock()
+			panic(r.NewTypeError("Database %s is already attached", a.name))
+		}
+	}
+	so.connector.attached = append(so.connector.attached, a)
+	so.connector.mu.Unlock()
+	// the attachment is checked on a new connection
+	if err := so.db.Ping(); err != nil {
+		so.detach(a.name)
+		panic(r.NewGoError(err))
 	}
-	_ = mo.db.Close()
 	return _undefined
 }
 
-func (r *Runtime) builtinSQLite_exec(call FunctionCall) Value {
-	thisObj := r.toObject(call.This)
-	mo, ok := thisObj.self.(*sqliteObject)
-	if !ok {
-		panic(r.NewTypeError("Method SQLite.prototype.exec called on incompatible receiver %s", r.objectproto_toString(FunctionCall{This: thisObj})))
+// builtinSQLite_detach is detach(name).
+func (r *Runtime) builtinSQLite_detach(call FunctionCall) Value {
+	so := r.toSQLite("detach", call)
+	so.reconnect()
+	so.detach(call.Argument(0).String())
+	return _undefined
+}
+
+func (so *sqliteObject) detach(name string) {
+	c := so.connector
+	c.mu.Lock()
+	for i, a := range c.attached {
+		if strings.EqualFold(a.name, name) {
+			c.attached = append(c.attached[:i:i], c.attached[i+1:]...)
+			break
+		}
 	}
-	_sql := call.Argument(0).toString().String()
-	_, err := mo.db.Exec(_sql)
-	return r.toBoolean(err == nil)
+	c.mu.Unlock()
+	so.db.SetMaxIdleConns(0)
+	so.db.SetMaxIdleConns(so.maxIdle)
 }
 
-func (r *Runtime) builtinSQLite_query(call FunctionCall) Value {
-	thisObj := r.toObject(call.This)
-	mo, ok := thisObj.self.(*sqliteObject)
+// builtinSQLite_backup is backup(toPath). It copies the database with the online backup API of SQLite, the
+// database stays usable meanwhile.
+func (r *Runtime) builtinSQLite_backup(call FunctionCall) Value {
+	so := r.toSQLite("backup", call)
+	path := call.Argument(0).String()
+	ctx := c0.Background()
+	conn, err := so.db.Conn(ctx)
+	if err != nil {
+		panic(r.NewGoError(err))
+	}
+	defer func() {
+		_ = conn.Close()
+	}()
+	err = conn.Raw(func(dc any) error {
+		b, err := dc.(interface {
+			NewBackup(string) (*sqlite.Backup, error)
+		}).NewBackup(path)
+		if err != nil {
+			return err
+		}
+		for {
+			more, err := b.Step(-1)
+			if err != nil {
+				_ = b.Finish()
+				return err
+			}
+			if !more {
+				return b.Finish()
+			}
+		}
+	})
+	if err != nil {
+		panic(r.NewGoError(err))
+	}
+	return _undefined
+}
+
+// sqliteFunction is a SQL function implemented in JavaScript. The functions of modernc.org/sqlite are registered
+// for the whole process and added to the connections opened afterwards, so a name is bound to the implementation of
+// a single database, its owner, which must be called from the goroutine of its Runtime. The databases of the same
+// Runtime may replace the implementation, the other Runtimes cannot register the name until the owner is closed.
+type sqliteFunction struct {
+	name      string
+	aggregate bool
+
+	mu                         sync.Mutex
+	owner                      *sqliteObject // nil once the owner is closed
+	scalar                     Value
+	init, step, final, inverse Value
+}
+
+var (
+	sqliteFunctionsMu sync.Mutex
+	sqliteFunctions   = make(map[string]*sqliteFunction)
+)
+
+// registerFunction binds name to the implementation set by bind. Whether a function is deterministic is fixed
+// by its first registration.
+func (so *sqliteObject) registerFunction(name string, aggregate, deterministic bool, bind func(f *sqliteFunction)) {
+	r := so.val.runtime
+	so.reconnect()
+	key := strings.ToLower(name)
+	sqliteFunctionsMu.Lock()
+	f, ok := sqliteFunctions[key]
 	if !ok {
-		panic(r.NewTypeError("Method SQLite.prototype.query called on incompatible receiver %s", r.objectproto_toString(FunctionCall{This: thisObj})))
+		f = &sqliteFunction{name: name, aggregate: aggregate}
+		impl := &sqlite.FunctionImpl{NArgs: -1, Deterministic: deterministic}
+		if aggregate {
+			impl.MakeAggregate = f.makeAggregate
+		} else {
+			impl.Scalar = f.call
+		}
+		if err := sqlite.RegisterFunction(key, impl); err != nil {
+			sqliteFunctionsMu.Unlock()
+			panic(r.NewGoError(err))
+		}
+		sqliteFunctions[key] = f
+	}
+	sqliteFunctionsMu.Unlock()
+	if f.aggregate != aggregate {
+		kind := "a scalar"
+		if f.aggregate {
+			kind = "an aggregate"
+		}
+		panic(r.NewTypeError("%s is already registered as %s function", name, kind))
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.owner != nil && f.owner.val.runtime != r {
+		panic(r.NewTypeError("SQL function %s is registered by another Runtime", name))
+	}
+	f.owner = so
+	bind(f)
+}
+
+// releaseFunctions unbinds the functions owned by the database, which is closed.
+func (so *sqliteObject) releaseFunctions() {
+	sqliteFunctionsMu.Lock()
+	defer sqliteFunctionsMu.Unlock()
+	for _, f := range sqliteFunctions {
+		f.mu.Lock()
+		if f.owner == so {
+			f.owner, f.scalar, f.init, f.step, f.final, f.inverse = nil, nil, nil, nil, nil, nil
+		}
+		f.mu.Unlock()
+	}
+}
+
+// runtime returns the Runtime of the owner, or an error if the function is not bound.
+func (f *sqliteFunction) runtime() (*Runtime, error) {
+	if f.owner == nil {
+		return nil, fmt.Errorf("SQL function %s is not registered", f.name)
 	}
-	_sql := call.Argument(0).toString().String()
-	rows, err := DatabaseQuery(mo.db, _sql)
+	return f.owner.val.runtime, nil
+}
+
+func (r *Runtime) sqliteArgs(args []driver.Value) []Value {
+	values := make([]Value, len(args))
+	for i, arg := range args {
+		if b, ok := arg.([]byte); ok {
+			// the arguments are not valid after the call
+			arg = append([]byte(nil), b...)
+		}
+		values[i] = r.columnToValue(arg)
+	}
+	return values
+}
+
+// sqliteResult converts the result of a function to a value of SQLite: null, a number, a string, a boolean, binary
+// data or a Date, which is stored as text like the parameters of type Date.
+func (r *Runtime) sqliteResult(v Value) (driver.Value, error) {
+	if v == nil || v == _undefined || v == _null {
+		return nil, nil
+	}
+	if o, ok := v.(*Object); ok {
+		switch d := o.self.(type) {
+		case *arrayBufferObject, *typedArrayObject, *dataViewObject:
+			b, _ := r.httpBytes(o)
+			return append([]byte(nil), b...), nil
+		case *dateObject:
+			if t, ok := d.export(nil).(time.Time); ok {
+				return t.Format("2006-01-02 15:04:05.999999999-07:00"), nil
+			}
+			return nil, nil
+		}
+		return nil, fmt.Errorf("unsupported result of a SQL function: %s", v.String())
+	}
+	switch e := v.Export().(type) {
+	case int64, float64, string, bool:
+		return e, nil
+	}
+	return v.String(), nil
+}
+
+func (f *sqliteFunction) call(_ *sqlite.FunctionContext, args []driver.Value) (driver.Value, error) {
+	f.mu.Lock()
+	r, err := f.runtime()
+	scalar := f.scalar
+	f.mu.Unlock()
+	if err != nil {
+		return nil, err
+	}
+	fn, _ := AssertFunction(scalar)
+	v, err := fn(_undefined, r.sqliteArgs(args)...)
+	if err != nil {
+		return nil, err
+	}
+	return r.sqliteResult(v)
+}
+
+// sqliteAggregate is an evaluation of an aggregate function, which reduces the rows to state.
+type sqliteAggregate struct {
+	runtime              *Runtime
+	step, final, inverse Value
+	state                Value
+}
+
+func (f *sqliteFunction) makeAggregate(sqlite.FunctionContext) (sqlite.AggregateFunction, error) {
+	f.mu.Lock()
+	r, err := f.runtime()
+	a := &sqliteAggregate{runtime: r, step: f.step, final: f.final, inverse: f.inverse, state: f.init}
+	f.mu.Unlock()
 	if err != nil {
-		return _null
-	} else {
-		return r.ToValue(rows)
+		return nil, err
+	}
+	if init, ok := AssertFunction(a.state); ok {
+		state, err := init(_undefined)
+		if err != nil {
+			return nil, err
+		}
+		a.state = state
+	}
+	if a.state == nil {
+		a.state = _undefined
 	}
+	return a, nil
 }
 
+func (a *sqliteAggregate) reduce(fn Value, args []driver.Value) error {
+	callable, _ := AssertFunction(fn)
+	state, err := callable(_undefined, append([]Value{a.state}, a.runtime.sqliteArgs(args)...)...)
+	if err != nil {
+		return err
+	}
+	a.state = state
+	return nil
+}
+
+func (a *sqliteAggregate) Step(_ *sqlite.FunctionContext, args []driver.Value) error {
+	return a.reduce(a.step, args)
+}
+
+func (a *sqliteAggregate) WindowInverse(_ *sqlite.FunctionContext, args []driver.Value) error {
+	if a.inverse == nil {
+		return fmt.Errorf("the aggregate function has no inverse and cannot be used as a window function")
+	}
+	return a.reduce(a.inverse, args)
+}
+
+func (a *sqliteAggregate) WindowValue(*sqlite.FunctionContext) (driver.Value, error) {
+	v := a.state
+	if a.final != nil {
+		final, _ := AssertFunction(a.final)
+		var err error
+		if v, err = final(_undefined, v); err != nil {
+			return nil, err
+		}
+	}
+	return a.runtime.sqliteResult(v)
+}
+
+func (a *sqliteAggregate) Final(*sqlite.FunctionContext) {}
+
+// builtinSQLite_function is function(name, fn, {deterministic}). It registers fn as a scalar SQL function, which is
+// called with the arguments of each invocation.
+func (r *Runtime) builtinSQLite_function(call FunctionCall) Value {
+	so := r.toSQLite("function", call)
+	name := call.Argument(0).String()
+	fn := call.Argument(1)
+	if _, ok := AssertFunction(fn); !ok {
+		panic(r.NewTypeError("The implementation of a SQL function must be a function"))
+	}
+	deterministic := false
+	if opts, ok := call.Argument(2).(*Object); ok {
+		deterministic = nilSafe(opts.self.getStr("deterministic", nil)).ToBoolean()
+	}
+	so.registerFunction(name, false, deterministic, func(f *sqliteFunction) {
+		f.scalar = fn
+	})
+	return _undefined
+}
+
+// builtinSQLite_aggregate is aggregate(name, {init, step, final, inverse, deterministic}). The state of an
+// evaluation starts with init, or the result of init if it is a function, and step(state, ...args) returns the next
+// state for each row. The result is final(state), or the state without final. inverse(state, ...args) removes a row
+// from the state, which is needed to use the function as a window function.
+func (r *Runtime) builtinSQLite_aggregate(call FunctionCall) Value {
+	so := r.toSQLite("aggregate", call)
+	name := call.Argument(0).String()
+	opts := r.toObject(call.Argument(1))
+	fn := func(name string, required bool) Value {
+		v := opts.self.getStr(unistring.NewFromString(name), nil)
+		if v == nil || v == _undefined {
+			if required {
+				panic(r.NewTypeError("The %s of an aggregate function must be a function", name))
+			}
+			return nil
+		}
+		if _, ok := AssertFunction(v); !ok {
+			panic(r.NewTypeError("The %s of an aggregate function must be a function", name))
+		}
+		return v
+	}
+	step, final, inverse := fn("step", true), fn("final", false), fn("inverse", false)
+	init := opts.self.getStr("init", nil)
+	deterministic := nilSafe(opts.self.getStr("deterministic", nil)).ToBoolean()
+	so.registerFunction(name, true, deterministic, func(f *sqliteFunction) {
+		f.init, f.step, f.final, f.inverse = init, step, final, inverse
+	})
+	return _undefined
+}
+
+func (r *Runtime) builtinSQLite_close(call FunctionCall) Value {
+	so := r.toSQLite("close", call)
+	_ = so.db.Close()
+	so.releaseFunctions()
+	if so.keeper != nil {
+		_ = so.keeper.Close()
+		so.keeper = nil
+	}
+	return _undefined
+}
+
+// builtin_newSQLite is new SQLite(path, options) with the options of Database. The path ":memory:" is an in-memory
+// database, which is shared by the connections of the object and dropped when it is closed.
 func (r *Runtime) builtin_newSQLite(args []Value, newTarget *Object) *Object {
 	if newTarget == nil {
 		panic(r.needNew("SQLite"))
 	}
-	if len(args) != 1 {
-		panic("number of arguments must be 1")
+	if len(args) < 1 {
+		panic("number of arguments must be at least 1")
 	}
 
 	// 连接数据库
-	// sqlite3 的 host 可以是 ":memory:"，表示该数据库是内存数据库
-	_host := args[0].toString().String()
-	db, err := sql.Open("sqlite", _host)
-	if err != nil {
-		return nil
-	} else {
-		proto := r.getPrototypeFromCtor(newTarget, r.global.SQLite, r.global.SQLitePrototype)
-		o := &Object{runtime: r}
-		mo := &sqliteObject{
+	_path := args[0].toString().String()
+	memory := _path == ":memory:" || _path == ""
+	if memory {
+		_path = fmt.Sprintf("file:goscript-memory-%d?mode=memory&cache=shared", atomic.AddInt64(&sqliteMemories, 1))
+	}
+	connector := &sqliteConnector{dsn: _path}
+	proto := r.getPrototypeFromCtor(newTarget, r.global.SQLite, r.global.SQLitePrototype)
+	o := &Object{runtime: r}
+	so := &sqliteObject{
+		databaseObject: databaseObject{
 			baseObject: baseObject{
 				class:      classSQLite,
 				val:        o,
@@ -71,20 +457,40 @@ func (r *Runtime) builtin_newSQLite(args []Value, newTarget *Object) *Object {
 				extensible: true,
 				values:     nil,
 			},
-			db: db,
+		},
+		connector: connector,
+		maxIdle:   2, // the default of database/sql
+	}
+	if memory {
+		keeper, err := connector.Connect(c0.Background())
+		if err != nil {
+			panic(r.NewGoError(err))
 		}
-		o.self = mo
-		mo.init()
-		return o
+		so.keeper = keeper
 	}
+	so.driver = "sqlite"
+	r.configureDatabase(&so.databaseObject, sql.OpenDB(connector), args[1:])
+	if len(args) > 1 {
+		if opts, ok := args[1].(*Object); ok {
+			if v := opts.self.getStr("maxIdle", nil); v != nil && v != _undefined {
+				so.maxIdle = int(v.ToInteger())
+			}
+		}
+	}
+	o.self = so
+	so.init()
+	return o
 }
 
 func (r *Runtime) createSQLiteProto(val *Object) objectImpl {
-	o := newBaseObjectObj(val, r.global.ObjectPrototype, classSQLite)
+	o := newBaseObjectObj(val, r.global.DatabasePrototype, classObject)
 	o._putProp("constructor", r.global.SQLite, true, false, true)
 	o._putProp("close", r.newNativeFunc(r.builtinSQLite_close, nil, "close", nil, 0), true, false, true)
-	o._putProp("exec", r.newNativeFunc(r.builtinSQLite_exec, nil, "exec", nil, 1), true, false, true)
-	o._putProp("query", r.newNativeFunc(r.builtinSQLite_query, nil, "query", nil, 1), true, false, true)
+	o._putProp("attach", r.newNativeFunc(r.builtinSQLite_attach, nil, "attach", nil, 2), true, false, true)
+	o._putProp("detach", r.newNativeFunc(r.builtinSQLite_detach, nil, "detach", nil, 1), true, false, true)
+	o._putProp("backup", r.newNativeFunc(r.builtinSQLite_backup, nil, "backup", nil, 1), true, false, true)
+	o._putProp("function", r.newNativeFunc(r.builtinSQLite_function, nil, "function", nil, 2), true, false, true)
+	o._putProp("aggregate", r.newNativeFunc(r.builtinSQLite_aggregate, nil, "aggregate", nil, 2), true, false, true)
 	o._putSym(SymToStringTag, valueProp(asciiString(classSQLite), false, false, true))
 	return o
 }
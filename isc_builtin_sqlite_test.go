@@ -0,0 +1,300 @@
+package goscript
+
+import (
+	"database/sql"
+	"path/filepath"
+	"testing"
+)
+
+func TestSQLiteStatements(t *testing.T) {
+	vm := newWithTestLib(t)
+	_ = vm.Set("path", filepath.Join(t.TempDir(), "test.db"))
+	_, err := vm.RunString(`
+	var db = new SQLite(path);
+	db.exec("create table users (id integer primary key autoincrement, name text, age integer)");
+	var res = db.exec("insert into users (name, age) values (?, ?)", "alice", 30);
+	assert.sameValue(res.rowsAffected, 1, "rowsAffected");
+	assert.sameValue(res.lastInsertId, 1, "lastInsertId");
+	db.exec("insert into users (name, age) values (?, ?)", "bob'; drop table users; --", null);
+
+	var rows = db.query("select name from users where age > ?", 20);
+	assert.sameValue(rows.length, 1, "rows");
+	assert.sameValue(rows[0].name, "alice", "row");
+	assert.sameValue(db.query("select id from users where name = ?", "bob'; drop table users; --").length, 1, "injection");
+
+	var tx = db.begin();
+	assert.sameValue(Object.prototype.toString.call(tx), "[object SQLTransaction]", "toStringTag");
+	tx.exec("insert into users (name, age) values (?, ?)", "carol", 40);
+	assert.sameValue(tx.query("select id from users").length, 3, "in transaction");
+	tx.rollback();
+	assert.sameValue(db.query("select id from users").length, 2, "rollback");
+
+	tx = db.begin();
+	assert.sameValue(tx.exec("update users set age = ? where name = ?", 31, "alice").rowsAffected, 1, "update");
+	tx.commit();
+	assert.sameValue(db.query("select age from users where name = 'alice'")[0].age, 31, "commit");
+
+	try {
+		tx.commit();
+		throw new Error("commit twice");
+	} catch (e) {
+		assert.sameValue(e instanceof Error, true, "commit error");
+	}
+	try {
+		db.query("select * from missing");
+		throw new Error("no error");
+	} catch (e) {
+		assert.sameValue(e instanceof Error, true, "error type");
+		assert.sameValue(e.message.indexOf("no such table") >= 0, true, "driver message: " + e.message);
+	}
+	["exec", "query"].forEach(function (method) {
+		try {
+			db[method]();
+			throw new Error("no error");
+		} catch (e) {
+			assert.sameValue(e.message !== "no error", true, method + " without arguments");
+		}
+	});
+	db.close();
+	`)
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestSQLiteTypedRows(t *testing.T) {
+	vm := newWithTestLib(t)
+	_ = vm.Set("path", filepath.Join(t.TempDir(), "test.db"))
+	_, err := vm.RunString(`
+	var db = new SQLite(path);
+	db.exec("create table items (item_id integer, unit_price real, in_stock boolean, created_at datetime, raw_data blob, note text)");
+	db.exec("insert into items values (?, ?, ?, ?, ?, ?)", 1, 2.5, true, new Date(Date.UTC(2024, 0, 2, 3, 4, 5)),
+		new Uint8Array([1, 2, 3]), null);
+	db.exec("insert into items values (2, null, 0, null, null, 'n')");
+
+	var rows = db.query("select * from items order by item_id");
+	assert.sameValue(rows.length, 2, "rows with nulls are kept");
+	assert.sameValue(Object.keys(rows[0]).join(), "itemId,unitPrice,inStock,createdAt,rawData,note", "keys in column order");
+	var row = rows[0];
+	assert.sameValue(row.itemId, 1, "integer");
+	assert.sameValue(row.unitPrice, 2.5, "real");
+	assert.sameValue(row.inStock, true, "boolean");
+	assert.sameValue(row.createdAt instanceof Date, true, "datetime");
+	assert.sameValue(row.createdAt.getTime(), Date.UTC(2024, 0, 2, 3, 4, 5), "datetime value");
+	assert.sameValue(row.rawData instanceof Uint8Array, true, "blob");
+	assert.sameValue(Array.prototype.join.call(row.rawData), "1,2,3", "blob value");
+	assert.sameValue(row.note, null, "null");
+	assert.sameValue(rows[1].unitPrice, null, "null real");
+	assert.sameValue(rows[1].inStock, false, "false");
+	assert.sameValue(rows[1].note, "n", "text");
+	row = db.query("select 9007199254740991 as safe, 9007199254740993 as big, -9007199254740993 as small")[0];
+	assert.sameValue(row.safe, 9007199254740991, "safe integer");
+	assert.sameValue(row.big, "9007199254740993", "big integer");
+	assert.sameValue(row.small, "-9007199254740993", "small integer");
+	db.close();
+
+	db = new SQLite(path, {camelCase: false});
+	assert.sameValue(Object.keys(db.query("select item_id from items")[0]).join(), "item_id", "camelCase off");
+	assert.sameValue(Object.keys(db.begin().query("select item_id from items")[0]).join(), "item_id", "camelCase off in transaction");
+	db.close();
+	`)
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestCamelCase(t *testing.T) {
+	for _, tc := range []struct{ in, out string }{
+		{"name", "name"},
+		{"user_name", "userName"},
+		{"created_at_utc", "createdAtUtc"},
+		{"_id", "Id"},
+		{"a__b", "aB"},
+		{"prix_été", "prixÉté"},
+	} {
+		if s := camelCase(tc.in); s != tc.out {
+			t.Errorf("camelCase(%q) = %q, want %q", tc.in, s, tc.out)
+		}
+	}
+}
+
+func TestDatabaseQuery(t *testing.T) {
+	db, err := sql.Open("sqlite", ":memory:")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	const query = "select 1 as item_id, 2.5 as unit_price, 'x' as note"
+	rows, err := DatabaseQuery(db, query)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(rows) != 1 || rows[0]["itemId"] != "1" || rows[0]["unitPrice"] != "2.5" || rows[0]["note"] != "x" {
+		t.Fatalf("Unexpected rows: %v", rows)
+	}
+	rows, err = DatabaseQuery(db, "select 1 as a, null as b union all select 2, 'x'")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(rows) != 2 || rows[0]["a"] != "1" || rows[0]["b"] != "" || rows[1]["b"] != "x" {
+		t.Fatalf("Unexpected rows with NULL: %v", rows)
+	}
+	if _, err = DatabaseQuery(db, "select * from missing"); err == nil {
+		t.Fatal("no error")
+	}
+	typed, err := DatabaseQueryTyped(db, query)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(typed) != 1 || typed[0]["itemId"] != int64(1) || typed[0]["unitPrice"] != 2.5 || typed[0]["note"] != "x" {
+		t.Fatalf("Unexpected typed rows: %v", typed)
+	}
+}
+
+func TestSQLiteMemoryAttachBackup(t *testing.T) {
+	vm := newWithTestLib(t)
+	dir := t.TempDir()
+	_ = vm.Set("other", filepath.Join(dir, "other.db"))
+	_ = vm.Set("copy", filepath.Join(dir, "copy.db"))
+	_, err := vm.RunString(`
+	var db = new SQLite(":memory:", {maxOpen: 4});
+	db.exec("create table t (v integer)");
+	var tx = db.begin();
+	tx.exec("insert into t values (1)");
+	// another connection of the pool sees the same in-memory database
+	assert.sameValue(db.query("select count(*) as n from sqlite_master where name = 't'")[0].n, 1, "shared memory");
+	try {
+		db.attach(other, "other");
+		throw new Error("attach in transaction");
+	} catch (e) {
+		assert.sameValue(e instanceof TypeError, true, "attach in transaction");
+	}
+	tx.commit();
+
+	var o = new SQLite(other);
+	o.exec("create table u (w text)");
+	o.exec("insert into u values ('x')");
+	o.close();
+
+	db.attach(other, "other");
+	assert.sameValue(db.query("select w from other.u")[0].w, "x", "attached");
+	tx = db.begin();
+	assert.sameValue(tx.query("select count(*) as n from t, other.u")[0].n, 1, "attached in transaction");
+	tx.rollback();
+	db.detach("other");
+	try {
+		db.query("select w from other.u");
+		throw new Error("detached");
+	} catch (e) {
+		assert.sameValue(e.message.indexOf("no such table") >= 0, true, "detached: " + e.message);
+	}
+
+	db.backup(copy);
+	db.close();
+	var c = new SQLite(copy);
+	assert.sameValue(c.query("select v from t")[0].v, 1, "backup");
+	c.close();
+
+	var m = new SQLite(":memory:");
+	assert.sameValue(m.query("select count(*) as n from sqlite_master")[0].n, 0, "separate memory databases");
+	m.close();
+	`)
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestSQLiteFunctions(t *testing.T) {
+	vm := newWithTestLib(t)
+	_, err := vm.RunString(`
+	var db = new SQLite(":memory:");
+	db.exec("create table t (g text, v integer, b blob)");
+	db.exec("insert into t values ('a', 1, ?), ('a', 2, null), ('b', 5, null)", new Uint8Array([7, 8]));
+
+	db.function("js_double", function (v) { return v * 2; }, {deterministic: true});
+	assert.sameValue(db.query("select js_double(v) as d from t where g = 'b'")[0].d, 10, "scalar");
+	db.function("js_len", function (b) { return b === null ? -1 : b.length; });
+	assert.sameValue(db.query("select js_len(b) as n from t where v = 1")[0].n, 2, "blob argument");
+	db.function("js_concat", function () { return Array.prototype.join.call(arguments, "-"); });
+	assert.sameValue(db.query("select js_concat(g, v, 'z') as s from t where v = 5")[0].s, "b-5-z", "variadic");
+	db.function("js_fail", function () { throw new Error("boom"); });
+	try {
+		db.query("select js_fail()");
+		throw new Error("no error");
+	} catch (e) {
+		assert.sameValue(e.message.indexOf("boom") >= 0, true, "exception: " + e.message);
+	}
+
+	db.aggregate("js_sum", {
+		init: function () { return 0; },
+		step: function (s, v) { return s + v; },
+		inverse: function (s, v) { return s - v; }
+	});
+	var rows = db.query("select g, js_sum(v) as s from t group by g order by g");
+	assert.sameValue(rows[0].s, 3, "aggregate a");
+	assert.sameValue(rows[1].s, 5, "aggregate b");
+	rows = db.query("select js_sum(v) over (order by v rows between 1 preceding and current row) as s from t order by v");
+	assert.sameValue(rows.map(function (r) { return r.s; }).join(), "1,3,7", "window");
+
+	db.aggregate("js_list", {init: "", step: function (s, v) { return s + v; }, final: function (s) { return "[" + s + "]"; }});
+	assert.sameValue(db.query("select js_list(v) as l from (select v from t order by v)")[0].l, "[125]", "final");
+
+	try {
+		db.function("js_sum", function () {});
+		throw new Error("kind changed");
+	} catch (e) {
+		assert.sameValue(e instanceof TypeError, true, "kind changed");
+	}
+	db.close();
+	`)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// the functions of the databases of a Runtime cannot be replaced by another Runtime until they are closed
+	if _, err = vm.RunString(`
+	var mine = new SQLite(":memory:");
+	mine.function("js_owned", function () { return "mine"; });
+	var other = new SQLite(":memory:");
+	other.function("js_owned", function () { return "other"; });
+	assert.sameValue(mine.query("select js_owned() as s")[0].s, "other", "same Runtime");
+	`); err != nil {
+		t.Fatal(err)
+	}
+	vm2 := New()
+	_, err = vm2.RunString(`
+	var db = new SQLite(":memory:");
+	try {
+		db.function("js_owned", function () { return "theirs"; });
+		throw new Error("no error");
+	} catch (e) {
+		if (!(e instanceof TypeError)) {
+			throw e;
+		}
+	}
+	`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err = vm.RunString(`mine.close(); other.close();`); err != nil {
+		t.Fatal(err)
+	}
+	_, err = vm2.RunString(`
+	try {
+		db.query("select js_owned()");
+		throw new Error("no error");
+	} catch (e) {
+		if (e.message.indexOf("not registered") < 0) {
+			throw e;
+		}
+	}
+	db.function("js_owned", function () { return "theirs"; });
+	if (db.query("select js_owned() as s")[0].s !== "theirs") {
+		throw new Error("not replaced");
+	}
+	db.close();
+	`)
+	if err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,672 @@
+package goscript
+
+import (
+	c0 "context"
+	"errors"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+
+	"github.com/rarnu/goscript/unistring"
+	"golang.org/x/net/websocket"
+)
+
+// WebSocket 客户端（与浏览器兼容的 API）以及 HTTP.createServer 的 WebSocket 服务端。事件由 Scheduler（如 eventloop）在
+// Runtime 的 goroutine 上派发。
+// golang.org/x/net/websocket 不提供关闭帧的状态码和原因：发送的关闭帧总是 1000，close 事件的 code 在正常关闭时为 1000，
+// 否则为 1006，本端调用 close(code, reason) 时为其参数
+
+const (
+	wsConnecting = iota
+	wsOpen
+	wsClosing
+	wsClosed
+)
+
+const (
+	wsCloseNormal   = 1000
+	wsCloseAbnormal = 1006
+)
+
+// wsMessage is a message received or to be sent, a nil message closes the connection once the previous ones are sent.
+type wsMessage struct {
+	data   []byte
+	binary bool
+}
+
+// wsQueue is the unbounded queue of the messages to send, so that send never blocks.
+type wsQueue struct {
+	mu       sync.Mutex
+	messages []*wsMessage
+	closed   bool
+	signal   chan struct{}
+	buffered int64 // the number of bytes of the queued messages
+}
+
+func (q *wsQueue) push(m *wsMessage) {
+	q.mu.Lock()
+	if !q.closed {
+		q.messages = append(q.messages, m)
+		if m != nil {
+			q.buffered += int64(len(m.data))
+		}
+	}
+	q.mu.Unlock()
+	select {
+	case q.signal <- struct{}{}:
+	default:
+	}
+}
+
+// pop waits for the next message, ok is false once the queue is closed.
+func (q *wsQueue) pop() (m *wsMessage, ok bool) {
+	for {
+		q.mu.Lock()
+		if len(q.messages) > 0 {
+			m, q.messages = q.messages[0], q.messages[1:]
+			q.mu.Unlock()
+			return m, true
+		}
+		closed := q.closed
+		q.mu.Unlock()
+		if closed {
+			return nil, false
+		}
+		<-q.signal
+	}
+}
+
+// sent removes the size of a sent message from the buffered amount.
+func (q *wsQueue) sent(m *wsMessage) {
+	q.mu.Lock()
+	q.buffered -= int64(len(m.data))
+	q.mu.Unlock()
+}
+
+func (q *wsQueue) bufferedAmount() int64 {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return q.buffered
+}
+
+// close discards the queued messages and stops the writer.
+func (q *wsQueue) close() {
+	q.mu.Lock()
+	q.closed, q.messages, q.buffered = true, nil, 0
+	q.mu.Unlock()
+	select {
+	case q.signal <- struct{}{}:
+	default:
+	}
+}
+
+var wsCodec = websocket.Codec{
+	Marshal: func(v interface{}) ([]byte, byte, error) {
+		m := v.(*wsMessage)
+		if m.binary {
+			return m.data, websocket.BinaryFrame, nil
+		}
+		return m.data, websocket.TextFrame, nil
+	},
+	Unmarshal: func(data []byte, payloadType byte, v interface{}) error {
+		m := v.(*wsMessage)
+		m.data, m.binary = data, payloadType == websocket.BinaryFrame
+		return nil
+	},
+}
+
+// webSocketObject is a WebSocket, opened by the constructor or accepted by a server. Its fields are used on the
+// goroutine of the Runtime, except out.
+type webSocketObject struct {
+	baseObject
+	url        string
+	protocol   string
+	binaryType string
+	state      int
+	listeners  map[string][]Value
+
+	out *wsQueue
+
+	// the status of a close requested by close
+	closeRequested bool
+	closeCode      int
+	closeReason    string
+
+	done func(func()) // completes the operation that keeps the scheduler running while the socket is not closed
+}
+
+func (r *Runtime) newWebSocket(proto *Object, u string) *webSocketObject {
+	o := &Object{runtime: r}
+	ws := &webSocketObject{
+		baseObject: baseObject{class: classWebSocket, val: o, prototype: proto, extensible: true, values: nil},
+		url:        u,
+		binaryType: "arraybuffer",
+		listeners:  make(map[string][]Value),
+		out:        &wsQueue{signal: make(chan struct{}, 1)},
+	}
+	o.self = ws
+	ws.init()
+	ws.done = r.scheduler.Schedule()
+	return ws
+}
+
+// fire dispatches an event to the on<type> handler and to the listeners. As in browsers, the exceptions of the
+// handlers do not stop the dispatch.
+func (ws *webSocketObject) fire(typ string, props map[string]any) {
+	r := ws.val.runtime
+	event := r.NewObject()
+	_ = event.Set("type", typ)
+	_ = event.Set("target", ws.val)
+	for k, v := range props {
+		_ = event.Set(k, v)
+	}
+	if fn, ok := AssertFunction(ws.val.Get("on" + typ)); ok {
+		_, _ = fn(ws.val, event)
+	}
+	for _, listener := range append([]Value(nil), ws.listeners[typ]...) {
+		if fn, ok := AssertFunction(listener); ok {
+			_, _ = fn(ws.val, event)
+		}
+	}
+}
+
+// serve sends the queued messages and dispatches the received ones until the connection is closed. It is called on
+// the goroutine of the connection.
+func (ws *webSocketObject) serve(conn *websocket.Conn) {
+	r := ws.val.runtime
+	out := ws.out
+	go func() {
+		for {
+			m, ok := out.pop()
+			if !ok {
+				return
+			}
+			if m == nil {
+				_ = conn.Close()
+				out.close()
+				return
+			}
+			err := wsCodec.Send(conn, m)
+			out.sent(m)
+			if err != nil {
+				_ = conn.Close()
+				out.close()
+				return
+			}
+		}
+	}()
+
+	var err error
+	for {
+		m := &wsMessage{}
+		if err = wsCodec.Receive(conn, m); err != nil {
+			break
+		}
+		r.dispatch(func() {
+			if ws.state != wsOpen {
+				return
+			}
+			var data Value
+			if m.binary {
+				data = r.NewArrayBuffer(m.data).toValue(r)
+			} else {
+				data = newStringValue(string(m.data))
+			}
+			ws.fire("message", map[string]any{"data": data, "origin": ws.origin()})
+		})
+	}
+	_ = conn.Close()
+	clean := err == io.EOF
+	ws.finish(func() {
+		out.close()
+		code, reason := wsCloseNormal, ""
+		if ws.closeRequested {
+			code, reason, clean = ws.closeCode, ws.closeReason, true
+		} else if !clean {
+			code = wsCloseAbnormal
+			ws.fire("error", map[string]any{"message": err.Error()})
+		}
+		ws.state = wsClosed
+		ws.fire("close", map[string]any{"code": code, "reason": reason, "wasClean": clean})
+	})
+}
+
+// finish runs the last function of the socket on the goroutine of the Runtime and releases the scheduler.
+func (ws *webSocketObject) finish(fn func()) {
+	if done := ws.done; done != nil {
+		ws.done = nil
+		done(fn)
+		return
+	}
+	ws.val.runtime.dispatch(fn)
+}
+
+func (ws *webSocketObject) origin() string {
+	u, err := url.Parse(ws.url)
+	if err != nil {
+		return ""
+	}
+	return u.Scheme + "://" + u.Host
+}
+
+// connect opens the connection of a client socket on a new goroutine.
+func (ws *webSocketObject) connect(config *websocket.Config) {
+	r := ws.val.runtime
+	go func() {
+		conn, err := websocket.DialConfig(config)
+		if err != nil {
+			ws.finish(func() {
+				ws.state = wsClosed
+				ws.fire("error", map[string]any{"message": err.Error()})
+				ws.fire("close", map[string]any{"code": wsCloseAbnormal, "reason": "", "wasClean": false})
+			})
+			return
+		}
+		opened := make(chan bool, 1)
+		r.dispatch(func() {
+			if ws.closeRequested {
+				// closed while connecting
+				opened <- false
+				return
+			}
+			ws.state = wsOpen
+			if len(config.Protocol) == 1 {
+				ws.protocol = config.Protocol[0]
+			}
+			opened <- true
+			ws.fire("open", nil)
+		})
+		if !<-opened {
+			_ = conn.Close()
+			ws.finish(func() {
+				ws.state = wsClosed
+				ws.fire("error", map[string]any{"message": "WebSocket is closed before the connection is established"})
+				ws.fire("close", map[string]any{"code": wsCloseAbnormal, "reason": "", "wasClean": false})
+			})
+			return
+		}
+		ws.serve(conn)
+	}()
+}
+
+// builtin_newWebSocket is new WebSocket(url, protocols, options). The options are not standard: {headers, origin,
+// tls}, tls is the tls option of HTTP.request.
+func (r *Runtime) builtin_newWebSocket(args []Value, newTarget *Object) *Object {
+	if newTarget == nil {
+		panic(r.needNew("WebSocket"))
+	}
+	if r.scheduler == nil {
+		panic(r.NewTypeError("WebSocket requires an event loop"))
+	}
+	arg := func(i int) Value {
+		if i < len(args) {
+			return args[i]
+		}
+		return _undefined
+	}
+	u, err := url.Parse(arg(0).String())
+	if err == nil {
+		switch u.Scheme {
+		case "http":
+			u.Scheme = "ws"
+		case "https":
+			u.Scheme = "wss"
+		case "ws", "wss":
+		default:
+			err = errors.New("unsupported scheme")
+		}
+	}
+	if err != nil || u.Host == "" || u.Fragment != "" {
+		panic(r.newSyntaxError("Invalid WebSocket URL: "+arg(0).String(), 0))
+	}
+	var protocols []string
+	if p := arg(1); !IsUndefined(p) && !IsNull(p) {
+		if a, ok := p.(*Object); ok && a.self.className() == classArray {
+			for _, k := range a.Keys() {
+				protocols = append(protocols, a.Get(k).String())
+			}
+		} else {
+			protocols = []string{p.String()}
+		}
+	}
+	for i, p := range protocols {
+		if !isHeaderToken(p) {
+			panic(r.newSyntaxError("Invalid WebSocket protocol: "+p, 0))
+		}
+		for _, q := range protocols[:i] {
+			if q == p {
+				panic(r.newSyntaxError("Duplicate WebSocket protocol: "+p, 0))
+			}
+		}
+	}
+
+	origin := &url.URL{Scheme: "http", Host: u.Host}
+	if u.Scheme == "wss" {
+		origin.Scheme = "https"
+	}
+	config := &websocket.Config{Location: u, Origin: origin, Protocol: protocols, Version: websocket.ProtocolVersionHybi13, Header: http.Header{}}
+	if opts := arg(2); !IsUndefined(opts) && !IsNull(opts) {
+		o := r.toObject(opts)
+		if v := httpOption(o, "headers"); v != nil {
+			config.Header = r.httpHeader(v)
+		}
+		if v := httpOption(o, "origin"); v != nil {
+			if config.Origin, err = url.Parse(v.String()); err != nil {
+				panic(r.NewTypeError("Invalid origin: %s", v.String()))
+			}
+		}
+		if v := httpOption(o, "tls"); v != nil {
+			config.TlsConfig = r.httpTLSConfig(v)
+		}
+	}
+
+	ws := r.newWebSocket(r.getPrototypeFromCtor(newTarget, r.global.WebSocket, r.global.WebSocketPrototype), u.String())
+	ws.connect(config)
+	return ws.val
+}
+
+func (r *Runtime) toWebSocket(v Value, method string) *webSocketObject {
+	thisObj := r.toObject(v)
+	ws, ok := thisObj.self.(*webSocketObject)
+	if !ok {
+		panic(r.NewTypeError("Method WebSocket.prototype.%s called on incompatible receiver %s", method, r.objectproto_toString(FunctionCall{This: thisObj})))
+	}
+	return ws
+}
+
+// builtinWebSocket_send queues a string or bytes. The messages sent after the socket is closing are discarded.
+func (r *Runtime) builtinWebSocket_send(call FunctionCall) Value {
+	ws := r.toWebSocket(call.This, "send")
+	if ws.state == wsConnecting {
+		panic(r.newInvalidStateError("Failed to execute 'send' on 'WebSocket': Still in CONNECTING state."))
+	}
+	m := &wsMessage{}
+	data := call.Argument(0)
+	if _, ok := data.(valueString); ok {
+		m.data = []byte(data.String())
+	} else if b, ok := r.httpBytes(data); ok && data != nil {
+		m.data, m.binary = append([]byte(nil), b...), true
+	} else {
+		m.data = []byte(data.String())
+	}
+	if ws.state != wsOpen {
+		return _undefined
+	}
+	ws.out.push(m)
+	return _undefined
+}
+
+// builtinWebSocket_close closes the socket: close(code, reason). The code is 1000 or in 3000-4999.
+func (r *Runtime) builtinWebSocket_close(call FunctionCall) Value {
+	ws := r.toWebSocket(call.This, "close")
+	code, reason := wsCloseNormal, ""
+	if v := call.Argument(0); !IsUndefined(v) {
+		code = int(v.ToInteger())
+		if code != wsCloseNormal && (code < 3000 || code > 4999) {
+			panic(r.newInvalidAccessError("The code must be either 1000, or between 3000 and 4999. " + v.String() + " is neither."))
+		}
+	}
+	if v := call.Argument(1); !IsUndefined(v) {
+		reason = v.String()
+		if len(reason) > 123 {
+			panic(r.newSyntaxError("The message must not be greater than 123 bytes.", 0))
+		}
+	}
+	if ws.state == wsClosing || ws.state == wsClosed || ws.closeRequested {
+		return _undefined
+	}
+	ws.closeRequested, ws.closeCode, ws.closeReason = true, code, reason
+	if ws.state == wsOpen {
+		ws.out.push(nil)
+	}
+	ws.state = wsClosing
+	return _undefined
+}
+
+func (r *Runtime) builtinWebSocket_addEventListener(call FunctionCall) Value {
+	ws := r.toWebSocket(call.This, "addEventListener")
+	typ, listener := call.Argument(0).String(), call.Argument(1)
+	if _, ok := AssertFunction(listener); ok {
+		for _, l := range ws.listeners[typ] {
+			if l.SameAs(listener) {
+				return _undefined
+			}
+		}
+		ws.listeners[typ] = append(ws.listeners[typ], listener)
+	}
+	return _undefined
+}
+
+func (r *Runtime) builtinWebSocket_removeEventListener(call FunctionCall) Value {
+	ws := r.toWebSocket(call.This, "removeEventListener")
+	typ := call.Argument(0).String()
+	for i, l := range ws.listeners[typ] {
+		if l.SameAs(call.Argument(1)) {
+			ws.listeners[typ] = append(ws.listeners[typ][:i:i], ws.listeners[typ][i+1:]...)
+			break
+		}
+	}
+	return _undefined
+}
+
+func (r *Runtime) builtinWebSocket_getUrl(call FunctionCall) Value {
+	return newStringValue(r.toWebSocket(call.This, "url").url)
+}
+
+func (r *Runtime) builtinWebSocket_getProtocol(call FunctionCall) Value {
+	return newStringValue(r.toWebSocket(call.This, "protocol").protocol)
+}
+
+func (r *Runtime) builtinWebSocket_getExtensions(call FunctionCall) Value {
+	r.toWebSocket(call.This, "extensions")
+	return stringEmpty
+}
+
+func (r *Runtime) builtinWebSocket_getReadyState(call FunctionCall) Value {
+	return intToValue(int64(r.toWebSocket(call.This, "readyState").state))
+}
+
+func (r *Runtime) builtinWebSocket_getBufferedAmount(call FunctionCall) Value {
+	return intToValue(r.toWebSocket(call.This, "bufferedAmount").out.bufferedAmount())
+}
+
+func (r *Runtime) builtinWebSocket_getBinaryType(call FunctionCall) Value {
+	return newStringValue(r.toWebSocket(call.This, "binaryType").binaryType)
+}
+
+// builtinWebSocket_setBinaryType only supports arraybuffer, there is no Blob. Other values are ignored.
+func (r *Runtime) builtinWebSocket_setBinaryType(call FunctionCall) Value {
+	ws := r.toWebSocket(call.This, "binaryType")
+	switch call.Argument(0).String() {
+	case "arraybuffer":
+		ws.binaryType = "arraybuffer"
+	case "blob":
+		panic(r.NewTypeError("The binaryType blob is not supported"))
+	}
+	return _undefined
+}
+
+func (r *Runtime) newDOMException(name, msg string) Value {
+	e := r.newError(r.global.Error, "%s", msg).(*Object)
+	e.self._putProp("name", asciiString(name), true, false, true)
+	return e
+}
+
+func (r *Runtime) newInvalidStateError(msg string) Value {
+	return r.newDOMException("InvalidStateError", msg)
+}
+
+func (r *Runtime) newInvalidAccessError(msg string) Value {
+	return r.newDOMException("InvalidAccessError", msg)
+}
+
+// server
+
+// httpSockets are the sockets accepted by a server, which are closed when it shuts down.
+type httpSockets struct {
+	mu      sync.Mutex
+	sockets map[*websocket.Conn]struct{}
+	idle    chan struct{} // closed when there are no more sockets
+}
+
+func (s *httpSockets) add(conn *websocket.Conn) {
+	s.mu.Lock()
+	if s.sockets == nil {
+		s.sockets = make(map[*websocket.Conn]struct{})
+	}
+	s.sockets[conn] = struct{}{}
+	s.mu.Unlock()
+}
+
+func (s *httpSockets) remove(conn *websocket.Conn) {
+	s.mu.Lock()
+	delete(s.sockets, conn)
+	if len(s.sockets) == 0 && s.idle != nil {
+		close(s.idle)
+		s.idle = nil
+	}
+	s.mu.Unlock()
+}
+
+// shutdown closes the sockets and waits until their close events are dispatched.
+func (s *httpSockets) shutdown(ctx c0.Context) error {
+	s.mu.Lock()
+	for conn := range s.sockets {
+		_ = conn.Close()
+	}
+	if len(s.sockets) == 0 {
+		s.mu.Unlock()
+		return nil
+	}
+	if s.idle == nil {
+		s.idle = make(chan struct{})
+	}
+	idle := s.idle
+	s.mu.Unlock()
+	select {
+	case <-idle:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// serveWebSocket upgrades a request to a WebSocket and calls the handler of the route with the socket and the
+// request. The origin is not checked. The socket is served until it is closed.
+func (s *httpServerObject) serveWebSocket(w http.ResponseWriter, req *http.Request, rt *httpRoute, params map[string]string) {
+	r := s.runtime
+	server := websocket.Server{
+		Handshake: func(config *websocket.Config, req *http.Request) error {
+			// choose the first protocol of the client that the route supports
+			offered := config.Protocol
+			config.Protocol = nil
+			for _, p := range offered {
+				for _, q := range rt.protocols {
+					if p == q {
+						config.Protocol = []string{p}
+						return nil
+					}
+				}
+			}
+			return nil
+		},
+		Handler: func(conn *websocket.Conn) {
+			s.sockets.add(conn)
+			defer s.sockets.remove(conn)
+			opened := make(chan *webSocketObject, 1)
+			r.dispatch(func() {
+				ws := r.newWebSocket(r.global.WebSocketPrototype, "ws://"+req.Host+req.URL.RequestURI())
+				if req.TLS != nil {
+					ws.url = "wss" + strings.TrimPrefix(ws.url, "ws")
+				}
+				ws.state = wsOpen
+				if len(conn.Config().Protocol) == 1 {
+					ws.protocol = conn.Config().Protocol[0]
+				}
+				opened <- ws
+				reqObj := r.newHttpServerRequest(req, nil, params)
+				if fn, ok := AssertFunction(rt.handler); ok {
+					if _, err := fn(_undefined, ws.val, reqObj); err != nil && s.onError != nil {
+						var ex *Exception
+						if errors.As(err, &ex) {
+							if onError, ok := AssertFunction(s.onError); ok {
+								_, _ = onError(_undefined, ex.Value(), reqObj)
+							}
+						}
+					}
+				}
+			})
+			(<-opened).serve(conn)
+		},
+	}
+	server.ServeHTTP(w, req)
+}
+
+// builtinHTTPServer_ws adds a WebSocket route: ws(pattern, handler, {protocols}). The handler is called with an open
+// WebSocket and the request of the upgrade; protocols are the subprotocols the route supports.
+func (r *Runtime) builtinHTTPServer_ws(call FunctionCall) Value {
+	s := r.toHttpServer(call.This, "ws")
+	rt := r.addHttpRoute(s, http.MethodGet, call.Argument(0), call.Argument(1))
+	rt.upgrade = true
+	if opts := call.Argument(2); !IsUndefined(opts) && !IsNull(opts) {
+		if v := httpOption(r.toObject(opts), "protocols"); v != nil {
+			for _, p := range r.toObject(v).Keys() {
+				rt.protocols = append(rt.protocols, r.toObject(v).Get(p).String())
+			}
+		}
+	}
+	return call.This
+}
+
+func (r *Runtime) createWebSocketProto(val *Object) objectImpl {
+	o := newBaseObjectObj(val, r.global.ObjectPrototype, classObject)
+	o._putProp("constructor", r.global.WebSocket, true, false, true)
+	o._putProp("send", r.newNativeFunc(r.builtinWebSocket_send, nil, "send", nil, 1), true, false, true)
+	o._putProp("close", r.newNativeFunc(r.builtinWebSocket_close, nil, "close", nil, 0), true, false, true)
+	o._putProp("addEventListener", r.newNativeFunc(r.builtinWebSocket_addEventListener, nil, "addEventListener", nil, 2), true, false, true)
+	o._putProp("removeEventListener", r.newNativeFunc(r.builtinWebSocket_removeEventListener, nil, "removeEventListener", nil, 2), true, false, true)
+	accessor := func(name unistring.String, getter, setter func(FunctionCall) Value) {
+		p := &valueProperty{
+			getterFunc:   r.newNativeFunc(getter, nil, "get "+name, nil, 0),
+			accessor:     true,
+			configurable: true,
+		}
+		if setter != nil {
+			p.setterFunc = r.newNativeFunc(setter, nil, "set "+name, nil, 1)
+		}
+		o.setOwnStr(name, p, true)
+	}
+	accessor("url", r.builtinWebSocket_getUrl, nil)
+	accessor("protocol", r.builtinWebSocket_getProtocol, nil)
+	accessor("extensions", r.builtinWebSocket_getExtensions, nil)
+	accessor("readyState", r.builtinWebSocket_getReadyState, nil)
+	accessor("bufferedAmount", r.builtinWebSocket_getBufferedAmount, nil)
+	accessor("binaryType", r.builtinWebSocket_getBinaryType, r.builtinWebSocket_setBinaryType)
+	for _, name := range []unistring.String{"onopen", "onmessage", "onerror", "onclose"} {
+		o._putProp(name, _null, true, true, true)
+	}
+	r.putWebSocketConstants(o)
+	o._putSym(SymToStringTag, valueProp(asciiString(classWebSocket), false, false, true))
+	return o
+}
+
+func (r *Runtime) putWebSocketConstants(o *baseObject) {
+	o._putProp("CONNECTING", intToValue(wsConnecting), false, true, false)
+	o._putProp("OPEN", intToValue(wsOpen), false, true, false)
+	o._putProp("CLOSING", intToValue(wsClosing), false, true, false)
+	o._putProp("CLOSED", intToValue(wsClosed), false, true, false)
+}
+
+func (r *Runtime) createWebSocket(val *Object) objectImpl {
+	o := r.newNativeConstructOnly(val, r.builtin_newWebSocket, r.global.WebSocketPrototype, "WebSocket", 1)
+	r.putWebSocketConstants(&o.baseObject)
+	return o
+}
+
+func (r *Runtime) initWebSocket() {
+	r.global.WebSocketPrototype = r.newLazyObject(r.createWebSocketProto)
+	r.global.WebSocket = r.newLazyObject(r.createWebSocket)
+	r.addToGlobal("WebSocket", r.global.WebSocket)
+}
@@ -0,0 +1,89 @@
+package goscript
+
+import (
+	c0 "context"
+	"testing"
+
+	"golang.org/x/net/websocket"
+)
+
+func TestWebSocketServer(t *testing.T) {
+	vm := New()
+	_, err := vm.RunString(`
+	[
+		function () { new WebSocket("ws://localhost/"); },
+		function () { HTTP.createServer().listen("127.0.0.1:0"); },
+	].forEach(function (f) {
+		try {
+			f();
+		} catch (e) {
+			if (e instanceof TypeError) {
+				return;
+			}
+		}
+		throw new Error("no TypeError without an event loop");
+	});
+	`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	run := startScheduler(t, vm)
+	_, err = runScript(run, vm, `
+	var closed = [];
+	var server = HTTP.createServer();
+	server.ws("/ws", function(ws, req) {
+		ws.send("welcome " + req.query.name);
+		ws.onmessage = function(e) {
+			if (e.data === "bye") {
+				ws.close();
+			} else {
+				ws.send(e.data instanceof ArrayBuffer ? e.data.byteLength + " bytes" : e.data.toUpperCase());
+			}
+		};
+		ws.onclose = function(e) { closed.push(e.code); };
+	});
+	server.listen("127.0.0.1:0");
+	`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var u string
+	run(func() {
+		u = vm.Get("server").ToObject(vm).Get("url").String()
+	})
+	conn, err := websocket.Dial("ws"+u[len("http"):]+"/ws?name=go", "", "http://localhost/")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var msg string
+	receive := func(expected string) {
+		t.Helper()
+		if err := websocket.Message.Receive(conn, &msg); err != nil {
+			t.Fatal(err)
+		}
+		if msg != expected {
+			t.Fatalf("Expected %q, got %q", expected, msg)
+		}
+	}
+	receive("welcome go")
+	_ = websocket.Message.Send(conn, "abc")
+	receive("ABC")
+	_ = websocket.Message.Send(conn, []byte{1, 2, 3, 4})
+	receive("4 bytes")
+	_ = websocket.Message.Send(conn, "bye")
+	if err := websocket.Message.Receive(conn, &msg); err == nil {
+		t.Fatalf("Expected the connection to be closed, got %q", msg)
+	}
+	_ = conn.Close()
+
+	if err := vm.ShutdownHTTPServers(c0.Background()); err != nil {
+		t.Fatal(err)
+	}
+	var s string
+	run(func() {
+		s = vm.Get("closed").String()
+	})
+	if s != "1000" {
+		t.Fatalf("Unexpected close events: %s", s)
+	}
+}
@@ -0,0 +1,226 @@
+/*
+Package jsdoc implements a parser for JSDoc comments.
+
+	program, err := parser.ParseFile(nil, "example.js", src, 0, parser.WithComments)
+	if err != nil {
+	    return err
+	}
+	for _, stmt := range program.Body {
+	    if decl, ok := stmt.(*ast.FunctionDeclaration); ok {
+	        if c := program.CommentMap.Doc(decl); c != nil {
+	            doc := jsdoc.Parse(c.Text)
+	            // ... use doc.Params and doc.Returns ...
+	        }
+	    }
+	}
+
+Only the structure of the comment is parsed: the description, the block tags and the type expressions in braces.
+Type expressions are returned as written, they are not checked in any way.
+*/
+package jsdoc
+
+import (
+	"strings"
+)
+
+// Doc is a parsed JSDoc comment.
+type Doc struct {
+	Description string   // The text before the first block tag
+	Params      []*Param // The @param (@arg, @argument) tags
+	Returns     *Returns // The @returns (@return) tag, nil if there is none
+	Tags        []*Tag   // All block tags in source order, including @param and @returns
+}
+
+// Param is a @param tag, e.g. `@param {string} [options.name="x"] - The name`.
+type Param struct {
+	Name        string // The name, e.g. "options.name"
+	Type        string // The type expression without the braces, e.g. "string", empty if it is not given
+	Description string
+	Optional    bool   // The name is in square brackets or the type ends with "="
+	Default     string // The default value given in square brackets, e.g. `"x"`
+}
+
+// Returns is a @returns tag, e.g. `@returns {Promise<number>} The count`.
+type Returns struct {
+	Type        string // The type expression without the braces, empty if it is not given
+	Description string
+}
+
+// Tag is a block tag.
+type Tag struct {
+	Name string // The name without the "@", e.g. "param"
+	Text string // The rest of the tag with the line breaks preserved
+}
+
+// Param returns the @param tag with the given name, or nil if there is none.
+func (self *Doc) Param(name string) *Param {
+	for _, p := range self.Params {
+		if p.Name == name {
+			return p
+		}
+	}
+	return nil
+}
+
+// Tag returns the first block tag with the given name (without the "@"), or nil if there is none.
+func (self *Doc) Tag(name string) *Tag {
+	for _, t := range self.Tags {
+		if t.Name == name {
+			return t
+		}
+	}
+	return nil
+}
+
+// Parse parses the text of a JSDoc comment. The comment markers ("/**", "*/" and the leading "*" of each line)
+// are removed if present. Inline tags such as {@link x} are left in the descriptions as they are.
+func Parse(text string) *Doc {
+	doc := &Doc{}
+	var description []string
+	var tag *Tag
+	var lines []string
+	flush := func() {
+		if tag != nil {
+			tag.Text = strings.TrimSpace(strings.Join(lines, "\n"))
+			doc.addTag(tag)
+		}
+	}
+	inFence := false
+	for _, line := range strings.Split(strip(text), "\n") {
+		trimmed := strings.TrimSpace(line)
+		if strings.HasPrefix(trimmed, "```") {
+			inFence = !inFence
+		}
+		if !inFence && strings.HasPrefix(trimmed, "@") {
+			flush()
+			name := trimmed[1:]
+			rest := ""
+			if i := strings.IndexAny(name, " \t"); i >= 0 {
+				name, rest = name[:i], strings.TrimSpace(name[i:])
+			}
+			tag = &Tag{Name: name}
+			lines = []string{rest}
+			continue
+		}
+		if tag != nil {
+			lines = append(lines, trimmed)
+		} else {
+			description = append(description, trimmed)
+		}
+	}
+	flush()
+	doc.Description = strings.TrimSpace(strings.Join(description, "\n"))
+	return doc
+}
+
+// strip removes the comment markers.
+func strip(text string) string {
+	text = strings.TrimSpace(text)
+	if strings.HasPrefix(text, "/**") {
+		text = text[3:]
+	} else if strings.HasPrefix(text, "/*") {
+		text = text[2:]
+	}
+	text = strings.TrimSuffix(text, "*/")
+	text = strings.NewReplacer("\r\n", "\n", "\r", "\n").Replace(text)
+	lines := strings.Split(text, "\n")
+	for i, line := range lines {
+		trimmed := strings.TrimLeft(line, " \t")
+		if strings.HasPrefix(trimmed, "*") {
+			line = trimmed[1:]
+		}
+		lines[i] = line
+	}
+	return strings.Join(lines, "\n")
+}
+
+func (self *Doc) addTag(tag *Tag) {
+	self.Tags = append(self.Tags, tag)
+	switch tag.Name {
+	case "param", "arg", "argument":
+		p := &Param{}
+		text := tag.Text
+		p.Type, text = parseType(text)
+		if strings.HasSuffix(p.Type, "=") {
+			p.Optional = true
+			p.Type = strings.TrimSpace(strings.TrimSuffix(p.Type, "="))
+		}
+		p.Name, text = parseName(text)
+		if strings.HasPrefix(p.Name, "[") {
+			p.Optional = true
+			p.Name = strings.TrimSuffix(p.Name[1:], "]")
+			if i := strings.IndexByte(p.Name, '='); i >= 0 {
+				p.Name, p.Default = strings.TrimSpace(p.Name[:i]), strings.TrimSpace(p.Name[i+1:])
+			}
+		}
+		p.Description = description(text)
+		self.Params = append(self.Params, p)
+	case "returns", "return":
+		r := &Returns{}
+		var text string
+		r.Type, text = parseType(tag.Text)
+		r.Description = description(text)
+		self.Returns = r
+	}
+}
+
+// parseType parses a type expression in braces at the start of text. It returns the type without the braces
+// and the rest of the text.
+func parseType(text string) (typ, rest string) {
+	if !strings.HasPrefix(text, "{") {
+		return "", text
+	}
+	depth := 0
+	for i, chr := range text {
+		switch chr {
+		case '{':
+			depth++
+		case '}':
+			depth--
+			if depth == 0 {
+				return strings.TrimSpace(text[1:i]), strings.TrimSpace(text[i+1:])
+			}
+		}
+	}
+	// unbalanced braces, take it all as the type
+	return strings.TrimSpace(text[1:]), ""
+}
+
+// parseName parses a parameter name, which is either a word or a name in square brackets that may contain
+// a default value, e.g. [name="a b"].
+func parseName(text string) (name, rest string) {
+	if strings.HasPrefix(text, "[") {
+		depth := 0
+		var quote rune
+		for i, chr := range text {
+			switch {
+			case quote != 0:
+				if chr == quote {
+					quote = 0
+				}
+			case chr == '"' || chr == '\'' || chr == '`':
+				quote = chr
+			case chr == '[' || chr == '{' || chr == '(':
+				depth++
+			case chr == ']' || chr == '}' || chr == ')':
+				depth--
+				if depth == 0 {
+					return text[:i+1], strings.TrimSpace(text[i+1:])
+				}
+			}
+		}
+		return text, ""
+	}
+	if i := strings.IndexAny(text, " \t\n"); i >= 0 {
+		return text[:i], strings.TrimSpace(text[i:])
+	}
+	return text, ""
+}
+
+// description removes the optional hyphen separating a name or a type from the description.
+func description(text string) string {
+	if strings.HasPrefix(text, "- ") || text == "-" || strings.HasPrefix(text, "-\n") {
+		text = text[1:]
+	}
+	return strings.TrimSpace(text)
+}
@@ -0,0 +1,103 @@
+package jsdoc
+
+import (
+	"testing"
+
+	"github.com/rarnu/goscript/ast"
+	"github.com/rarnu/goscript/parser"
+)
+
+func TestParse(t *testing.T) {
+	doc := Parse(`/**
+	 * Creates an order.
+	 *
+	 * The order is {@link Order validated} first.
+	 * @param {string} customer - The customer id
+	 * @param {{id: string, qty: number}[]} items The items,
+	 *   at least one
+	 * @param {number=} discount
+	 * @param {string} [options.currency="EUR"] The currency
+	 * @param note
+	 * @returns {Promise<Order>} The created order
+	 * @throws {Error} If the customer is unknown
+	 * @example
+	 * createOrder("c1", [{id: "a", qty: 1}])
+	 */`)
+
+	if doc.Description != "Creates an order.\n\nThe order is {@link Order validated} first." {
+		t.Fatalf("Unexpected description: %q", doc.Description)
+	}
+	expected := []Param{
+		{Name: "customer", Type: "string", Description: "The customer id"},
+		{Name: "items", Type: "{id: string, qty: number}[]", Description: "The items,\nat least one"},
+		{Name: "discount", Type: "number", Optional: true},
+		{Name: "options.currency", Type: "string", Description: "The currency", Optional: true, Default: `"EUR"`},
+		{Name: "note"},
+	}
+	if len(doc.Params) != len(expected) {
+		t.Fatalf("Unexpected number of params: %d", len(doc.Params))
+	}
+	for i, p := range doc.Params {
+		if *p != expected[i] {
+			t.Errorf("Unexpected param %d: %+v", i, *p)
+		}
+	}
+	if doc.Param("discount") != doc.Params[2] || doc.Param("x") != nil {
+		t.Fatal("Param lookup failed")
+	}
+	if doc.Returns == nil || *doc.Returns != (Returns{Type: "Promise<Order>", Description: "The created order"}) {
+		t.Fatalf("Unexpected returns: %+v", doc.Returns)
+	}
+	if len(doc.Tags) != 8 {
+		t.Fatalf("Unexpected number of tags: %d", len(doc.Tags))
+	}
+	if tag := doc.Tag("throws"); tag == nil || tag.Text != "{Error} If the customer is unknown" {
+		t.Fatalf("Unexpected tag: %+v", tag)
+	}
+	if tag := doc.Tag("example"); tag == nil || tag.Text != `createOrder("c1", [{id: "a", qty: 1}])` {
+		t.Fatalf("Unexpected tag: %+v", tag)
+	}
+
+	doc = Parse("/** @return {boolean} */")
+	if doc.Description != "" || doc.Returns == nil || doc.Returns.Type != "boolean" || doc.Returns.Description != "" {
+		t.Fatalf("Unexpected doc: %+v", doc)
+	}
+
+	doc = Parse("Just text,\nno tags")
+	if doc.Description != "Just text,\nno tags" || len(doc.Tags) != 0 || doc.Returns != nil {
+		t.Fatalf("Unexpected doc: %+v", doc)
+	}
+}
+
+func TestParseProgramDoc(t *testing.T) {
+	program, err := parser.ParseFile(nil, "", `
+	/** Not attached to anything */
+
+	// helper
+	function helper() {}
+
+	/**
+	 * @param {number} a First
+	 * @param {number} b Second
+	 * @returns {number}
+	 */
+	function main(a, b) { return helper(a) + b }
+	`, 0, parser.WithComments)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if program.CommentMap.Doc(program.Body[0]) != nil {
+		t.Fatal("helper must not have a doc comment")
+	}
+	c := program.CommentMap.Doc(program.Body[1])
+	if c == nil {
+		t.Fatal("main has no doc comment")
+	}
+	doc := Parse(c.Text)
+	if len(doc.Params) != 2 || doc.Params[1].Name != "b" || doc.Params[1].Description != "Second" || doc.Returns.Type != "number" {
+		t.Fatalf("Unexpected doc: %+v", doc)
+	}
+	if _, ok := program.Body[1].(*ast.FunctionDeclaration); !ok {
+		t.Fatalf("Unexpected statement: %T", program.Body[1])
+	}
+}
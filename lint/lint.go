@@ -0,0 +1,265 @@
+/*
+Package lint implements static checks of scripts before they are published.
+
+	issues := lint.Lint("script.js", src, nil)
+	if len(issues) > 0 {
+	    _ = json.NewEncoder(os.Stdout).Encode(issues)
+	}
+
+The checks work on the AST produced by the parser in the error recovery mode, so syntax errors are reported
+as issues too (with the rule ID "syntax-error") and do not hide the issues in the rest of the script.
+Each rule can be disabled through the Config. The available rules are listed by Rules.
+*/
+package lint
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/rarnu/goscript/ast"
+	"github.com/rarnu/goscript/file"
+	"github.com/rarnu/goscript/parser"
+)
+
+// Rule IDs.
+const (
+	RuleSyntaxError         = "syntax-error"
+	RuleNoUnusedVars        = "no-unused-vars"
+	RuleNoUndef             = "no-undef"
+	RuleNoShadow            = "no-shadow"
+	RuleNoUnreachable       = "no-unreachable"
+	RuleEqeqeq              = "eqeqeq"
+	RuleNoGlobalAssign      = "no-global-assign"
+	RuleNoAwaitOutsideAsync = "no-await-outside-async"
+)
+
+// Rule describes a lint rule.
+type Rule struct {
+	ID          string `json:"id"`
+	Description string `json:"description"`
+}
+
+var rules = []Rule{
+	{RuleSyntaxError, "The script cannot be parsed"},
+	{RuleNoUnusedVars, "A local variable, function or class is declared but never read (names starting with _ are ignored)"},
+	{RuleNoUndef, "A variable is used but not declared, neither in the script nor as a known global"},
+	{RuleNoShadow, "A declaration hides a declaration of the same name in an enclosing scope"},
+	{RuleNoUnreachable, "A statement can never be reached after return, throw, break or continue"},
+	{RuleEqeqeq, "== or != is used instead of === or !=="},
+	{RuleNoGlobalAssign, "A built-in global (e.g. HTTP or Redis) is assigned to or redeclared"},
+	{RuleNoAwaitOutsideAsync, "await is used outside of an async function"},
+}
+
+// Rules returns all the rules in the order in which they are documented.
+func Rules() []Rule {
+	return append([]Rule(nil), rules...)
+}
+
+// Config controls the checks. The zero value enables all the rules.
+type Config struct {
+	// Rules enables (true) or disables (false) the rules by their IDs. The rules that are not listed are enabled.
+	Rules map[string]bool `json:"rules"`
+	// Globals are the names of additional globals provided by the host, e.g. functions set with Runtime.Set.
+	Globals []string `json:"globals"`
+	// ReadOnlyGlobals are the names of additional globals that must not be assigned to or redeclared.
+	ReadOnlyGlobals []string `json:"readOnlyGlobals"`
+}
+
+func (c *Config) enabled(rule string) bool {
+	if c == nil || c.Rules == nil {
+		return true
+	}
+	enabled, ok := c.Rules[rule]
+	return !ok || enabled
+}
+
+// Issue is a problem found in a script.
+type Issue struct {
+	Rule      string `json:"rule"`
+	Message   string `json:"message"`
+	File      string `json:"file,omitempty"`
+	Line      int    `json:"line"`
+	Column    int    `json:"column"`
+	EndLine   int    `json:"endLine"`
+	EndColumn int    `json:"endColumn"`
+}
+
+func (i *Issue) String() string {
+	filename := i.File
+	if filename == "" {
+		filename = "(anonymous)"
+	}
+	return fmt.Sprintf("%s:%d:%d: %s (%s)", filename, i.Line, i.Column, i.Message, i.Rule)
+}
+
+// Lint parses and checks the source of a script. TypeScript is accepted if the file name ends with ".ts".
+// The issues are sorted by their positions.
+func Lint(filename, src string, config *Config) []Issue {
+	opts := []parser.Option{parser.WithErrorRecovery, parser.WithDisableSourceMaps}
+	if strings.HasSuffix(filename, ".ts") {
+		opts = append(opts, parser.WithTypeScript)
+	}
+	prg, err := parser.ParseFile(nil, filename, src, 0, opts...)
+	l := newLinter(prg, config)
+	if list, ok := err.(parser.ErrorList); ok {
+		for _, e := range list {
+			l.syntaxError(e)
+		}
+	}
+	return l.run()
+}
+
+// LintProgram checks a parsed program. It must have been parsed with the default base (a nil file.FileSet).
+func LintProgram(prg *ast.Program, config *Config) []Issue {
+	return newLinter(prg, config).run()
+}
+
+type linter struct {
+	prg    *ast.Program
+	config *Config
+
+	globals, readOnly map[string]bool
+
+	issues    []Issue
+	variables []*variable
+	refs      []*reference
+}
+
+func newLinter(prg *ast.Program, config *Config) *linter {
+	l := &linter{
+		prg:      prg,
+		config:   config,
+		globals:  make(map[string]bool),
+		readOnly: make(map[string]bool),
+	}
+	for _, list := range [][]string{builtinGlobals, iscGlobals, hostGlobals} {
+		for _, name := range list {
+			l.globals[name] = true
+		}
+	}
+	for _, list := range [][]string{builtinGlobals, iscGlobals} {
+		for _, name := range list {
+			l.readOnly[name] = true
+		}
+	}
+	if config != nil {
+		for _, name := range config.Globals {
+			l.globals[name] = true
+		}
+		for _, name := range config.ReadOnlyGlobals {
+			l.globals[name] = true
+			l.readOnly[name] = true
+		}
+	}
+	return l
+}
+
+func (l *linter) run() []Issue {
+	if l.prg != nil {
+		l.check()
+	}
+	sort.SliceStable(l.issues, func(i, j int) bool {
+		a, b := &l.issues[i], &l.issues[j]
+		if a.Line != b.Line {
+			return a.Line < b.Line
+		}
+		return a.Column < b.Column
+	})
+	return l.issues
+}
+
+func (l *linter) position(idx file.Idx) file.Position {
+	if l.prg == nil || l.prg.File == nil {
+		return file.Position{}
+	}
+	return l.prg.File.Position(int(idx) - l.prg.File.Base())
+}
+
+func (l *linter) report(rule string, from, to file.Idx, msg string) {
+	if !l.config.enabled(rule) {
+		return
+	}
+	pos, end := l.position(from), l.position(to)
+	l.issues = append(l.issues, Issue{
+		Rule:      rule,
+		Message:   msg,
+		File:      pos.Filename,
+		Line:      pos.Line,
+		Column:    pos.Column,
+		EndLine:   end.Line,
+		EndColumn: end.Column,
+	})
+}
+
+func (l *linter) syntaxError(e *parser.Error) {
+	rule := RuleSyntaxError
+	msg := e.Message
+	pos, end := e.Position, e.End
+	// `await x` outside of an async function is parsed as the identifier await followed by an unexpected token.
+	if l.prg != nil && l.prg.File != nil {
+		src := l.prg.File.Source()
+		if offset := l.offset(pos); offset >= 0 && offset <= len(src) {
+			before := strings.TrimRight(src[:offset], " \t")
+			if strings.HasSuffix(before, "await") && (len(before) == 5 || !isIdentifierPart(before[len(before)-6])) {
+				rule = RuleNoAwaitOutsideAsync
+				msg = "'await' is only allowed in async functions"
+				start := l.prg.File.Base() + len(before) - 5
+				pos, end = l.position(file.Idx(start)), l.position(file.Idx(start+5))
+			}
+		}
+	}
+	if !l.config.enabled(rule) {
+		return
+	}
+	l.issues = append(l.issues, Issue{
+		Rule:      rule,
+		Message:   msg,
+		File:      pos.Filename,
+		Line:      pos.Line,
+		Column:    pos.Column,
+		EndLine:   end.Line,
+		EndColumn: end.Column,
+	})
+}
+
+// offset returns the offset in the source of a position.
+func (l *linter) offset(pos file.Position) int {
+	fl := l.prg.File
+	offset := sort.Search(len(fl.Source())+1, func(offset int) bool {
+		p := fl.Position(offset)
+		return p.Line > pos.Line || p.Line == pos.Line && p.Column >= pos.Column
+	})
+	if p := fl.Position(offset); p.Line != pos.Line || p.Column != pos.Column {
+		return -1
+	}
+	return offset
+}
+
+func isIdentifierPart(b byte) bool {
+	return b == '_' || b == '$' || b >= 'a' && b <= 'z' || b >= 'A' && b <= 'Z' || b >= '0' && b <= '9' || b >= 0x80
+}
+
+// builtinGlobals are the globals of ECMAScript provided by the runtime.
+var builtinGlobals = []string{
+	"AggregateError", "Array", "ArrayBuffer", "Boolean", "DataView", "Date", "Error", "EvalError", "Float32Array",
+	"Float64Array", "Function", "GoError", "Infinity", "Int16Array", "Int32Array", "Int8Array", "JSON", "Map", "Math",
+	"NaN", "Number", "Object", "Promise", "Proxy", "RangeError", "ReferenceError", "Reflect", "RegExp", "Set",
+	"String", "Symbol", "SyntaxError", "TypeError", "URIError", "Uint16Array", "Uint32Array", "Uint8Array",
+	"Uint8ClampedArray", "WeakMap", "WeakSet", "decodeURI", "decodeURIComponent", "encodeURI",
+	"encodeURIComponent", "escape", "eval", "globalThis", "isFinite", "isNaN", "parseFloat", "parseInt",
+	"undefined", "unescape",
+}
+
+// iscGlobals are the globals provided by the ISC built-ins.
+var iscGlobals = []string{
+	"AbortController", "AbortSignal", "Crypto", "Dameng", "Database", "Etcd", "File", "HTTP", "Headers", "InfluxDB",
+	"InfluxDBPoint", "Kubernetes", "Mssql", "Mysql", "Oracle", "Postgres", "Redis", "RedisCluster", "RedisClusterV8",
+	"RedisV8", "Request", "Response", "SQLite", "WebSocket",
+}
+
+// hostGlobals are the globals that are usually provided by the host through the modules.
+var hostGlobals = []string{
+	"URL", "URLSearchParams", "clearInterval", "clearTimeout", "console", "exports", "module",
+	"process", "require", "setInterval", "setTimeout",
+}
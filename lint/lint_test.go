@@ -0,0 +1,140 @@
+package lint
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func summary(issues []Issue) string {
+	var s []string
+	for _, i := range issues {
+		s = append(s, fmt.Sprintf("%s %d:%d", i.Rule, i.Line, i.Column))
+	}
+	return strings.Join(s, ", ")
+}
+
+func TestLint(t *testing.T) {
+	test := func(src, expected string) {
+		t.Helper()
+		if s := summary(Lint("test.js", src, nil)); s != expected {
+			t.Errorf("%q: expected %q, got %q", src, expected, s)
+		}
+	}
+
+	// no-unused-vars
+	test("function f(a) { var x = 1; let y; const _z = 2; return a }", "no-unused-vars 1:21, no-unused-vars 1:32")
+	test("var x = 1; function f() {}", "")
+	test("function f() { function g() {} class C {} }", "no-unused-vars 1:25, no-unused-vars 1:38")
+	test("function f() { let x = 1; return () => x }", "")
+	test("function f() { let x = 0; x++; x += 1; x = 2 }", "no-unused-vars 1:20")
+	test("function f() { let x; return typeof x }", "")
+	test("function f(o) { for (const k in o) {} }", "no-unused-vars 1:28")
+	test("function f() { try {} catch (e) {} }", "")
+	test("function f() { const {a, b: [c = 1], ...d} = {}; return [a, c, d] }", "")
+
+	// no-undef
+	test("foo()", "no-undef 1:1")
+	test("x = 1", "no-undef 1:1")
+	test("typeof foo", "")
+	test("HTTP.get(url)", "no-undef 1:10")
+	test("console.log(Math.max(1, 2), JSON, o.p, {p: 1}.p)", "no-undef 1:35")
+	test("f(); function f() { return arguments[0] }", "")
+	test("const f = () => arguments", "no-undef 1:17")
+	test("const g = function h() { return h }", "")
+	test("class A { #p = 1; m() { return this.#p + A.q } static { var s = 1; return s } }", "")
+	test("o = {[k]: 1, k: 2}", "no-undef 1:1, no-undef 1:7")
+	test("l: for (;;) { if (Math) continue l; break l }", "")
+	test("const a = 1; [a, b] = [c, d]", "no-undef 1:18, no-undef 1:24, no-undef 1:27")
+
+	// no-shadow
+	test("let x; function f(x) { return x }", "no-shadow 1:19")
+	test("function f() { let x = 1; { let x = 2; return x } }", "no-unused-vars 1:20, no-shadow 1:33")
+	test("function f() { let x; if (x) { var y } return y }", "")
+	test("var f = function f() {}", "")
+
+	// no-unreachable
+	test("function f() { return; f(); g() }", "no-unreachable 1:24, no-undef 1:29")
+	test("function f() { return 1; function g() {} var x }", "no-unused-vars 1:35, no-unused-vars 1:46")
+	test("function f(x) { if (x) { return 1 } else { throw x } x++ }", "no-unreachable 1:55")
+	test("function f(x) { if (x) { return 1 } x++ }", "")
+	test("for (;;) { if (a) { break } else { continue } a() }", "no-undef 1:16, no-unreachable 1:47, no-undef 1:47")
+	test("function f() { try { return 1 } finally { f() } f() }", "no-unreachable 1:49")
+	test("switch (1) { case 1: break; f() }", "no-unreachable 1:29, no-undef 1:29")
+
+	// eqeqeq
+	test("const a = 1; a == 1; a != null; a === 1", "eqeqeq 1:14, eqeqeq 1:22")
+
+	// no-global-assign
+	test("HTTP = 1; Redis.x = 2", "no-global-assign 1:1")
+	test("var Redis = 1; function Mysql() {}", "no-global-assign 1:5, no-global-assign 1:25")
+	test("function f() { let HTTP = 1; return HTTP }", "")
+	test("[Crypto] = [1]; undefined = 2", "no-global-assign 1:2, no-global-assign 1:17")
+
+	// no-await-outside-async
+	test("await foo()", "no-await-outside-async 1:1")
+	test("function f() {\n  return await (x)\n}", "no-await-outside-async 2:10, no-undef 2:17")
+	test("async function f() { await g() }", "no-undef 1:28")
+
+	// syntax-error
+	test("let a = ;\nfoo()", "syntax-error 1:9, no-undef 2:1")
+}
+
+func TestLintTypeScript(t *testing.T) {
+	issues := Lint("test.ts", "function f(a: number): number { let b: string; return a }", nil)
+	if s := summary(issues); s != "no-unused-vars 1:37" {
+		t.Fatalf("Unexpected issues: %s", s)
+	}
+}
+
+func TestConfig(t *testing.T) {
+	src := "a == b; c = 1; Tenant = 2"
+	if s := summary(Lint("", src, nil)); s != "eqeqeq 1:1, no-undef 1:1, no-undef 1:6, no-undef 1:9, no-undef 1:16" {
+		t.Fatalf("Unexpected issues: %s", s)
+	}
+	config := &Config{
+		Rules:           map[string]bool{RuleEqeqeq: false, RuleNoUnusedVars: true},
+		Globals:         []string{"a", "b"},
+		ReadOnlyGlobals: []string{"Tenant"},
+	}
+	if s := summary(Lint("", src, config)); s != "no-undef 1:9, no-global-assign 1:16" {
+		t.Fatalf("Unexpected issues: %s", s)
+	}
+
+	var c Config
+	if err := json.Unmarshal([]byte(`{"rules": {"no-undef": false}, "globals": ["a"]}`), &c); err != nil {
+		t.Fatal(err)
+	}
+	if c.enabled(RuleNoUndef) || !c.enabled(RuleEqeqeq) || len(c.Globals) != 1 {
+		t.Fatalf("Unexpected config: %+v", c)
+	}
+}
+
+func TestIssueJSON(t *testing.T) {
+	issues := Lint("script.js", "\n  foo", nil)
+	b, err := json.Marshal(issues)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := `[{"rule":"no-undef","message":"'foo' is not defined","file":"script.js","line":2,"column":3,"endLine":2,"endColumn":6}]`
+	if string(b) != expected {
+		t.Fatalf("Unexpected JSON: %s", b)
+	}
+	if s := issues[0].String(); s != "script.js:2:3: 'foo' is not defined (no-undef)" {
+		t.Fatalf("Unexpected string: %s", s)
+	}
+}
+
+func TestRules(t *testing.T) {
+	seen := make(map[string]bool)
+	for _, r := range Rules() {
+		if r.ID == "" || r.Description == "" || seen[r.ID] {
+			t.Fatalf("Invalid rule: %+v", r)
+		}
+		seen[r.ID] = true
+	}
+	if len(seen) != 8 {
+		t.Fatalf("Unexpected number of rules: %d", len(seen))
+	}
+}
@@ -0,0 +1,521 @@
+package lint
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/rarnu/goscript/ast"
+	"github.com/rarnu/goscript/file"
+	"github.com/rarnu/goscript/token"
+	"github.com/rarnu/goscript/unistring"
+)
+
+// The scopes follow the ones the parser opens (see parser/scope.go): the program, the functions and the class
+// static blocks are function scopes that receive the var declarations, the blocks, the loops, the switch
+// statements and the catch clauses are block scopes for the lexical declarations.
+type scope struct {
+	outer    *scope
+	function bool
+	names    map[unistring.String]*variable
+}
+
+func newScope(outer *scope, function bool) *scope {
+	return &scope{
+		outer:    outer,
+		function: function,
+		names:    make(map[unistring.String]*variable),
+	}
+}
+
+// functionScope returns the scope that receives the var declarations.
+func (s *scope) functionScope() *scope {
+	for !s.function {
+		s = s.outer
+	}
+	return s
+}
+
+func (s *scope) lookup(name unistring.String) *variable {
+	for ; s != nil; s = s.outer {
+		if v := s.names[name]; v != nil {
+			return v
+		}
+	}
+	return nil
+}
+
+type variableKind int
+
+const (
+	kindVariable variableKind = iota // var, let or const
+	kindFunction
+	kindClass
+	kindParameter
+	kindCatchParameter
+	kindFuncName // the name of a function or a class expression, only visible inside of it
+	kindImplicit // arguments
+)
+
+type variable struct {
+	name  unistring.String
+	kind  variableKind
+	scope *scope
+	id    *ast.Identifier // nil for the implicit variables
+	used  bool
+}
+
+type referenceKind int
+
+const (
+	refRead referenceKind = 1 << iota
+	refWrite
+	refTypeof
+)
+
+type reference struct {
+	id    *ast.Identifier
+	kind  referenceKind
+	scope *scope
+}
+
+// check collects the declarations and the references of the program and reports the issues. The references are
+// resolved after the walk because the declarations are visible in the whole scope, not only after them.
+func (l *linter) check() {
+	w := &walker{l: l, scope: newScope(nil, true)}
+	w.statements(l.prg.Body)
+
+	for _, ref := range l.refs {
+		name := ref.id.Name
+		if v := ref.scope.lookup(name); v != nil {
+			// updates such as x++ or x += 1 alone do not use the value
+			if ref.kind&refWrite == 0 {
+				v.used = true
+			}
+			continue
+		}
+		end := ref.id.Idx + file.Idx(len(name))
+		switch {
+		case name == "await":
+			l.report(RuleNoAwaitOutsideAsync, ref.id.Idx, end, "'await' is only allowed in async functions")
+		case l.globals[name.String()]:
+			if ref.kind&refWrite != 0 && l.readOnly[name.String()] {
+				l.report(RuleNoGlobalAssign, ref.id.Idx, end, fmt.Sprintf("Read-only global '%s' should not be modified", name))
+			}
+		case ref.kind != refTypeof:
+			l.report(RuleNoUndef, ref.id.Idx, end, fmt.Sprintf("'%s' is not defined", name))
+		}
+	}
+
+	for _, v := range l.variables {
+		if v.id == nil {
+			continue
+		}
+		from, to := v.id.Idx, v.id.Idx+file.Idx(len(v.name))
+		if v.scope.outer == nil {
+			// the global declarations may be used by the host, they are only checked against the built-ins
+			if l.readOnly[v.name.String()] {
+				l.report(RuleNoGlobalAssign, from, to, fmt.Sprintf("Read-only global '%s' should not be redeclared", v.name))
+			}
+			continue
+		}
+		switch v.kind {
+		case kindVariable, kindFunction, kindClass:
+			if !v.used && !strings.HasPrefix(v.name.String(), "_") {
+				l.report(RuleNoUnusedVars, from, to, fmt.Sprintf("'%s' is declared but never used", v.name))
+			}
+		}
+		if v.kind != kindFuncName {
+			if o := v.scope.outer.lookup(v.name); o != nil && o.id != nil && o.kind != kindFuncName {
+				l.report(RuleNoShadow, from, to, fmt.Sprintf("'%s' is already declared in the upper scope on line %d",
+					v.name, l.position(o.id.Idx).Line))
+			}
+		}
+	}
+}
+
+// walker visits the nodes of a scope. The nodes that open a scope, declare names or contain identifiers that are
+// not variable references are handled explicitly, the rest is left to ast.Walk.
+type walker struct {
+	l     *linter
+	scope *scope
+}
+
+func (w *walker) Visit(node ast.Node) ast.Visitor {
+	switch n := node.(type) {
+	case nil:
+		return nil
+	case *ast.Identifier:
+		w.ref(n, refRead)
+	case *ast.DotExpression:
+		ast.Walk(w, n.Left)
+	case *ast.PrivateDotExpression:
+		ast.Walk(w, n.Left)
+	case *ast.MetaProperty, *ast.BranchStatement, *ast.PrivateIdentifier:
+		// not variable references
+	case *ast.LabelledStatement:
+		ast.Walk(w, n.Statement)
+	case *ast.PropertyKeyed:
+		if n.Computed {
+			ast.Walk(w, n.Key)
+		}
+		ast.Walk(w, n.Value)
+	case *ast.BinaryExpression:
+		if n.Operator == token.EQUAL || n.Operator == token.NOT_EQUAL {
+			w.l.report(RuleEqeqeq, n.Idx0(), n.Idx1(), fmt.Sprintf("Expected '%s=' and instead saw '%s'", n.Operator, n.Operator))
+		}
+		return w
+	case *ast.UnaryExpression:
+		if id, ok := n.Operand.(*ast.Identifier); ok {
+			switch n.Operator {
+			case token.TYPEOF:
+				w.ref(id, refTypeof)
+				return nil
+			case token.INCREMENT, token.DECREMENT:
+				w.ref(id, refRead|refWrite)
+				return nil
+			}
+		}
+		return w
+	case *ast.AssignExpression:
+		if n.Operator == token.ASSIGN {
+			w.assignTarget(n.Left)
+		} else if id, ok := n.Left.(*ast.Identifier); ok {
+			w.ref(id, refRead|refWrite)
+		} else {
+			ast.Walk(w, n.Left)
+		}
+		ast.Walk(w, n.Right)
+
+	case *ast.Program:
+		w.statements(n.Body)
+	case *ast.BlockStatement:
+		w.block(n.List)
+	case *ast.CaseStatement:
+		if n.Test != nil {
+			ast.Walk(w, n.Test)
+		}
+		w.statements(n.Consequent)
+	case *ast.SwitchStatement:
+		ast.Walk(w, n.Discriminant)
+		inner := w.inner(false)
+		for _, c := range n.Body {
+			ast.Walk(inner, c)
+		}
+	case *ast.CatchStatement:
+		inner := w.inner(false)
+		if n.Parameter != nil {
+			inner.declarePattern(n.Parameter, kindCatchParameter, inner.scope)
+		}
+		inner.statements(n.Body.List)
+	case *ast.ForStatement:
+		inner := w.inner(false)
+		if n.Initializer != nil {
+			ast.Walk(inner, n.Initializer)
+		}
+		if n.Test != nil {
+			ast.Walk(inner, n.Test)
+		}
+		if n.Update != nil {
+			ast.Walk(inner, n.Update)
+		}
+		ast.Walk(inner, n.Body)
+	case *ast.ForInStatement:
+		w.forInto(n.Into, n.Source, n.Body)
+	case *ast.ForOfStatement:
+		w.forInto(n.Into, n.Source, n.Body)
+	case *ast.ForLoopInitializerVarDeclList:
+		w.bindings(n.List, kindVariable, w.scope.functionScope())
+	case *ast.ForLoopInitializerLexicalDecl:
+		w.bindings(n.LexicalDeclaration.List, kindVariable, w.scope)
+	case *ast.VariableStatement:
+		w.bindings(n.List, kindVariable, w.scope.functionScope())
+	case *ast.LexicalDeclaration:
+		w.bindings(n.List, kindVariable, w.scope)
+
+	case *ast.FunctionDeclaration:
+		if n.Function.Name != nil {
+			w.declare(n.Function.Name, kindFunction, w.scope)
+		}
+		w.function(n.Function.ParameterList, n.Function.Body, true)
+	case *ast.FunctionLiteral:
+		inner := w
+		if n.Name != nil {
+			inner = w.inner(false)
+			inner.declare(n.Name, kindFuncName, inner.scope)
+		}
+		inner.function(n.ParameterList, n.Body, true)
+	case *ast.ArrowFunctionLiteral:
+		switch body := n.Body.(type) {
+		case *ast.BlockStatement:
+			w.function(n.ParameterList, body, false)
+		case *ast.ExpressionBody:
+			inner := w.inner(true)
+			inner.parameters(n.ParameterList)
+			ast.Walk(inner, body.Expression)
+		}
+	case *ast.ClassDeclaration:
+		if n.Class.Name != nil {
+			w.declare(n.Class.Name, kindClass, w.scope)
+		}
+		w.class(n.Class)
+	case *ast.ClassLiteral:
+		inner := w
+		if n.Name != nil {
+			inner = w.inner(false)
+			inner.declare(n.Name, kindFuncName, inner.scope)
+		}
+		inner.class(n)
+
+	default:
+		return w
+	}
+	return nil
+}
+
+func (w *walker) inner(function bool) *walker {
+	return &walker{l: w.l, scope: newScope(w.scope, function)}
+}
+
+func (w *walker) ref(id *ast.Identifier, kind referenceKind) {
+	w.l.refs = append(w.l.refs, &reference{id: id, kind: kind, scope: w.scope})
+}
+
+func (w *walker) declare(id *ast.Identifier, kind variableKind, s *scope) {
+	if s.names[id.Name] != nil {
+		// redeclarations (e.g. var x; var x) refer to the same variable
+		return
+	}
+	v := &variable{name: id.Name, kind: kind, scope: s, id: id}
+	s.names[id.Name] = v
+	w.l.variables = append(w.l.variables, v)
+}
+
+// declarePattern declares the names bound by a binding target in the scope s and walks the default values and
+// the computed keys of the patterns.
+func (w *walker) declarePattern(target ast.Expression, kind variableKind, s *scope) {
+	switch t := target.(type) {
+	case *ast.Identifier:
+		w.declare(t, kind, s)
+	case *ast.ArrayPattern:
+		for _, elem := range t.Elements {
+			if elem != nil {
+				w.declarePattern(elem, kind, s)
+			}
+		}
+		if t.Rest != nil {
+			w.declarePattern(t.Rest, kind, s)
+		}
+	case *ast.ObjectPattern:
+		for _, prop := range t.Properties {
+			w.declarePattern(prop, kind, s)
+		}
+		if t.Rest != nil {
+			w.declarePattern(t.Rest, kind, s)
+		}
+	case *ast.PropertyShort:
+		w.declare(&t.Name, kind, s)
+		if t.Initializer != nil {
+			ast.Walk(w, t.Initializer)
+		}
+	case *ast.PropertyKeyed:
+		if t.Computed {
+			ast.Walk(w, t.Key)
+		}
+		w.declarePattern(t.Value, kind, s)
+	case *ast.SpreadElement:
+		w.declarePattern(t.Expression, kind, s)
+	case *ast.AssignExpression:
+		w.declarePattern(t.Left, kind, s)
+		ast.Walk(w, t.Right)
+	case *ast.BadExpression:
+	default:
+		ast.Walk(w, target)
+	}
+}
+
+// assignTarget records the writes to the variables assigned by the left-hand side of an assignment.
+func (w *walker) assignTarget(target ast.Expression) {
+	switch t := target.(type) {
+	case *ast.Identifier:
+		w.ref(t, refWrite)
+	case *ast.ArrayPattern:
+		for _, elem := range t.Elements {
+			if elem != nil {
+				w.assignTarget(elem)
+			}
+		}
+		if t.Rest != nil {
+			w.assignTarget(t.Rest)
+		}
+	case *ast.ObjectPattern:
+		for _, prop := range t.Properties {
+			w.assignTarget(prop)
+		}
+		if t.Rest != nil {
+			w.assignTarget(t.Rest)
+		}
+	case *ast.PropertyShort:
+		w.ref(&t.Name, refWrite)
+		if t.Initializer != nil {
+			ast.Walk(w, t.Initializer)
+		}
+	case *ast.PropertyKeyed:
+		if t.Computed {
+			ast.Walk(w, t.Key)
+		}
+		w.assignTarget(t.Value)
+	case *ast.SpreadElement:
+		w.assignTarget(t.Expression)
+	case *ast.AssignExpression:
+		w.assignTarget(t.Left)
+		ast.Walk(w, t.Right)
+	default:
+		ast.Walk(w, target)
+	}
+}
+
+func (w *walker) bindings(list []*ast.Binding, kind variableKind, s *scope) {
+	for _, b := range list {
+		w.declarePattern(b.Target, kind, s)
+		if b.Initializer != nil {
+			ast.Walk(w, b.Initializer)
+		}
+	}
+}
+
+func (w *walker) parameters(params *ast.ParameterList) {
+	if params == nil {
+		return
+	}
+	w.bindings(params.List, kindParameter, w.scope)
+	if params.Rest != nil {
+		w.declarePattern(params.Rest, kindParameter, w.scope)
+	}
+}
+
+// function walks the parameters and the body of a function in a new function scope. The body shares the scope
+// with the parameters.
+func (w *walker) function(params *ast.ParameterList, body *ast.BlockStatement, hasArguments bool) {
+	inner := w.inner(true)
+	if hasArguments {
+		name := unistring.String("arguments")
+		v := &variable{name: name, kind: kindImplicit, scope: inner.scope}
+		inner.scope.names[name] = v
+	}
+	inner.parameters(params)
+	if body != nil {
+		inner.statements(body.List)
+	}
+}
+
+func (w *walker) class(n *ast.ClassLiteral) {
+	if n.SuperClass != nil {
+		ast.Walk(w, n.SuperClass)
+	}
+	for _, elem := range n.Body {
+		switch e := elem.(type) {
+		case *ast.MethodDefinition:
+			if e.Computed {
+				ast.Walk(w, e.Key)
+			}
+			ast.Walk(w, e.Body)
+		case *ast.FieldDefinition:
+			if e.Computed {
+				ast.Walk(w, e.Key)
+			}
+			if e.Initializer != nil {
+				ast.Walk(w, e.Initializer)
+			}
+		case *ast.ClassStaticBlock:
+			w.inner(true).statements(e.Block.List)
+		}
+	}
+}
+
+func (w *walker) forInto(into ast.ForInto, source ast.Expression, body ast.Statement) {
+	inner := w.inner(false)
+	switch into := into.(type) {
+	case *ast.ForIntoVar:
+		inner.bindings([]*ast.Binding{into.Binding}, kindVariable, inner.scope.functionScope())
+	case *ast.ForDeclaration:
+		inner.declarePattern(into.Target, kindVariable, inner.scope)
+	case *ast.ForIntoExpression:
+		inner.assignTarget(into.Expression)
+	}
+	ast.Walk(inner, source)
+	ast.Walk(inner, body)
+}
+
+// block walks the statements of a block in a new block scope.
+func (w *walker) block(list []ast.Statement) {
+	w.inner(false).statements(list)
+}
+
+// statements walks a statement list of the current scope and reports the first unreachable statement.
+func (w *walker) statements(list []ast.Statement) {
+	w.checkUnreachable(list)
+	for _, stmt := range list {
+		ast.Walk(w, stmt)
+	}
+}
+
+func (w *walker) checkUnreachable(list []ast.Statement) {
+	for i, stmt := range list {
+		if !terminates(stmt) {
+			continue
+		}
+		for _, next := range list[i+1:] {
+			if isHoisted(next) {
+				continue
+			}
+			w.l.report(RuleNoUnreachable, next.Idx0(), next.Idx1(), "Unreachable code")
+			break
+		}
+		return
+	}
+}
+
+// isHoisted reports whether a statement has an effect even if it is not reached: the function declarations and
+// the var declarations without initializers. The empty and the broken statements are ignored as well.
+func isHoisted(stmt ast.Statement) bool {
+	switch s := stmt.(type) {
+	case *ast.FunctionDeclaration, *ast.EmptyStatement, *ast.BadStatement:
+		return true
+	case *ast.VariableStatement:
+		for _, b := range s.List {
+			if b.Initializer != nil {
+				return false
+			}
+		}
+		return true
+	}
+	return false
+}
+
+// terminates reports whether the normal completion of a statement is impossible, so that the statements that
+// follow it are unreachable.
+func terminates(stmt ast.Statement) bool {
+	switch s := stmt.(type) {
+	case *ast.ReturnStatement, *ast.ThrowStatement, *ast.BranchStatement:
+		return true
+	case *ast.BlockStatement:
+		return terminatesList(s.List)
+	case *ast.IfStatement:
+		return s.Alternate != nil && terminates(s.Consequent) && terminates(s.Alternate)
+	case *ast.TryStatement:
+		if s.Finally != nil && terminatesList(s.Finally.List) {
+			return true
+		}
+		return terminatesList(s.Body.List) && (s.Catch == nil || terminatesList(s.Catch.Body.List))
+	}
+	return false
+}
+
+func terminatesList(list []ast.Statement) bool {
+	for _, stmt := range list {
+		if terminates(stmt) {
+			return true
+		}
+	}
+	return false
+}
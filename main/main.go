@@ -3,9 +3,11 @@ package main
 import (
 	crand "crypto/rand"
 	"encoding/binary"
+	"encoding/json"
 	"flag"
 	"fmt"
 	"github.com/rarnu/goscript"
+	"github.com/rarnu/goscript/lint"
 	"github.com/rarnu/goscript/module/console"
 	"github.com/rarnu/goscript/module/require"
 	"io"
@@ -14,11 +16,16 @@ import (
 	"os"
 	"runtime/debug"
 	"runtime/pprof"
+	"strings"
 	"time"
 )
 
 var cpuprofile = flag.String("cpuprofile", "", "write cpu profile to file")
+var jsprofile = flag.String("jsprofile", "", "write JavaScript cpu profile to file")
+var coverage = flag.String("coverage", "", "write coverage report to file (Istanbul JSON if the name ends with .json, LCOV otherwise)")
 var timelimit = flag.Int("timelimit", 0, "max time to run (in seconds)")
+var lintMode = flag.Bool("lint", false, "check the script instead of running it and write the issues as JSON")
+var lintconfig = flag.String("lintconfig", "", "read the lint configuration (JSON) from file")
 
 func readSource(filename string) ([]byte, error) {
 	if filename == "" || filename == "-" {
@@ -40,6 +47,18 @@ func load(vm *goscript.Runtime, call goscript.FunctionCall) goscript.Value {
 	return v
 }
 
+func writeCoverage(cov *goscript.Coverage, filename string) error {
+	f, err := os.Create(filename)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	if strings.HasSuffix(filename, ".json") {
+		return cov.WriteIstanbul(f)
+	}
+	return cov.WriteLCOV(f)
+}
+
 func newRandSource() goscript.RandSource {
 	var seed int64
 	if err := binary.Read(crand.Reader, binary.LittleEndian, &seed); err != nil {
@@ -48,7 +67,44 @@ func newRandSource() goscript.RandSource {
 	return rand.New(rand.NewSource(seed)).Float64
 }
 
-func run() error {
+// runLint checks the script and writes the issues to stdout. It reports whether there are no issues.
+func runLint() (bool, error) {
+	filename := flag.Arg(0)
+	src, err := readSource(filename)
+	if err != nil {
+		return false, err
+	}
+	if filename == "" || filename == "-" {
+		filename = "<stdin>"
+	}
+
+	config := &lint.Config{}
+	if *lintconfig != "" {
+		b, err := os.ReadFile(*lintconfig)
+		if err != nil {
+			return false, err
+		}
+		if err := json.Unmarshal(b, config); err != nil {
+			return false, fmt.Errorf("could not parse %s: %v", *lintconfig, err)
+		}
+	}
+	// the functions set by run
+	config.Globals = append(config.Globals, "load", "readFile")
+
+	issues := lint.Lint(filename, string(src), config)
+	if issues == nil {
+		issues = []lint.Issue{}
+	}
+	enc := json.NewEncoder(os.Stdout)
+	enc.SetIndent("", "  ")
+	enc.SetEscapeHTML(false)
+	if err := enc.Encode(issues); err != nil {
+		return false, err
+	}
+	return len(issues) == 0, nil
+}
+
+func run() (err error) {
 	filename := flag.Arg(0)
 	src, err := readSource(filename)
 	if err != nil {
@@ -62,7 +118,18 @@ func run() error {
 	vm := goscript.New()
 	vm.SetRandSource(newRandSource())
 
-	new(require.Registry).Enable(vm)
+	var cov *goscript.Coverage
+	if *coverage != "" {
+		cov = goscript.NewCoverage()
+		vm.SetCoverage(cov)
+		defer func() {
+			if e := writeCoverage(cov, *coverage); e != nil && err == nil {
+				err = e
+			}
+		}()
+	}
+
+	require.NewRegistry(require.WithCoverage(cov)).Enable(vm)
 	console.Enable(vm)
 
 	_ = vm.Set("load", func(call goscript.FunctionCall) goscript.Value {
@@ -77,13 +144,29 @@ func run() error {
 		return string(b), nil
 	})
 
+	if *jsprofile != "" {
+		f, e := os.Create(*jsprofile)
+		if e != nil {
+			return e
+		}
+		defer f.Close()
+		if e = vm.StartProfile(f, 0); e != nil {
+			return e
+		}
+		defer func() {
+			if e := vm.StopProfile(); e != nil && err == nil {
+				err = e
+			}
+		}()
+	}
+
 	if *timelimit > 0 {
 		time.AfterFunc(time.Duration(*timelimit)*time.Second, func() {
 			vm.Interrupt("timeout")
 		})
 	}
 
-	prg, err := goscript.Compile(filename, string(src), false)
+	prg, err := goscript.CompileWithCoverage(filename, string(src), false, cov)
 	if err != nil {
 		return err
 	}
@@ -108,6 +191,18 @@ func main() {
 		defer pprof.StopCPUProfile()
 	}
 
+	if *lintMode {
+		ok, err := runLint()
+		if err != nil {
+			fmt.Println(err)
+			os.Exit(64)
+		}
+		if !ok {
+			os.Exit(1)
+		}
+		return
+	}
+
 	if err := run(); err != nil {
 		switch err := err.(type) {
 		case *goscript.Exception:
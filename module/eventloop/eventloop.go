@@ -70,6 +70,7 @@ func NewEventLoop(opts ...Option) *EventLoop {
 	vm.Set("setInterval", loop.setInterval)
 	vm.Set("clearTimeout", loop.clearTimeout)
 	vm.Set("clearInterval", loop.clearInterval)
+	vm.SetScheduler(loop)
 
 	return loop
 }
@@ -229,6 +230,25 @@ func (loop *EventLoop) RunOnLoop(fn func(*goscript.Runtime)) {
 	loop.addAuxJob(func() { fn(loop.vm) })
 }
 
+// Schedule implements goscript.Scheduler, so that the asynchronous built-ins (such as HTTP.fetch) keep the loop
+// running until they complete, and settle their promises on the loop through RunOnLoop. It must be called inside
+// the loop.
+func (loop *EventLoop) Schedule() func(func()) {
+	loop.jobCount++
+	return func(fn func()) {
+		loop.RunOnLoop(func(*goscript.Runtime) {
+			loop.jobCount--
+			fn()
+		})
+	}
+}
+
+// Post implements goscript.Scheduler, it runs fn on the loop (e.g. the handlers of HTTP.createServer). It is safe
+// to call inside or outside the loop.
+func (loop *EventLoop) Post(fn func()) {
+	loop.addAuxJob(fn)
+}
+
 func (loop *EventLoop) runAux() {
 	loop.auxJobsLock.Lock()
 	jobs := loop.auxJobs
@@ -3,6 +3,10 @@ package eventloop
 import (
 	"fmt"
 	"github.com/rarnu/goscript"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
 	"sync/atomic"
 	"testing"
 	"time"
@@ -415,3 +419,201 @@ func TestEventLoop_StopNoWait(t *testing.T) {
 		t.Fatal("ran != 0")
 	}
 }
+
+func TestFetchConcurrent(t *testing.T) {
+	t.Parallel()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		select {
+		case <-time.After(300 * time.Millisecond):
+		case <-req.Context().Done():
+			return
+		}
+		_, _ = w.Write([]byte(req.URL.Query().Get("i")))
+	}))
+	defer srv.Close()
+
+	const SCRIPT = `
+	var result, aborted;
+	var requests = [];
+	for (var i = 0; i < 20; i++) {
+		requests.push(HTTP.fetch(base + "?i=" + i).then(function(res) { return res.text(); }));
+	}
+	Promise.all(requests).then(function(texts) { result = texts.join(); });
+
+	var controller = new AbortController();
+	HTTP.fetch(base, {signal: controller.signal}).catch(function(e) { aborted = e.name; });
+	setTimeout(function() { controller.abort(); }, 50);
+	`
+
+	loop := NewEventLoop()
+	start := time.Now()
+	var result, aborted goscript.Value
+	loop.Run(func(vm *goscript.Runtime) {
+		vm.Set("base", srv.URL)
+		if _, err := vm.RunString(SCRIPT); err != nil {
+			t.Fatal(err)
+		}
+	})
+	loop.Run(func(vm *goscript.Runtime) {
+		result, aborted = vm.Get("result"), vm.Get("aborted")
+	})
+	if elapsed := time.Since(start); elapsed > 2*time.Second {
+		t.Fatalf("The requests are not concurrent: %v", elapsed)
+	}
+	if result.String() != "0,1,2,3,4,5,6,7,8,9,10,11,12,13,14,15,16,17,18,19" {
+		t.Fatal(result)
+	}
+	if aborted.String() != "AbortError" {
+		t.Fatal(aborted)
+	}
+}
+
+func TestHTTPServer(t *testing.T) {
+	t.Parallel()
+	const SCRIPT = `
+	var closed = false;
+	var server = HTTP.createServer(function(req, res) {
+		// the responses are finished later on the loop
+		setTimeout(function() { res.send(req.query.i); }, 200);
+	});
+	server.post("/close", function(req, res) {
+		res.end("closing");
+		server.close().then(function() { closed = true; });
+	});
+	server.listen("127.0.0.1:0");
+	url = server.url;
+	`
+
+	loop := NewEventLoop()
+	urls := make(chan string, 1)
+	done := make(chan bool)
+	go func() {
+		loop.Run(func(vm *goscript.Runtime) {
+			if _, err := vm.RunString(SCRIPT); err != nil {
+				t.Error(err)
+			}
+			urls <- vm.Get("url").String()
+		})
+		// the loop runs until the server is closed
+		var closed bool
+		loop.Run(func(vm *goscript.Runtime) {
+			closed = vm.Get("closed").ToBoolean()
+		})
+		done <- closed
+	}()
+	base := <-urls
+
+	start := time.Now()
+	results := make(chan string, 10)
+	for i := 0; i < 10; i++ {
+		go func(i int) {
+			resp, err := http.Get(fmt.Sprintf("%s/?i=%d", base, i))
+			if err != nil {
+				results <- err.Error()
+				return
+			}
+			defer resp.Body.Close()
+			b, _ := io.ReadAll(resp.Body)
+			results <- string(b)
+		}(i)
+	}
+	seen := make(map[string]bool)
+	for i := 0; i < 10; i++ {
+		seen[<-results] = true
+	}
+	if len(seen) != 10 || !seen["0"] || !seen["9"] {
+		t.Fatalf("Unexpected responses: %v", seen)
+	}
+	if elapsed := time.Since(start); elapsed > 1500*time.Millisecond {
+		t.Fatalf("The requests are not concurrent: %v", elapsed)
+	}
+
+	resp, err := http.Post(base+"/close", "text/plain", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_ = resp.Body.Close()
+	select {
+	case closed := <-done:
+		if !closed {
+			t.Fatal("The promise of close was not resolved")
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("The loop keeps running after the server is closed")
+	}
+}
+
+func TestWebSocket(t *testing.T) {
+	t.Parallel()
+	const SCRIPT = `
+	var log = [];
+	var server = HTTP.createServer();
+	server.ws("/echo/:room", function(ws, req) {
+		log.push("server open " + req.params.room + " " + ws.protocol + " " + req.headers["x-token"]);
+		ws.onmessage = function(e) {
+			ws.send(typeof e.data === "string" ? "echo " + e.data : e.data);
+		};
+		ws.onclose = function(e) {
+			log.push("server close " + e.wasClean);
+			server.close();
+		};
+	}, {protocols: ["chat"]});
+	server.listen("127.0.0.1:0");
+
+	var ws = new WebSocket(server.url.replace("http", "ws") + "/echo/r1", ["other", "chat"], {headers: {"x-token": "t1"}});
+	log.push("state " + ws.readyState + " " + (ws.readyState === WebSocket.CONNECTING));
+	ws.addEventListener("open", function() {
+		log.push("open " + ws.protocol + " " + ws.readyState);
+		ws.send("hello");
+		ws.send(new Uint8Array([1, 2, 3]));
+	});
+	var received = 0;
+	ws.onmessage = function(e) {
+		if (typeof e.data === "string") {
+			log.push("text " + e.data);
+		} else {
+			log.push("binary " + Array.from(new Uint8Array(e.data)).join());
+		}
+		if (++received === 2) {
+			ws.close(4000, "done");
+			log.push("closing " + ws.readyState);
+		}
+	};
+	ws.onclose = function(e) {
+		log.push("close " + e.code + " " + e.reason + " " + e.wasClean + " " + ws.readyState);
+	};
+
+	var failed = new WebSocket("ws://127.0.0.1:1/");
+	failed.onerror = function() { log.push("error"); };
+	failed.onclose = function(e) { log.push("failed close " + e.code); };
+	`
+
+	loop := NewEventLoop()
+	done := make(chan string)
+	go func() {
+		loop.Run(func(vm *goscript.Runtime) {
+			if _, err := vm.RunString(SCRIPT); err != nil {
+				t.Error(err)
+			}
+		})
+		var log string
+		loop.Run(func(vm *goscript.Runtime) {
+			log = vm.Get("log").String()
+		})
+		done <- log
+	}()
+	var log string
+	select {
+	case log = <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("The loop keeps running")
+	}
+	for _, expected := range []string{
+		"state 0 true", "server open r1 chat t1", "open chat 1", "text echo hello", "binary 1,2,3", "closing 2",
+		"close 4000 done true 3", "server close true", "error", "failed close 1006",
+	} {
+		if !strings.Contains(","+log+",", ","+expected+",") {
+			t.Fatalf("%q not in %s", expected, log)
+		}
+	}
+}
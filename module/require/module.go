@@ -38,6 +38,7 @@ type Registry struct {
 
 	srcLoader     SourceLoader
 	globalFolders []string
+	coverage      *js.Coverage
 }
 
 type RequireModule struct {
@@ -86,6 +87,14 @@ func WithGlobalFolders(globalFolders ...string) Option {
 	}
 }
 
+// WithCoverage instruments all modules compiled by the registry so that their statement, branch and function
+// hit counts are recorded into cov (see goscript.Coverage).
+func WithCoverage(cov *js.Coverage) Option {
+	return func(r *Registry) {
+		r.coverage = cov
+	}
+}
+
 // Enable adds the require() function to the specified runtime.
 func (r *Registry) Enable(runtime *js.Runtime) *RequireModule {
 	rrt := &RequireModule{
@@ -156,12 +165,21 @@ func (r *Registry) getCompiledSource(p string) (*js.Program, error) {
 			s = "module.exports = JSON.parse('" + template.JSEscapeString(s) + "')"
 		}
 
+		opts := []parser.Option{parser.WithSourceMapLoader(r.srcLoader)}
+		if path.Ext(p) == ".ts" {
+			opts = append(opts, parser.WithTypeScript)
+		}
+
 		source := "(function(exports, require, module) {" + s + "\n})"
-		parsed, err := js.Parse(p, source, parser.WithSourceMapLoader(r.srcLoader))
+		parsed, err := js.Parse(p, source, opts...)
 		if err != nil {
 			return nil, err
 		}
-		prg, err = js.CompileAST(parsed, false)
+		if r.coverage != nil {
+			prg, err = js.CompileASTWithCoverage(parsed, false, r.coverage)
+		} else {
+			prg, err = js.CompileAST(parsed, false)
+		}
 		if err == nil {
 			if r.compiled == nil {
 				r.compiled = make(map[string]*js.Program)
@@ -240,6 +240,8 @@ func TestResolve(t *testing.T) {
 		"/node_modules/b/file.js":                `exports.name = "app13"`,
 		"node_modules/app14/index.js":            `exports.name = "app14"`,
 		"../node_modules/app15/index.js":         `exports.name = "app15"`,
+		"/home/src/app16/app16.ts":               `const name: string = "app16"; exports.name = name`,
+		"/home/src/app17/index.ts":               `enum E { app17 } exports.name = E[0] as string`,
 	}
 
 	for i, tc := range []struct {
@@ -271,6 +273,9 @@ func TestResolve(t *testing.T) {
 		{"/", "./app13/app13", true, "name", "app13"},
 		{".", "app14", true, "name", "app14"},
 		{"..", "nonexistent", false, "", ""},
+		{"/home/src", "./app16/app16", true, "name", "app16"},
+		{"/home/src", "./app16/app16.ts", true, "name", "app16"},
+		{"/home/src", "./app17", true, "name", "app17"},
 	} {
 		vm, mod, err := testRequire(tc.src, tc.path, globalFolders, fs)
 		if err != nil {
@@ -98,6 +98,11 @@ func (r *RequireModule) loadAsFile(path string) (module *js.Object, err error) {
 	}
 
 	p = path + ".json"
+	if module, err = r.loadModule(p); module != nil || err != nil {
+		return
+	}
+
+	p = path + ".ts"
 	return r.loadModule(p)
 }
 
@@ -108,6 +113,11 @@ func (r *RequireModule) loadIndex(modpath string) (module *js.Object, err error)
 	}
 
 	p = path.Join(modpath, "index.json")
+	if module, err = r.loadModule(p); module != nil || err != nil {
+		return
+	}
+
+	p = path.Join(modpath, "index.ts")
 	return r.loadModule(p)
 }
 
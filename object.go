@@ -38,20 +38,36 @@ const (
 	classGenerator         = "Generator"
 	classGeneratorFunction = "GeneratorFunction"
 
-	classEtcd           = "Etcd"
-	classDameng         = "Dameng"
-	classInfluxDB       = "InfluxDB"
-	classInfluxDBWrite  = "InfluxDBWrite"
-	classInfluxDBQuery  = "InfluxDBQuery"
-	classInfluxDBPoint  = "InfluxDBPoint"
-	classMssql          = "Mssql"
-	classMysql          = "Mysql"
-	classOracle         = "Oracle"
-	classRedis          = "Redis"
-	classRedisCluster   = "RedisCluster"
-	classRedisV8        = "RedisV8"
-	classRedisClusterV8 = "RedisClusterV8"
-	classSQLite         = "SQLite"
+	classEtcd               = "Etcd"
+	classDameng             = "Dameng"
+	classDatabase           = "Database"
+	classHTTPResponse       = "HTTPResponse"
+	classHTTPMultipart      = "HTTPMultipart"
+	classHTTPClient         = "HTTPClient"
+	classHTTPServer         = "HTTPServer"
+	classHTTPServerRequest  = "HTTPServerRequest"
+	classHTTPServerResponse = "HTTPServerResponse"
+	classWebSocket          = "WebSocket"
+	classHeaders            = "Headers"
+	classRequest            = "Request"
+	classResponse           = "Response"
+	classAbortController    = "AbortController"
+	classAbortSignal        = "AbortSignal"
+	classInfluxDB           = "InfluxDB"
+	classInfluxDBWrite      = "InfluxDBWrite"
+	classInfluxDBQuery      = "InfluxDBQuery"
+	classInfluxDBPoint      = "InfluxDBPoint"
+	classMssql              = "Mssql"
+	classMysql              = "Mysql"
+	classOracle             = "Oracle"
+	classPostgres           = "Postgres"
+	classRedis              = "Redis"
+	classRedisPipeline      = "RedisPipeline"
+	classSQLite             = "SQLite"
+	classSQLTransaction     = "SQLTransaction"
+	classSQLCursor          = "SQLCursor"
+	classSQLAsyncCursor     = "SQLAsyncCursor"
+	classSQLQuery           = "SQLQuery"
 )
 
 var (
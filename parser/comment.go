@@ -0,0 +1,134 @@
+package parser
+
+import (
+	"sort"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/rarnu/goscript/ast"
+	"github.com/rarnu/goscript/file"
+)
+
+// addComment records the comment starting at offset and ending at the current character. Comments may be
+// scanned more than once (the parser looks ahead and backtracks), only the first occurrence is recorded.
+func (self *_parser) addComment(offset int) {
+	if !self.opts.comments {
+		return
+	}
+	idx := self.idxOf(offset)
+	if n := len(self.comments); n > 0 && self.comments[n-1].Idx >= idx {
+		return
+	}
+	self.comments = append(self.comments, &ast.Comment{
+		Idx:  idx,
+		Text: self.str[offset:self.chrOffset],
+	})
+}
+
+// attachComments builds the comment map of the program according to the rules described in ast.CommentMap.
+func (self *_parser) attachComments(prg *ast.Program) ast.CommentMap {
+	cmap := make(ast.CommentMap)
+	if len(self.comments) == 0 {
+		return cmap
+	}
+
+	var byStart []ast.Node
+	ast.Inspect(prg, func(node ast.Node) bool {
+		if node != nil && node != ast.Node(prg) && node.Idx0() > 0 && node.Idx1() >= node.Idx0() {
+			byStart = append(byStart, node)
+		}
+		return true
+	})
+	// Walk visits the nodes in pre-order, so of the nodes starting at the same position the outer ones come first.
+	sort.SliceStable(byStart, func(i, j int) bool {
+		return byStart[i].Idx0() < byStart[j].Idx0()
+	})
+	// Of the nodes ending at the same position the outermost one comes last.
+	byEnd := make([]ast.Node, len(byStart))
+	for i, node := range byStart {
+		byEnd[len(byEnd)-1-i] = node
+	}
+	sort.SliceStable(byEnd, func(i, j int) bool {
+		if byEnd[i].Idx1() != byEnd[j].Idx1() {
+			return byEnd[i].Idx1() < byEnd[j].Idx1()
+		}
+		return byEnd[i].Idx0() > byEnd[j].Idx0()
+	})
+
+	for i, c := range self.comments {
+		var node ast.Node
+		if !c.IsDoc() && self.endsLine(c) {
+			if n := sort.Search(len(byEnd), func(k int) bool { return byEnd[k].Idx1() > c.Idx }); n > 0 {
+				if prev := byEnd[n-1]; self.isTrailingGap(prev.Idx1(), c.Idx) {
+					node = prev
+				}
+			}
+		}
+		if node == nil {
+			end := c.End()
+			if n := sort.Search(len(byStart), func(k int) bool { return byStart[k].Idx0() >= end }); n < len(byStart) {
+				if next := byStart[n]; self.isLeadingGap(end, next.Idx0(), self.comments[i+1:]) {
+					node = next
+				}
+			}
+		}
+		if node == nil {
+			for _, n := range byStart {
+				if n.Idx0() > c.Idx {
+					break
+				}
+				if n.Idx1() >= c.End() {
+					node = n
+				}
+			}
+		}
+		if node == nil {
+			node = prg
+		}
+		cmap[node] = append(cmap[node], c)
+	}
+	return cmap
+}
+
+// isTrailingGap reports whether there are only blanks, semicolons and commas between from and to on the same line.
+func (self *_parser) isTrailingGap(from, to file.Idx) bool {
+	for _, chr := range self.slice(from, to) {
+		switch chr {
+		case ';', ',', ' ', '\t', '\v', '\f', '\u00a0', '\ufeff':
+		default:
+			return false
+		}
+	}
+	return true
+}
+
+// endsLine reports whether there is only white space after the comment up to the end of the line.
+func (self *_parser) endsLine(c *ast.Comment) bool {
+	for _, chr := range self.str[int(c.End())-self.base:] {
+		switch chr {
+		case '\n', '\r', '\u2028', '\u2029':
+			return true
+		case ' ', '\t', '\v', '\f', '\u00a0', '\ufeff':
+		default:
+			return false
+		}
+	}
+	return true
+}
+
+// isLeadingGap reports whether there are only white space and the given comments between from and to.
+func (self *_parser) isLeadingGap(from, to file.Idx, comments []*ast.Comment) bool {
+	for idx := from; idx < to; {
+		if len(comments) > 0 && comments[0].Idx == idx {
+			idx = comments[0].End()
+			comments = comments[1:]
+			continue
+		}
+		chr, width := utf8.DecodeRuneInString(self.str[int(idx)-self.base:])
+		if !unicode.IsSpace(chr) && chr != '\ufeff' {
+			return false
+		}
+		idx += file.Idx(width)
+	}
+	return true
+}
@@ -0,0 +1,75 @@
+package parser
+
+import (
+	"testing"
+
+	"github.com/rarnu/goscript/ast"
+)
+
+func TestComments(t *testing.T) {
+	tt(t, func() {
+		src := `#!/usr/bin/env goscript
+// header
+
+/**
+ * Entry point.
+ * @param {string} name
+ */
+function main(name /* inline */) {
+	let x = f(1, /* arg */ 2); // trailing
+	return x
+	// end of body
+}
+var o = { /* empty */ };
+/* last */`
+		program, err := ParseFile(nil, "", src, 0, WithComments)
+		is(err, nil)
+		is(len(program.Comments), 9)
+		is(program.Comments[0].Text, "#!/usr/bin/env goscript")
+		is(program.Comments[1].Text, "// header")
+		is(program.Comments[2].IsDoc(), true)
+		is(program.Comments[8].Text, "/* last */")
+		for _, c := range program.Comments {
+			is(src[c.Idx-1:c.End()-1], c.Text)
+		}
+
+		attached := func(node ast.Node) (texts []string) {
+			for _, c := range program.CommentMap[node] {
+				texts = append(texts, c.Text)
+			}
+			return
+		}
+
+		fn := program.Body[0].(*ast.FunctionDeclaration)
+		is(attached(fn), "[#!/usr/bin/env goscript // header /**\n * Entry point.\n * @param {string} name\n */]")
+		is(program.CommentMap.Doc(fn), program.Comments[2])
+		is(len(program.CommentMap.Leading(fn)), 3)
+		is(attached(fn.Function.ParameterList), "[/* inline */]")
+
+		body := fn.Function.Body
+		decl := body.List[0].(*ast.LexicalDeclaration)
+		is(attached(decl), "[// trailing]")
+		call := decl.List[0].Initializer.(*ast.CallExpression)
+		is(attached(call.ArgumentList[1]), "[/* arg */]")
+		is(attached(body), "[// end of body]")
+		is(program.CommentMap.Doc(body.List[1]), nil)
+
+		obj := program.Body[1].(*ast.VariableStatement).List[0].Initializer
+		is(attached(obj), "[/* empty */]")
+		is(attached(program), "[/* last */]")
+
+		// comments scanned twice when the parser backtracks are recorded once
+		program, err = ParseFile(nil, "", "let f = (a /* a */, b) => a, g = (/* c */ 1)", 0, WithComments)
+		is(err, nil)
+		is(len(program.Comments), 2)
+
+		program, err = ParseFile(nil, "", "// x", 0, WithComments)
+		is(err, nil)
+		is(attached(program), "[// x]")
+
+		program, err = ParseFile(nil, "", "a /* x */", 0)
+		is(err, nil)
+		is(len(program.Comments), 0)
+		is(program.CommentMap == nil, true)
+	})
+}
@@ -49,8 +49,11 @@ const (
 // A SyntaxError is a description of an ECMAScript syntax error.
 
 // An Error represents a parsing error. It includes the position where the error occurred and a message/description.
+// End is the position just after the offending token, it is the same as Position if the error does not refer to a
+// token (e.g. at the end of input).
 type Error struct {
 	Position file.Position
+	End      file.Position
 	Message  string
 }
 
@@ -85,11 +88,28 @@ func (self *_parser) error(place interface{}, msg string, msgValues ...interface
 	}
 
 	position := self.position(idx)
+	end := position
+	if idx == self.idx && self.token != token.EOF {
+		end = self.position(self.idxOf(self.chrOffset))
+	}
 	msg = fmt.Sprintf(msg, msgValues...)
-	self.errors.Add(position, msg)
+	if self.opts.errorRecovery && len(self.errors) > 0 {
+		// the same problem is often reported by both the lexer and the parser
+		if last := self.errors[len(self.errors)-1]; last.Position == position {
+			return last
+		}
+	}
+	self.errors = append(self.errors, &Error{Position: position, End: end, Message: msg})
 	return self.errors[len(self.errors)-1]
 }
 
+// abort abandons the current statement or list element in the error recovery mode.
+func (self *_parser) abort() {
+	if self.opts.errorRecovery {
+		panic(bailout{})
+	}
+}
+
 func (self *_parser) errorUnexpected(idx file.Idx, chr rune) error {
 	if chr == -1 {
 		return self.error(idx, err_UnexpectedEndOfInput)
@@ -98,6 +118,7 @@ func (self *_parser) errorUnexpected(idx file.Idx, chr rune) error {
 }
 
 func (self *_parser) errorUnexpectedToken(tkn token.Token) error {
+	defer self.abort()
 	switch tkn {
 	case token.EOF:
 		return self.error(file.Idx(0), err_UnexpectedEndOfInput)
@@ -126,7 +147,7 @@ type ErrorList []*Error
 
 // Add adds an Error with given position and message to an ErrorList.
 func (self *ErrorList) Add(position file.Position, msg string) {
-	*self = append(*self, &Error{position, msg})
+	*self = append(*self, &Error{Position: position, End: position, Message: msg})
 }
 
 // Reset resets an ErrorList to no errors.
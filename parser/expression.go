@@ -176,6 +176,7 @@ func (self *_parser) reinterpretSequenceAsArrowFuncParams(list []ast.Expression)
 
 func (self *_parser) parseParenthesisedExpression() ast.Expression {
 	opening := self.idx
+	errorCount := len(self.errors)
 	self.expect(token.LEFT_PARENTHESIS)
 	var list []ast.Expression
 	if self.token != token.RIGHT_PARENTHESIS {
@@ -203,7 +204,7 @@ func (self *_parser) parseParenthesisedExpression() ast.Expression {
 		}
 	}
 	self.expect(token.RIGHT_PARENTHESIS)
-	if len(list) == 1 && len(self.errors) == 0 {
+	if len(list) == 1 && len(self.errors) == errorCount {
 		return list[0]
 	}
 	if len(list) == 0 {
@@ -307,6 +308,14 @@ func (self *_parser) parseVariableDeclaration(declarationList *[]*ast.Binding) *
 		Target: self.parseBindingTarget(),
 	}
 
+	if self.opts.typeScript {
+		if self.token == token.QUESTION_MARK || self.token == token.NOT {
+			// optional parameter or definite assignment assertion
+			self.next()
+		}
+		self.tsSkipTypeAnnotation()
+	}
+
 	if declarationList != nil {
 		*declarationList = append(*declarationList, node)
 	}
@@ -418,7 +427,7 @@ func (self *_parser) parseObjectProperty() ast.Property {
 			}
 		}
 		switch {
-		case self.token == token.LEFT_PARENTHESIS:
+		case self.token == token.LEFT_PARENTHESIS || self.opts.typeScript && self.token == token.LESS:
 			return &ast.PropertyKeyed{
 				Key:      value,
 				Kind:     ast.PropertyKindMethod,
@@ -480,6 +489,11 @@ func (self *_parser) parseObjectProperty() ast.Property {
 }
 
 func (self *_parser) parseMethodDefinition(keyStartIdx file.Idx, kind ast.PropertyKind, generator, async bool) *ast.FunctionLiteral {
+	classMember := self.ts.classMember
+	self.ts.classMember = false
+	if self.opts.typeScript && self.token == token.LESS {
+		self.tsSkipTypeParameters()
+	}
 	idx1 := self.idx
 	if generator != self.scope.allowYield {
 		self.scope.allowYield = generator
@@ -494,6 +508,8 @@ func (self *_parser) parseMethodDefinition(keyStartIdx file.Idx, kind ast.Proper
 		}()
 	}
 	parameterList := self.parseFunctionParameterList()
+	props := self.ts.paramProps
+	self.ts.paramProps = nil
 	switch kind {
 	case ast.PropertyKindGet:
 		if len(parameterList.List) > 0 || parameterList.Rest != nil {
@@ -504,6 +520,14 @@ func (self *_parser) parseMethodDefinition(keyStartIdx file.Idx, kind ast.Proper
 			self.error(idx1, "Setter must have exactly one formal parameter.")
 		}
 	}
+	if self.opts.typeScript {
+		self.tsSkipTypeAnnotation()
+		if classMember && self.token != token.LEFT_BRACE {
+			// overload signature or abstract method
+			self.optionalSemicolon()
+			return nil
+		}
+	}
 	node := &ast.FunctionLiteral{
 		Function:      keyStartIdx,
 		ParameterList: parameterList,
@@ -511,6 +535,9 @@ func (self *_parser) parseMethodDefinition(keyStartIdx file.Idx, kind ast.Proper
 		Async:         async,
 	}
 	node.Body, node.DeclarationList = self.parseFunctionBlock(async, async, generator)
+	if len(props) > 0 {
+		self.tsInsertParamProps(node.Body, props)
+	}
 	node.Source = self.slice(keyStartIdx, node.Body.Idx1())
 	return node
 }
@@ -519,7 +546,15 @@ func (self *_parser) parseObjectLiteral() *ast.ObjectLiteral {
 	var value []ast.Property
 	idx0 := self.expect(token.LEFT_BRACE)
 	for self.token != token.RIGHT_BRACE && self.token != token.EOF {
-		property := self.parseObjectProperty()
+		var property ast.Property
+		if self.opts.errorRecovery {
+			// a broken property is left out
+			if p, ok := self.parseListElement(func() ast.Expression { return self.parseObjectProperty() }).(ast.Property); ok {
+				property = p
+			}
+		} else {
+			property = self.parseObjectProperty()
+		}
 		if property != nil {
 			value = append(value, property)
 		}
@@ -548,14 +583,7 @@ func (self *_parser) parseArrayLiteral() *ast.ArrayLiteral {
 			value = append(value, nil)
 			continue
 		}
-		if self.token == token.ELLIPSIS {
-			self.next()
-			value = append(value, &ast.SpreadElement{
-				Expression: self.parseAssignmentExpression(),
-			})
-		} else {
-			value = append(value, self.parseAssignmentExpression())
-		}
+		value = append(value, self.parseListElement(self.parseElement))
 		if self.token != token.RIGHT_BRACKET {
 			self.expect(token.COMMA)
 		}
@@ -569,6 +597,17 @@ func (self *_parser) parseArrayLiteral() *ast.ArrayLiteral {
 	}
 }
 
+// parseElement parses an array element or a call argument, which may be a spread element.
+func (self *_parser) parseElement() ast.Expression {
+	if self.token == token.ELLIPSIS {
+		self.next()
+		return &ast.SpreadElement{
+			Expression: self.parseAssignmentExpression(),
+		}
+	}
+	return self.parseAssignmentExpression()
+}
+
 func (self *_parser) parseTemplateLiteral(tagged bool) *ast.TemplateLiteral {
 	res := &ast.TemplateLiteral{
 		OpenQuote: self.idx,
@@ -594,6 +633,10 @@ func (self *_parser) parseTemplateLiteral(tagged bool) *ast.TemplateLiteral {
 			res.CloseQuote = self.idxOf(end)
 			break
 		}
+		if self.opts.errorRecovery {
+			// the closing brace of the substitution is passed by the next call to next()
+			self.pushBracket(token.LEFT_BRACE)
+		}
 		expr := self.parseExpression()
 		res.Expressions = append(res.Expressions, expr)
 		if self.token != token.RIGHT_BRACE {
@@ -612,16 +655,7 @@ func (self *_parser) parseTaggedTemplateLiteral(tag ast.Expression) *ast.Templat
 func (self *_parser) parseArgumentList() (argumentList []ast.Expression, idx0, idx1 file.Idx) {
 	idx0 = self.expect(token.LEFT_PARENTHESIS)
 	for self.token != token.RIGHT_PARENTHESIS {
-		var item ast.Expression
-		if self.token == token.ELLIPSIS {
-			self.next()
-			item = &ast.SpreadElement{
-				Expression: self.parseAssignmentExpression(),
-			}
-		} else {
-			item = self.parseAssignmentExpression()
-		}
-		argumentList = append(argumentList, item)
+		argumentList = append(argumentList, self.parseListElement(self.parseElement))
 		if self.token != token.COMMA {
 			break
 		}
@@ -740,6 +774,10 @@ L:
 			left = self.parseBracketMember(left)
 		case token.BACKTICK:
 			left = self.parseTaggedTemplateLiteral(left)
+		case token.LESS:
+			if !self.opts.typeScript || !self.tsSkipCallTypeArguments() {
+				break L
+			}
 		default:
 			break L
 		}
@@ -791,6 +829,16 @@ L:
 			default:
 				left = self.parseDotMember(left)
 			}
+		case token.LESS:
+			if !self.opts.typeScript || !self.tsSkipCallTypeArguments() {
+				break L
+			}
+		case token.NOT:
+			if !self.opts.typeScript || self.implicitSemicolon {
+				break L
+			}
+			// TypeScript non-null assertion
+			self.tsNext()
 		default:
 			break L
 		}
@@ -969,9 +1017,10 @@ func (self *_parser) parseRelationalExpression() ast.Expression {
 		}
 		self.next()
 		if self.token == token.IN {
+			operator := self.token
 			self.next()
 			return &ast.BinaryExpression{
-				Operator: self.token,
+				Operator: operator,
 				Left:     left,
 				Right:    self.parseShiftExpression(),
 			}
@@ -979,6 +1028,9 @@ func (self *_parser) parseRelationalExpression() ast.Expression {
 		return left
 	}
 	left := self.parseShiftExpression()
+	if self.opts.typeScript {
+		self.tsSkipAsExpression()
+	}
 
 	allowIn := self.scope.allowIn
 	self.scope.allowIn = true
@@ -1232,15 +1284,31 @@ func (self *_parser) parseAssignmentExpression() ast.Expression {
 	var state parserState
 	switch self.token {
 	case token.LEFT_PARENTHESIS:
+		if self.opts.typeScript {
+			if f := self.tsParseArrowFunction(start, false); f != nil {
+				return f
+			}
+		}
 		self.mark(&state)
 		parenthesis = true
+	case token.LESS:
+		if self.opts.typeScript {
+			if f := self.tsParseArrowFunction(start, false); f != nil {
+				return f
+			}
+		}
 	case token.ASYNC:
 		tok := self.peek()
 		if self.isBindingId(tok) {
 			// async x => ...
 			self.next()
 			return self.parseSingleArgArrowFunction(start, true)
-		} else if tok == token.LEFT_PARENTHESIS {
+		} else if tok == token.LEFT_PARENTHESIS || self.opts.typeScript && tok == token.LESS {
+			if self.opts.typeScript {
+				if f := self.tsParseArrowFunction(start, true); f != nil {
+					return f
+				}
+			}
 			self.mark(&state)
 			async = true
 		}
@@ -1252,7 +1320,15 @@ func (self *_parser) parseAssignmentExpression() ast.Expression {
 	default:
 		self.tokenToBindingId()
 	}
-	left := self.parseConditionalExpression()
+	var left ast.Expression
+	if self.opts.errorRecovery && (parenthesis || async) {
+		var arrow bool
+		if left, arrow = self.parseArrowFunctionOrConditional(start, &state, async); arrow {
+			return left
+		}
+	} else {
+		left = self.parseConditionalExpression()
+	}
 	var operator token.Token
 	switch self.token {
 	case token.ASSIGN:
@@ -1292,7 +1368,7 @@ func (self *_parser) parseAssignmentExpression() ast.Expression {
 				}},
 			}
 		} else if parenthesis {
-			if seq, ok := left.(*ast.SequenceExpression); ok && len(self.errors) == 0 {
+			if seq, ok := left.(*ast.SequenceExpression); ok && len(self.errors) == state.errorCount {
 				paramList = self.reinterpretSequenceAsArrowFuncParams(seq.Sequence)
 			} else {
 				self.restore(&state)
@@ -1371,7 +1447,10 @@ func (self *_parser) parseYieldExpression() ast.Expression {
 	if !self.implicitSemicolon && self.token != token.SEMICOLON && self.token != token.RIGHT_BRACE && self.token != token.EOF {
 		var state parserState
 		self.mark(&state)
-		expr := self.parseAssignmentExpression()
+		var expr ast.Expression
+		if !self.tryParse(func() { expr = self.parseAssignmentExpression() }) {
+			expr = &ast.BadExpression{}
+		}
 		if _, bad := expr.(*ast.BadExpression); bad {
 			expr = nil
 			self.restore(&state)
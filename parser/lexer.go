@@ -195,6 +195,8 @@ type parserState struct {
 	chr                                rune
 	chrOffset, offset                  int
 	errorCount                         int
+	brackets                           *bracket
+	lastEnd                            file.Idx
 }
 
 func (self *_parser) mark(state *parserState) *parserState {
@@ -205,6 +207,7 @@ func (self *_parser) mark(state *parserState) *parserState {
 		self.idx, self.token, self.literal, self.parsedLiteral, self.implicitSemicolon, self.insertSemicolon, self.chr, self.chrOffset, self.offset
 
 	state.errorCount = len(self.errors)
+	state.brackets, state.lastEnd = self.brackets, self.lastEnd
 	return state
 }
 
@@ -212,6 +215,7 @@ func (self *_parser) restore(state *parserState) {
 	self.idx, self.token, self.literal, self.parsedLiteral, self.implicitSemicolon, self.insertSemicolon, self.chr, self.chrOffset, self.offset =
 		state.idx, state.tok, state.literal, state.parsedLiteral, state.implicitSemicolon, state.insertSemicolon, state.chr, state.chrOffset, state.offset
 	self.errors = self.errors[:state.errorCount]
+	self.brackets, self.lastEnd = state.brackets, state.lastEnd
 }
 
 func (self *_parser) peek() token.Token {
@@ -364,14 +368,17 @@ func (self *_parser) scan() (tkn token.Token, literal string, parsedLiteral unis
 					tkn = self.switch2(token.MULTIPLY, token.MULTIPLY_ASSIGN)
 				}
 			case '/':
+				start := self.chrOffset - 1
 				if self.chr == '/' {
 					self.skipSingleLineComment()
+					self.addComment(start)
 					continue
 				} else if self.chr == '*' {
 					if self.skipMultiLineComment() {
 						self.insertSemicolon = false
 						self.implicitSemicolon = true
 					}
+					self.addComment(start)
 					continue
 				} else {
 					// Could be division, could be RegExp literal
@@ -436,6 +443,7 @@ func (self *_parser) scan() (tkn token.Token, literal string, parsedLiteral unis
 			case '#':
 				if self.chrOffset == 1 && self.chr == '!' {
 					self.skipSingleLineComment()
+					self.addComment(0)
 					continue
 				}
 
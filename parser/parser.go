@@ -54,10 +54,13 @@ const (
 type options struct {
 	disableSourceMaps bool
 	sourceMapLoader   func(path string) ([]byte, error)
+	typeScript        bool
+	errorRecovery     bool
+	comments          bool
 }
 
 // Option represents one of the options for the parser to use in the Parse methods. Currently supported are:
-// WithDisableSourceMaps and WithSourceMapLoader.
+// WithDisableSourceMaps, WithSourceMapLoader, WithTypeScript, WithErrorRecovery and WithComments.
 type Option func(*options)
 
 // WithDisableSourceMaps is an option to disable source maps support. May save a bit of time when source maps
@@ -77,6 +80,29 @@ func WithSourceMapLoader(loader func(path string) ([]byte, error)) Option {
 	}
 }
 
+// WithTypeScript is an option to accept TypeScript syntax. Type annotations, interfaces, type aliases, generics,
+// `as` expressions and non-null assertions are stripped, enums and constructor parameter properties are lowered
+// to the equivalent JavaScript. Types are not checked in any way.
+func WithTypeScript(opts *options) {
+	opts.typeScript = true
+}
+
+// WithErrorRecovery is an option to keep parsing after syntax errors, e.g. to report all of them in an editor.
+// A statement that fails to parse is replaced with an ast.BadStatement and the parser resumes at the start of the
+// next statement. Within call arguments, array and object literals a broken element is replaced with an
+// ast.BadExpression (or omitted in case of an object property) and the parser resumes at the next element.
+// The returned ErrorList contains every diagnostic with its start and end positions, and the returned program
+// is not nil even if there are errors.
+func WithErrorRecovery(opts *options) {
+	opts.errorRecovery = true
+}
+
+// WithComments is an option to record the comments in ast.Program.Comments and to attach them to the nodes in
+// ast.Program.CommentMap (see ast.CommentMap for the rules). Use the jsdoc package to parse documentation comments.
+func WithComments(opts *options) {
+	opts.comments = true
+}
+
 type _parser struct {
 	str    string
 	length int
@@ -95,7 +121,11 @@ type _parser struct {
 	insertSemicolon   bool // If we see a newline, then insert an implicit semicolon
 	implicitSemicolon bool // An implicit semicolon exists
 
-	errors ErrorList
+	errors   ErrorList
+	comments []*ast.Comment
+
+	brackets *bracket // The brackets that are open before the current token (only in the error recovery mode)
+	lastEnd  file.Idx // The end of the previous token (only in the error recovery mode)
 
 	recover struct {
 		// Scratch when trying to seek to the next statement, etc.
@@ -105,6 +135,7 @@ type _parser struct {
 
 	mode Mode
 	opts options
+	ts   tsState
 
 	file *file.File
 }
@@ -220,6 +251,9 @@ func (self *_parser) parse() (*ast.Program, error) {
 }
 
 func (self *_parser) next() {
+	if self.opts.errorRecovery {
+		self.trackBrackets()
+	}
 	self.token, self.literal, self.parsedLiteral, self.idx = self.scan()
 }
 
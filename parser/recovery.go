@@ -0,0 +1,230 @@
+package parser
+
+import (
+	"strings"
+
+	"github.com/rarnu/goscript/ast"
+	"github.com/rarnu/goscript/file"
+	"github.com/rarnu/goscript/token"
+)
+
+// bailout is raised (as a panic) in the error recovery mode when the parser cannot continue the current
+// statement or expression. It is recovered at the nearest statement or list element boundary.
+type bailout struct{}
+
+// bracket is an element of the stack of the brackets that are open before the current token. It is only
+// maintained in the error recovery mode. The stack is immutable, so that it can be saved and restored along
+// with the rest of the parser state.
+type bracket struct {
+	tkn   token.Token
+	outer *bracket
+}
+
+func (self *bracket) closer() token.Token {
+	switch self.tkn {
+	case token.LEFT_PARENTHESIS:
+		return token.RIGHT_PARENTHESIS
+	case token.LEFT_BRACKET:
+		return token.RIGHT_BRACKET
+	}
+	return token.RIGHT_BRACE
+}
+
+// find returns the innermost bracket that is closed by tkn, or nil if there is none down to (but not including)
+// the bracket bottom.
+func (self *bracket) find(tkn token.Token, bottom *bracket) *bracket {
+	for b := self; b != bottom && b != nil; b = b.outer {
+		if b.closer() == tkn {
+			return b
+		}
+	}
+	return nil
+}
+
+func (self *_parser) pushBracket(tkn token.Token) {
+	self.brackets = &bracket{tkn: tkn, outer: self.brackets}
+}
+
+// trackBrackets updates the bracket stack and the end of the last token when the parser moves past the
+// current token. A closing bracket also closes the brackets inside of it that are still open, an unmatched
+// closing bracket is ignored.
+func (self *_parser) trackBrackets() {
+	switch self.token {
+	case token.LEFT_PARENTHESIS, token.LEFT_BRACKET, token.LEFT_BRACE:
+		self.pushBracket(self.token)
+	case token.RIGHT_PARENTHESIS, token.RIGHT_BRACKET, token.RIGHT_BRACE:
+		if b := self.brackets.find(self.token, nil); b != nil {
+			self.brackets = b.outer
+		}
+	}
+	if self.token != token.EOF {
+		self.lastEnd = self.idxOf(self.chrOffset)
+	}
+}
+
+// tryParse runs f and reports whether it has completed. In the error recovery mode f may bail out, in which
+// case the scope and the TypeScript state are restored and false is returned. The position of the parser is
+// left as it was at the point of the error.
+func (self *_parser) tryParse(f func()) (ok bool) {
+	scope, saved, ts := self.scope, *self.scope, self.ts
+	defer func() {
+		if x := recover(); x != nil {
+			if _, isBailout := x.(bailout); !isBailout {
+				panic(x)
+			}
+			self.scope, *scope, self.ts = scope, saved, ts
+			ok = false
+		}
+	}()
+	f()
+	return true
+}
+
+// newlineBefore reports whether there is a line terminator between the last token and the current one.
+func (self *_parser) newlineBefore() bool {
+	return strings.ContainsAny(self.slice(self.lastEnd, self.idx), "\n\r\u2028\u2029")
+}
+
+// isStatementKeyword reports whether tkn is a keyword that can only start a statement or a declaration.
+// Such a keyword at the beginning of a line ends the skipping of a broken statement regardless of brackets.
+func isStatementKeyword(tkn token.Token) bool {
+	switch tkn {
+	case token.VAR, token.LET, token.CONST, token.FUNCTION, token.CLASS, token.IF, token.FOR, token.WHILE,
+		token.DO, token.SWITCH, token.TRY, token.THROW, token.RETURN, token.BREAK, token.CONTINUE, token.WITH,
+		token.DEBUGGER:
+		return true
+	}
+	return false
+}
+
+// parseStatementListItem parses a statement of a statement list. In the error recovery mode a syntax error
+// turns the statement into an ast.BadStatement and the parser skips to the start of the next statement.
+func (self *_parser) parseStatementListItem() ast.Statement {
+	self.scope.allowLet = true
+	if !self.opts.errorRecovery {
+		return self.parseStatement()
+	}
+	start, outer := self.idx, self.brackets
+	var stmt ast.Statement
+	if self.tryParse(func() { stmt = self.parseStatement() }) {
+		return stmt
+	}
+	return &ast.BadStatement{From: start, To: self.skipStatement(start, outer)}
+}
+
+// skipStatement skips the rest of a statement starting at start which failed to parse, outer is the innermost
+// bracket that was open at the start. Skipping stops after a semicolon or before a new line at the level of the
+// statement, before the closing brace of the enclosing block, or before a keyword that starts a statement on a
+// new line. Parentheses and square brackets that are left open do not count, as a missing closing one is the
+// more likely mistake. It returns the end of the skipped input.
+func (self *_parser) skipStatement(start file.Idx, outer *bracket) file.Idx {
+	end := start
+	if self.lastEnd > end {
+		end = self.lastEnd
+	}
+	for self.token != token.EOF {
+		atLevel := self.brackets.find(token.RIGHT_BRACE, outer) == nil
+		if self.idx > start {
+			if self.newlineBefore() && (atLevel || isStatementKeyword(self.token)) {
+				break
+			}
+			if self.token == token.RIGHT_BRACE && atLevel && outer != nil {
+				// closes the enclosing block
+				break
+			}
+			if atLevel && (self.token == token.CASE || self.token == token.DEFAULT) {
+				break
+			}
+		}
+		semicolon := self.token == token.SEMICOLON
+		self.next()
+		end = self.lastEnd
+		if atLevel && semicolon {
+			break
+		}
+	}
+	// the brackets that are still open belong to the broken statement
+	self.brackets = outer
+	return end
+}
+
+// parseListElement parses an element of a bracketed list (call arguments, array elements or object properties)
+// using f. In the error recovery mode a syntax error turns the element into an ast.BadExpression and the parser
+// skips to the next comma or to the closing bracket of the list. If the list cannot be recovered (e.g. the
+// statement ends before it is closed), the list is abandoned and the error is recovered by the enclosing list
+// or statement.
+func (self *_parser) parseListElement(f func() ast.Expression) ast.Expression {
+	if !self.opts.errorRecovery {
+		return f()
+	}
+	start, list := self.idx, self.brackets
+	var expr ast.Expression
+	if self.tryParse(func() { expr = f() }) {
+		return expr
+	}
+	end := start
+	if self.lastEnd > end {
+		end = self.lastEnd
+	}
+	for {
+		switch self.token {
+		case token.COMMA:
+			if self.brackets == list {
+				return &ast.BadExpression{From: start, To: end}
+			}
+		case token.RIGHT_PARENTHESIS, token.RIGHT_BRACKET, token.RIGHT_BRACE:
+			if self.brackets.find(self.token, list) == nil {
+				if self.token == list.closer() {
+					self.brackets = list
+					return &ast.BadExpression{From: start, To: end}
+				}
+				if list.find(self.token, nil) != nil {
+					// closes a bracket outside of the list
+					self.brackets = list.outer
+					panic(bailout{})
+				}
+			}
+		case token.SEMICOLON:
+			if self.brackets == list {
+				self.brackets = list.outer
+				panic(bailout{})
+			}
+		case token.EOF:
+			self.brackets = list.outer
+			panic(bailout{})
+		}
+		if self.idx > start && self.newlineBefore() && isStatementKeyword(self.token) {
+			self.brackets = list.outer
+			panic(bailout{})
+		}
+		self.next()
+		end = self.lastEnd
+	}
+}
+
+// parseArrowFunctionOrConditional is used in the error recovery mode in place of parseConditionalExpression when
+// the expression may turn out to be the parameter list of an arrow function, which is not necessarily a valid
+// expression, e.g. (...rest) => {}. A syntax error is final only if the input cannot be parsed as a parameter list
+// followed by an arrow either, in which case the arrow function is returned and arrow is true. The state must have
+// been marked at the start of the expression.
+func (self *_parser) parseArrowFunctionOrConditional(start file.Idx, state *parserState, async bool) (left ast.Expression, arrow bool) {
+	if self.tryParse(func() { left = self.parseConditionalExpression() }) {
+		return left, false
+	}
+	self.restore(state)
+	allowAwait := self.scope.allowAwait
+	if async {
+		self.next() // skip "async"
+		self.scope.allowAwait = true
+	}
+	var paramList *ast.ParameterList
+	if self.tryParse(func() { paramList = self.parseFunctionParameterList() }) &&
+		self.token == token.ARROW && len(self.errors) == state.errorCount {
+		left = self.parseArrowFunction(start, paramList, async)
+		self.scope.allowAwait = allowAwait
+		return left, true
+	}
+	self.scope.allowAwait = allowAwait
+	self.restore(state)
+	return self.parseConditionalExpression(), false
+}
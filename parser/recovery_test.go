@@ -0,0 +1,161 @@
+package parser
+
+import (
+	goast "go/ast"
+	goparser "go/parser"
+	gotoken "go/token"
+	"reflect"
+	"strconv"
+	"testing"
+
+	"github.com/rarnu/goscript/ast"
+	"github.com/rarnu/goscript/file"
+)
+
+func TestErrorRecovery(t *testing.T) {
+	tt(t, func() {
+		test := func(src string, statements string, errors ...string) *ast.Program {
+			program, err := ParseFile(nil, "", src, 0, WithErrorRecovery)
+			var list ErrorList
+			if err != nil {
+				list = err.(ErrorList)
+			}
+			is(len(list), len(errors))
+			for i, e := range list {
+				is(e.Error()+" - "+strconv.Itoa(e.End.Line)+":"+strconv.Itoa(e.End.Column), errors[i])
+			}
+			var kinds string
+			for _, stmt := range program.Body {
+				if kinds != "" {
+					kinds += " "
+				}
+				kinds += reflect.TypeOf(stmt).Elem().Name()
+			}
+			is(kinds, statements)
+			return program
+		}
+
+		test("let x = 1", "LexicalDeclaration")
+
+		program := test("let x = ;\nfoo(1;\nfunction f() { return 1 + ; }\nvar ok = 2;",
+			"BadStatement BadStatement FunctionDeclaration VariableStatement",
+			"(anonymous): Line 1:9 Unexpected token ; - 1:10",
+			"(anonymous): Line 2:6 Unexpected token ; - 2:7",
+			"(anonymous): Line 3:27 Unexpected token ; - 3:28",
+		)
+		bad := program.Body[1].(*ast.BadStatement)
+		is(bad.From, file.Idx(11))
+		is(bad.To, file.Idx(17))
+		body := program.Body[2].(*ast.FunctionDeclaration).Function.Body.List
+		is(len(body), 1)
+		_ = body[0].(*ast.BadStatement)
+
+		program = test("foo(a +, b, [1, *, 3], {a: 1, b: ], c})\nbar()",
+			"ExpressionStatement ExpressionStatement",
+			"(anonymous): Line 1:8 Unexpected token , - 1:9",
+			"(anonymous): Line 1:17 Unexpected token * - 1:18",
+			"(anonymous): Line 1:34 Unexpected token ] - 1:35",
+		)
+		call := program.Body[0].(*ast.ExpressionStatement).Expression.(*ast.CallExpression)
+		is(len(call.ArgumentList), 4)
+		badExpr := call.ArgumentList[0].(*ast.BadExpression)
+		is(badExpr.From, file.Idx(5))
+		is(badExpr.To, file.Idx(8))
+		_ = call.ArgumentList[1].(*ast.Identifier)
+		arr := call.ArgumentList[2].(*ast.ArrayLiteral)
+		is(len(arr.Value), 3)
+		_ = arr.Value[1].(*ast.BadExpression)
+		obj := call.ArgumentList[3].(*ast.ObjectLiteral)
+		is(len(obj.Value), 2)
+
+		test("var a = [1, 2\nvar b = 3",
+			"BadStatement VariableStatement",
+			"(anonymous): Line 2:1 Unexpected token var - 2:4",
+		)
+		test("if (x {\n  y()\n}\nz()",
+			"BadStatement ExpressionStatement",
+			"(anonymous): Line 1:7 Unexpected token { - 1:8",
+		)
+		test("function f() {\n  foo(\n}\ng()",
+			"FunctionDeclaration ExpressionStatement",
+			"(anonymous): Line 3:1 Unexpected token } - 3:2",
+		)
+		test("class A { m() { x = } n() {} }\nq()",
+			"ClassDeclaration ExpressionStatement",
+			"(anonymous): Line 1:21 Unexpected token } - 1:22",
+		)
+		test("switch (x) { case 1: a(; case 2: b() }\nc()",
+			"SwitchStatement ExpressionStatement",
+			"(anonymous): Line 1:24 Unexpected token ; - 1:25",
+		)
+		test("`a${b +}c`; d()",
+			"BadStatement ExpressionStatement",
+			"(anonymous): Line 1:8 Unexpected token } - 1:9",
+		)
+		test("a b c\nd()\n}",
+			"BadStatement ExpressionStatement BadStatement",
+			"(anonymous): Line 1:3 Unexpected identifier - 1:4",
+			"(anonymous): Line 3:1 Unexpected token } - 3:2",
+		)
+		test("let f = (...a) => a, g = () => 1, h = async (x,) => x; (a, b) + ",
+			"LexicalDeclaration BadStatement",
+			"(anonymous): Line 1:65 Unexpected end of input - 1:65",
+		)
+		test("x = 1; break foo; y = z = ;",
+			"ExpressionStatement BadStatement EmptyStatement BadStatement",
+			"(anonymous): Line 1:8 Undefined label 'foo' - 1:8",
+			"(anonymous): Line 1:27 Unexpected token ; - 1:28",
+		)
+
+		program, err := ParseFile(nil, "", "let x = ;\nfoo(1;", 0)
+		is(err, "(anonymous): Line 1:9 Unexpected token ; (and 1 more errors)")
+		is(program != nil, true)
+	})
+}
+
+// TestErrorRecoveryCorpus checks that the error recovery mode does not affect valid programs and does not break
+// down on invalid ones.
+func TestErrorRecoveryCorpus(t *testing.T) {
+	count := 0
+	for name, opts := range map[string][]Option{"parser_test.go": nil, "typescript_test.go": {WithTypeScript}} {
+		f, err := goparser.ParseFile(gotoken.NewFileSet(), name, nil, 0)
+		if err != nil {
+			t.Fatal(err)
+		}
+		goast.Inspect(f, func(n goast.Node) bool {
+			call, ok := n.(*goast.CallExpr)
+			if !ok || len(call.Args) != 2 {
+				return true
+			}
+			if fn, ok := call.Fun.(*goast.Ident); !ok || fn.Name != "test" {
+				return true
+			}
+			lit, ok := call.Args[0].(*goast.BasicLit)
+			if !ok || lit.Kind != gotoken.STRING {
+				return true
+			}
+			src, err := strconv.Unquote(lit.Value)
+			if err != nil {
+				return true
+			}
+			count++
+			expected, expectedErr := ParseFile(nil, "", src, 0, opts...)
+			program, err := ParseFile(nil, "", src, 0, append(opts, WithErrorRecovery)...)
+			if expectedErr == nil {
+				if err != nil {
+					t.Errorf("%q: unexpected error: %v", src, err)
+				} else if !reflect.DeepEqual(program, expected) {
+					t.Errorf("%q: the programs differ", src)
+				}
+			} else if err == nil {
+				t.Errorf("%q: expected an error", src)
+			} else if program == nil {
+				t.Errorf("%q: no program", src)
+			}
+			return true
+		})
+	}
+	if count < 300 {
+		t.Fatalf("The corpus is too small: %d", count)
+	}
+}
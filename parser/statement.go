@@ -28,8 +28,7 @@ func (self *_parser) parseEmptyStatement() ast.Statement {
 
 func (self *_parser) parseStatementList() (list []ast.Statement) {
 	for self.token != token.RIGHT_BRACE && self.token != token.EOF {
-		self.scope.allowLet = true
-		list = append(list, self.parseStatement())
+		list = append(list, self.parseStatementListItem())
 	}
 
 	return
@@ -42,6 +41,12 @@ func (self *_parser) parseStatement() ast.Statement {
 		return &ast.BadStatement{From: self.idx, To: self.idx + 1}
 	}
 
+	if self.opts.typeScript {
+		if st := self.tsParseStatement(); st != nil {
+			return st
+		}
+	}
+
 	switch self.token {
 	case token.SEMICOLON:
 		return self.parseEmptyStatement()
@@ -75,14 +80,10 @@ func (self *_parser) parseStatement() ast.Statement {
 		return self.parseLexicalDeclaration(self.token)
 	case token.ASYNC:
 		if f := self.parseMaybeAsyncFunction(true); f != nil {
-			return &ast.FunctionDeclaration{
-				Function: f,
-			}
+			return self.functionDeclaration(f)
 		}
 	case token.FUNCTION:
-		return &ast.FunctionDeclaration{
-			Function: self.parseFunction(true, false, self.idx),
-		}
+		return self.functionDeclaration(self.parseFunction(true, false, self.idx))
 	case token.CLASS:
 		return &ast.ClassDeclaration{
 			Class: self.parseClass(true),
@@ -141,6 +142,7 @@ func (self *_parser) parseTryStatement() ast.Statement {
 		if self.token == token.LEFT_PARENTHESIS {
 			self.next()
 			parameter = self.parseBindingTarget()
+			self.tsSkipTypeAnnotation()
 			self.expect(token.RIGHT_PARENTHESIS)
 		}
 		node.Catch = &ast.CatchStatement{
@@ -167,6 +169,9 @@ func (self *_parser) parseFunctionParameterList() *ast.ParameterList {
 	opening := self.expect(token.LEFT_PARENTHESIS)
 	var list []*ast.Binding
 	var rest ast.Expression
+	var props []*ast.Identifier
+	ctor := self.ts.ctor
+	self.ts.ctor = false
 	if !self.scope.inFuncParams {
 		self.scope.inFuncParams = true
 		defer func() {
@@ -177,14 +182,37 @@ func (self *_parser) parseFunctionParameterList() *ast.ParameterList {
 		if self.token == token.ELLIPSIS {
 			self.next()
 			rest = self.reinterpretAsDestructBindingTarget(self.parseAssignmentExpression())
+			self.tsSkipTypeAnnotation()
 			break
 		}
-		self.parseVariableDeclaration(&list)
+		if self.opts.typeScript && self.token == token.THIS && len(list) == 0 {
+			// TypeScript this parameter: function f(this: Window)
+			self.next()
+			self.tsSkipTypeAnnotation()
+		} else {
+			start := self.idx
+			prop := false
+			for self.tsIsModifier("public", "private", "protected", "readonly", "override") {
+				prop = true
+				self.next()
+			}
+			binding := self.parseVariableDeclaration(&list)
+			if prop {
+				if id, ok := binding.Target.(*ast.Identifier); !ctor {
+					self.error(start, "A parameter property is only allowed in a constructor implementation")
+				} else if !ok {
+					self.error(start, "A parameter property may not be declared using a binding pattern")
+				} else {
+					props = append(props, id)
+				}
+			}
+		}
 		if self.token != token.RIGHT_PARENTHESIS {
 			self.expect(token.COMMA)
 		}
 	}
 	closing := self.expect(token.RIGHT_PARENTHESIS)
+	self.ts.paramProps = props
 
 	return &ast.ParameterList{
 		Opening: opening,
@@ -241,6 +269,10 @@ func (self *_parser) parseFunction(declaration, async bool, start file.Idx) *ast
 	}
 	node.Name = name
 
+	if self.opts.typeScript && self.token == token.LESS {
+		self.tsSkipTypeParameters()
+	}
+
 	if declaration {
 		if async != self.scope.allowAwait {
 			self.scope.allowAwait = async
@@ -257,12 +289,31 @@ func (self *_parser) parseFunction(declaration, async bool, start file.Idx) *ast
 	}
 
 	node.ParameterList = self.parseFunctionParameterList()
+	if self.opts.typeScript {
+		self.tsSkipTypeAnnotation()
+		if declaration && self.token != token.LEFT_BRACE {
+			// overload signature or ambient declaration
+			self.optionalSemicolon()
+			return node
+		}
+	}
 	node.Body, node.DeclarationList = self.parseFunctionBlock(async, async, self.scope.allowYield)
 	node.Source = self.slice(node.Idx0(), node.Idx1())
 
 	return node
 }
 
+// functionDeclaration returns the statement for a function declaration. A TypeScript function signature
+// has no body and no runtime meaning.
+func (self *_parser) functionDeclaration(f *ast.FunctionLiteral) ast.Statement {
+	if f.Body == nil {
+		return &ast.EmptyStatement{Semicolon: f.Function}
+	}
+	return &ast.FunctionDeclaration{
+		Function: f,
+	}
+}
+
 func (self *_parser) parseFunctionBlock(async, allowAwait, allowYield bool) (body *ast.BlockStatement, declarationList []*ast.VariableDeclaration) {
 	self.openScope()
 	self.scope.inFunction = true
@@ -318,10 +369,18 @@ func (self *_parser) parseClass(declaration bool) *ast.ClassLiteral {
 
 	node.Name = name
 
-	if self.token != token.LEFT_BRACE {
+	if self.opts.typeScript && self.token == token.LESS {
+		self.tsSkipTypeParameters()
+	}
+
+	if self.token != token.LEFT_BRACE && !self.tsIsImplements() {
 		self.expect(token.EXTENDS)
 		node.SuperClass = self.parseLeftHandSideExpressionAllowCall()
+		if self.opts.typeScript && self.token == token.LESS {
+			self.tsSkipTypeArguments()
+		}
 	}
+	self.tsSkipImplementsClause()
 
 	self.expect(token.LEFT_BRACE)
 
@@ -331,6 +390,7 @@ func (self *_parser) parseClass(declaration bool) *ast.ClassLiteral {
 			continue
 		}
 		start := self.idx
+		typeOnly := self.tsSkipClassModifiers()
 		static := false
 		if self.token == token.STATIC {
 			switch self.peek() {
@@ -350,6 +410,17 @@ func (self *_parser) parseClass(declaration bool) *ast.ClassLiteral {
 				static = true
 			}
 		}
+		if self.opts.typeScript {
+			if self.tsSkipClassModifiers() {
+				typeOnly = true
+			}
+			if self.tsIsIndexSignature() {
+				self.tsSkipBalanced(token.LEFT_BRACKET, token.RIGHT_BRACKET)
+				self.tsSkipTypeAnnotation()
+				self.optionalSemicolon()
+				continue
+			}
+		}
 
 		var kind ast.PropertyKind
 		var async bool
@@ -389,7 +460,12 @@ func (self *_parser) parseClass(declaration bool) *ast.ClassLiteral {
 			self.error(value.Idx0(), "Classes may not have a static property named 'prototype'")
 		}
 
-		if kind == "" && self.token == token.LEFT_PARENTHESIS {
+		if self.opts.typeScript && (self.token == token.QUESTION_MARK || self.token == token.NOT) {
+			// optional member or definite assignment assertion
+			self.next()
+		}
+
+		if kind == "" && (self.token == token.LEFT_PARENTHESIS || self.opts.typeScript && self.token == token.LESS) {
 			kind = ast.PropertyKindMethod
 		}
 
@@ -408,11 +484,19 @@ func (self *_parser) parseClass(declaration bool) *ast.ClassLiteral {
 					self.error(value.Idx0(), "Class constructor may not be a private method")
 				}
 			}
+			if self.opts.typeScript {
+				self.ts.classMember = true
+				self.ts.ctor = keyName == "constructor" && !computed && !static
+			}
+			body := self.parseMethodDefinition(methodBodyStart, kind, generator, async)
+			if body == nil || typeOnly {
+				continue
+			}
 			md := &ast.MethodDefinition{
 				Idx:      start,
 				Key:      value,
 				Kind:     kind,
-				Body:     self.parseMethodDefinition(methodBodyStart, kind, generator, async),
+				Body:     body,
 				Static:   static,
 				Computed: computed,
 			}
@@ -428,6 +512,7 @@ func (self *_parser) parseClass(declaration bool) *ast.ClassLiteral {
 			if isCtor {
 				self.error(value.Idx0(), "Classes may not have a field named 'constructor'")
 			}
+			self.tsSkipTypeAnnotation()
 			var initializer ast.Expression
 			if self.token == token.ASSIGN {
 				self.next()
@@ -438,6 +523,9 @@ func (self *_parser) parseClass(declaration bool) *ast.ClassLiteral {
 				self.errorUnexpectedToken(self.token)
 				break
 			}
+			if typeOnly {
+				continue
+			}
 			node.Body = append(node.Body, &ast.FieldDefinition{
 				Idx:         start,
 				Key:         value,
@@ -512,9 +600,10 @@ func (self *_parser) parseThrowStatement() ast.Statement {
 }
 
 func (self *_parser) parseSwitchStatement() ast.Statement {
-	self.expect(token.SWITCH)
+	idx := self.expect(token.SWITCH)
 	self.expect(token.LEFT_PARENTHESIS)
 	node := &ast.SwitchStatement{
+		Switch:       idx,
 		Discriminant: self.parseExpression(),
 		Default:      -1,
 	}
@@ -548,9 +637,10 @@ func (self *_parser) parseSwitchStatement() ast.Statement {
 }
 
 func (self *_parser) parseWithStatement() ast.Statement {
-	self.expect(token.WITH)
+	idx := self.expect(token.WITH)
 	self.expect(token.LEFT_PARENTHESIS)
 	node := &ast.WithStatement{
+		With:   idx,
 		Object: self.parseExpression(),
 	}
 	self.expect(token.RIGHT_PARENTHESIS)
@@ -580,9 +670,7 @@ func (self *_parser) parseCaseStatement() *ast.CaseStatement {
 			self.token == token.DEFAULT {
 			break
 		}
-		self.scope.allowLet = true
-		node.Consequent = append(node.Consequent, self.parseStatement())
-
+		node.Consequent = append(node.Consequent, self.parseStatementListItem())
 	}
 
 	return node
@@ -817,8 +905,9 @@ func (self *_parser) parseDoWhileStatement() ast.Statement {
 		self.scope.inIteration = inIteration
 	}()
 
-	self.expect(token.DO)
-	node := &ast.DoWhileStatement{}
+	node := &ast.DoWhileStatement{
+		Do: self.expect(token.DO),
+	}
 	if self.token == token.LEFT_BRACE {
 		node.Body = self.parseBlockStatement()
 	} else {
@@ -838,10 +927,11 @@ func (self *_parser) parseDoWhileStatement() ast.Statement {
 }
 
 func (self *_parser) parseWhileStatement() ast.Statement {
-	self.expect(token.WHILE)
+	idx := self.expect(token.WHILE)
 	self.expect(token.LEFT_PARENTHESIS)
 	node := &ast.WhileStatement{
-		Test: self.parseExpression(),
+		While: idx,
+		Test:  self.parseExpression(),
 	}
 	self.expect(token.RIGHT_PARENTHESIS)
 	node.Body = self.parseIterationStatement()
@@ -850,9 +940,10 @@ func (self *_parser) parseWhileStatement() ast.Statement {
 }
 
 func (self *_parser) parseIfStatement() ast.Statement {
-	self.expect(token.IF)
+	idx := self.expect(token.IF)
 	self.expect(token.LEFT_PARENTHESIS)
 	node := &ast.IfStatement{
+		If:   idx,
 		Test: self.parseExpression(),
 	}
 	self.expect(token.RIGHT_PARENTHESIS)
@@ -875,8 +966,7 @@ func (self *_parser) parseIfStatement() ast.Statement {
 
 func (self *_parser) parseSourceElements() (body []ast.Statement) {
 	for self.token != token.EOF {
-		self.scope.allowLet = true
-		body = append(body, self.parseStatement())
+		body = append(body, self.parseStatementListItem())
 	}
 
 	return body
@@ -888,6 +978,10 @@ func (self *_parser) parseProgram() *ast.Program {
 		DeclarationList: self.scope.declarationList,
 		File:            self.file,
 	}
+	if self.opts.comments {
+		prg.Comments = self.comments
+		prg.CommentMap = self.attachComments(prg)
+	}
 	self.file.SetSourceMap(self.parseSourceMap())
 	return prg
 }
@@ -1045,6 +1139,7 @@ illegal:
 
 // Find the next statement after an error (recover)
 func (self *_parser) nextStatement() {
+	self.abort()
 	for {
 		switch self.token {
 		case token.BREAK, token.CONTINUE,
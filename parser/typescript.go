@@ -0,0 +1,690 @@
+package parser
+
+import (
+	"strconv"
+
+	"github.com/rarnu/goscript/ast"
+	"github.com/rarnu/goscript/file"
+	"github.com/rarnu/goscript/token"
+	"github.com/rarnu/goscript/unistring"
+)
+
+// TypeScript support (see WithTypeScript). Type level syntax is skipped token by token without building
+// any nodes, so the resulting AST only contains plain JavaScript and keeps the positions of the source.
+// Enums and parameter properties have a runtime meaning and are lowered to equivalent JavaScript nodes.
+
+type tsState struct {
+	classMember bool              // the method being parsed is a class member, so it may be a signature without body
+	ctor        bool              // the parameter list being parsed belongs to a class constructor
+	paramProps  []*ast.Identifier // parameter properties of the last parsed parameter list
+}
+
+// tsNext is like next, but allows an implicit semicolon after the current token. It is used for tokens that may
+// end a type, e.g. `void` or `>`, which would not end a statement in JavaScript.
+func (self *_parser) tsNext() {
+	self.insertSemicolon = true
+	self.next()
+}
+
+// tsSplitGreater consumes a single '>'. Tokens that start with '>' (e.g. '>>' in Array<Array<number>>)
+// are split, the rest of the token becomes the current token.
+func (self *_parser) tsSplitGreater() {
+	var rest token.Token
+	switch self.token {
+	case token.GREATER:
+		self.tsNext()
+		return
+	case token.SHIFT_RIGHT:
+		rest = token.GREATER
+	case token.UNSIGNED_SHIFT_RIGHT:
+		rest = token.SHIFT_RIGHT
+	case token.GREATER_OR_EQUAL:
+		rest = token.ASSIGN
+	case token.SHIFT_RIGHT_ASSIGN:
+		rest = token.GREATER_OR_EQUAL
+	case token.UNSIGNED_SHIFT_RIGHT_ASSIGN:
+		rest = token.SHIFT_RIGHT_ASSIGN
+	default:
+		self.errorUnexpectedToken(self.token)
+		return
+	}
+	self.token = rest
+	self.idx++
+}
+
+// tsSkipBalanced skips everything from the opening token up to and including the matching closing token.
+func (self *_parser) tsSkipBalanced(open, close token.Token) {
+	self.expect(open)
+	for depth := 1; ; {
+		switch self.token {
+		case open:
+			depth++
+		case close:
+			depth--
+			if depth == 0 {
+				self.tsNext()
+				return
+			}
+		case token.EOF:
+			self.errorUnexpectedToken(self.token)
+			return
+		}
+		self.next()
+	}
+}
+
+// tsIsModifier reports whether the current token is one of the given modifiers (e.g. "private") and not
+// the name of a member or a parameter.
+func (self *_parser) tsIsModifier(modifiers ...string) bool {
+	if !self.opts.typeScript || self.token != token.IDENTIFIER {
+		return false
+	}
+	for _, m := range modifiers {
+		if self.literal == m {
+			switch self.peek() {
+			case token.LEFT_PARENTHESIS, token.RIGHT_PARENTHESIS, token.ASSIGN, token.SEMICOLON, token.COLON,
+				token.COMMA, token.QUESTION_MARK, token.NOT, token.RIGHT_BRACE, token.LESS, token.EOF:
+				return false
+			}
+			return true
+		}
+	}
+	return false
+}
+
+// tsSkipTypeAnnotation skips an optional type annotation, i.e. a colon followed by a type.
+func (self *_parser) tsSkipTypeAnnotation() {
+	if self.opts.typeScript && self.token == token.COLON {
+		self.next()
+		self.tsSkipType()
+	}
+}
+
+// tsSkipType skips a type.
+func (self *_parser) tsSkipType() {
+	self.tsSkipUnionType()
+	if self.token == token.EXTENDS && !self.implicitSemicolon {
+		// conditional type: A extends B ? C : D
+		self.next()
+		self.tsSkipUnionType()
+		self.expect(token.QUESTION_MARK)
+		self.tsSkipType()
+		self.expect(token.COLON)
+		self.tsSkipType()
+	}
+}
+
+func (self *_parser) tsSkipUnionType() {
+	if self.token == token.OR || self.token == token.AND {
+		self.next()
+	}
+	for {
+		self.tsSkipTypeOperand()
+		if self.token != token.OR && self.token != token.AND {
+			return
+		}
+		self.next()
+	}
+}
+
+func (self *_parser) tsSkipTypeOperand() {
+	switch self.token {
+	case token.LESS:
+		// generic function type: <T>(x: T) => T
+		self.tsSkipTypeParameters()
+		self.tsSkipFunctionType()
+		return
+	case token.NEW:
+		// constructor type: new (x: T) => U
+		self.next()
+		if self.token == token.LESS {
+			self.tsSkipTypeParameters()
+		}
+		self.tsSkipFunctionType()
+		return
+	case token.LEFT_PARENTHESIS:
+		// function type or parenthesised type
+		self.tsSkipBalanced(token.LEFT_PARENTHESIS, token.RIGHT_PARENTHESIS)
+		if self.token == token.ARROW {
+			self.next()
+			self.tsSkipType()
+			return
+		}
+	case token.LEFT_BRACE:
+		// object type or mapped type
+		self.tsSkipBalanced(token.LEFT_BRACE, token.RIGHT_BRACE)
+	case token.LEFT_BRACKET:
+		// tuple type
+		self.tsSkipBalanced(token.LEFT_BRACKET, token.RIGHT_BRACKET)
+	case token.STRING, token.NUMBER:
+		self.tsNext()
+	case token.MINUS:
+		// negative number literal type
+		self.next()
+		if self.token != token.NUMBER {
+			self.errorUnexpectedToken(self.token)
+		}
+		self.tsNext()
+	case token.BACKTICK:
+		// template literal type
+		self.parseTemplateLiteral(false)
+	case token.TYPEOF:
+		// type query: typeof x.y
+		self.next()
+		self.tsSkipEntityName()
+	default:
+		if !token.IsId(self.token) {
+			self.errorUnexpectedToken(self.token)
+			self.next()
+			return
+		}
+		switch self.literal {
+		case "keyof", "unique", "readonly", "infer", "asserts":
+			switch tok := self.peek(); {
+			case token.IsId(tok), tok == token.LEFT_PARENTHESIS, tok == token.LEFT_BRACKET, tok == token.LEFT_BRACE,
+				tok == token.STRING, tok == token.NUMBER:
+				self.next()
+				self.tsSkipTypeOperand()
+				return
+			}
+		}
+		self.tsSkipEntityName()
+		if self.token == token.LESS && !self.implicitSemicolon {
+			self.tsSkipTypeArguments()
+		}
+		if self.token == token.IDENTIFIER && self.literal == "is" && !self.implicitSemicolon {
+			// type predicate: x is string
+			self.next()
+			self.tsSkipType()
+			return
+		}
+	}
+	// array types and indexed access types
+	for self.token == token.LEFT_BRACKET && !self.implicitSemicolon {
+		self.tsSkipBalanced(token.LEFT_BRACKET, token.RIGHT_BRACKET)
+	}
+}
+
+// tsSkipEntityName skips a possibly qualified name, e.g. NodeJS.Timer.
+func (self *_parser) tsSkipEntityName() {
+	for {
+		if token.IsId(self.token) {
+			self.tsNext()
+		} else {
+			self.expect(token.IDENTIFIER)
+		}
+		if self.token != token.PERIOD {
+			return
+		}
+		self.next()
+	}
+}
+
+// tsSkipFunctionType skips the parameters and the return type of a function type.
+func (self *_parser) tsSkipFunctionType() {
+	self.tsSkipBalanced(token.LEFT_PARENTHESIS, token.RIGHT_PARENTHESIS)
+	self.expect(token.ARROW)
+	self.tsSkipType()
+}
+
+// tsSkipTypeArguments skips a type argument list, e.g. <string, number>.
+func (self *_parser) tsSkipTypeArguments() {
+	self.expect(token.LESS)
+	for {
+		self.tsSkipType()
+		if self.token != token.COMMA {
+			break
+		}
+		self.next()
+	}
+	self.tsSplitGreater()
+}
+
+// tsSkipTypeParameters skips a type parameter list, e.g. <T, K extends keyof T = keyof T>.
+func (self *_parser) tsSkipTypeParameters() {
+	self.expect(token.LESS)
+	for self.token != token.GREATER && self.token != token.EOF {
+		for (self.token == token.CONST || self.token == token.IN || self.literal == "out") && token.IsId(self.peek()) {
+			self.next()
+		}
+		if token.IsId(self.token) {
+			self.next()
+		} else {
+			self.expect(token.IDENTIFIER)
+		}
+		if self.token == token.EXTENDS {
+			self.next()
+			self.tsSkipType()
+		}
+		if self.token == token.ASSIGN {
+			self.next()
+			self.tsSkipType()
+		}
+		if self.token != token.COMMA {
+			break
+		}
+		self.next()
+	}
+	self.tsSplitGreater()
+}
+
+// tsSkipCallTypeArguments skips the type arguments of a call, e.g. f<string>(x). If the less-than sign turns
+// out to be a comparison operator, the parser state is restored and false is returned.
+func (self *_parser) tsSkipCallTypeArguments() bool {
+	var state parserState
+	self.mark(&state)
+	if !self.tryParse(self.tsSkipTypeArguments) || len(self.errors) > state.errorCount || self.token != token.LEFT_PARENTHESIS && self.token != token.BACKTICK {
+		self.restore(&state)
+		return false
+	}
+	return true
+}
+
+// tsSkipAsExpression skips the type part of `as` and `satisfies` expressions.
+func (self *_parser) tsSkipAsExpression() {
+	for self.token == token.IDENTIFIER && (self.literal == "as" || self.literal == "satisfies") && !self.implicitSemicolon {
+		self.next()
+		if self.token == token.CONST {
+			self.tsNext()
+		} else {
+			self.tsSkipType()
+		}
+	}
+}
+
+// tsParseArrowFunction parses an arrow function that may have type parameters, parameter types or a return
+// type, e.g. (x: number): string => String(x). If the input is not an arrow function, the parser state is
+// restored and nil is returned.
+func (self *_parser) tsParseArrowFunction(start file.Idx, async bool) ast.Expression {
+	var state parserState
+	self.mark(&state)
+	// Look ahead to avoid parsing every parenthesised expression twice.
+	lookahead := func() {
+		if async {
+			self.next()
+		}
+		if self.token == token.LESS {
+			self.tsSkipTypeParameters()
+		}
+		if self.token == token.LEFT_PARENTHESIS {
+			self.tsSkipBalanced(token.LEFT_PARENTHESIS, token.RIGHT_PARENTHESIS)
+		}
+	}
+	if !self.tryParse(lookahead) || self.token != token.ARROW && self.token != token.COLON {
+		self.restore(&state)
+		return nil
+	}
+	self.restore(&state)
+
+	if async {
+		self.next()
+		if !self.scope.allowAwait {
+			self.scope.allowAwait = true
+			defer func() {
+				self.scope.allowAwait = false
+			}()
+		}
+	}
+	var paramList *ast.ParameterList
+	params := func() {
+		if self.token == token.LESS {
+			self.tsSkipTypeParameters()
+		}
+		paramList = self.parseFunctionParameterList()
+		self.tsSkipTypeAnnotation()
+	}
+	if !self.tryParse(params) || len(self.errors) > state.errorCount || self.token != token.ARROW {
+		self.restore(&state)
+		return nil
+	}
+	return self.parseArrowFunction(start, paramList, async)
+}
+
+// tsIsIndexSignature reports whether the parser is at an index signature, e.g. [key: string]: number.
+func (self *_parser) tsIsIndexSignature() bool {
+	if self.token != token.LEFT_BRACKET {
+		return false
+	}
+	var state parserState
+	self.mark(&state)
+	self.next()
+	res := token.IsId(self.token)
+	if res {
+		self.next()
+		res = self.token == token.COLON
+	}
+	self.restore(&state)
+	return res
+}
+
+// tsSkipClassModifiers skips the TypeScript modifiers of a class member. It returns true if the member
+// exists at the type level only (i.e. it is abstract or declared).
+func (self *_parser) tsSkipClassModifiers() (typeOnly bool) {
+	for self.tsIsModifier("public", "private", "protected", "readonly", "override", "abstract", "declare") {
+		if self.literal == "abstract" || self.literal == "declare" {
+			typeOnly = true
+		}
+		self.next()
+	}
+	return
+}
+
+// tsSkipImplementsClause skips the implements clause of a class.
+func (self *_parser) tsSkipImplementsClause() {
+	if !self.tsIsImplements() {
+		return
+	}
+	self.next()
+	for {
+		self.tsSkipType()
+		if self.token != token.COMMA {
+			return
+		}
+		self.next()
+	}
+}
+
+func (self *_parser) tsIsImplements() bool {
+	return self.opts.typeScript && self.token == token.IDENTIFIER && self.literal == "implements"
+}
+
+// tsInsertParamProps adds the assignments of constructor parameter properties (e.g. `constructor(private x)`)
+// to the constructor body. They are inserted after the super() call if there is one.
+func (self *_parser) tsInsertParamProps(body *ast.BlockStatement, props []*ast.Identifier) {
+	pos := 0
+	for i, st := range body.List {
+		if st, ok := st.(*ast.ExpressionStatement); ok {
+			if call, ok := st.Expression.(*ast.CallExpression); ok {
+				if _, ok := call.Callee.(*ast.SuperExpression); ok {
+					pos = i + 1
+					break
+				}
+			}
+		}
+	}
+	list := make([]ast.Statement, 0, len(body.List)+len(props))
+	list = append(list, body.List[:pos]...)
+	for _, id := range props {
+		list = append(list, &ast.ExpressionStatement{
+			Expression: &ast.AssignExpression{
+				Operator: token.ASSIGN,
+				Left: &ast.DotExpression{
+					Left:       &ast.ThisExpression{Idx: id.Idx},
+					Identifier: *id,
+				},
+				Right: &ast.Identifier{Name: id.Name, Idx: id.Idx},
+			},
+		})
+	}
+	body.List = append(list, body.List[pos:]...)
+}
+
+// tsParseStatement parses the TypeScript specific statements. It returns nil if the current token does not
+// start one of them.
+func (self *_parser) tsParseStatement() ast.Statement {
+	switch self.token {
+	case token.KEYWORD:
+		if self.literal == "enum" {
+			return self.tsParseEnum(self.idx)
+		}
+	case token.CONST:
+		var state parserState
+		self.mark(&state)
+		start := self.idx
+		self.next()
+		if self.token == token.KEYWORD && self.literal == "enum" {
+			return self.tsParseEnum(start)
+		}
+		self.restore(&state)
+	case token.IDENTIFIER:
+		switch self.literal {
+		case "interface":
+			if self.peek() == token.IDENTIFIER {
+				return self.tsSkipInterface()
+			}
+		case "type":
+			if self.peek() == token.IDENTIFIER {
+				return self.tsSkipTypeAlias()
+			}
+		case "declare":
+			switch self.peek() {
+			case token.IDENTIFIER, token.KEYWORD, token.VAR, token.LET, token.CONST, token.FUNCTION, token.CLASS, token.ASYNC:
+				return self.tsSkipDeclaration()
+			}
+		case "abstract":
+			if self.peek() == token.CLASS {
+				self.next()
+				return &ast.ClassDeclaration{
+					Class: self.parseClass(true),
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// tsSkipInterface skips an interface declaration.
+func (self *_parser) tsSkipInterface() ast.Statement {
+	start := self.idx
+	self.next() // interface
+	self.next() // name
+	if self.token == token.LESS {
+		self.tsSkipTypeParameters()
+	}
+	if self.token == token.EXTENDS {
+		self.next()
+		for {
+			self.tsSkipType()
+			if self.token != token.COMMA {
+				break
+			}
+			self.next()
+		}
+	}
+	self.tsSkipBalanced(token.LEFT_BRACE, token.RIGHT_BRACE)
+	return &ast.EmptyStatement{Semicolon: start}
+}
+
+// tsSkipTypeAlias skips a type alias declaration.
+func (self *_parser) tsSkipTypeAlias() ast.Statement {
+	start := self.idx
+	self.next() // type
+	self.next() // name
+	if self.token == token.LESS {
+		self.tsSkipTypeParameters()
+	}
+	self.expect(token.ASSIGN)
+	self.tsSkipType()
+	self.semicolon()
+	return &ast.EmptyStatement{Semicolon: start}
+}
+
+// tsSkipDeclaration skips an ambient declaration, e.g. `declare const VERSION: string`. The declaration
+// ends with a semicolon (explicit or implicit) outside any brackets.
+func (self *_parser) tsSkipDeclaration() ast.Statement {
+	start := self.idx
+	self.next() // declare
+	depth := 0
+	for self.token != token.EOF {
+		switch self.token {
+		case token.LEFT_BRACE, token.LEFT_PARENTHESIS, token.LEFT_BRACKET:
+			depth++
+		case token.RIGHT_BRACE, token.RIGHT_PARENTHESIS, token.RIGHT_BRACKET:
+			if depth == 0 {
+				// belongs to the enclosing block
+				return &ast.EmptyStatement{Semicolon: start}
+			}
+			depth--
+		case token.SEMICOLON:
+			if depth == 0 {
+				self.next()
+				return &ast.EmptyStatement{Semicolon: start}
+			}
+		}
+		self.next()
+		if depth == 0 && self.implicitSemicolon {
+			break
+		}
+	}
+	return &ast.EmptyStatement{Semicolon: start}
+}
+
+// tsParseEnum parses an enum declaration and lowers it the same way the TypeScript compiler does, except
+// that members are also declared as constants, so that they can be referred to by the following members:
+//
+//	var E = (function (E) {
+//		const A = E["A"] = 0; E[A] = "A";
+//		const B = E["B"] = A + 1; E[B] = "B";
+//		const C = E["C"] = "c";
+//		return E;
+//	})(E || {});
+func (self *_parser) tsParseEnum(start file.Idx) ast.Statement {
+	self.next() // enum
+	self.tokenToBindingId()
+	if self.token != token.IDENTIFIER {
+		idx := self.expect(token.IDENTIFIER)
+		self.nextStatement()
+		return &ast.BadStatement{From: idx, To: self.idx}
+	}
+	name := self.parseIdentifier()
+	enum := func(idx file.Idx) *ast.Identifier {
+		return &ast.Identifier{Name: name.Name, Idx: idx}
+	}
+	member := func(idx file.Idx, key unistring.String) ast.Expression {
+		return &ast.BracketExpression{
+			Left: enum(idx),
+			Member: &ast.StringLiteral{
+				Idx:     idx,
+				Literal: strconv.Quote(key.String()),
+				Value:   key,
+			},
+			LeftBracket:  idx,
+			RightBracket: idx,
+		}
+	}
+
+	leftBrace := self.expect(token.LEFT_BRACE)
+	var list []ast.Statement
+	var prev func() ast.Expression
+	for self.token != token.RIGHT_BRACE && self.token != token.EOF {
+		idx, key, tkn := self.idx, self.parsedLiteral, self.token
+		if tkn != token.STRING && !token.IsId(tkn) {
+			self.errorUnexpectedToken(tkn)
+			self.nextStatement()
+			return &ast.BadStatement{From: start, To: self.idx}
+		}
+		self.next()
+
+		var value ast.Expression
+		if self.token == token.ASSIGN {
+			self.next()
+			value = self.parseAssignmentExpression()
+		} else if prev == nil {
+			value = &ast.NumberLiteral{Idx: idx, Literal: "0", Value: int64(0)}
+		} else {
+			value = &ast.BinaryExpression{
+				Operator: token.PLUS,
+				Left:     prev(),
+				Right:    &ast.NumberLiteral{Idx: idx, Literal: "1", Value: int64(1)},
+			}
+		}
+		assign := &ast.AssignExpression{
+			Operator: token.ASSIGN,
+			Left:     member(idx, key),
+			Right:    value,
+		}
+
+		if kw, _ := token.IsKeyword(key.String()); tkn == token.IDENTIFIER && kw == 0 && key != name.Name && key != "arguments" && key != "eval" {
+			list = append(list, &ast.LexicalDeclaration{
+				Idx:   idx,
+				Token: token.CONST,
+				List: []*ast.Binding{{
+					Target:      &ast.Identifier{Name: key, Idx: idx},
+					Initializer: assign,
+				}},
+			})
+			prev = func() ast.Expression {
+				return &ast.Identifier{Name: key, Idx: idx}
+			}
+		} else {
+			list = append(list, &ast.ExpressionStatement{
+				Expression: assign,
+			})
+			prev = func() ast.Expression {
+				return member(idx, key)
+			}
+		}
+
+		switch value.(type) {
+		case *ast.StringLiteral, *ast.TemplateLiteral:
+			// string members have no reverse mapping
+		default:
+			list = append(list, &ast.ExpressionStatement{
+				Expression: &ast.AssignExpression{
+					Operator: token.ASSIGN,
+					Left: &ast.BracketExpression{
+						Left:         enum(idx),
+						Member:       prev(),
+						LeftBracket:  idx,
+						RightBracket: idx,
+					},
+					Right: &ast.StringLiteral{
+						Idx:     idx,
+						Literal: strconv.Quote(key.String()),
+						Value:   key,
+					},
+				},
+			})
+		}
+
+		if self.token != token.RIGHT_BRACE {
+			self.expect(token.COMMA)
+		}
+	}
+	rightBrace := self.expect(token.RIGHT_BRACE)
+	list = append(list, &ast.ReturnStatement{
+		Return:   rightBrace,
+		Argument: enum(rightBrace),
+	})
+
+	fn := &ast.FunctionLiteral{
+		Function: start,
+		ParameterList: &ast.ParameterList{
+			Opening: name.Idx,
+			List: []*ast.Binding{{
+				Target: enum(name.Idx),
+			}},
+			Closing: name.Idx1(),
+		},
+		Body: &ast.BlockStatement{
+			LeftBrace:  leftBrace,
+			List:       list,
+			RightBrace: rightBrace,
+		},
+		Source: self.slice(start, rightBrace+1),
+	}
+	declList := []*ast.Binding{{
+		Target: name,
+		Initializer: &ast.CallExpression{
+			Callee:          fn,
+			LeftParenthesis: rightBrace,
+			ArgumentList: []ast.Expression{&ast.BinaryExpression{
+				Operator: token.LOGICAL_OR,
+				Left:     enum(rightBrace),
+				Right: &ast.ObjectLiteral{
+					LeftBrace:  rightBrace,
+					RightBrace: rightBrace,
+				},
+			}},
+			RightParenthesis: rightBrace,
+		},
+	}}
+	self.scope.declare(&ast.VariableDeclaration{
+		Var:  start,
+		List: declList,
+	})
+	return &ast.VariableStatement{
+		Var:  start,
+		List: declList,
+	}
+}
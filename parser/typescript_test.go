@@ -0,0 +1,62 @@
+package parser
+
+import (
+	"testing"
+
+	"github.com/rarnu/goscript/ast"
+	"github.com/rarnu/goscript/file"
+)
+
+func TestTypeScript(t *testing.T) {
+	tt(t, func() {
+		test := func(src string, expect interface{}) *ast.Program {
+			program, err := ParseFile(nil, "", src, 0, WithTypeScript)
+			is(firstErr(err), expect)
+			return program
+		}
+
+		test("let x: number = 1, y!: string", nil)
+		test("let m: Map<string, Array<Array<number>>>= new Map()", nil)
+		test("function f<T extends object = {}>(this: Window, a?: T, ...rest: T[]): a is T {}", nil)
+		test("function f(x: number): void;\nfunction f(x) {}", nil)
+		test("interface A<T> extends B<T>, C { x: T; m(): void }", nil)
+		test("type F = <T>(x: T) => T extends string ? 'a' : 'b'", nil)
+		test("declare namespace N { const x: number }\nlet y = 1", nil)
+		test("enum E { A, B = 'b', 'c-d' = 5 }", nil)
+		test("const enum E { A }", nil)
+		test("abstract class A<T> extends B<T> implements C, D<T> { abstract m(): T; [k: string]: any; private x?: number }", nil)
+		test("class A { constructor(private readonly x: number, public y = 1) {} }", nil)
+		test("let a = b as unknown as string, c = d!.e![0]!, f = g<string>(1), h = i satisfies J", nil)
+		test("let f = async <T,>(x: T): Promise<T> => x", nil)
+		test("let a = 1 < 2, b = c < d > (e)", nil)
+
+		test("class A { m(private x) {} }", "(anonymous): Line 1:13 A parameter property is only allowed in a constructor implementation")
+		test("function f(public x) {}", "(anonymous): Line 1:12 A parameter property is only allowed in a constructor implementation")
+		test("class A { constructor(public {x}) {} }", "(anonymous): Line 1:23 A parameter property may not be declared using a binding pattern")
+		test("let x: = 1", "(anonymous): Line 1:8 Unexpected token =")
+
+		_, err := ParseFile(nil, "", "let x: number = 1", 0)
+		is(firstErr(err), "(anonymous): Line 1:6 Unexpected token :")
+	})
+}
+
+func TestTypeScriptPosition(t *testing.T) {
+	tt(t, func() {
+		src := "let x: number = foo<string>(1) as any;\nclass A { constructor(private y: number) {} }"
+		parser := _newParser("", src, 1, WithTypeScript)
+		program, err := parser.parse()
+		is(err, nil)
+
+		decl := program.Body[0].(*ast.LexicalDeclaration)
+		call := decl.List[0].Initializer.(*ast.CallExpression)
+		is(call.Idx0(), file.Idx(17))
+		is(parser.slice(call.Idx0(), call.Idx1()), "foo<string>(1)")
+
+		ctor := program.Body[1].(*ast.ClassDeclaration).Class.Body[0].(*ast.MethodDefinition)
+		body := ctor.Body.Body
+		is(len(body.List), 1)
+		assign := body.List[0].(*ast.ExpressionStatement).Expression.(*ast.AssignExpression)
+		is(parser.slice(assign.Right.Idx0(), assign.Right.Idx1()), "y")
+		is(ctor.Body.Source, "constructor(private y: number) {}")
+	})
+}
@@ -0,0 +1,828 @@
+package printer
+
+import (
+	"math"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf16"
+	"unicode/utf8"
+
+	"github.com/rarnu/goscript/ast"
+	"github.com/rarnu/goscript/token"
+	"github.com/rarnu/goscript/unistring"
+)
+
+// Operator precedence levels, from the loosest to the tightest binding.
+const (
+	precSequence = iota
+	precAssign
+	precConditional
+	precCoalesce
+	precLogicalOr
+	precLogicalAnd
+	precBitwiseOr
+	precBitwiseXor
+	precBitwiseAnd
+	precEquality
+	precRelational
+	precShift
+	precAdditive
+	precMultiplicative
+	precExponent
+	precPrefix
+	precPostfix
+	precCall
+	precMember
+	precPrimary
+)
+
+func binaryPrecedence(op token.Token) int {
+	switch op {
+	case token.COALESCE:
+		return precCoalesce
+	case token.LOGICAL_OR:
+		return precLogicalOr
+	case token.LOGICAL_AND:
+		return precLogicalAnd
+	case token.OR:
+		return precBitwiseOr
+	case token.EXCLUSIVE_OR:
+		return precBitwiseXor
+	case token.AND:
+		return precBitwiseAnd
+	case token.EQUAL, token.NOT_EQUAL, token.STRICT_EQUAL, token.STRICT_NOT_EQUAL:
+		return precEquality
+	case token.LESS, token.GREATER, token.LESS_OR_EQUAL, token.GREATER_OR_EQUAL, token.INSTANCEOF, token.IN:
+		return precRelational
+	case token.SHIFT_LEFT, token.SHIFT_RIGHT, token.UNSIGNED_SHIFT_RIGHT:
+		return precShift
+	case token.PLUS, token.MINUS:
+		return precAdditive
+	case token.MULTIPLY, token.SLASH, token.REMAINDER:
+		return precMultiplicative
+	case token.EXPONENT:
+		return precExponent
+	}
+	return precSequence
+}
+
+func precedence(expr ast.Expression) int {
+	switch expr := expr.(type) {
+	case *ast.SequenceExpression:
+		return precSequence
+	case *ast.AssignExpression, *ast.ArrowFunctionLiteral, *ast.YieldExpression, *ast.SpreadElement:
+		return precAssign
+	case *ast.ConditionalExpression:
+		return precConditional
+	case *ast.BinaryExpression:
+		return binaryPrecedence(expr.Operator)
+	case *ast.UnaryExpression:
+		if expr.Postfix {
+			return precPostfix
+		}
+		return precPrefix
+	case *ast.AwaitExpression:
+		return precPrefix
+	case *ast.NumberLiteral:
+		if math.Signbit(numberValue(expr)) {
+			return precPrefix
+		}
+	case *ast.CallExpression, *ast.OptionalChain:
+		return precCall
+	case *ast.DotExpression, *ast.PrivateDotExpression, *ast.BracketExpression, *ast.NewExpression,
+		*ast.MetaProperty, *ast.Optional:
+		return precMember
+	case *ast.TemplateLiteral:
+		if expr.Tag != nil {
+			return precMember
+		}
+	}
+	return precPrimary
+}
+
+// leftChild returns the subexpression printed at the very beginning of expr or nil if there is none.
+func leftChild(expr ast.Expression) ast.Expression {
+	switch e := expr.(type) {
+	case *ast.CallExpression:
+		return e.Callee
+	case *ast.DotExpression:
+		return e.Left
+	case *ast.PrivateDotExpression:
+		return e.Left
+	case *ast.BracketExpression:
+		return e.Left
+	case *ast.AssignExpression:
+		return e.Left
+	case *ast.BinaryExpression:
+		return e.Left
+	case *ast.ConditionalExpression:
+		return e.Test
+	case *ast.SequenceExpression:
+		if len(e.Sequence) > 0 {
+			return e.Sequence[0]
+		}
+	case *ast.UnaryExpression:
+		if e.Postfix {
+			return e.Operand
+		}
+	case *ast.TemplateLiteral:
+		return e.Tag
+	case *ast.OptionalChain:
+		return e.Expression
+	case *ast.Optional:
+		return e.Expression
+	}
+	return nil
+}
+
+// leftmost returns the expression printed at the very beginning of expr.
+func leftmost(expr ast.Expression) ast.Expression {
+	for {
+		left := leftChild(expr)
+		if left == nil {
+			return expr
+		}
+		expr = left
+	}
+}
+
+// startsWithBrace reports whether expr, when printed at the start of a statement or an arrow function body,
+// would be mistaken for a block or a declaration.
+func startsWithBrace(expr ast.Expression) bool {
+	switch leftmost(expr).(type) {
+	case *ast.ObjectLiteral, *ast.ObjectPattern, *ast.FunctionLiteral, *ast.ClassLiteral:
+		return true
+	}
+	return false
+}
+
+// expression prints expr, wrapping it into parentheses if its precedence is lower than prec.
+func (p *printer) expression(expr ast.Expression, prec int) {
+	if precedence(expr) < prec {
+		p.mark(expr.Idx0())
+		p.write("(")
+		noIn := p.noIn
+		p.noIn = false
+		p.expression(expr, precSequence)
+		p.noIn = noIn
+		p.write(")")
+		return
+	}
+
+	p.mark(expr.Idx0())
+	switch e := expr.(type) {
+	case *ast.Identifier:
+		p.write(e.Name.String())
+	case *ast.PrivateIdentifier:
+		p.write("#" + e.Name.String())
+	case *ast.ThisExpression:
+		p.write("this")
+	case *ast.SuperExpression:
+		p.write("super")
+	case *ast.NullLiteral:
+		p.write("null")
+	case *ast.BooleanLiteral:
+		if e.Value {
+			p.write("true")
+		} else {
+			p.write("false")
+		}
+	case *ast.NumberLiteral:
+		p.write(numberLiteral(e))
+	case *ast.StringLiteral:
+		p.write(stringLiteral(e))
+	case *ast.RegExpLiteral:
+		p.write("/" + e.Pattern + "/" + e.Flags)
+	case *ast.TemplateLiteral:
+		p.templateLiteral(e)
+	case *ast.ArrayLiteral:
+		p.elements(e.Value, nil)
+	case *ast.ArrayPattern:
+		p.elements(e.Elements, e.Rest)
+	case *ast.ObjectLiteral:
+		p.properties(e.Value, nil)
+	case *ast.ObjectPattern:
+		p.properties(e.Properties, e.Rest)
+	case *ast.PropertyShort, *ast.PropertyKeyed:
+		p.property(e.(ast.Property))
+	case *ast.SpreadElement:
+		p.write("...")
+		p.expression(e.Expression, precAssign)
+	case *ast.Binding:
+		p.binding(e)
+	case *ast.FunctionLiteral:
+		p.functionLiteral(e)
+	case *ast.ArrowFunctionLiteral:
+		p.arrowFunction(e)
+	case *ast.ClassLiteral:
+		p.classLiteral(e)
+	case *ast.SequenceExpression:
+		for i, item := range e.Sequence {
+			if i > 0 {
+				p.write(",")
+				p.space()
+			}
+			p.expression(item, precAssign)
+		}
+	case *ast.AssignExpression:
+		p.expression(e.Left, precCall)
+		p.space()
+		if op := e.Operator.String(); strings.HasSuffix(op, "=") {
+			p.write(op)
+		} else {
+			p.write(op + "=")
+		}
+		p.space()
+		p.expression(e.Right, precAssign)
+	case *ast.ConditionalExpression:
+		p.expression(e.Test, precCoalesce)
+		p.space()
+		p.write("?")
+		p.space()
+		p.expression(e.Consequent, precAssign)
+		p.space()
+		p.write(":")
+		p.space()
+		p.expression(e.Alternate, precAssign)
+	case *ast.BinaryExpression:
+		p.binary(e)
+	case *ast.UnaryExpression:
+		if e.Postfix {
+			p.expression(e.Operand, precCall)
+			p.write(e.Operator.String())
+			return
+		}
+		p.write(e.Operator.String())
+		if e.Operator == token.DELETE || e.Operator == token.TYPEOF || e.Operator == token.VOID {
+			p.space()
+		}
+		p.expression(e.Operand, precPrefix)
+	case *ast.AwaitExpression:
+		p.write("await")
+		p.space()
+		p.expression(e.Argument, precPrefix)
+	case *ast.YieldExpression:
+		p.write("yield")
+		if e.Delegate {
+			p.write("*")
+		}
+		if e.Argument != nil {
+			p.space()
+			p.expression(e.Argument, precAssign)
+		}
+	case *ast.DotExpression:
+		p.memberObject(e.Left)
+		if _, ok := e.Left.(*ast.Optional); !ok {
+			p.write(".")
+		}
+		p.mark(e.Identifier.Idx)
+		p.write(e.Identifier.Name.String())
+	case *ast.PrivateDotExpression:
+		p.memberObject(e.Left)
+		if _, ok := e.Left.(*ast.Optional); !ok {
+			p.write(".")
+		}
+		p.write("#" + e.Identifier.Name.String())
+	case *ast.BracketExpression:
+		p.memberObject(e.Left)
+		p.write("[")
+		p.expression(e.Member, precSequence)
+		p.write("]")
+	case *ast.CallExpression:
+		p.memberObject(e.Callee)
+		p.arguments(e.ArgumentList)
+	case *ast.NewExpression:
+		p.write("new")
+		if hasCall(e.Callee) {
+			p.space()
+			p.write("(")
+			p.expression(e.Callee, precSequence)
+			p.write(")")
+		} else {
+			p.memberObject(e.Callee)
+		}
+		p.arguments(e.ArgumentList)
+	case *ast.MetaProperty:
+		p.write(e.Meta.Name.String() + "." + e.Property.Name.String())
+	case *ast.OptionalChain:
+		p.expression(e.Expression, precCall)
+	case *ast.Optional:
+		p.memberObject(e.Expression)
+		p.write("?.")
+	default:
+		panic(&printError{node: expr})
+	}
+}
+
+// memberObject prints the object of a member access, the callee of a call or the tag of a template.
+func (p *printer) memberObject(expr ast.Expression) {
+	paren := false
+	switch e := expr.(type) {
+	case *ast.OptionalChain:
+		// (a?.b).c is not the same as a?.b.c
+		paren = true
+	case *ast.NumberLiteral:
+		// 1.toString() is a syntax error
+		lit := numberLiteral(e)
+		paren = strings.IndexFunc(lit, func(r rune) bool { return r < '0' || r > '9' }) == -1
+	}
+	if paren {
+		p.write("(")
+		p.expression(expr, precSequence)
+		p.write(")")
+		return
+	}
+	p.expression(expr, precCall)
+}
+
+// hasCall reports whether the callee of a new expression contains a call that must not be taken as the arguments
+// of the new expression.
+func hasCall(expr ast.Expression) bool {
+	for {
+		switch e := expr.(type) {
+		case *ast.CallExpression, *ast.OptionalChain:
+			return true
+		case *ast.DotExpression:
+			expr = e.Left
+		case *ast.PrivateDotExpression:
+			expr = e.Left
+		case *ast.BracketExpression:
+			expr = e.Left
+		case *ast.TemplateLiteral:
+			if e.Tag == nil {
+				return false
+			}
+			expr = e.Tag
+		default:
+			return false
+		}
+	}
+}
+
+func (p *printer) binary(e *ast.BinaryExpression) {
+	prec := binaryPrecedence(e.Operator)
+	if e.Operator == token.IN && p.noIn {
+		p.write("(")
+		p.noIn = false
+		p.binary(e)
+		p.noIn = true
+		p.write(")")
+		return
+	}
+	leftPrec, rightPrec := prec, prec+1
+	if e.Operator == token.EXPONENT {
+		// -a ** b is a syntax error
+		leftPrec, rightPrec = precPostfix, prec
+	}
+	p.operand(e.Left, e.Operator, leftPrec)
+	p.space()
+	p.write(e.Operator.String())
+	p.space()
+	p.operand(e.Right, e.Operator, rightPrec)
+}
+
+func (p *printer) operand(expr ast.Expression, op token.Token, prec int) {
+	if b, ok := expr.(*ast.BinaryExpression); ok {
+		// ?? cannot be mixed with && or || without parentheses
+		if (op == token.COALESCE) != (b.Operator == token.COALESCE) &&
+			(op == token.LOGICAL_OR || op == token.LOGICAL_AND || b.Operator == token.LOGICAL_OR || b.Operator == token.LOGICAL_AND) &&
+			(op == token.COALESCE || b.Operator == token.COALESCE) {
+			prec = precPrimary
+		}
+	}
+	if pi, ok := expr.(*ast.PrivateIdentifier); ok {
+		// #x in obj
+		p.expression(pi, precSequence)
+		return
+	}
+	p.expression(expr, prec)
+}
+
+func (p *printer) arguments(list []ast.Expression) {
+	p.write("(")
+	for i, arg := range list {
+		if i > 0 {
+			p.write(",")
+			p.space()
+		}
+		p.expression(arg, precAssign)
+	}
+	p.write(")")
+}
+
+func (p *printer) elements(list []ast.Expression, rest ast.Expression) {
+	p.write("[")
+	for i, elem := range list {
+		if i > 0 {
+			p.write(",")
+			if elem != nil {
+				p.space()
+			}
+		}
+		if elem != nil {
+			p.expression(elem, precAssign)
+		}
+	}
+	if len(list) > 0 && list[len(list)-1] == nil {
+		// a trailing hole needs an extra comma
+		p.write(",")
+	}
+	if rest != nil {
+		if len(list) > 0 {
+			p.write(",")
+			p.space()
+		}
+		p.write("...")
+		p.expression(rest, precAssign)
+	}
+	p.write("]")
+}
+
+func (p *printer) properties(list []ast.Property, rest ast.Expression) {
+	p.write("{")
+	if len(list) == 0 && rest == nil {
+		p.write("}")
+		return
+	}
+	p.space()
+	for i, prop := range list {
+		if i > 0 {
+			p.write(",")
+			p.space()
+		}
+		p.property(prop)
+	}
+	if rest != nil {
+		if len(list) > 0 {
+			p.write(",")
+			p.space()
+		}
+		p.write("...")
+		p.expression(rest, precAssign)
+	}
+	p.space()
+	p.write("}")
+}
+
+func (p *printer) property(prop ast.Property) {
+	switch prop := prop.(type) {
+	case *ast.PropertyShort:
+		p.mark(prop.Name.Idx)
+		p.write(prop.Name.Name.String())
+		if prop.Initializer != nil {
+			p.space()
+			p.write("=")
+			p.space()
+			p.expression(prop.Initializer, precAssign)
+		}
+	case *ast.PropertyKeyed:
+		if prop.Kind == ast.PropertyKindValue || prop.Kind == "" {
+			p.propertyKey(prop.Key, prop.Computed)
+			p.write(":")
+			p.space()
+			p.expression(prop.Value, precAssign)
+			return
+		}
+		fn, ok := prop.Value.(*ast.FunctionLiteral)
+		if !ok {
+			panic(&printError{node: prop})
+		}
+		p.method(prop.Key, prop.Kind, prop.Computed, fn)
+	case *ast.SpreadElement:
+		p.expression(prop, precSequence)
+	default:
+		panic(&printError{node: prop})
+	}
+}
+
+func (p *printer) propertyKey(key ast.Expression, computed bool) {
+	if computed {
+		p.write("[")
+		p.expression(key, precAssign)
+		p.write("]")
+		return
+	}
+	if s, ok := key.(*ast.StringLiteral); ok {
+		if name := s.Value.String(); isIdentifierName(name) {
+			p.mark(s.Idx)
+			p.write(name)
+			return
+		}
+	}
+	p.expression(key, precPrimary)
+}
+
+// method prints a method of an object literal or a class (without the static modifier).
+func (p *printer) method(key ast.Expression, kind ast.PropertyKind, computed bool, fn *ast.FunctionLiteral) {
+	p.mark(key.Idx0())
+	if fn.Async {
+		p.write("async")
+	}
+	switch kind {
+	case ast.PropertyKindGet, ast.PropertyKindSet:
+		p.write(string(kind))
+	}
+	if fn.Generator {
+		p.write("*")
+	} else if fn.Async || kind == ast.PropertyKindGet || kind == ast.PropertyKindSet {
+		p.raw(" ")
+	}
+	p.propertyKey(key, computed)
+	p.parameterList(fn.ParameterList)
+	p.space()
+	p.block(fn.Body)
+}
+
+func (p *printer) templateLiteral(e *ast.TemplateLiteral) {
+	if e.Tag != nil {
+		p.memberObject(e.Tag)
+	}
+	p.write("`")
+	for i, elem := range e.Elements {
+		p.raw(elem.Literal)
+		if i < len(e.Expressions) {
+			p.raw("${")
+			p.expression(e.Expressions[i], precSequence)
+			p.raw("}")
+		}
+	}
+	p.raw("`")
+}
+
+func (p *printer) binding(b *ast.Binding) {
+	p.expression(b.Target, precAssign)
+	if b.Initializer != nil {
+		p.space()
+		p.write("=")
+		p.space()
+		p.expression(b.Initializer, precAssign)
+	}
+}
+
+func (p *printer) bindings(list []*ast.Binding) {
+	for i, b := range list {
+		if i > 0 {
+			p.write(",")
+			p.space()
+		} else {
+			p.raw(" ")
+		}
+		p.binding(b)
+	}
+}
+
+func (p *printer) parameterList(list *ast.ParameterList) {
+	p.write("(")
+	if list != nil {
+		for i, b := range list.List {
+			if i > 0 {
+				p.write(",")
+				p.space()
+			}
+			p.binding(b)
+		}
+		if list.Rest != nil {
+			if len(list.List) > 0 {
+				p.write(",")
+				p.space()
+			}
+			p.write("...")
+			p.expression(list.Rest, precAssign)
+		}
+	}
+	p.write(")")
+}
+
+func (p *printer) functionLiteral(fn *ast.FunctionLiteral) {
+	if fn.Async {
+		p.write("async")
+	}
+	p.write("function")
+	if fn.Generator {
+		p.write("*")
+	}
+	if fn.Name != nil {
+		p.space()
+		p.mark(fn.Name.Idx)
+		p.write(fn.Name.Name.String())
+	}
+	p.parameterList(fn.ParameterList)
+	p.space()
+	p.block(fn.Body)
+}
+
+func (p *printer) arrowFunction(fn *ast.ArrowFunctionLiteral) {
+	if fn.Async {
+		p.write("async")
+		p.space()
+	}
+	p.parameterList(fn.ParameterList)
+	p.space()
+	p.write("=>")
+	p.space()
+	p.conciseBody(fn.Body)
+}
+
+func (p *printer) conciseBody(body ast.ConciseBody) {
+	switch body := body.(type) {
+	case *ast.BlockStatement:
+		p.block(body)
+	case *ast.ExpressionBody:
+		noIn := p.noIn
+		p.noIn = false
+		if startsWithBrace(body.Expression) {
+			p.write("(")
+			p.expression(body.Expression, precSequence)
+			p.write(")")
+		} else {
+			p.expression(body.Expression, precAssign)
+		}
+		p.noIn = noIn
+	default:
+		panic(&printError{node: body})
+	}
+}
+
+func (p *printer) classLiteral(cls *ast.ClassLiteral) {
+	p.write("class")
+	if cls.Name != nil {
+		p.mark(cls.Name.Idx)
+		p.write(cls.Name.Name.String())
+	}
+	if cls.SuperClass != nil {
+		p.write("extends")
+		p.space()
+		p.expression(cls.SuperClass, precCall)
+	}
+	p.space()
+	p.write("{")
+	noIn := p.noIn
+	p.noIn = false
+	p.level++
+	for _, elem := range cls.Body {
+		p.newline()
+		p.node(elem)
+	}
+	p.level--
+	p.noIn = noIn
+	if len(cls.Body) > 0 {
+		p.newline()
+	}
+	p.write("}")
+}
+
+func (p *printer) fieldDefinition(f *ast.FieldDefinition) {
+	p.mark(f.Idx)
+	if f.Static {
+		p.write("static")
+		p.raw(" ")
+	}
+	p.propertyKey(f.Key, f.Computed)
+	if f.Initializer != nil {
+		p.space()
+		p.write("=")
+		p.space()
+		p.expression(f.Initializer, precAssign)
+	}
+	p.write(";")
+}
+
+func (p *printer) methodDefinition(m *ast.MethodDefinition) {
+	p.mark(m.Idx)
+	if m.Static {
+		p.write("static")
+		p.raw(" ")
+	}
+	p.method(m.Key, m.Kind, m.Computed, m.Body)
+}
+
+func (p *printer) staticBlock(b *ast.ClassStaticBlock) {
+	p.mark(b.Static)
+	p.write("static")
+	p.space()
+	p.block(b.Block)
+}
+
+func numberValue(n *ast.NumberLiteral) float64 {
+	switch v := n.Value.(type) {
+	case int64:
+		return float64(v)
+	case float64:
+		return v
+	}
+	return math.NaN()
+}
+
+// numberLiteral returns the literal of the number as written in the source, unless the value has been changed.
+func numberLiteral(n *ast.NumberLiteral) string {
+	v := numberValue(n)
+	if lit := n.Literal; lit != "" {
+		if i, err := strconv.ParseInt(lit, 0, 64); err == nil && float64(i) == v {
+			return lit
+		}
+		if f, err := strconv.ParseFloat(lit, 64); err == nil && f == v {
+			return lit
+		}
+	}
+	switch {
+	case math.IsNaN(v):
+		return "(0/0)"
+	case math.IsInf(v, 1):
+		return "(1/0)"
+	case math.IsInf(v, -1):
+		return "(-1/0)"
+	}
+	if i, ok := n.Value.(int64); ok {
+		return strconv.FormatInt(i, 10)
+	}
+	return strconv.FormatFloat(v, 'g', -1, 64)
+}
+
+// stringLiteral returns the literal of the string as written in the source, unless the value has been changed.
+func stringLiteral(s *ast.StringLiteral) string {
+	if lit := s.Literal; len(lit) >= 2 && (lit[0] == '"' || lit[0] == '\'') && lit[len(lit)-1] == lit[0] &&
+		strings.IndexByte(lit, '\\') == -1 && lit[1:len(lit)-1] == s.Value.String() {
+		return lit
+	}
+	return quote(s.Value)
+}
+
+func quote(s unistring.String) string {
+	var b strings.Builder
+	b.WriteByte('"')
+	writeUnit := func(c uint16) {
+		b.WriteString(`\u`)
+		h := strconv.FormatUint(uint64(c), 16)
+		b.WriteString(strings.Repeat("0", 4-len(h)))
+		b.WriteString(h)
+	}
+	writeRune := func(r rune) {
+		switch r {
+		case '"':
+			b.WriteString(`\"`)
+		case '\\':
+			b.WriteString(`\\`)
+		case '\n':
+			b.WriteString(`\n`)
+		case '\r':
+			b.WriteString(`\r`)
+		case '\t':
+			b.WriteString(`\t`)
+		case '\b':
+			b.WriteString(`\b`)
+		case '\f':
+			b.WriteString(`\f`)
+		case '\u2028', '\u2029':
+			writeUnit(uint16(r))
+		default:
+			if r < 0x20 || r == 0x7f {
+				writeUnit(uint16(r))
+			} else {
+				b.WriteRune(r)
+			}
+		}
+	}
+	if u := s.AsUtf16(); u != nil {
+		u = u[1:]
+		for i := 0; i < len(u); i++ {
+			c := u[i]
+			if utf16.IsSurrogate(rune(c)) {
+				if c < 0xDC00 && i+1 < len(u) && u[i+1] >= 0xDC00 && u[i+1] <= 0xDFFF {
+					writeRune(utf16.DecodeRune(rune(c), rune(u[i+1])))
+					i++
+				} else {
+					// a lone surrogate cannot be represented in UTF-8
+					writeUnit(c)
+				}
+				continue
+			}
+			writeRune(rune(c))
+		}
+	} else {
+		for _, r := range string(s) {
+			writeRune(r)
+		}
+	}
+	b.WriteByte('"')
+	return b.String()
+}
+
+func isIdentifierName(s string) bool {
+	if s == "" {
+		return false
+	}
+	for i, r := range s {
+		if r == utf8.RuneError {
+			return false
+		}
+		if r == '$' || r == '_' || unicode.IsLetter(r) {
+			continue
+		}
+		if i > 0 && (unicode.IsDigit(r) || unicode.In(r, unicode.Mn, unicode.Mc, unicode.Pc) || r == '\u200c' || r == '\u200d') {
+			continue
+		}
+		return false
+	}
+	return true
+}
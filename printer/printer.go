@@ -0,0 +1,247 @@
+/*
+Package printer implements printing of JavaScript source code from the AST produced by the parser.
+
+	program, err := parser.ParseFile(nil, "example.js", src, 0)
+	if err != nil {
+	    return err
+	}
+	// ... inspect or modify the program ...
+	code, sourceMap, err := printer.PrintWithSourceMap(program, printer.WithSourceMapURL("example.min.js.map"))
+
+The printer adds parentheses wherever they are required by operator precedence, so programmatically built or
+modified trees are printed correctly. Comments and the original formatting are not preserved.
+*/
+package printer
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/rarnu/goscript/ast"
+	"github.com/rarnu/goscript/file"
+)
+
+type options struct {
+	minify       bool
+	indent       string
+	sourceMapURL string
+}
+
+// Option represents one of the options for the printer. Currently supported are:
+// WithMinify, WithIndent and WithSourceMapURL.
+type Option func(*options)
+
+// WithMinify is an option to produce the shortest output: all optional whitespace and line breaks are omitted.
+func WithMinify(opts *options) {
+	opts.minify = true
+}
+
+// WithIndent is an option to set the string used for one level of indentation. The default is four spaces.
+// It has no effect when WithMinify is used.
+func WithIndent(indent string) Option {
+	return func(opts *options) {
+		opts.indent = indent
+	}
+}
+
+// WithSourceMapURL is an option to append a "//# sourceMappingURL=" comment with the given URL to the output.
+func WithSourceMapURL(url string) Option {
+	return func(opts *options) {
+		opts.sourceMapURL = url
+	}
+}
+
+// Print returns the JavaScript source code of the node. Any node type of the ast package may be printed, except
+// ast.BadExpression and ast.BadStatement which only appear in programs that failed to parse.
+func Print(node ast.Node, opts ...Option) (string, error) {
+	p := newPrinter(opts)
+	if err := p.run(node); err != nil {
+		return "", err
+	}
+	return string(p.buf), nil
+}
+
+// Fprint writes the JavaScript source code of the node to w. See Print.
+func Fprint(w io.Writer, node ast.Node, opts ...Option) error {
+	p := newPrinter(opts)
+	if err := p.run(node); err != nil {
+		return err
+	}
+	_, err := w.Write(p.buf)
+	return err
+}
+
+// PrintWithSourceMap returns the JavaScript source code of the program together with a source map (revision 3)
+// which maps it back to the source the program was parsed from. The map can be loaded back by the parser
+// (see parser.WithSourceMapLoader) so that positions in the printed code are reported in terms of the original.
+// Nodes that were created programmatically (i.e. have no position) are not mapped.
+func PrintWithSourceMap(prg *ast.Program, opts ...Option) (code string, sourceMap []byte, err error) {
+	if prg.File == nil {
+		return "", nil, errors.New("printer: program has no source file")
+	}
+	p := newPrinter(opts)
+	p.sm = newSourceMapBuilder(prg.File)
+	if err = p.run(prg); err != nil {
+		return "", nil, err
+	}
+	sourceMap, err = p.sm.marshal()
+	if err != nil {
+		return "", nil, err
+	}
+	return string(p.buf), sourceMap, nil
+}
+
+type printError struct {
+	node ast.Node
+}
+
+func (e *printError) Error() string {
+	return fmt.Sprintf("printer: cannot print %T", e.node)
+}
+
+type printer struct {
+	opts  options
+	buf   []byte
+	level int
+
+	// true while printing the initializer of a for statement where the 'in' operator must be parenthesised
+	noIn bool
+
+	// line number and the buffer offset of the start of the current output line
+	line, lineStart int
+
+	sm      *sourceMapBuilder
+	pending file.Idx
+}
+
+func newPrinter(opts []Option) *printer {
+	p := &printer{}
+	p.opts.indent = "    "
+	for _, opt := range opts {
+		opt(&p.opts)
+	}
+	return p
+}
+
+func (p *printer) run(node ast.Node) (err error) {
+	defer func() {
+		if x := recover(); x != nil {
+			if e, ok := x.(*printError); ok {
+				err = e
+				return
+			}
+			panic(x)
+		}
+	}()
+	p.node(node)
+	if p.opts.sourceMapURL != "" {
+		if len(p.buf) > 0 && p.buf[len(p.buf)-1] != '\n' {
+			p.raw("\n")
+		}
+		p.raw("//# sourceMappingURL=" + p.opts.sourceMapURL + "\n")
+	}
+	return nil
+}
+
+// raw appends s to the output as is.
+func (p *printer) raw(s string) {
+	if i := strings.LastIndexByte(s, '\n'); i >= 0 {
+		p.line += strings.Count(s, "\n")
+		p.lineStart = len(p.buf) + i + 1
+	}
+	p.buf = append(p.buf, s...)
+}
+
+// write appends a token to the output. A space is inserted if the token would otherwise merge with the
+// previous one (e.g. two identifiers, "a - -b" or "a / /re/").
+func (p *printer) write(s string) {
+	if s == "" {
+		return
+	}
+	if n := len(p.buf); n > 0 {
+		last, first := p.buf[n-1], s[0]
+		if isIdentifierPart(last) && isIdentifierPart(first) ||
+			(last == '+' || last == '-') && first == last ||
+			last == '/' && (first == '/' || first == '*') {
+			p.raw(" ")
+		}
+	}
+	if p.pending != 0 {
+		p.sm.add(p.pending, p.line, len(p.buf)-p.lineStart)
+		p.pending = 0
+	}
+	p.raw(s)
+}
+
+// space writes an optional space.
+func (p *printer) space() {
+	if !p.opts.minify {
+		p.raw(" ")
+	}
+}
+
+// newline starts a new line at the current indentation level.
+func (p *printer) newline() {
+	if !p.opts.minify {
+		p.raw("\n" + strings.Repeat(p.opts.indent, p.level))
+	}
+}
+
+// mark records idx as the original position of the next token.
+func (p *printer) mark(idx file.Idx) {
+	if p.sm != nil && p.pending == 0 {
+		p.pending = idx
+	}
+}
+
+func isIdentifierPart(chr byte) bool {
+	return chr == '$' || chr == '_' || chr == '\\' ||
+		'a' <= chr && chr <= 'z' || 'A' <= chr && chr <= 'Z' ||
+		'0' <= chr && chr <= '9' || chr >= 0x80
+}
+
+func (p *printer) node(node ast.Node) {
+	switch n := node.(type) {
+	case *ast.Program:
+		p.program(n)
+	case ast.Statement:
+		p.statement(n)
+	case ast.Expression:
+		p.expression(n, precSequence)
+	case *ast.ExpressionBody:
+		p.conciseBody(n)
+	case *ast.VariableDeclaration:
+		p.write("var")
+		p.bindings(n.List)
+	case *ast.ParameterList:
+		p.parameterList(n)
+	case *ast.FieldDefinition:
+		p.fieldDefinition(n)
+	case *ast.MethodDefinition:
+		p.methodDefinition(n)
+	case *ast.ClassStaticBlock:
+		p.staticBlock(n)
+	case *ast.TemplateElement:
+		p.write(n.Literal)
+	case *ast.ForLoopInitializerExpression, *ast.ForLoopInitializerVarDeclList, *ast.ForLoopInitializerLexicalDecl:
+		p.forLoopInitializer(n.(ast.ForLoopInitializer))
+	case *ast.ForIntoVar, *ast.ForDeclaration, *ast.ForIntoExpression:
+		p.forInto(n.(ast.ForInto))
+	default:
+		panic(&printError{node: node})
+	}
+}
+
+func (p *printer) program(prg *ast.Program) {
+	for i, stmt := range prg.Body {
+		if i > 0 {
+			p.newline()
+		}
+		p.statement(stmt)
+	}
+	if len(prg.Body) > 0 && !p.opts.minify {
+		p.raw("\n")
+	}
+}
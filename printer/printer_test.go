@@ -0,0 +1,220 @@
+package printer
+
+import (
+	"strings"
+	"testing"
+
+	goscript "github.com/rarnu/goscript"
+	"github.com/rarnu/goscript/ast"
+	"github.com/rarnu/goscript/parser"
+	"github.com/rarnu/goscript/token"
+	"github.com/rarnu/goscript/unistring"
+)
+
+func TestPrint(t *testing.T) {
+	const src = `
+	var a = 1,b
+	function f(x, {y = 2, ...z}, ...rest) { if (x) return y; else { for (let i of rest) a += i } }
+	label: do a++; while (a < 10)
+	switch (a) { case 1: break; default: }
+	try { f() } catch { }
+	class C extends (a, Object) { static #p = 1; get x() { return C.#p } static { } }
+	`
+	prg, err := parser.ParseFile(nil, "", src, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	const expected = `var a = 1, b;
+function f(x, { y = 2, ...z }, ...rest) {
+	if (x)
+		return y;
+	else {
+		for (let i of rest)
+			a += i;
+	}
+}
+label: do
+	a++;
+while (a < 10);
+switch (a) {
+case 1:
+	break;
+default:
+}
+try {
+	f();
+} catch {}
+class C extends (a, Object) {
+	static #p = 1;
+	get x() {
+		return C.#p;
+	}
+	static {}
+}
+`
+	out, err := Print(prg, WithIndent("\t"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if out != expected {
+		t.Fatalf("Unexpected output:\n%s", out)
+	}
+
+	const expectedMin = `var a=1,b;function f(x,{y=2,...z},...rest){if(x)return y;else{for(let i of rest)a+=i;}}label:do a++;while(a<10);switch(a){case 1:break;default:}try{f();}catch{}class C extends(a,Object){static #p=1;get x(){return C.#p;}static{}}`
+	out, err = Print(prg, WithMinify)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if out != expectedMin {
+		t.Fatalf("Unexpected output:\n%s", out)
+	}
+}
+
+func TestPrintNodes(t *testing.T) {
+	id := func(name string) *ast.Identifier {
+		return &ast.Identifier{Name: unistring.String(name)}
+	}
+	num := func(v int64) *ast.NumberLiteral {
+		return &ast.NumberLiteral{Value: v}
+	}
+	for _, tc := range []struct {
+		node     ast.Node
+		expected string
+	}{
+		{&ast.BinaryExpression{Operator: token.MULTIPLY, Left: &ast.BinaryExpression{Operator: token.PLUS, Left: id("a"), Right: id("b")}, Right: id("c")}, "(a + b) * c"},
+		{&ast.BinaryExpression{Operator: token.MINUS, Left: id("a"), Right: &ast.BinaryExpression{Operator: token.MINUS, Left: id("b"), Right: id("c")}}, "a - (b - c)"},
+		{&ast.BinaryExpression{Operator: token.EXPONENT, Left: &ast.UnaryExpression{Operator: token.MINUS, Operand: id("a")}, Right: num(2)}, "(-a) ** 2"},
+		{&ast.BinaryExpression{Operator: token.COALESCE, Left: &ast.BinaryExpression{Operator: token.LOGICAL_OR, Left: id("a"), Right: id("b")}, Right: id("c")}, "(a || b) ?? c"},
+		{&ast.BinaryExpression{Operator: token.IN, Left: &ast.PrivateIdentifier{Identifier: *id("x")}, Right: id("o")}, "#x in o"},
+		{&ast.UnaryExpression{Operator: token.MINUS, Operand: &ast.UnaryExpression{Operator: token.DECREMENT, Operand: id("a")}}, "- --a"},
+		{&ast.NewExpression{Callee: &ast.DotExpression{Left: &ast.CallExpression{Callee: id("f")}, Identifier: *id("C")}}, "new (f().C)()"},
+		{&ast.DotExpression{Left: num(1), Identifier: *id("toString")}, "(1).toString"},
+		{&ast.DotExpression{Left: &ast.OptionalChain{Expression: &ast.DotExpression{Left: &ast.Optional{Expression: id("a")}, Identifier: *id("b")}}, Identifier: *id("c")}, "(a?.b).c"},
+		{&ast.CallExpression{Callee: &ast.ArrowFunctionLiteral{ParameterList: &ast.ParameterList{}, Body: &ast.ExpressionBody{Expression: &ast.ObjectLiteral{}}}}, "(() => ({}))()"},
+		{&ast.ExpressionStatement{Expression: &ast.AssignExpression{Operator: token.ASSIGN, Left: &ast.ObjectPattern{Properties: []ast.Property{&ast.PropertyShort{Name: *id("a")}}}, Right: id("o")}}, "({ a } = o);"},
+		{&ast.ExpressionStatement{Expression: &ast.AssignExpression{Operator: token.ASSIGN, Left: &ast.BracketExpression{Left: id("let"), Member: num(0)}, Right: num(1)}}, "(let[0] = 1);"},
+		{&ast.IfStatement{Test: id("a"), Consequent: &ast.IfStatement{Test: id("b"), Consequent: &ast.EmptyStatement{}}, Alternate: &ast.EmptyStatement{}}, "if (a) {\n    if (b);\n} else;"},
+		{&ast.StringLiteral{Value: unistring.String("it's \"a\"\n\u2028")}, `"it's \"a\"\n\u2028"`},
+		{&ast.PropertyKeyed{Key: &ast.StringLiteral{Value: "a-b"}, Kind: ast.PropertyKindValue, Value: num(1)}, `"a-b": 1`},
+		{&ast.ForStatement{Initializer: &ast.ForLoopInitializerExpression{Expression: &ast.BinaryExpression{Operator: token.IN, Left: id("a"), Right: id("b")}}, Body: &ast.EmptyStatement{}}, "for ((a in b);;);"},
+		{&ast.VariableDeclaration{List: []*ast.Binding{{Target: id("a"), Initializer: num(1)}}}, "var a = 1"},
+		{&ast.TemplateElement{Literal: `a\n`}, `a\n`},
+	} {
+		out, err := Print(tc.node)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if out != tc.expected {
+			t.Errorf("%T: expected %q, got %q", tc.node, tc.expected, out)
+		}
+	}
+
+	if _, err := Print(&ast.BadStatement{}); err == nil || err.Error() != "printer: cannot print *ast.BadStatement" {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+}
+
+func TestPrintRoundTrip(t *testing.T) {
+	srcs := []string{
+		`var a = 1, b = 2, c = 3; [(a + b) * c, a - -b, a + +b, -(-a), (a, b), a ** 2, 2 ** 3 ** 2, (2 ** 3) ** 2, a - (b - c), a / (b / c)].join()`,
+		`var o = {a: {b: null}}; [o?.a?.b, o.x?.y.z, (o?.a).b, o.a?.["b"], typeof o?.f?.()].join()`,
+		`(function(){ return 1 })() + (() => ({a: 1}))().a`,
+		`({a: 1}).a + [1,,2,,].length`,
+		`var x = 0; for (var i = ("a" in {a: 1}) ? 1 : 0; i < 3; i++) x += i; x`,
+		`class A { static #c = 1; #x = 2; get x() { return this.#x } static [("s" + 1)]() { return A.#c } static { this.z = 5 } } new A().x + A.s1() + A.z`,
+		`function* g() { yield 1; yield* [2, 3]; } var r = []; for (const v of g()) r.push(v); r.join()`,
+		"var t = (s, ...v) => s.raw.join('|') + v.join(); t`a${1}b\\n${2}`",
+		`var {a, b: [c = 5, ...d], ...e} = {a: 1, b: [undefined, 2, 3], f: 4}; [a, c, d, e.f].join()`,
+		`label: for (var i = 0; i < 3; i++) { for (;;) { if (i) continue label; else break label; } } i`,
+		`var s = ""; switch (2) { case 1: s += "a"; case 2: s += "b"; default: s += "c" } s`,
+		`var r; try { throw new Error("x") } catch ({message}) { r = message } finally { r += "!" } r`,
+		`if (1) if (0) "a"; else "b"`,
+		`var n = null, u; [n ?? "d", (n || u) ?? "e", n ?? (u || "f")].join()`,
+		`new (function(){ this.v = 1 })().v + new Date(0).getTime() + (1).toString() + 1.5.toFixed(1)`,
+		`async function f() { await null; return -(await 1) } typeof f()`,
+		`var a = [1,2,3]; delete a[0]; typeof typeof void 0 + a.length`,
+		`"  \"q\" \\" + '\'' + "\x00".length`,
+		`/a\/b/g.test("a/b") && 5 / /1/.source`,
+		`var x = 1; x += 2; x **= 2; x >>>= 1; x`,
+		`let y = (a, b = a) => a + b; y(1)`,
+		`(class { static m() { return new.target } }).m()`,
+		`var let_ = [1]; let_[0]`,
+		`var o = {get a() { return 1 }, set a(v) {}, *g() {}, async h() {}, [1 + 1]: 2, "x-y": 3, 4: 5}; o.a + o[2] + o["x-y"] + o[4]`,
+		`var i = 0; do i++; while (i < 5); i`,
+		`class P { #x; static has(o) { return #x in o } } P.has(new P()) && !P.has({})`,
+	}
+	for _, src := range srcs {
+		prg, err := parser.ParseFile(nil, "", src, 0)
+		if err != nil {
+			t.Fatalf("%s: %v", src, err)
+		}
+		expected, err := goscript.New().RunString(src)
+		if err != nil {
+			t.Fatalf("%s: %v", src, err)
+		}
+		for _, opts := range [][]Option{nil, {WithMinify}} {
+			out, err := Print(prg, opts...)
+			if err != nil {
+				t.Fatal(err)
+			}
+			v, err := goscript.New().RunString(out)
+			if err != nil {
+				t.Errorf("%s -> %s: %v", src, out, err)
+				continue
+			}
+			if !v.StrictEquals(expected) {
+				t.Errorf("%s -> %s: %v != %v", src, out, v, expected)
+			}
+		}
+	}
+}
+
+func TestPrintSourceMap(t *testing.T) {
+	const src = `function check(x) {
+    if (x > 1) {
+        throw new Error("too big");
+    }
+}
+check(1);
+check(2);
+`
+	prg, err := parser.ParseFile(nil, "orig.js", src, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	code, sourceMap, err := PrintWithSourceMap(prg, WithMinify, WithSourceMapURL("out.js.map"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasSuffix(code, "\n//# sourceMappingURL=out.js.map\n") || strings.Count(code, "\n") != 2 {
+		t.Fatalf("Unexpected code: %s", code)
+	}
+
+	var loaded string
+	loader := func(path string) ([]byte, error) {
+		loaded = path
+		return sourceMap, nil
+	}
+	out, err := goscript.Parse("out.js", code, parser.WithSourceMapLoader(loader))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if loaded != "out.js.map" {
+		t.Fatalf("Unexpected source map path: %q", loaded)
+	}
+	throw := out.Body[0].(*ast.FunctionDeclaration).Function.Body.List[0].(*ast.IfStatement).Consequent.(*ast.BlockStatement).List[0]
+	pos := out.File.Position(int(throw.Idx0()) - out.File.Base())
+	if pos.Filename != "orig.js" || pos.Line != 3 || pos.Column != 8 {
+		t.Fatalf("Unexpected position: %v", pos)
+	}
+
+	p, err := goscript.CompileAST(out, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = goscript.New().RunProgram(p)
+	if ex, ok := err.(*goscript.Exception); !ok || !strings.Contains(ex.String(), "at check (orig.js:3:") ||
+		!strings.Contains(ex.String(), "orig.js:7:") {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+}
@@ -0,0 +1,124 @@
+package printer
+
+import (
+	"encoding/json"
+	"sort"
+	"strings"
+
+	"github.com/rarnu/goscript/file"
+)
+
+const base64Digits = "ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz0123456789+/"
+
+// sourceMapBuilder collects the mappings of a source map (revision 3). Columns are byte offsets within a line,
+// which is what the parser uses when looking positions up.
+type sourceMapBuilder struct {
+	file        *file.File
+	lineOffsets []int
+
+	mappings strings.Builder
+
+	// the previous segment, the values are encoded relative to it
+	genLine, genCol, srcLine, srcCol int
+	hasSegment                       bool
+}
+
+func newSourceMapBuilder(fl *file.File) *sourceMapBuilder {
+	src := fl.Source()
+	lineOffsets := []int{0}
+	for pos := 0; pos < len(src); {
+		n := nextLineStart(src[pos:])
+		if n == -1 {
+			break
+		}
+		pos += n
+		lineOffsets = append(lineOffsets, pos)
+	}
+	return &sourceMapBuilder{
+		file:        fl,
+		lineOffsets: lineOffsets,
+	}
+}
+
+// nextLineStart returns the offset of the start of the next line in s, or -1 if s is the last line.
+// The line terminators are the same as those recognised by file.File.
+func nextLineStart(s string) int {
+	for pos, chr := range s {
+		switch chr {
+		case '\r':
+			if pos < len(s)-1 && s[pos+1] == '\n' {
+				return pos + 2
+			}
+			return pos + 1
+		case '\n':
+			return pos + 1
+		case '\u2028', '\u2029':
+			return pos + 3
+		}
+	}
+	return -1
+}
+
+// add maps the generated position (0-based line and column) to the original position of idx.
+func (b *sourceMapBuilder) add(idx file.Idx, genLine, genCol int) {
+	offset := int(idx) - b.file.Base()
+	if idx <= 0 || offset < 0 || offset > len(b.file.Source()) {
+		return
+	}
+	srcLine := sort.Search(len(b.lineOffsets), func(i int) bool { return b.lineOffsets[i] > offset }) - 1
+	srcCol := offset - b.lineOffsets[srcLine]
+
+	if genLine > b.genLine {
+		for i := b.genLine; i < genLine; i++ {
+			b.mappings.WriteByte(';')
+		}
+		b.genLine, b.genCol = genLine, 0
+	} else if b.hasSegment {
+		if genCol == b.genCol {
+			return
+		}
+		b.mappings.WriteByte(',')
+	}
+	b.writeVLQ(genCol - b.genCol)
+	b.writeVLQ(0) // there is only one source
+	b.writeVLQ(srcLine - b.srcLine)
+	b.writeVLQ(srcCol - b.srcCol)
+	b.genCol, b.srcLine, b.srcCol = genCol, srcLine, srcCol
+	b.hasSegment = true
+}
+
+func (b *sourceMapBuilder) writeVLQ(v int) {
+	var u uint
+	if v < 0 {
+		u = uint(-v)<<1 | 1
+	} else {
+		u = uint(v) << 1
+	}
+	for {
+		digit := u & 0x1f
+		u >>= 5
+		if u != 0 {
+			digit |= 0x20
+		}
+		b.mappings.WriteByte(base64Digits[digit])
+		if u == 0 {
+			break
+		}
+	}
+}
+
+func (b *sourceMapBuilder) marshal() ([]byte, error) {
+	return json.Marshal(struct {
+		Version        int      `json:"version"`
+		Sources        []string `json:"sources"`
+		SourcesContent []string `json:"sourcesContent"`
+		Names          []string `json:"names"`
+		Mappings       string   `json:"mappings"`
+	}{
+		Version:        3,
+		Sources:        []string{b.file.Name()},
+		SourcesContent: []string{b.file.Source()},
+		Names:          []string{},
+		Mappings:       b.mappings.String(),
+	})
+}
@@ -0,0 +1,360 @@
+package printer
+
+import (
+	"github.com/rarnu/goscript/ast"
+	"github.com/rarnu/goscript/token"
+)
+
+func (p *printer) statement(stmt ast.Statement) {
+	p.mark(stmt.Idx0())
+	switch s := stmt.(type) {
+	case *ast.BlockStatement:
+		p.block(s)
+	case *ast.EmptyStatement:
+		p.write(";")
+	case *ast.ExpressionStatement:
+		if startsWithBrace(s.Expression) || startsWithLetBracket(s.Expression) {
+			p.write("(")
+			p.expression(s.Expression, precSequence)
+			p.write(")")
+		} else {
+			p.expression(s.Expression, precSequence)
+		}
+		p.write(";")
+	case *ast.VariableStatement:
+		p.write("var")
+		p.bindings(s.List)
+		p.write(";")
+	case *ast.LexicalDeclaration:
+		p.lexicalDeclaration(s)
+		p.write(";")
+	case *ast.FunctionDeclaration:
+		p.functionLiteral(s.Function)
+	case *ast.ClassDeclaration:
+		p.classLiteral(s.Class)
+	case *ast.IfStatement:
+		p.write("if")
+		p.space()
+		p.condition(s.Test)
+		consequent := s.Consequent
+		if s.Alternate != nil && endsWithIf(consequent) {
+			// the else would be taken by the inner if statement
+			consequent = &ast.BlockStatement{List: []ast.Statement{consequent}}
+		}
+		p.body(consequent)
+		if s.Alternate != nil {
+			if _, ok := consequent.(*ast.BlockStatement); ok {
+				p.space()
+			} else {
+				p.newline()
+			}
+			p.write("else")
+			if _, ok := s.Alternate.(*ast.IfStatement); ok {
+				p.raw(" ")
+				p.statement(s.Alternate)
+			} else {
+				p.body(s.Alternate)
+			}
+		}
+	case *ast.DoWhileStatement:
+		p.write("do")
+		p.body(s.Body)
+		if _, ok := s.Body.(*ast.BlockStatement); ok {
+			p.space()
+		} else {
+			p.newline()
+		}
+		p.write("while")
+		p.space()
+		p.condition(s.Test)
+		p.write(";")
+	case *ast.WhileStatement:
+		p.write("while")
+		p.space()
+		p.condition(s.Test)
+		p.body(s.Body)
+	case *ast.ForStatement:
+		p.write("for")
+		p.space()
+		p.write("(")
+		if s.Initializer != nil {
+			p.forLoopInitializer(s.Initializer)
+		}
+		p.write(";")
+		if s.Test != nil {
+			p.space()
+			p.expression(s.Test, precSequence)
+		}
+		p.write(";")
+		if s.Update != nil {
+			p.space()
+			p.expression(s.Update, precSequence)
+		}
+		p.write(")")
+		p.body(s.Body)
+	case *ast.ForInStatement:
+		p.write("for")
+		p.space()
+		p.write("(")
+		p.forInto(s.Into)
+		p.write("in")
+		p.space()
+		p.expression(s.Source, precSequence)
+		p.write(")")
+		p.body(s.Body)
+	case *ast.ForOfStatement:
+		p.write("for")
+		p.space()
+		p.write("(")
+		p.forInto(s.Into)
+		p.write("of")
+		p.space()
+		p.expression(s.Source, precAssign)
+		p.write(")")
+		p.body(s.Body)
+	case *ast.BranchStatement:
+		p.write(s.Token.String())
+		if s.Label != nil {
+			p.raw(" ")
+			p.mark(s.Label.Idx)
+			p.write(s.Label.Name.String())
+		}
+		p.write(";")
+	case *ast.ReturnStatement:
+		p.write("return")
+		if s.Argument != nil {
+			p.space()
+			p.expression(s.Argument, precSequence)
+		}
+		p.write(";")
+	case *ast.ThrowStatement:
+		p.write("throw")
+		p.space()
+		p.expression(s.Argument, precSequence)
+		p.write(";")
+	case *ast.TryStatement:
+		p.write("try")
+		p.space()
+		p.block(s.Body)
+		if s.Catch != nil {
+			p.space()
+			p.catchClause(s.Catch)
+		}
+		if s.Finally != nil {
+			p.space()
+			p.write("finally")
+			p.space()
+			p.block(s.Finally)
+		}
+	case *ast.CatchStatement:
+		p.catchClause(s)
+	case *ast.SwitchStatement:
+		p.write("switch")
+		p.space()
+		p.condition(s.Discriminant)
+		p.space()
+		p.write("{")
+		for _, c := range s.Body {
+			p.newline()
+			p.caseClause(c)
+		}
+		if len(s.Body) > 0 {
+			p.newline()
+		}
+		p.write("}")
+	case *ast.CaseStatement:
+		p.caseClause(s)
+	case *ast.LabelledStatement:
+		p.mark(s.Label.Idx)
+		p.write(s.Label.Name.String())
+		p.write(":")
+		p.space()
+		p.statement(s.Statement)
+	case *ast.WithStatement:
+		p.write("with")
+		p.space()
+		p.condition(s.Object)
+		p.body(s.Body)
+	case *ast.DebuggerStatement:
+		p.write("debugger;")
+	default:
+		panic(&printError{node: stmt})
+	}
+}
+
+// startsWithLetBracket reports whether expr starts with "let [" which would be taken for a lexical declaration.
+func startsWithLetBracket(expr ast.Expression) bool {
+	for ; expr != nil; expr = leftChild(expr) {
+		if b, ok := expr.(*ast.BracketExpression); ok {
+			if id, ok := b.Left.(*ast.Identifier); ok && id.Name == "let" {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// endsWithIf reports whether stmt ends with an if statement without an else clause.
+func endsWithIf(stmt ast.Statement) bool {
+	for {
+		switch s := stmt.(type) {
+		case *ast.IfStatement:
+			if s.Alternate == nil {
+				return true
+			}
+			stmt = s.Alternate
+		case *ast.LabelledStatement:
+			stmt = s.Statement
+		case *ast.WhileStatement:
+			stmt = s.Body
+		case *ast.ForStatement:
+			stmt = s.Body
+		case *ast.ForInStatement:
+			stmt = s.Body
+		case *ast.ForOfStatement:
+			stmt = s.Body
+		case *ast.WithStatement:
+			stmt = s.Body
+		default:
+			return false
+		}
+	}
+}
+
+func (p *printer) condition(expr ast.Expression) {
+	p.write("(")
+	p.expression(expr, precSequence)
+	p.write(")")
+}
+
+// body prints the body of a compound statement.
+func (p *printer) body(stmt ast.Statement) {
+	if b, ok := stmt.(*ast.BlockStatement); ok {
+		p.space()
+		p.block(b)
+		return
+	}
+	if _, ok := stmt.(*ast.EmptyStatement); ok {
+		p.write(";")
+		return
+	}
+	p.level++
+	p.newline()
+	p.statement(stmt)
+	p.level--
+}
+
+func (p *printer) block(b *ast.BlockStatement) {
+	p.mark(b.LeftBrace)
+	p.write("{")
+	if len(b.List) == 0 {
+		p.write("}")
+		return
+	}
+	noIn := p.noIn
+	p.noIn = false
+	p.level++
+	for _, stmt := range b.List {
+		p.newline()
+		p.statement(stmt)
+	}
+	p.level--
+	p.noIn = noIn
+	p.newline()
+	p.write("}")
+}
+
+func (p *printer) lexicalDeclaration(decl *ast.LexicalDeclaration) {
+	if decl.Token == token.CONST {
+		p.write("const")
+	} else {
+		p.write("let")
+	}
+	p.bindings(decl.List)
+}
+
+func (p *printer) caseClause(c *ast.CaseStatement) {
+	p.mark(c.Case)
+	if c.Test != nil {
+		p.write("case")
+		p.space()
+		p.expression(c.Test, precSequence)
+	} else {
+		p.write("default")
+	}
+	p.write(":")
+	p.level++
+	for _, stmt := range c.Consequent {
+		p.newline()
+		p.statement(stmt)
+	}
+	p.level--
+}
+
+func (p *printer) catchClause(c *ast.CatchStatement) {
+	p.mark(c.Catch)
+	p.write("catch")
+	if c.Parameter != nil {
+		p.space()
+		p.write("(")
+		p.expression(c.Parameter, precAssign)
+		p.write(")")
+	}
+	p.space()
+	p.block(c.Body)
+}
+
+func (p *printer) forLoopInitializer(init ast.ForLoopInitializer) {
+	p.noIn = true
+	switch init := init.(type) {
+	case *ast.ForLoopInitializerExpression:
+		if startsWithLetBracket(init.Expression) {
+			p.write("(")
+			p.expression(init.Expression, precSequence)
+			p.write(")")
+		} else {
+			p.expression(init.Expression, precSequence)
+		}
+	case *ast.ForLoopInitializerVarDeclList:
+		p.mark(init.Var)
+		p.write("var")
+		p.bindings(init.List)
+	case *ast.ForLoopInitializerLexicalDecl:
+		p.mark(init.LexicalDeclaration.Idx)
+		p.lexicalDeclaration(&init.LexicalDeclaration)
+	default:
+		panic(&printError{node: init})
+	}
+	p.noIn = false
+}
+
+func (p *printer) forInto(into ast.ForInto) {
+	switch into := into.(type) {
+	case *ast.ForIntoVar:
+		p.write("var")
+		p.raw(" ")
+		p.noIn = true
+		p.binding(into.Binding)
+		p.noIn = false
+	case *ast.ForDeclaration:
+		p.mark(into.Idx)
+		if into.IsConst {
+			p.write("const")
+		} else {
+			p.write("let")
+		}
+		p.raw(" ")
+		p.expression(into.Target, precAssign)
+	case *ast.ForIntoExpression:
+		if startsWithLetBracket(into.Expression) {
+			p.write("(")
+			p.expression(into.Expression, precSequence)
+			p.write(")")
+		} else {
+			p.expression(into.Expression, precCall)
+		}
+	default:
+		panic(&printError{node: into})
+	}
+	p.raw(" ")
+}
@@ -0,0 +1,203 @@
+package goscript
+
+import (
+	"errors"
+	"io"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/google/pprof/profile"
+)
+
+// DefaultProfileInterval is the sampling interval used by StartProfile when a non-positive interval is given.
+const DefaultProfileInterval = 10 * time.Millisecond
+
+// profMaxStackDepth limits the number of frames recorded per sample.
+const profMaxStackDepth = 128
+
+// vmProfSampleRequest is stored into vm.interrupted by the profiler to ask the vm to take a sample
+// at the next instruction boundary. Any other non-zero value means the vm has been interrupted.
+const vmProfSampleRequest uint32 = 2
+
+var (
+	ErrProfilerRunning    = errors.New("profiler is already running")
+	ErrProfilerNotRunning = errors.New("profiler is not running")
+)
+
+type profSample struct {
+	stack []StackFrame
+	value time.Duration
+}
+
+type profiler struct {
+	w        io.Writer
+	interval time.Duration
+	start    time.Time
+
+	// reqTime is the time (in Unix nanoseconds) of the last sample request, accessed atomically.
+	reqTime int64
+
+	samples []profSample
+
+	stop chan struct{}
+	done chan struct{}
+}
+
+// StartProfile starts a sampling profiler that captures the JavaScript call stack of the Runtime
+// every interval (DefaultProfileInterval if interval <= 0). When StopProfile is called the collected
+// samples are written to w as a gzip-compressed pprof profile, where every frame is a JavaScript
+// function together with its source file and line, suitable for `go tool pprof`.
+//
+// Samples are only taken while the Runtime executes JavaScript code. A sample requested during a
+// native Go function is taken when it returns, and attributed to the JavaScript frame that called it,
+// only if this is within the interval, so the time of longer native calls is not counted.
+// This method is safe to call concurrently with a running script.
+func (r *Runtime) StartProfile(w io.Writer, interval time.Duration) error {
+	if interval <= 0 {
+		interval = DefaultProfileInterval
+	}
+	vm := r.vm
+	vm.profLock.Lock()
+	defer vm.profLock.Unlock()
+	if vm.prof != nil {
+		return ErrProfilerRunning
+	}
+	p := &profiler{
+		w:        w,
+		interval: interval,
+		start:    time.Now(),
+		stop:     make(chan struct{}),
+		done:     make(chan struct{}),
+	}
+	vm.prof = p
+	go p.run(vm)
+	return nil
+}
+
+// StopProfile stops the profiler started with StartProfile and writes the profile.
+// This method is safe to call concurrently with a running script.
+func (r *Runtime) StopProfile() error {
+	vm := r.vm
+	vm.profLock.Lock()
+	p := vm.prof
+	vm.prof = nil
+	vm.profLock.Unlock()
+	if p == nil {
+		return ErrProfilerNotRunning
+	}
+	close(p.stop)
+	<-p.done
+	return p.build(time.Now()).Write(p.w)
+}
+
+func (p *profiler) run(vm *vm) {
+	defer close(p.done)
+	ticker := time.NewTicker(p.interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-p.stop:
+			return
+		case <-ticker.C:
+			atomic.StoreInt64(&p.reqTime, time.Now().UnixNano())
+			atomic.CompareAndSwapUint32(&vm.interrupted, 0, vmProfSampleRequest)
+		}
+	}
+}
+
+// profSample is called by the vm loop when a sample has been requested.
+func (vm *vm) profSample() {
+	vm.profLock.Lock()
+	defer vm.profLock.Unlock()
+	p := vm.prof
+	if p == nil {
+		return
+	}
+	// A request that was made while the runtime was idle must not be attributed to the code that happens to run next.
+	elapsed := time.Duration(time.Now().UnixNano() - atomic.LoadInt64(&p.reqTime))
+	if elapsed > p.interval {
+		return
+	}
+	p.samples = append(p.samples, profSample{
+		stack: vm.r.CaptureCallStack(profMaxStackDepth, nil),
+		value: p.interval,
+	})
+}
+
+// profFuncName returns the function name of the frame. Placeholders such as <anonymous> are
+// put in square brackets because pprof treats angle brackets as C++ template arguments and strips them.
+func profFuncName(frame *StackFrame) string {
+	name := frame.FuncName()
+	if strings.HasPrefix(name, "<") && strings.HasSuffix(name, ">") {
+		return "[" + name[1:len(name)-1] + "]"
+	}
+	return name
+}
+
+type profFuncKey struct {
+	name, file string
+}
+
+type profLocKey struct {
+	fn   *profile.Function
+	line int
+}
+
+func (p *profiler) build(end time.Time) *profile.Profile {
+	pr := &profile.Profile{
+		SampleType: []*profile.ValueType{
+			{Type: "samples", Unit: "count"},
+			{Type: "cpu", Unit: "nanoseconds"},
+		},
+		PeriodType:    &profile.ValueType{Type: "cpu", Unit: "nanoseconds"},
+		Period:        p.interval.Nanoseconds(),
+		TimeNanos:     p.start.UnixNano(),
+		DurationNanos: end.Sub(p.start).Nanoseconds(),
+	}
+	mapping := &profile.Mapping{ID: 1, File: "[JavaScript]"}
+	pr.Mapping = []*profile.Mapping{mapping}
+
+	funcs := make(map[profFuncKey]*profile.Function)
+	locs := make(map[profLocKey]*profile.Location)
+
+	for _, s := range p.samples {
+		sample := &profile.Sample{
+			Value:    []int64{1, s.value.Nanoseconds()},
+			Location: make([]*profile.Location, 0, len(s.stack)),
+		}
+		for i := range s.stack {
+			frame := &s.stack[i]
+			pos := frame.Position()
+			fk := profFuncKey{name: profFuncName(frame), file: frame.SrcName()}
+			fn := funcs[fk]
+			if fn == nil {
+				fn = &profile.Function{
+					ID:         uint64(len(pr.Function) + 1),
+					Name:       fk.name,
+					SystemName: fk.name,
+					Filename:   fk.file,
+				}
+				if frame.prg != nil && frame.prg.src != nil {
+					fn.StartLine = int64(frame.prg.src.Position(frame.prg.sourceOffset(0)).Line)
+				}
+				funcs[fk] = fn
+				pr.Function = append(pr.Function, fn)
+			}
+			lk := profLocKey{fn: fn, line: pos.Line}
+			loc := locs[lk]
+			if loc == nil {
+				loc = &profile.Location{
+					ID:      uint64(len(pr.Location) + 1),
+					Mapping: mapping,
+					Line:    []profile.Line{{Function: fn, Line: int64(pos.Line)}},
+				}
+				locs[lk] = loc
+				pr.Location = append(pr.Location, loc)
+			}
+			sample.Location = append(sample.Location, loc)
+		}
+		pr.Sample = append(pr.Sample, sample)
+	}
+	return pr
+}
@@ -0,0 +1,93 @@
+package goscript
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/google/pprof/profile"
+)
+
+func TestProfiler(t *testing.T) {
+	const SCRIPT = `
+	function busy() {
+		var s = 0;
+		for (var i = 0; i < 1000; i++) {
+			s += Math.sqrt(i);
+		}
+		return s;
+	}
+	function outer(until) {
+		while (Date.now() < until) {
+			busy();
+		}
+	}
+	outer(Date.now() + 300);
+	`
+	vm := New()
+	var buf bytes.Buffer
+	if err := vm.StartProfile(&buf, time.Millisecond); err != nil {
+		t.Fatal(err)
+	}
+	if err := vm.StartProfile(&buf, time.Millisecond); err != ErrProfilerRunning {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if _, err := vm.RunScript("test.js", SCRIPT); err != nil {
+		t.Fatal(err)
+	}
+	if err := vm.StopProfile(); err != nil {
+		t.Fatal(err)
+	}
+	if err := vm.StopProfile(); err != ErrProfilerNotRunning {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	p, err := profile.Parse(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(p.Sample) == 0 {
+		t.Fatal("No samples")
+	}
+	found := false
+	for _, s := range p.Sample {
+		if len(s.Location) < 2 {
+			continue
+		}
+		leaf, caller := s.Location[0].Line[0], s.Location[1].Line[0]
+		if leaf.Function.Name == "busy" && caller.Function.Name == "outer" {
+			if leaf.Function.Filename != "test.js" {
+				t.Fatalf("Unexpected filename: %q", leaf.Function.Filename)
+			}
+			if leaf.Line < 2 || leaf.Line > 8 {
+				t.Fatalf("Unexpected line: %d", leaf.Line)
+			}
+			found = true
+			break
+		}
+	}
+	if !found {
+		t.Fatal("busy() called from outer() not found in samples")
+	}
+}
+
+func TestProfilerInterrupt(t *testing.T) {
+	vm := New()
+	var buf bytes.Buffer
+	if err := vm.StartProfile(&buf, time.Millisecond); err != nil {
+		t.Fatal(err)
+	}
+	time.AfterFunc(100*time.Millisecond, func() {
+		vm.Interrupt("halt")
+	})
+	_, err := vm.RunString("for (;;) {}")
+	if _, ok := err.(*InterruptedError); !ok {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if err := vm.StopProfile(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := profile.Parse(&buf); err != nil {
+		t.Fatal(err)
+	}
+}
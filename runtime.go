@@ -12,6 +12,7 @@ import (
 	"reflect"
 	"runtime"
 	"strconv"
+	"strings"
 	"time"
 
 	"golang.org/x/text/collate"
@@ -148,34 +149,58 @@ type global struct {
 	arrayValues   *Object
 	arrayToString *Object
 
-	Etcd                    *Object
-	EtcdPrototype           *Object
-	Dameng                  *Object
-	DamengPrototype         *Object
-	InfluxDB                *Object
-	InfluxDBPrototype       *Object
-	InfluxDBWrite           *Object
-	InfluxDBWritePrototype  *Object
-	InfluxDBQuery           *Object
-	InfluxDBQueryPrototype  *Object
-	InfluxDBPoint           *Object
-	InfluxDBPointPrototype  *Object
-	Mssql                   *Object
-	MssqlPrototype          *Object
-	Mysql                   *Object
-	MysqlPrototype          *Object
-	Oracle                  *Object
-	OraclePrototype         *Object
-	Redis                   *Object
-	RedisPrototype          *Object
-	RedisV8                 *Object
-	RedisV8Prototype        *Object
-	RedisCluster            *Object
-	RedisClusterPrototype   *Object
-	RedisClusterV8          *Object
-	RedisClusterV8Prototype *Object
-	SQLite                  *Object
-	SQLitePrototype         *Object
+	Etcd                        *Object
+	EtcdPrototype               *Object
+	HTTPResponsePrototype       *Object
+	HTTPMultipartPrototype      *Object
+	HTTPClientPrototype         *Object
+	HTTPServerPrototype         *Object
+	HTTPServerRequestPrototype  *Object
+	HTTPServerResponsePrototype *Object
+	Headers                     *Object
+	HeadersPrototype            *Object
+	Request                     *Object
+	RequestPrototype            *Object
+	Response                    *Object
+	ResponsePrototype           *Object
+	AbortController             *Object
+	AbortControllerPrototype    *Object
+	AbortSignal                 *Object
+	AbortSignalPrototype        *Object
+	WebSocket                   *Object
+	WebSocketPrototype          *Object
+	Dameng                      *Object
+	DamengPrototype             *Object
+	Database                    *Object
+	DatabasePrototype           *Object
+	InfluxDB                    *Object
+	InfluxDBPrototype           *Object
+	InfluxDBWrite               *Object
+	InfluxDBWritePrototype      *Object
+	InfluxDBQuery               *Object
+	InfluxDBQueryPrototype      *Object
+	InfluxDBPoint               *Object
+	InfluxDBPointPrototype      *Object
+	Mssql                       *Object
+	MssqlPrototype              *Object
+	Mysql                       *Object
+	MysqlPrototype              *Object
+	Oracle                      *Object
+	OraclePrototype             *Object
+	Postgres                    *Object
+	PostgresPrototype           *Object
+	Redis                       *Object
+	RedisPrototype              *Object
+	RedisV8                     *Object
+	RedisCluster                *Object
+	RedisClusterV8              *Object
+	RedisPipelinePrototype      *Object
+	SQLite                      *Object
+	SQLitePrototype             *Object
+	SQLTransactionPrototype     *Object
+	SQLCursorPrototype          *Object
+	SQLAsyncCursorPrototype     *Object
+	SQLQueryPrototype           *Object
 }
 
 type Flag int
@@ -225,6 +250,10 @@ type Runtime struct {
 
 	promiseRejectionTracker PromiseRejectionTracker
 	asyncContextTracker     AsyncContextTracker
+	scheduler               Scheduler
+	httpServe               httpServeState
+
+	coverage *Coverage
 }
 
 func (r *Runtime) GetVm() *vm {
@@ -511,18 +540,19 @@ func (r *Runtime) init() {
 	r.initCrypto()
 	r.initFile()
 	r.initHttp()
+	r.initFetch()
+	r.initWebSocket()
 	r.initK8s()
 
+	r.initDatabase()
 	r.initDameng()
 	r.initEtcd()
 	r.initInfluxDB()
 	r.initMssql()
 	r.initMySQL()
 	r.initOracle()
+	r.initPostgres()
 	r.initRedis()
-	r.initRedisCluster()
-	r.initRedisV8()
-	r.initRedisClusterV8()
 	r.initSQLite()
 
 	r.global.thrower = r.newNativeFunc(r.builtin_thrower, nil, "", nil, 0)
@@ -1416,19 +1446,20 @@ func (r *Runtime) AttachDebugger() *Debugger {
 // Compile creates an internal representation of the JavaScript code that can be later run using the Runtime.RunProgram()
 // method. This representation is not linked to a runtime in any way and can be run in multiple runtimes (possibly
 // at the same time).
+// If the name has the .ts extension, the source is parsed as TypeScript (see parser.WithTypeScript).
 func Compile(name, src string, strict bool) (*Program, error) {
-	return compile(name, src, strict, true, nil, false)
+	return compile(name, src, strict, true, nil, false, nil)
 }
 
 // CompileAST creates an internal representation of the JavaScript code that can be later run using the Runtime.RunProgram()
 // method. This representation is not linked to a runtime in any way and can be run in multiple runtimes (possibly
 // at the same time).
 func CompileAST(prg *js_ast.Program, strict bool) (*Program, error) {
-	return compileAST(prg, strict, true, nil, false)
+	return compileAST(prg, strict, true, nil, false, nil)
 }
 
 func CompileASTDebug(prg *js_ast.Program, strict bool) (*Program, error) {
-	return compileAST(prg, strict, true, nil, true)
+	return compileAST(prg, strict, true, nil, true, nil)
 }
 
 // MustCompile is like Compile but panics if the code cannot be compiled.
@@ -1451,6 +1482,9 @@ func MustCompile(name, src string, strict bool) *Program {
 //	// ...
 //
 // Otherwise use Compile which combines both steps.
+//
+// With parser.WithErrorRecovery the returned program is not nil even if there are syntax errors, and all of them
+// can be obtained with errors.As(err, &list) where list is a parser.ErrorList.
 func Parse(name, src string, options ...parser.Option) (prg *js_ast.Program, err error) {
 	prg, err1 := parser.ParseFile(nil, name, src, 0, options...)
 	if err1 != nil {
@@ -1459,22 +1493,29 @@ func Parse(name, src string, options ...parser.Option) (prg *js_ast.Program, err
 			CompilerError: CompilerError{
 				Message: err1.Error(),
 			},
+			cause: err1,
 		}
 	}
 	return
 }
 
-func compile(name, src string, strict, inGlobal bool, evalVm *vm, debug bool, parserOptions ...parser.Option) (p *Program, err error) {
+func compile(name, src string, strict, inGlobal bool, evalVm *vm, debug bool, cov *Coverage, parserOptions ...parser.Option) (p *Program, err error) {
+	if evalVm == nil && strings.HasSuffix(name, ".ts") {
+		parserOptions = append(parserOptions, parser.WithTypeScript)
+	}
 	prg, err := Parse(name, src, parserOptions...)
 	if err != nil {
 		return
 	}
 
-	return compileAST(prg, strict, inGlobal, evalVm, debug)
+	return compileAST(prg, strict, inGlobal, evalVm, debug, cov)
 }
 
-func compileAST(prg *js_ast.Program, strict, inGlobal bool, evalVm *vm, debug bool) (p *Program, err error) {
+func compileAST(prg *js_ast.Program, strict, inGlobal bool, evalVm *vm, debug bool, cov *Coverage) (p *Program, err error) {
 	c := newCompiler(debug)
+	if cov != nil && prg.File != nil {
+		c.coverage = cov.file(prg.File)
+	}
 
 	defer func() {
 		if x := recover(); x != nil {
@@ -1494,7 +1535,11 @@ func compileAST(prg *js_ast.Program, strict, inGlobal bool, evalVm *vm, debug bo
 }
 
 func (r *Runtime) compile(name, src string, strict, inGlobal bool, evalVm *vm) (p *Program, err error) {
-	p, err = compile(name, src, strict, inGlobal, evalVm, r.vm.debugMode, r.parserOptions...)
+	var cov *Coverage
+	if evalVm == nil {
+		cov = r.coverage
+	}
+	p, err = compile(name, src, strict, inGlobal, evalVm, r.vm.debugMode, cov, r.parserOptions...)
 	if err != nil {
 		switch x1 := err.(type) {
 		case *CompilerSyntaxError:
@@ -2526,6 +2571,38 @@ func (r *Runtime) SetTimeSource(now Now) {
 	r.now = now
 }
 
+// Scheduler runs the completions of the asynchronous built-ins (such as HTTP.fetch) on the goroutine of the
+// Runtime, see SetScheduler. It is implemented by eventloop.EventLoop.
+type Scheduler interface {
+	// Schedule registers a pending operation, which keeps the scheduler running until it completes. It is called on
+	// the goroutine of the Runtime. The returned function must be called exactly once, from any goroutine, with the
+	// function that completes the operation on the goroutine of the Runtime.
+	Schedule() (complete func(func()))
+	// Post runs fn on the goroutine of the Runtime as soon as possible. It may be called from any goroutine, the
+	// functions are run in the order they are posted.
+	Post(fn func())
+}
+
+// SetScheduler sets the scheduler of the asynchronous built-ins. Without a scheduler their I/O is performed on the
+// goroutine of the Runtime before they return, i.e. the returned promises are settled (but not yet reacted to)
+// immediately and concurrent operations are serialized.
+func (r *Runtime) SetScheduler(s Scheduler) {
+	r.scheduler = s
+}
+
+// runAsync runs work on a new goroutine if there is a scheduler, and then the function returned by work on the
+// goroutine of the Runtime. work must not use the Runtime or any Values.
+func (r *Runtime) runAsync(work func() (complete func())) {
+	if r.scheduler == nil {
+		work()()
+		return
+	}
+	complete := r.scheduler.Schedule()
+	go func() {
+		complete(work())
+	}()
+}
+
 // SetParserOptions sets parser options to be used by RunString, RunScript and eval() within the code.
 func (r *Runtime) SetParserOptions(opts ...parser.Option) {
 	r.parserOptions = opts
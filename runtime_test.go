@@ -2055,6 +2055,20 @@ func TestRuntime_SetParserOptions_Eval(t *testing.T) {
 	}
 }
 
+func TestParseWithErrorRecovery(t *testing.T) {
+	prg, err := Parse("test.js", "let a = ;\nlet b = 1;\nf(b +);", parser.WithErrorRecovery)
+	var list parser.ErrorList
+	if !errors.As(err, &list) {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if len(list) != 2 || list[0].Position.Line != 1 || list[1].Position.Line != 3 || list[1].End.Column != 7 {
+		t.Fatalf("Unexpected errors: %v", list)
+	}
+	if prg == nil || len(prg.Body) != 3 {
+		t.Fatal("Unexpected program")
+	}
+}
+
 func TestNativeCallWithRuntimeParameter(t *testing.T) {
 	vm := New()
 	vm.Set("f", func(_ FunctionCall, r *Runtime) Value {
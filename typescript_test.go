@@ -0,0 +1,96 @@
+package goscript
+
+import (
+	"testing"
+)
+
+func TestTypeScript(t *testing.T) {
+	const SCRIPT = `
+	interface Shape<T = number> extends Object {
+		area(): T;
+		readonly name?: string;
+	}
+	type Pair<A, B> = [A, B];
+	type Fn = (x: number, ...rest: string[]) => void
+	declare const VERSION: string;
+	declare function external(x: number): void;
+
+	enum Color { Red, Green = 4, Blue }
+	const enum Dir { Up = "UP", Down = "DOWN" }
+	enum Flags { None = 0, A = 1 << 0, B = 1 << 1, AB = A | B }
+
+	abstract class Base<T> implements Shape<T> {
+		private static count: number = 0;
+		protected abstract size: number;
+		[key: string]: any;
+		constructor(public readonly name: string, private tags?: string[]) {
+			Base.count++;
+		}
+		abstract area(): T;
+		describe(this: Base<T>, prefix: string = ">"): string {
+			return prefix + this.name + ":" + this.area();
+		}
+		static created(): number { return Base.count; }
+	}
+
+	class Square extends Base<number> {
+		declare extra: string;
+		side!: number;
+		constructor(side: number, public label: string = "sq") {
+			super("square");
+			this.side = side;
+		}
+		area(): number {
+			return this.side * this.side;
+		}
+	}
+
+	function identity<T>(x: T): T;
+	function identity<T>(x: T): T {
+		return x;
+	}
+
+	function first<T extends { length: number }>(xs: Array<Array<T>>, f: (x: T) => boolean = () => true): T | undefined {
+		const inner = xs[0]!;
+		return inner.find(x => f(x as T));
+	}
+
+	const sq = new Square(3);
+	const map = new Map<string, Array<number>>();
+	map.set("a", [1, 2]);
+	const add = <T,>(a: number, b: number): number => a + b;
+	const asyncFn = async (x: number): Promise<number> => x * 2;
+	let n: number | null = null;
+	let v = (n ?? 5) as unknown as number;
+	const o = { m<T>(x: T): T { return x; } };
+	let r = 1 < 2 > false;
+	let items: Pair<string, number>[] = [["x", 1]];
+
+	[
+		sq.describe(), sq.label, Base.created(), Color.Green, Color[4], Color.Blue, Color[0], Dir.Up, Flags.AB,
+		identity<string>("id"), first([[1, 2]]), map.get("a")!.length, add(2, 3), v, o.m(7), r, items[0][1],
+		typeof Dir[("UP" as any)], sq.hasOwnProperty("extra"), "side" in sq, sq.hasOwnProperty("name"),
+	].join(",");
+	`
+	vm := New()
+	v, err := vm.RunScript("test.ts", SCRIPT)
+	if err != nil {
+		t.Fatal(err)
+	}
+	const expected = ">square:9,sq,1,4,Green,5,Red,UP,3,id,1,2,5,5,7,false,1,undefined,false,true,true"
+	if s := v.String(); s != expected {
+		t.Fatalf("Unexpected result: %s\nExpected:      %s", s, expected)
+	}
+}
+
+func TestTypeScriptOnlyForTsFiles(t *testing.T) {
+	_, err := Compile("test.js", "let x: number = 1;", false)
+	if err == nil {
+		t.Fatal("Expected a syntax error")
+	}
+	vm := New()
+	_, err = vm.RunScript("test.ts", "eval('let y: number = 1')")
+	if err == nil {
+		t.Fatal("Expected a syntax error")
+	}
+}
@@ -330,6 +330,9 @@ type vm struct {
 	interruptVal  interface{}
 	interruptLock sync.Mutex
 
+	prof     *profiler
+	profLock sync.Mutex
+
 	curAsyncRunner *asyncRunner
 
 	debugger  *Debugger
@@ -571,8 +574,15 @@ func (vm *vm) Halted() bool {
 func (vm *vm) run() {
 	interrupted := false
 	for {
-		if interrupted = atomic.LoadUint32(&vm.interrupted) != 0; interrupted {
-			break
+		if f := atomic.LoadUint32(&vm.interrupted); f != 0 {
+			if f != vmProfSampleRequest {
+				interrupted = true
+				break
+			}
+			if atomic.CompareAndSwapUint32(&vm.interrupted, vmProfSampleRequest, 0) {
+				vm.profSample()
+			}
+			continue
 		}
 		pc := vm.pc
 		if pc < 0 || pc >= len(vm.prg.code) {
@@ -606,8 +616,15 @@ func (vm *vm) debug() {
 	interrupted := false
 	ticks := 0
 	for {
-		if interrupted = atomic.LoadUint32(&vm.interrupted) != 0; interrupted {
-			break
+		if f := atomic.LoadUint32(&vm.interrupted); f != 0 {
+			if f != vmProfSampleRequest {
+				interrupted = true
+				break
+			}
+			if atomic.CompareAndSwapUint32(&vm.interrupted, vmProfSampleRequest, 0) {
+				vm.profSample()
+			}
+			continue
 		}
 		if vm.debugger != nil {
 			if !vm.debugger.active && vm.debugger.breakpoint() {